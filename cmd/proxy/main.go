@@ -11,6 +11,7 @@ import (
 	"github.com/splitio/split-synchronizer/v5/splitio/log"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshotcli"
 )
 
 const (
@@ -37,10 +38,22 @@ func setupConfig(cliArgs *cconf.CliFlags) (*conf.Main, error) {
 
 	var err error
 	proxyConf.FlagSetsFilter, err = cconf.ValidateFlagsets(proxyConf.FlagSetsFilter)
+	for idx := range proxyConf.Environments {
+		var envErr error
+		proxyConf.Environments[idx].FlagSetsFilter, envErr = cconf.ValidateFlagsets(proxyConf.Environments[idx].FlagSetsFilter)
+		if err == nil && envErr != nil {
+			err = envErr
+		}
+	}
 	return &proxyConf, err
 }
 
 func main() {
+	// snapshot tooling doesn't start the proxy & may write to stdout, so it's handled before anything is printed
+	if len(os.Args) > 1 && os.Args[1] == snapshotcli.Command {
+		os.Exit(snapshotcli.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	fmt.Println(splitio.ASCILogo)
 	fmt.Printf("\nSplit Proxy - Version: %s (%s) \n", splitio.Version, splitio.CommitVersion)
 
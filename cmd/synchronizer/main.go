@@ -11,6 +11,7 @@ import (
 	"github.com/splitio/split-synchronizer/v5/splitio/log"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/snapshotcli"
 )
 
 const (
@@ -41,6 +42,11 @@ func setupConfig(cliArgs *cconf.CliFlags) (*conf.Main, error) {
 }
 
 func main() {
+	// snapshot tooling doesn't start the synchronizer & may write to stdout, so it's handled before anything is printed
+	if len(os.Args) > 1 && os.Args[1] == snapshotcli.Command {
+		os.Exit(snapshotcli.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	fmt.Println(splitio.ASCILogo)
 	fmt.Printf("\nSplit Synchronizer - Version: %s (%s) \n", splitio.Version, splitio.CommitVersion)
 
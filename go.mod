@@ -7,6 +7,7 @@ require (
 	github.com/gin-contrib/gzip v0.0.6
 	github.com/gin-gonic/gin v1.10.0
 	github.com/google/uuid v1.3.0
+	github.com/klauspost/compress v1.17.11
 	github.com/splitio/gincache v1.0.1
 	github.com/splitio/go-split-commons/v6 v6.0.1
 	github.com/splitio/go-toolkit/v5 v5.4.0
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
@@ -9,10 +10,19 @@ import (
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/admin/controllers"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
-	cstorage "github.com/splitio/split-synchronizer/v5/splitio/common/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,23 +33,47 @@ const baseShutdownPath = "/shutdown"
 
 // Options encapsulates dependencies & config options for the Admin server
 type Options struct {
-	Host              string
-	Port              int
-	Name              string
-	Proxy             bool
-	Username          string
-	Password          string
-	Logger            logging.LoggerInterface
-	Storages          adminCommon.Storages
-	ImpressionsEvCalc evcalc.Monitor
-	EventsEvCalc      evcalc.Monitor
-	Runtime           common.Runtime
-	HcAppMonitor      application.MonitorIterface
-	HcServicesMonitor services.MonitorIterface
-	Snapshotter       cstorage.Snapshotter
-	TLS               *tls.Config
-	FullConfig        interface{}
-	FlagSpecVersion   string
+	Host                string
+	Port                int
+	Name                string
+	Proxy               bool
+	Username            string
+	Password            string
+	Logger              logging.LoggerInterface
+	Storages            adminCommon.Storages
+	ImpressionsEvCalc   evcalc.Monitor
+	EventsEvCalc        evcalc.Monitor
+	Runtime             common.Runtime
+	HcAppMonitor        application.MonitorIterface
+	HcServicesMonitor   services.MonitorIterface
+	Snapshots           *snapshot.Builder
+	SnapshotLoader      snapshots.Loader
+	TLS                 *tls.Config
+	FullConfig          interface{}
+	FlagSpecVersion     string
+	Spools              map[string]tasks.Spool
+	DeadLetters         tasks.DeadLetterStore
+	DeadLetterSinks     map[string]tasks.DeferredRecordingTask
+	APIKeys             apikeys.Registry
+	AuditLog            audit.Log
+	HTTPCache           caching.Admin
+	FlagOverrides       overrides.Store
+	KillSwitch          killswitch.Switch
+	Pipelines           []task.Tunable
+	PipelineDeadLetters storage.DeadLetterStore
+	DeadLetterReplayer  *task.DeadLetterReplayer
+	Environments        map[string]*Environment // additional split-proxy environments, keyed by name
+}
+
+// Environment bundles the resources of an additional split-proxy environment. They're managed through the same
+// endpoints as the ones of the default environment (the ones set in Options), by supplying an `env` query parameter
+type Environment struct {
+	DeadLetters     tasks.DeadLetterStore
+	DeadLetterSinks map[string]tasks.DeferredRecordingTask
+	APIKeys         apikeys.Registry
+	HTTPCache       caching.Admin
+	FlagOverrides   overrides.Store
+	KillSwitch      killswitch.Switch
 }
 
 type AdminServer struct {
@@ -52,7 +86,8 @@ func NewServer(options *Options) (*AdminServer, error) {
 	admin := router.Group(baseAdminPath)
 	info := router.Group(baseInfoPath)
 	shutdown := router.Group(baseShutdownPath)
-	if options.Username != "" && options.Password != "" {
+	authenticated := options.Username != "" && options.Password != ""
+	if authenticated {
 		admin = router.Group(baseAdminPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
 		info = router.Group(baseInfoPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
 		shutdown = router.Group(baseShutdownPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
@@ -68,6 +103,11 @@ func NewServer(options *Options) (*AdminServer, error) {
 		options.Runtime,
 		options.HcAppMonitor,
 		options.FlagSpecVersion,
+		options.Spools,
+		options.FlagOverrides,
+		options.AuditLog,
+		options.KillSwitch,
+		options.Pipelines,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error instantiating dashboard controller: %w", err)
@@ -87,24 +127,117 @@ func NewServer(options *Options) (*AdminServer, error) {
 	infoController := controllers.NewInfoController(options.Proxy, options.Runtime, options.FullConfig)
 	infoController.Register(info)
 
-	observabilityController, err := controllers.NewObservabilityController(options.Proxy, options.Logger, options.Storages)
+	observabilityController, err := controllers.NewObservabilityController(options.Proxy, options.Logger, options.Storages, options.FlagOverrides)
 	if err != nil {
 		return nil, fmt.Errorf("error instantiating observability controller: %w", err)
 	}
 	observabilityController.Register(admin)
 
-	if options.Snapshotter != nil {
-		snapshotController := controllers.NewSnapshotController(options.Logger, options.Snapshotter)
+	// endpoints that change the state of the synchronizer/proxy are only exposed if the admin api is password-protected
+	snapshotLoader := options.SnapshotLoader
+	if !authenticated {
+		snapshotLoader = nil
+	}
+
+	if options.Snapshots != nil || snapshotLoader != nil {
+		snapshotController := controllers.NewSnapshotController(options.Logger, options.Snapshots, snapshotLoader)
 		snapshotController.Register(admin)
 	}
 
+	deadLetters := make(map[string]controllers.DeadLetterEnvironment)
+	registries := make(map[string]apikeys.Registry)
+	caches := make(map[string]caching.Admin)
+	flagOverrides := make(map[string]overrides.Store)
+	killSwitches := make(map[string]killswitch.Switch)
+	environments := map[string]*Environment{"": {
+		DeadLetters:     options.DeadLetters,
+		DeadLetterSinks: options.DeadLetterSinks,
+		APIKeys:         options.APIKeys,
+		HTTPCache:       options.HTTPCache,
+		FlagOverrides:   options.FlagOverrides,
+		KillSwitch:      options.KillSwitch,
+	}}
+	for name, env := range options.Environments {
+		environments[name] = env
+	}
+	for name, env := range environments {
+		if env.DeadLetters != nil {
+			deadLetters[name] = controllers.DeadLetterEnvironment{Store: env.DeadLetters, Sinks: env.DeadLetterSinks}
+		}
+		if env.APIKeys != nil {
+			registries[name] = env.APIKeys
+		}
+		if env.HTTPCache != nil {
+			caches[name] = env.HTTPCache
+		}
+		if env.FlagOverrides != nil {
+			flagOverrides[name] = env.FlagOverrides
+		}
+		if env.KillSwitch != nil {
+			killSwitches[name] = env.KillSwitch
+		}
+	}
+
+	if options.AuditLog != nil {
+		auditController := controllers.NewAuditController(options.AuditLog)
+		auditController.Register(admin)
+	}
+
+	if !authenticated {
+		if options.SnapshotLoader != nil || options.PipelineDeadLetters != nil || len(deadLetters) > 0 || len(registries) > 0 ||
+			len(caches) > 0 || len(flagOverrides) > 0 || len(killSwitches) > 0 {
+			options.Logger.Warning("Admin credentials not set. Endpoints managing snapshots, dead letters, apikeys, " +
+				"the http cache, flag overrides & kills are disabled")
+		}
+		return newAdminServer(options, router), nil
+	}
+
+	if len(deadLetters) > 0 {
+		deadLettersController := controllers.NewDeadLettersController(options.Logger, deadLetters)
+		deadLettersController.Register(admin)
+	}
+
+	if options.PipelineDeadLetters != nil {
+		deadLettersController := controllers.NewPipelineDeadLettersController(options.Logger, options.PipelineDeadLetters, options.DeadLetterReplayer)
+		deadLettersController.Register(admin)
+	}
+
+	if len(registries) > 0 {
+		apikeysController := controllers.NewAPIKeysController(options.Logger, registries)
+		apikeysController.Register(admin)
+	}
+
+	if len(caches) > 0 {
+		cacheController := controllers.NewCacheController(options.Logger, caches)
+		cacheController.Register(admin)
+	}
+
+	if len(flagOverrides) > 0 {
+		overridesController := controllers.NewOverridesController(options.Logger, flagOverrides)
+		overridesController.Register(admin)
+	}
+
+	if len(killSwitches) > 0 {
+		killSwitchController := controllers.NewKillSwitchController(options.Logger, killSwitches)
+		killSwitchController.Register(admin)
+	}
+
+	return newAdminServer(options, router), nil
+}
+
+func newAdminServer(options *Options, router *gin.Engine) *AdminServer {
 	return &AdminServer{
 		server: &http.Server{
 			Addr:      fmt.Sprintf("%s:%d", options.Host, options.Port),
 			Handler:   router,
 			TLSConfig: options.TLS,
 		},
-	}, nil
+	}
+}
+
+// Stop stops accepting connections & waits for in-flight requests to complete, until the context is done
+func (a *AdminServer) Stop(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
 }
 
 func (a *AdminServer) Start() error {
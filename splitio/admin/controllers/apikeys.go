@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
+)
+
+// APIKeysController bundles endpoints for managing the client apikeys accepted by the proxy at runtime
+type APIKeysController struct {
+	logger     logging.LoggerInterface
+	registries map[string]apikeys.Registry
+}
+
+// APIKeySummary is the representation of a client apikey returned by the admin API. Keys are never listed in full
+type APIKeySummary struct {
+	Apikey   string   `json:"apikey"`
+	FlagSets []string `json:"flagSets,omitempty"`
+	Source   string   `json:"source"`
+}
+
+// APIKeyRequest is the body accepted when adding or updating a client apikey
+type APIKeyRequest struct {
+	Apikey   string   `json:"apikey"`
+	FlagSets []string `json:"flagSets"`
+}
+
+// NewAPIKeysController constructs a new apikeys controller. `registries` are keyed by environment name
+func NewAPIKeysController(logger logging.LoggerInterface, registries map[string]apikeys.Registry) *APIKeysController {
+	return &APIKeysController{logger: logger, registries: registries}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *APIKeysController) Register(router gin.IRouter) {
+	router.GET("/apikeys", c.list)
+	router.POST("/apikeys", c.set)
+	router.DELETE("/apikeys/:apikey", c.revoke)
+}
+
+// Endpoint functions \{
+
+func (c *APIKeysController) list(ctx *gin.Context) {
+	registry, ok := forEnvironment(ctx, c.registries)
+	if !ok {
+		return
+	}
+
+	entries := registry.List()
+	summaries := make([]APIKeySummary, 0, len(entries))
+	for _, entry := range entries {
+		summaries = append(summaries, APIKeySummary{
+			Apikey:   logging.ObfuscateAPIKey(entry.Apikey),
+			FlagSets: entry.FlagSets,
+			Source:   entry.Source,
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"apikeys": summaries})
+}
+
+func (c *APIKeysController) set(ctx *gin.Context) {
+	registry, ok := forEnvironment(ctx, c.registries)
+	if !ok {
+		return
+	}
+
+	var body APIKeyRequest
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	err := registry.Set(body.Apikey, body.FlagSets, apikeys.SourceAdmin, actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.Status(http.StatusNoContent)
+	case errors.Is(err, apikeys.ErrReservedAPIKey):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func (c *APIKeysController) revoke(ctx *gin.Context) {
+	registry, ok := forEnvironment(ctx, c.registries)
+	if !ok {
+		return
+	}
+
+	err := registry.Revoke(ctx.Param("apikey"), actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.Status(http.StatusNoContent)
+	case errors.Is(err, apikeys.ErrUnknownAPIKey):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.logger.Error("error revoking apikey: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// \} -- end of endpoint functions
+
+// actorFrom returns the admin user performing the request, as authenticated by basic auth (if enabled)
+func actorFrom(ctx *gin.Context) string {
+	if user := ctx.GetString(gin.AuthUserKey); user != "" {
+		return user
+	}
+	return "admin"
+}
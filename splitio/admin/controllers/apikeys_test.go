@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+)
+
+type evictorMock struct{}
+
+func (evictorMock) EvictBySurrogate(string) {}
+func (evictorMock) EvictAll()               {}
+
+func TestAPIKeysController(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	validator := middleware.NewAPIKeyValidator([]string{"configured_key"}, nil)
+	registry := apikeys.NewRegistry(validator, evictorMock{}, auditLog, []*middleware.APIKeyValidator{middleware.NewAPIKeyValidator([]string{"other_env_key"}, nil)}, logger)
+
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.Use(func(ctx *gin.Context) { ctx.Set(gin.AuthUserKey, "someone") })
+	NewAPIKeysController(logger, map[string]apikeys.Registry{"": registry}).Register(router)
+	NewAuditController(auditLog).Register(router)
+
+	serve := func(method string, url string, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, "/apikeys", `{"apikey":"new_client_key","flagSets":["Set1"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/apikeys", `{"apikey":""}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/apikeys", `{"apikey":"k","flagSets":["!!"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/apikeys", `not json`).Code)
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/apikeys", `{"apikey":"other_env_key"}`).Code)
+	assert.True(t, validator.IsValid("new_client_key"))
+	assert.Equal(t, []string{"set1"}, validator.FlagSets("new_client_key"))
+
+	var listed struct {
+		APIKeys []APIKeySummary `json:"apikeys"`
+	}
+	resp := serve(http.MethodGet, "/apikeys", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	assert.Len(t, listed.APIKeys, 2)
+	assert.Equal(t, logging.ObfuscateAPIKey("configured_key"), listed.APIKeys[0].Apikey)
+	assert.Equal(t, apikeys.SourceConfig, listed.APIKeys[0].Source)
+	assert.Equal(t, logging.ObfuscateAPIKey("new_client_key"), listed.APIKeys[1].Apikey)
+	assert.Equal(t, apikeys.SourceAdmin, listed.APIKeys[1].Source)
+	assert.Equal(t, []string{"set1"}, listed.APIKeys[1].FlagSets)
+	assert.NotContains(t, resp.Body.String(), "new_client_key")
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/apikeys/configured_key", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/apikeys/configured_key", "").Code)
+	assert.False(t, validator.IsValid("configured_key"))
+
+	var entries struct {
+		Entries []audit.Entry `json:"entries"`
+	}
+	resp = serve(http.MethodGet, "/audit", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &entries))
+	assert.Len(t, entries.Entries, 2)
+	assert.Equal(t, "apikey.add", entries.Entries[0].Action)
+	assert.Equal(t, "apikey.revoke", entries.Entries[1].Action)
+	assert.Equal(t, "someone", entries.Entries[1].Actor)
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+)
+
+// AuditController exposes the log of administrative changes performed at runtime
+type AuditController struct {
+	auditLog audit.Log
+}
+
+// NewAuditController constructs a new audit controller
+func NewAuditController(auditLog audit.Log) *AuditController {
+	return &AuditController{auditLog: auditLog}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *AuditController) Register(router gin.IRouter) {
+	router.GET("/audit", c.list)
+}
+
+func (c *AuditController) list(ctx *gin.Context) {
+	entries := c.auditLog.Entries()
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"entries": entries})
+}
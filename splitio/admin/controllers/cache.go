@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+)
+
+// CacheController bundles endpoints for inspecting & purging the http cache used to serve sdk requests
+type CacheController struct {
+	logger logging.LoggerInterface
+	caches map[string]caching.Admin
+}
+
+// NewCacheController constructs a new http cache controller. `caches` are keyed by environment name
+func NewCacheController(logger logging.LoggerInterface, caches map[string]caching.Admin) *CacheController {
+	return &CacheController{logger: logger, caches: caches}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *CacheController) Register(router gin.IRouter) {
+	router.GET("/cache", c.stats)
+	router.GET("/cache/surrogates", c.surrogates)
+	router.POST("/cache/purge", c.purge)
+	router.DELETE("/cache", c.flush)
+}
+
+// Endpoint functions \{
+
+func (c *CacheController) stats(ctx *gin.Context) {
+	cache, ok := forEnvironment(ctx, c.caches)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cache.Stats())
+}
+
+func (c *CacheController) surrogates(ctx *gin.Context) {
+	cache, ok := forEnvironment(ctx, c.caches)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"surrogates": cache.Surrogates()})
+}
+
+// purge evicts the entries referenced by the `surrogate` query params, as well as the ones matching the `key` params.
+// Both can be repeated
+func (c *CacheController) purge(ctx *gin.Context) {
+	cache, ok := forEnvironment(ctx, c.caches)
+	if !ok {
+		return
+	}
+
+	surrogates := ctx.QueryArray("surrogate")
+	keys := ctx.QueryArray("key")
+	if len(surrogates) == 0 && len(keys) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one surrogate or key is required"})
+		return
+	}
+
+	before := cache.Stats().Entries
+	for _, surrogate := range surrogates {
+		cache.EvictBySurrogate(surrogate)
+	}
+	for _, key := range keys {
+		cache.Evict(key)
+	}
+	c.logger.Info("http cache entries purged through the admin api")
+	ctx.JSON(http.StatusOK, gin.H{"removed": before - cache.Stats().Entries})
+}
+
+func (c *CacheController) flush(ctx *gin.Context) {
+	cache, ok := forEnvironment(ctx, c.caches)
+	if !ok {
+		return
+	}
+
+	before := cache.Stats().Entries
+	cache.EvictAll()
+	c.logger.Info("http cache flushed through the admin api")
+	ctx.JSON(http.StatusOK, gin.H{"removed": before})
+}
+
+// \} -- end of endpoint functions
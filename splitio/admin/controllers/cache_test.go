@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+)
+
+func TestCacheController(t *testing.T) {
+	cache := caching.MakeProxyCache(100, 0)
+
+	_, sdkRouter := gin.CreateTestContext(httptest.NewRecorder())
+	sdkRouter.Use(cache.Handle)
+	sdkRouter.GET("/api/splitChanges", func(ctx *gin.Context) {
+		ctx.Set(caching.SurrogateContextKey, []string{caching.SplitSurrogate})
+		ctx.String(http.StatusOK, "splits")
+	})
+	sdkRouter.GET("/api/segmentChanges/:name", func(ctx *gin.Context) {
+		ctx.Set(caching.SurrogateContextKey, []string{caching.MakeSurrogateForSegmentChanges(ctx.Param("name"))})
+		ctx.String(http.StatusOK, "segment")
+	})
+	for _, path := range []string{"/api/splitChanges?since=1", "/api/splitChanges?since=2", "/api/segmentChanges/s1", "/api/segmentChanges/s2"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		sdkRouter.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	staging := caching.MakeProxyCache(100, 0)
+	NewCacheController(logging.NewLogger(nil), map[string]caching.Admin{"": cache, "staging": staging}).Register(router)
+	serve := func(method string, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, nil)
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var stats caching.Stats
+	resp := serve(http.MethodGet, "/cache")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, int64(4), stats.Entries)
+	assert.Equal(t, int64(4), stats.Misses)
+	assert.Equal(t, int64(100), stats.MaxEntries)
+
+	// other environments are selected through the env param
+	resp = serve(http.MethodGet, "/cache?env=staging")
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, int64(0), stats.Entries)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/cache?env=unknown").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/cache?env=unknown").Code)
+
+	var surrogates struct {
+		Surrogates map[string]int `json:"surrogates"`
+	}
+	resp = serve(http.MethodGet, "/cache/surrogates")
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &surrogates))
+	assert.Equal(t, map[string]int{caching.SplitSurrogate: 2, "se::s1": 1, "se::s2": 1}, surrogates.Surrogates)
+
+	var removed struct {
+		Removed int64 `json:"removed"`
+	}
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/cache/purge").Code)
+	resp = serve(http.MethodPost, "/cache/purge?surrogate=sp&key=/api/segmentChanges/s1")
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &removed))
+	assert.Equal(t, int64(3), removed.Removed)
+
+	resp = serve(http.MethodDelete, "/cache")
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &removed))
+	assert.Equal(t, int64(1), removed.Removed)
+	assert.Equal(t, int64(0), cache.Stats().Entries)
+}
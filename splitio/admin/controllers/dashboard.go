@@ -13,9 +13,14 @@ import (
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/admin/views/dashboard"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
 	"github.com/splitio/split-synchronizer/v5/splitio/log"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 )
 
 // DashboardController contains handlers for rendering the dashboard and its associated FE queries
@@ -29,6 +34,11 @@ type DashboardController struct {
 	eventsEvCalc      evcalc.Monitor
 	runtime           common.Runtime
 	appMonitor        application.MonitorIterface
+	spools            map[string]tasks.Spool
+	overrides         overrides.Store
+	auditLog          audit.Log
+	kills             killswitch.Switch
+	pipelines         []task.Tunable
 	FlagSpecVersion   string
 }
 
@@ -43,6 +53,11 @@ func NewDashboardController(
 	runtime common.Runtime,
 	appMonitor application.MonitorIterface,
 	flagSpecVersion string,
+	spools map[string]tasks.Spool,
+	flagOverrides overrides.Store,
+	auditLog audit.Log,
+	kills killswitch.Switch,
+	pipelines []task.Tunable,
 ) (*DashboardController, error) {
 
 	toReturn := &DashboardController{
@@ -54,6 +69,11 @@ func NewDashboardController(
 		eventsEvCalc:      eventsEvCalc,
 		impressionsEvCalc: impressionEvCalc,
 		appMonitor:        appMonitor,
+		spools:            spools,
+		overrides:         flagOverrides,
+		auditLog:          auditLog,
+		kills:             kills,
+		pipelines:         pipelines,
 		FlagSpecVersion:   flagSpecVersion,
 	}
 
@@ -143,6 +163,8 @@ func (c *DashboardController) gatherStats() *dashboard.GlobalStats {
 		eventsLambda = c.eventsEvCalc.Lambda()
 	}
 
+	pipelines, pipelineAdjustments := bundlePipelineInfo(c.pipelines)
+
 	return &dashboard.GlobalStats{
 		FeatureFlags:           bundleSplitInfo(c.storages.SplitStorage),
 		Segments:               bundleSegmentInfo(c.storages.SplitStorage, c.storages.SegmentStorage),
@@ -162,5 +184,11 @@ func (c *DashboardController) gatherStats() *dashboard.GlobalStats {
 		LoggedMessages:         errorMessages,
 		Uptime:                 int64(c.runtime.Uptime().Seconds()),
 		FlagSets:               getFlagSetsInfo(c.storages.SplitStorage),
+		Spools:                 bundleSpoolInfo(c.spools),
+		Overrides:              bundleOverrideInfo(c.overrides),
+		Kills:                  bundleKillInfo(c.kills),
+		AuditEntries:           bundleAuditInfo(c.auditLog),
+		Pipelines:              pipelines,
+		PipelineAdjustments:    pipelineAdjustments,
 	}
 }
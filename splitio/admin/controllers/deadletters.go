@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+)
+
+// DeadLettersController bundles endpoints for inspecting & replaying data that could not be posted to Split servers
+type DeadLettersController struct {
+	logger       logging.LoggerInterface
+	environments map[string]DeadLetterEnvironment
+	mutex        sync.Mutex
+}
+
+// DeadLetterEnvironment bundles the dead letters of an environment along with the tasks used to replay them.
+// `Sinks` maps each kind of data to the task used to post it again
+type DeadLetterEnvironment struct {
+	Store tasks.DeadLetterStore
+	Sinks map[string]tasks.DeferredRecordingTask
+}
+
+// DeadLetterSummary is the representation of a dead letter returned by the admin API
+type DeadLetterSummary struct {
+	ID          uint64    `json:"id"`
+	Kind        string    `json:"kind"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	FailedAt    time.Time `json:"failedAt"`
+	SDKVersion  string    `json:"sdkVersion"`
+	MachineIP   string    `json:"machineIp"`
+	MachineName string    `json:"machineName"`
+	Size        int       `json:"size"`
+	Payload     *string   `json:"payload,omitempty"`
+}
+
+// NewDeadLettersController constructs a new dead letters controller. `environments` are keyed by name
+func NewDeadLettersController(
+	logger logging.LoggerInterface,
+	environments map[string]DeadLetterEnvironment,
+) *DeadLettersController {
+	return &DeadLettersController{logger: logger, environments: environments}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *DeadLettersController) Register(router gin.IRouter) {
+	router.GET("/deadletters", c.list)
+	router.GET("/deadletters/:id", c.get)
+	router.POST("/deadletters/replay", c.replay)
+	router.DELETE("/deadletters", c.discard)
+}
+
+// Endpoint functions \{
+
+func (c *DeadLettersController) list(ctx *gin.Context) {
+	env, ok := forEnvironment(ctx, c.environments)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseDeadLetterFilter(ctx)
+	if !ok {
+		return
+	}
+
+	summaries := make([]DeadLetterSummary, 0)
+	for _, letter := range env.Store.List() {
+		if filter(&letter) {
+			summaries = append(summaries, summarizeDeadLetter(&letter, false))
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"items": summaries, "dropped": env.Store.Dropped()})
+}
+
+func (c *DeadLettersController) get(ctx *gin.Context) {
+	env, ok := forEnvironment(ctx, c.environments)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	letter, ok := env.Store.Get(id)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "dead letter not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, summarizeDeadLetter(letter, true))
+}
+
+// replay re-submits the selected dead letters through the task associated to their kind.
+// Only the ones that were accepted by their task are removed from the store
+func (c *DeadLettersController) replay(ctx *gin.Context) {
+	env, ok := forEnvironment(ctx, c.environments)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseDeadLetterFilter(ctx)
+	if !ok {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	replayed := make(map[uint64]struct{})
+	failed := 0
+	for _, letter := range env.Store.List() {
+		if !filter(&letter) {
+			continue
+		}
+
+		sink, ok := env.Sinks[letter.Kind]
+		if !ok {
+			c.logger.Error("no task available to replay dead letters of kind ", letter.Kind)
+			failed++
+			continue
+		}
+
+		if err := sink.Stage(letter.Item); err != nil {
+			c.logger.Error("error replaying dead letter: ", err)
+			failed++
+			continue
+		}
+		replayed[letter.ID] = struct{}{}
+	}
+
+	env.Store.Remove(func(letter *tasks.DeadLetter) bool {
+		_, ok := replayed[letter.ID]
+		return ok
+	})
+	ctx.JSON(http.StatusOK, gin.H{"replayed": len(replayed), "failed": failed})
+}
+
+func (c *DeadLettersController) discard(ctx *gin.Context) {
+	env, ok := forEnvironment(ctx, c.environments)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseDeadLetterFilter(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"removed": len(env.Store.Remove(filter))})
+}
+
+// \} -- end of endpoint functions
+
+// parseDeadLetterFilter builds a filter from the optional `kind` & `id` (can be repeated) query params.
+// If the params are invalid, a 400 is written & false is returned
+func parseDeadLetterFilter(ctx *gin.Context) (func(*tasks.DeadLetter) bool, bool) {
+	kind := ctx.Query("kind")
+	ids := make(map[uint64]struct{})
+	for _, raw := range ctx.QueryArray("id") {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + raw})
+			return nil, false
+		}
+		ids[id] = struct{}{}
+	}
+
+	return func(letter *tasks.DeadLetter) bool {
+		if kind != "" && letter.Kind != kind {
+			return false
+		}
+		if len(ids) > 0 {
+			if _, ok := ids[letter.ID]; !ok {
+				return false
+			}
+		}
+		return true
+	}, true
+}
+
+func summarizeDeadLetter(letter *tasks.DeadLetter, includePayload bool) DeadLetterSummary {
+	summary := DeadLetterSummary{
+		ID:         letter.ID,
+		Kind:       letter.Kind,
+		Attempts:   letter.Attempts,
+		LastError:  letter.LastError,
+		StatusCode: letter.StatusCode,
+		FailedAt:   letter.FailedAt,
+	}
+
+	if metadata, payload, ok := letter.Payload(); ok {
+		summary.SDKVersion = metadata.SDKVersion
+		summary.MachineIP = metadata.MachineIP
+		summary.MachineName = metadata.MachineName
+		summary.Size = len(payload)
+		if includePayload {
+			asStr := string(payload)
+			summary.Payload = &asStr
+		}
+	}
+	return summary
+}
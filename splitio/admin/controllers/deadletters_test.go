@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks/mocks"
+)
+
+func TestDeadLettersInspectAndReplay(t *testing.T) {
+	store := tasks.NewInMemoryDeadLetterStore(10)
+	store.Add(tasks.KindEvents, "e1", 5, errors.New("timeout"))
+	store.Add(tasks.KindImpressions, "i1", 5, errors.New("timeout"))
+	store.Add(tasks.KindEvents, "e2", 5, errors.New("timeout"))
+	store.Add("unknown", "u1", 5, errors.New("timeout"))
+
+	var staged []interface{}
+	events := &mocks.MockDeferredRecordingTask{StageCall: func(data interface{}) error {
+		if data == "e2" {
+			return tasks.ErrQueueFull
+		}
+		staged = append(staged, data)
+		return nil
+	}}
+	impressions := &mocks.MockDeferredRecordingTask{StageCall: func(data interface{}) error {
+		staged = append(staged, data)
+		return nil
+	}}
+
+	ctrl := NewDeadLettersController(logging.NewLogger(nil), map[string]DeadLetterEnvironment{"": {
+		Store: store,
+		Sinks: map[string]tasks.DeferredRecordingTask{
+			tasks.KindEvents:      events,
+			tasks.KindImpressions: impressions,
+		},
+	}})
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+
+	serve := func(method string, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, nil)
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var listed struct {
+		Items   []DeadLetterSummary `json:"items"`
+		Dropped int64               `json:"dropped"`
+	}
+	resp = serve(http.MethodGet, "/deadletters?kind=events")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	assert.Len(t, listed.Items, 2)
+	assert.Equal(t, "timeout", listed.Items[0].LastError)
+	assert.Equal(t, 5, listed.Items[0].Attempts)
+
+	resp = serve(http.MethodGet, "/deadletters/2")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/deadletters/123").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/deadletters/abc").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/deadletters/replay?id=abc").Code)
+
+	var replayed struct {
+		Replayed int `json:"replayed"`
+		Failed   int `json:"failed"`
+	}
+	resp = serve(http.MethodPost, "/deadletters/replay")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &replayed))
+	assert.Equal(t, 2, replayed.Replayed)
+	assert.Equal(t, 2, replayed.Failed) // e2 (queue full) & u1 (no sink)
+	assert.Equal(t, []interface{}{"e1", "i1"}, staged)
+
+	remaining := store.List()
+	assert.Len(t, remaining, 2)
+	assert.Equal(t, "e2", remaining[0].Item)
+	assert.Equal(t, "u1", remaining[1].Item)
+
+	var discarded struct {
+		Removed int `json:"removed"`
+	}
+	resp = serve(http.MethodDelete, "/deadletters?id=4")
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &discarded))
+	assert.Equal(t, 1, discarded.Removed)
+	assert.Len(t, store.List(), 1)
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// environmentParam is the query parameter used to select the split-proxy environment an admin request applies to.
+// Requests without it target the default environment
+const environmentParam = "env"
+
+// forEnvironment returns the resource of the environment selected by the request. If there's none, a 404 is written
+// and false is returned
+func forEnvironment[T any](ctx *gin.Context, resources map[string]T) (T, bool) {
+	name := ctx.Query(environmentParam)
+	resource, ok := resources[name]
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "unknown environment: " + name})
+	}
+	return resource, ok
+}
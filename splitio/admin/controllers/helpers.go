@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -9,11 +10,19 @@ import (
 	"github.com/splitio/go-split-commons/v6/telemetry"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/admin/views/dashboard"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
 	proxyStorage "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 )
 
+// how many audit log entries are shown in the dashboard
+const maxDashboardAuditEntries = 50
+
 func bundleSplitInfo(splitStorage storage.SplitStorageConsumer) []dashboard.SplitSummary {
 	all := splitStorage.All()
 	summaries := make([]dashboard.SplitSummary, 0, len(all))
@@ -235,6 +244,159 @@ func getFlagSetsInfo(splitsStorage storage.SplitStorage) []dashboard.FlagSetsSum
 	return summaries
 }
 
+func bundleSpoolInfo(spools map[string]tasks.Spool) []dashboard.SpoolSummary {
+	summaries := make([]dashboard.SpoolSummary, 0, len(spools))
+	for name, spool := range spools {
+		stats := spool.Stats()
+		summaries = append(summaries, dashboard.SpoolSummary{
+			Name:     name,
+			Items:    stats.Items,
+			Bytes:    stats.Bytes,
+			MaxBytes: stats.MaxBytes,
+			Dropped:  stats.Dropped,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[j].Name > summaries[i].Name
+	})
+
+	return summaries
+}
+
+func bundleOverrideInfo(store overrides.Store) []dashboard.OverrideSummary {
+	all := listOverrides(store)
+	summaries := make([]dashboard.OverrideSummary, 0, len(all))
+	for _, override := range all {
+		summary := dashboard.OverrideSummary{
+			Name:         override.Split,
+			Treatment:    override.Treatment,
+			Kill:         override.Kill,
+			Reason:       override.Reason,
+			CreatedBy:    override.CreatedBy,
+			CreatedAt:    override.CreatedAt.Format(time.RFC3339),
+			ChangeNumber: override.ChangeNumber,
+		}
+		if override.ExpiresAt != nil {
+			summary.ExpiresAt = override.ExpiresAt.Format(time.RFC3339)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func bundleKillInfo(kills killswitch.Switch) []dashboard.KillSummary {
+	if kills == nil {
+		return []dashboard.KillSummary{}
+	}
+
+	all := kills.List()
+	summaries := make([]dashboard.KillSummary, 0, len(all))
+	for _, kill := range all {
+		summaries = append(summaries, dashboard.KillSummary{
+			Name:             kill.Split,
+			DefaultTreatment: kill.DefaultTreatment,
+			Reason:           kill.Reason,
+			KilledBy:         kill.KilledBy,
+			KilledAt:         kill.KilledAt.Format(time.RFC3339),
+		})
+	}
+	return summaries
+}
+
+// bundleAuditInfo returns the latest entries of the audit log, newest first
+func bundleAuditInfo(auditLog audit.Log) []dashboard.AuditEntrySummary {
+	if auditLog == nil {
+		return []dashboard.AuditEntrySummary{}
+	}
+
+	all := auditLog.Entries()
+	if len(all) > maxDashboardAuditEntries {
+		all = all[len(all)-maxDashboardAuditEntries:]
+	}
+
+	summaries := make([]dashboard.AuditEntrySummary, 0, len(all))
+	for idx := len(all) - 1; idx >= 0; idx-- {
+		details := make([]string, 0, len(all[idx].Details))
+		for k, v := range all[idx].Details {
+			if v != "" {
+				details = append(details, k+"="+v)
+			}
+		}
+		sort.Strings(details)
+		summaries = append(summaries, dashboard.AuditEntrySummary{
+			Time:    all[idx].Time.Format(time.RFC3339),
+			Actor:   all[idx].Actor,
+			Action:  all[idx].Action,
+			Subject: all[idx].Subject,
+			Details: strings.Join(details, ", "),
+		})
+	}
+	return summaries
+}
+
+// bundlePipelineInfo returns the current settings of each pipeline, along with the adjustments made by the autoscaler
+// to all of them, newest first
+func bundlePipelineInfo(pipelines []task.Tunable) ([]dashboard.PipelineSummary, []dashboard.PipelineAdjustmentSummary) {
+	type namedAdjustment struct {
+		pipeline string
+		task.Adjustment
+	}
+
+	summaries := make([]dashboard.PipelineSummary, 0, len(pipelines))
+	var adjustments []namedAdjustment
+	for _, pipeline := range pipelines {
+		status := pipeline.Tuning()
+		summaries = append(summaries, dashboard.PipelineSummary{
+			Name:                  status.Name,
+			Autoscaling:           status.Autoscaling,
+			FetchSize:             status.Current.FetchSize,
+			MinFetchSize:          status.Min.FetchSize,
+			MaxFetchSize:          status.Max.FetchSize,
+			ProcessConcurrency:    status.Current.ProcessConcurrency,
+			MinProcessConcurrency: status.Min.ProcessConcurrency,
+			MaxProcessConcurrency: status.Max.ProcessConcurrency,
+			PostConcurrency:       status.Current.PostConcurrency,
+			MinPostConcurrency:    status.Min.PostConcurrency,
+			MaxPostConcurrency:    status.Max.PostConcurrency,
+		})
+		for _, adjustment := range status.Adjustments {
+			adjustments = append(adjustments, namedAdjustment{pipeline: status.Name, Adjustment: adjustment})
+		}
+	}
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].Time.After(adjustments[j].Time)
+	})
+
+	adjustmentSummaries := make([]dashboard.PipelineAdjustmentSummary, 0, len(adjustments))
+	for idx := range adjustments {
+		adjustmentSummaries = append(adjustmentSummaries, dashboard.PipelineAdjustmentSummary{
+			Time:     adjustments[idx].Time.Format(time.RFC3339),
+			Pipeline: adjustments[idx].pipeline,
+			Lambda:   adjustments[idx].Lambda,
+			Reason:   adjustments[idx].Reason,
+			From:     formatPipelineSettings(&adjustments[idx].From),
+			To:       formatPipelineSettings(&adjustments[idx].To),
+		})
+	}
+	return summaries, adjustmentSummaries
+}
+
+func formatPipelineSettings(settings *task.Settings) string {
+	formatted := fmt.Sprintf("process: %d, post: %d", settings.ProcessConcurrency, settings.PostConcurrency)
+	if settings.FetchSize == 0 {
+		return formatted
+	}
+	return fmt.Sprintf("fetch: %d, %s", settings.FetchSize, formatted)
+}
+
+// listOverrides returns the active flag overrides, or an empty list when they're not supported
+func listOverrides(store overrides.Store) []overrides.Override {
+	if store == nil {
+		return []overrides.Override{}
+	}
+	return store.List()
+}
+
 func getImpressionSize(impressionStorage storage.ImpressionMultiSdkConsumer) int64 {
 	if impressionStorage == nil {
 		return 0
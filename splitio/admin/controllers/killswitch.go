@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
+)
+
+// KillSwitchController bundles endpoints for killing & restoring feature flags locally
+type KillSwitchController struct {
+	logger   logging.LoggerInterface
+	switches map[string]killswitch.Switch
+}
+
+// KillRequest is the body accepted when killing a feature flag. If no default treatment is supplied,
+// the flag's current one is used
+type KillRequest struct {
+	DefaultTreatment string `json:"defaultTreatment"`
+	Reason           string `json:"reason"`
+}
+
+// RestoreRequest is the body accepted when restoring a feature flag
+type RestoreRequest struct {
+	Reason string `json:"reason"`
+}
+
+// NewKillSwitchController constructs a new kill switch controller. `switches` are keyed by environment name
+func NewKillSwitchController(logger logging.LoggerInterface, switches map[string]killswitch.Switch) *KillSwitchController {
+	return &KillSwitchController{logger: logger, switches: switches}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *KillSwitchController) Register(router gin.IRouter) {
+	router.GET("/killswitch", c.list)
+	router.POST("/killswitch/:split/kill", c.kill)
+	router.POST("/killswitch/:split/restore", c.restore)
+}
+
+// Endpoint functions \{
+
+func (c *KillSwitchController) list(ctx *gin.Context) {
+	kills, ok := forEnvironment(ctx, c.switches)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"kills": kills.List()})
+}
+
+func (c *KillSwitchController) kill(ctx *gin.Context) {
+	kills, ok := forEnvironment(ctx, c.switches)
+	if !ok {
+		return
+	}
+
+	var body KillRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	kill, err := kills.Kill(ctx.Param("split"), body.DefaultTreatment, body.Reason, actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, kill)
+	case errors.Is(err, killswitch.ErrUnknownFlag):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.logger.Error("error killing feature flag: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (c *KillSwitchController) restore(ctx *gin.Context) {
+	kills, ok := forEnvironment(ctx, c.switches)
+	if !ok {
+		return
+	}
+
+	var body RestoreRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	err := kills.Restore(ctx.Param("split"), body.Reason, actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.Status(http.StatusNoContent)
+	case errors.Is(err, killswitch.ErrNotKilled):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.logger.Error("error restoring feature flag: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// \} -- end of endpoint functions
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
+)
+
+func TestKillSwitchController(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	store, err := overrides.NewStore("", func() int64 { return 1 }, func(string) (int64, bool) { return 0, false }, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+	kills := overrides.NewKillSwitch(store, func(name string) *dtos.SplitDTO {
+		if name != "checkout_v2" {
+			return nil
+		}
+		return &dtos.SplitDTO{Name: name, DefaultTreatment: "on"}
+	})
+
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.Use(func(ctx *gin.Context) { ctx.Set(gin.AuthUserKey, "someone") })
+	NewKillSwitchController(logger, map[string]killswitch.Switch{"": kills}).Register(router)
+
+	serve := func(method string, url string, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := serve(http.MethodPost, "/killswitch/checkout_v2/kill", `{"defaultTreatment":"off","reason":"incident"}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var kill killswitch.Kill
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &kill))
+	assert.Equal(t, "off", kill.DefaultTreatment)
+	assert.Equal(t, "someone", kill.KilledBy)
+
+	// the body is optional
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/killswitch/checkout_v2/kill", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/killswitch/nonexistent/kill", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/killswitch/checkout_v2/kill", "not json").Code)
+
+	var listed struct {
+		Kills []killswitch.Kill `json:"kills"`
+	}
+	resp = serve(http.MethodGet, "/killswitch", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	assert.Len(t, listed.Kills, 1)
+	assert.Equal(t, "on", listed.Kills[0].DefaultTreatment)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, "/killswitch/checkout_v2/restore", `{"reason":"fixed"}`).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/killswitch/checkout_v2/restore", "").Code)
+	assert.Empty(t, kills.List())
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/observability"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
 	pstorage "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 
 	"github.com/splitio/go-toolkit/v5/logging"
@@ -49,6 +50,7 @@ type ProxyObservabilityController struct {
 	telemetry pstorage.TimeslicedProxyEndpointTelemetry
 	splits    observability.ObservableSplitStorage
 	segments  observability.ObservableSegmentStorage
+	overrides overrides.Store
 }
 
 // Register mounts the controller endpoints onto the supplied router
@@ -63,11 +65,18 @@ func (c *ProxyObservabilityController) observability(ctx *gin.Context) {
 		"activeFlagSets":          c.splits.GetAllFlagSetNames(),
 		"proxyEndpointStats":      c.telemetry.TimeslicedReport(),
 		"proxyEndpointStatsTotal": c.telemetry.TotalMetricsReport(),
+		"flagOverrides":           listOverrides(c.overrides),
 	})
 }
 
-// NewObservabilityController constructs and returns the appropriate struct dependeing on whether the app is split-proxy or split-sync
-func NewObservabilityController(proxy bool, logger logging.LoggerInterface, storagePack common.Storages) (ObservabilityController, error) {
+// NewObservabilityController constructs and returns the appropriate struct dependeing on whether the app is split-proxy or split-sync.
+// Flag overrides are only available in split-proxy (nil otherwise)
+func NewObservabilityController(
+	proxy bool,
+	logger logging.LoggerInterface,
+	storagePack common.Storages,
+	flagOverrides overrides.Store,
+) (ObservabilityController, error) {
 
 	splitStorage, ok := storagePack.SplitStorage.(observability.ObservableSplitStorage)
 	if !ok {
@@ -98,6 +107,7 @@ func NewObservabilityController(proxy bool, logger logging.LoggerInterface, stor
 		splits:    splitStorage,
 		segments:  segmentStorage,
 		telemetry: telemetry,
+		overrides: flagOverrides,
 	}, nil
 
 }
@@ -65,7 +65,7 @@ func TestSyncObservabilityEndpoint(t *testing.T) {
 		SegmentStorage: oSegmentStorage,
 	}
 
-	ctrl, err := NewObservabilityController(false, logger, storages)
+	ctrl, err := NewObservabilityController(false, logger, storages, nil)
 
 	if err != nil {
 		t.Error(err)
@@ -185,7 +185,7 @@ func TestProxyObservabilityEndpoint(t *testing.T) {
 		LocalTelemetryStorage: localTelemetryStorage,
 	}
 
-	ctrl, err := NewObservabilityController(true, logger, storages)
+	ctrl, err := NewObservabilityController(true, logger, storages, nil)
 	if err != nil {
 		t.Error(err)
 		return
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
+)
+
+// OverridesController bundles endpoints for managing local feature flag overrides
+type OverridesController struct {
+	logger logging.LoggerInterface
+	stores map[string]overrides.Store
+}
+
+// OverrideRequest is the body accepted when setting an override. The expiration can be set either as an absolute time
+// or as a number of seconds from now. If none is supplied, the override never expires
+type OverrideRequest struct {
+	Treatment  string     `json:"treatment"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	TTLSeconds int64      `json:"ttlSeconds"`
+}
+
+// NewOverridesController constructs a new flag overrides controller. `stores` are keyed by environment name
+func NewOverridesController(logger logging.LoggerInterface, stores map[string]overrides.Store) *OverridesController {
+	return &OverridesController{logger: logger, stores: stores}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *OverridesController) Register(router gin.IRouter) {
+	router.GET("/overrides", c.list)
+	router.PUT("/overrides/:split", c.set)
+	router.DELETE("/overrides/:split", c.remove)
+}
+
+// Endpoint functions \{
+
+func (c *OverridesController) list(ctx *gin.Context) {
+	store, ok := forEnvironment(ctx, c.stores)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"overrides": store.List()})
+}
+
+func (c *OverridesController) set(ctx *gin.Context) {
+	store, ok := forEnvironment(ctx, c.stores)
+	if !ok {
+		return
+	}
+
+	var body OverrideRequest
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if body.ExpiresAt != nil && body.TTLSeconds != 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "only one of expiresAt & ttlSeconds can be supplied"})
+		return
+	}
+
+	expiresAt := body.ExpiresAt
+	if body.TTLSeconds != 0 {
+		t := time.Now().Add(time.Duration(body.TTLSeconds) * time.Second)
+		expiresAt = &t
+	}
+
+	stored, err := store.Set(overrides.Override{
+		Split:     ctx.Param("split"),
+		Treatment: body.Treatment,
+		Reason:    body.Reason,
+		ExpiresAt: expiresAt,
+	}, actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, stored)
+	case errors.Is(err, overrides.ErrInvalidOverride), errors.Is(err, overrides.ErrAlreadyExpired):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.logger.Error("error setting flag override: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (c *OverridesController) remove(ctx *gin.Context) {
+	store, ok := forEnvironment(ctx, c.stores)
+	if !ok {
+		return
+	}
+
+	err := store.Remove(ctx.Param("split"), actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.Status(http.StatusNoContent)
+	case errors.Is(err, overrides.ErrUnknownOverride):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.logger.Error("error removing flag override: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// \} -- end of endpoint functions
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
+)
+
+func TestOverridesController(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	store, err := overrides.NewStore("", func() int64 { return 1 }, func(string) (int64, bool) { return 0, false }, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.Use(func(ctx *gin.Context) { ctx.Set(gin.AuthUserKey, "someone") })
+	NewOverridesController(logger, map[string]overrides.Store{"": store}).Register(router)
+
+	serve := func(method string, url string, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := serve(http.MethodPut, "/overrides/checkout_v2", `{"treatment":"off","reason":"incident","ttlSeconds":3600}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var stored overrides.Override
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &stored))
+	assert.Equal(t, "checkout_v2", stored.Split)
+	assert.Equal(t, "someone", stored.CreatedBy)
+	assert.NotNil(t, stored.ExpiresAt)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/overrides/f1", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/overrides/f1", `not json`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/overrides/f1", `{"treatment":"on","ttlSeconds":-10}`).Code)
+	assert.Equal(t, http.StatusBadRequest,
+		serve(http.MethodPut, "/overrides/f1", `{"treatment":"on","ttlSeconds":10,"expiresAt":"2030-01-01T00:00:00Z"}`).Code)
+
+	var listed struct {
+		Overrides []overrides.Override `json:"overrides"`
+	}
+	resp = serve(http.MethodGet, "/overrides", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	assert.Len(t, listed.Overrides, 1)
+	assert.Equal(t, "incident", listed.Overrides[0].Reason)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/overrides/checkout_v2", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/overrides/checkout_v2", "").Code)
+	assert.Empty(t, store.List())
+}
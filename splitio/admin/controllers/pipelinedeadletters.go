@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
+)
+
+// PipelineDeadLettersController bundles endpoints for inspecting, replaying & purging the batches of impressions,
+// events & unique keys that the synchronizer could not post to Split servers
+type PipelineDeadLettersController struct {
+	logger   logging.LoggerInterface
+	store    storage.DeadLetterStore
+	replayer *task.DeadLetterReplayer
+}
+
+// PipelineDeadLetterSummary is the representation of a pipeline dead letter returned by the admin API
+type PipelineDeadLetterSummary struct {
+	ID         int64       `json:"id"`
+	Pipeline   string      `json:"pipeline"`
+	URL        string      `json:"url"`
+	Attempts   int         `json:"attempts"`
+	LastError  string      `json:"lastError"`
+	StatusCode int         `json:"statusCode,omitempty"`
+	FailedAt   time.Time   `json:"failedAt"`
+	Size       int         `json:"size"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Payload    *string     `json:"payload,omitempty"`
+}
+
+// NewPipelineDeadLettersController constructs a new pipeline dead letters controller
+func NewPipelineDeadLettersController(
+	logger logging.LoggerInterface,
+	store storage.DeadLetterStore,
+	replayer *task.DeadLetterReplayer,
+) *PipelineDeadLettersController {
+	return &PipelineDeadLettersController{logger: logger, store: store, replayer: replayer}
+}
+
+// Register mounts the endpoints in the provided router
+func (c *PipelineDeadLettersController) Register(router gin.IRouter) {
+	router.GET("/deadletters", c.list)
+	router.GET("/deadletters/:id", c.get)
+	router.POST("/deadletters/replay", c.replay)
+	router.DELETE("/deadletters", c.purge)
+}
+
+// Endpoint functions \{
+
+func (c *PipelineDeadLettersController) list(ctx *gin.Context) {
+	filter, ok := parsePipelineDeadLetterFilter(ctx)
+	if !ok {
+		return
+	}
+
+	letters, err := c.store.List()
+	if err != nil {
+		c.logger.Error("error listing dead letters: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dropped, err := c.store.Dropped()
+	if err != nil {
+		c.logger.Error("error fetching dropped dead letters count: ", err)
+	}
+
+	summaries := make([]PipelineDeadLetterSummary, 0)
+	for idx := range letters {
+		if filter(&letters[idx]) {
+			summaries = append(summaries, summarizePipelineDeadLetter(&letters[idx], false))
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"items": summaries, "dropped": dropped})
+}
+
+func (c *PipelineDeadLettersController) get(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	letter, err := c.store.Get(id)
+	if err != nil {
+		c.logger.Error("error fetching dead letter: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if letter == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "dead letter not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, summarizePipelineDeadLetter(letter, true))
+}
+
+// replay posts the selected dead letters again. Only the ones that were accepted by Split servers are removed
+func (c *PipelineDeadLettersController) replay(ctx *gin.Context) {
+	filter, ok := parsePipelineDeadLetterFilter(ctx)
+	if !ok {
+		return
+	}
+
+	if c.replayer == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "replaying dead letters is not available"})
+		return
+	}
+
+	replayed, failed, err := c.replayer.Replay(filter)
+	if err != nil {
+		c.logger.Error("error replaying dead letters: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "replayed": replayed, "failed": failed})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"replayed": replayed, "failed": failed})
+}
+
+func (c *PipelineDeadLettersController) purge(ctx *gin.Context) {
+	filter, ok := parsePipelineDeadLetterFilter(ctx)
+	if !ok {
+		return
+	}
+
+	removed, err := c.store.Remove(filter)
+	if err != nil {
+		c.logger.Error("error purging dead letters: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"removed": len(removed)})
+}
+
+// \} -- end of endpoint functions
+
+// parsePipelineDeadLetterFilter builds a filter from the optional `pipeline` & `id` (can be repeated) query params.
+// If the params are invalid, a 400 is written & false is returned
+func parsePipelineDeadLetterFilter(ctx *gin.Context) (func(*storage.DeadLetter) bool, bool) {
+	pipeline := ctx.Query("pipeline")
+	ids := make(map[int64]struct{})
+	for _, raw := range ctx.QueryArray("id") {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + raw})
+			return nil, false
+		}
+		ids[id] = struct{}{}
+	}
+
+	return func(letter *storage.DeadLetter) bool {
+		if pipeline != "" && letter.Pipeline != pipeline {
+			return false
+		}
+		if len(ids) > 0 {
+			if _, ok := ids[letter.ID]; !ok {
+				return false
+			}
+		}
+		return true
+	}, true
+}
+
+func summarizePipelineDeadLetter(letter *storage.DeadLetter, includePayload bool) PipelineDeadLetterSummary {
+	summary := PipelineDeadLetterSummary{
+		ID:         letter.ID,
+		Pipeline:   letter.Pipeline,
+		URL:        letter.URL,
+		Attempts:   letter.Attempts,
+		LastError:  letter.LastError,
+		StatusCode: letter.StatusCode,
+		FailedAt:   letter.FailedAt,
+		Size:       len(letter.Body),
+	}
+
+	if includePayload {
+		asStr := string(letter.Body)
+		summary.Headers = letter.Headers
+		summary.Payload = &asStr
+	}
+	return summary
+}
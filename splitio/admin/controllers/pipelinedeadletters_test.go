@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
+)
+
+func TestPipelineDeadLettersInspectReplayAndPurge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer apikey" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	stored := []storage.DeadLetter{
+		{ID: 1, Pipeline: "impressions", Method: http.MethodPost, URL: server.URL, Body: []byte("i1"), Attempts: 3, StatusCode: 503},
+		{ID: 2, Pipeline: "events", Method: http.MethodPost, URL: server.URL, Body: []byte("e1"), Attempts: 3, StatusCode: 503},
+		{ID: 3, Pipeline: "events", Method: http.MethodPost, URL: "http://%invalid", Body: []byte("e2"), Attempts: 1},
+	}
+	store := &mocks.DeadLetterStoreMock{
+		ListCall: func() ([]storage.DeadLetter, error) { return append([]storage.DeadLetter(nil), stored...), nil },
+		GetCall: func(id int64) (*storage.DeadLetter, error) {
+			for idx := range stored {
+				if stored[idx].ID == id {
+					return &stored[idx], nil
+				}
+			}
+			return nil, nil
+		},
+		RemoveCall: func(filter func(*storage.DeadLetter) bool) ([]storage.DeadLetter, error) {
+			var removed, kept []storage.DeadLetter
+			for _, letter := range stored {
+				if filter(&letter) {
+					removed = append(removed, letter)
+				} else {
+					kept = append(kept, letter)
+				}
+			}
+			stored = kept
+			return removed, nil
+		},
+		DroppedCall: func() (int64, error) { return 7, nil },
+	}
+
+	logger := logging.NewLogger(nil)
+	ctrl := NewPipelineDeadLettersController(logger, store, task.NewDeadLetterReplayer(store, "apikey", time.Second, logger))
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+
+	serve := func(method string, url string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, nil)
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var listed struct {
+		Items   []PipelineDeadLetterSummary `json:"items"`
+		Dropped int64                       `json:"dropped"`
+	}
+	resp = serve(http.MethodGet, "/deadletters?pipeline=events")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &listed))
+	assert.Equal(t, int64(7), listed.Dropped)
+	assert.Equal(t, 2, len(listed.Items))
+	assert.Equal(t, int64(2), listed.Items[0].ID)
+	assert.Equal(t, 2, listed.Items[0].Size)
+	assert.Nil(t, listed.Items[0].Payload)
+
+	var single PipelineDeadLetterSummary
+	resp = serve(http.MethodGet, "/deadletters/1")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &single))
+	assert.Equal(t, "i1", *single.Payload)
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/deadletters/10").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/deadletters/abc").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/deadletters/replay?id=abc").Code)
+
+	var replayed struct {
+		Replayed int `json:"replayed"`
+		Failed   int `json:"failed"`
+	}
+	resp = serve(http.MethodPost, "/deadletters/replay?pipeline=events")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &replayed))
+	assert.Equal(t, 1, replayed.Replayed)
+	assert.Equal(t, 1, replayed.Failed)
+	assert.Equal(t, 2, len(stored))
+
+	resp = serve(http.MethodDelete, "/deadletters?id=3")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"removed":1}`, resp.Body.String())
+	assert.Equal(t, 1, len(stored))
+	assert.Equal(t, int64(1), stored[0].ID)
+}
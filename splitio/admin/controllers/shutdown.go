@@ -36,7 +36,8 @@ func (c *ShutdownController) stopProcess(ctx *gin.Context) {
 		toReturn = stopType
 		c.runtime.Kill()
 	case gracefulShutdown:
-		c.runtime.Shutdown()
+		// shutting down waits for in-flight admin requests (including this one) to complete, so it cannot block here
+		go c.runtime.Shutdown()
 	default:
 		ctx.String(http.StatusBadRequest, "Invalid sign type: %s", toReturn)
 		return
@@ -1,59 +1,76 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
-	"github.com/splitio/split-synchronizer/v5/splitio/common/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
 )
 
 // SnapshotController bundles endpoints associated to snapshot management
 type SnapshotController struct {
-	logger logging.LoggerInterface
-	db     storage.Snapshotter
+	logger  logging.LoggerInterface
+	builder *snapshot.Builder
+	loader  snapshots.Loader
 }
 
-// NewSnapshotController constructs a new snapshot controller
-func NewSnapshotController(logger logging.LoggerInterface, db storage.Snapshotter) *SnapshotController {
-	return &SnapshotController{logger: logger, db: db}
+// NewSnapshotController constructs a new snapshot controller. Snapshots can only be downloaded if a builder is
+// supplied, and uploaded if a loader is
+func NewSnapshotController(logger logging.LoggerInterface, builder *snapshot.Builder, loader snapshots.Loader) *SnapshotController {
+	return &SnapshotController{logger: logger, builder: builder, loader: loader}
 }
 
 // Register mounts the endpoints int he provided router
 func (c *SnapshotController) Register(router gin.IRouter) {
-	router.GET("/snapshot", c.downloadSnapshot)
+	if c.builder != nil {
+		router.GET("/snapshot", c.downloadSnapshot)
+	}
+	if c.loader != nil {
+		router.POST("/snapshot", c.loadSnapshot)
+	}
 }
 
 func (c *SnapshotController) downloadSnapshot(ctx *gin.Context) {
 	// curl http://localhost:3010/admin/proxy/snapshot --output split.proxy.0001.snapshot.gz
-	snapshotName := fmt.Sprintf("split.proxy.%d.snapshot", time.Now().UnixNano())
-	b, err := c.db.GetRawSnapshot()
-	if err != nil {
-		c.logger.Error("error getting contents from db to build snapshot: ", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error reading data"})
-		return
-	}
-
-	s, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB}, b)
+	encodedSnap, meta, err := c.builder.Build()
 	if err != nil {
 		c.logger.Error("error building snapshot: ", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error building snapshot"})
 		return
 	}
 
-	encodedSnap, err := s.Encode()
-	if err != nil {
-		c.logger.Error("error encoding snapshot: ", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error encoding snapshot"})
-		return
-	}
-
 	ctx.Writer.Header().Set("Content-Type", "application/octet-stream")
-	ctx.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, snapshotName))
+	ctx.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, snapshot.FileName(meta.CreatedAt)))
 	ctx.Writer.Header().Set("Content-Length", strconv.Itoa(len(encodedSnap)))
 	ctx.Writer.Write(encodedSnap)
 }
+
+// loadSnapshot replaces the data served with the one in the snapshot sent as the request body.
+// Snapshots older than the data currently served are only loaded if the `force` query param is set to true
+func (c *SnapshotController) loadSnapshot(ctx *gin.Context) {
+	// curl -X POST --data-binary @split.proxy.0001.snapshot http://localhost:3010/admin/snapshot?force=true
+	encoded, err := ctx.GetRawData()
+	if err != nil || len(encoded) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "a snapshot is required as the request body"})
+		return
+	}
+
+	force, _ := strconv.ParseBool(ctx.Query("force"))
+	result, err := c.loader.Load(encoded, force, actorFrom(ctx))
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, result)
+	case errors.Is(err, snapshots.ErrInvalidSnapshot), errors.Is(err, snapshots.ErrNoMatchingEnvironment):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, snapshots.ErrStaleSnapshot), errors.Is(err, snapshots.ErrSDKKeyMismatch):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error() + ". Use force=true to load it anyway"})
+	default:
+		c.logger.Error("error loading snapshot: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,6 +2,10 @@ package controllers
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 )
 
@@ -35,7 +40,7 @@ func TestDownloadProxySnapshot(t *testing.T) {
 		return
 	}
 
-	ctrl := NewSnapshotController(logging.NewLogger(nil), dbInstance)
+	ctrl := NewSnapshotController(logging.NewLogger(nil), snapshot.NewBuilder(dbInstance, nil, nil), nil)
 
 	resp := httptest.NewRecorder()
 	ctx, router := gin.CreateTestContext(resp)
@@ -76,3 +81,122 @@ func TestDownloadProxySnapshot(t *testing.T) {
 		t.Error("loaded snapshot is different to downloaded")
 	}
 }
+
+func TestDownloadSignedProxySnapshot(t *testing.T) {
+	snap, err := snapshot.DecodeFromFile("../../../test/snapshot/proxy.snapshot")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tmpDataFile, err := snap.WriteDataToTmpFile()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dbInstance, err := persistent.NewBoltWrapper(tmpDataFile, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	public, private, _ := ed25519.GenerateKey(nil)
+	describe := func() (snapshot.Metadata, error) {
+		return snapshot.Metadata{SpecVersion: "1.1", Environments: []snapshot.EnvironmentMetadata{{Name: "", Flags: 1, Segments: 1}}}, nil
+	}
+	ctrl := NewSnapshotController(logging.NewLogger(nil), snapshot.NewBuilder(dbInstance, private, describe), nil)
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/snapshot", nil)
+	router.ServeHTTP(resp, ctx.Request)
+
+	snapRes, err := snapshot.DecodeVerified(resp.Body.Bytes(), public)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !snapRes.Signed() {
+		t.Error("snapshot should be signed")
+	}
+
+	meta := snapRes.Meta()
+	if meta.Version != 1 || meta.Storage != snapshot.StorageBoltDB || meta.CreatedAt == 0 || meta.SpecVersion != "1.1" {
+		t.Error("unexpected metadata: ", meta)
+	}
+
+	if len(meta.Environments) != 1 || meta.Environments[0].Flags != 1 {
+		t.Error("unexpected environments metadata: ", meta.Environments)
+	}
+}
+
+type snapshotLoaderMock struct {
+	force bool
+	actor string
+	err   error
+}
+
+func (l *snapshotLoaderMock) Load(encoded []byte, force bool, actor string) (*snapshots.LoadResult, error) {
+	l.force, l.actor = force, actor
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &snapshots.LoadResult{Environments: []snapshots.EnvironmentResult{{Name: "(default)", ChangeNumber: int64(len(encoded))}}}, nil
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	loader := &snapshotLoaderMock{}
+	ctrl := NewSnapshotController(logging.NewLogger(nil), nil, loader)
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+
+	post := func(path string, body []byte) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		ctx.Request, _ = http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		router.ServeHTTP(resp, ctx.Request)
+		return resp
+	}
+
+	if resp := post("/snapshot", nil); resp.Code != http.StatusBadRequest {
+		t.Error("an empty body should be rejected. Got: ", resp.Code)
+	}
+
+	resp = post("/snapshot?force=true", []byte("some snapshot"))
+	if resp.Code != http.StatusOK || !loader.force || loader.actor != "admin" {
+		t.Error("the snapshot should be loaded. Got: ", resp.Code, loader.force, loader.actor)
+	}
+	var result snapshots.LoadResult
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil || len(result.Environments) != 1 || result.Environments[0].ChangeNumber != 13 {
+		t.Error("unexpected result: ", resp.Body.String(), err)
+	}
+
+	expectations := map[error]int{
+		fmt.Errorf("%w: something", snapshots.ErrInvalidSnapshot): http.StatusBadRequest,
+		snapshots.ErrNoMatchingEnvironment:                        http.StatusBadRequest,
+		fmt.Errorf("%w: something", snapshots.ErrStaleSnapshot):   http.StatusConflict,
+		fmt.Errorf("%w: something", snapshots.ErrSDKKeyMismatch):  http.StatusConflict,
+		errors.New("something"):                                   http.StatusInternalServerError,
+	}
+	for err, code := range expectations {
+		loader.err = err
+		if resp := post("/snapshot", []byte("some snapshot")); resp.Code != code {
+			t.Errorf("%s should result in a %d. Got: %d", err, code, resp.Code)
+		}
+	}
+
+	if resp := post("/snapshot", []byte("some snapshot")); loader.force {
+		t.Error("loads should not be forced unless requested. Got: ", resp.Code)
+	}
+
+	resp = httptest.NewRecorder()
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/snapshot", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	if resp.Code != http.StatusNotFound {
+		t.Error("snapshots should not be downloadable without a builder. Got: ", resp.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package dashboard
+
+const auditLog = `
+{{define "AuditLog"}}
+  <!-- AUDIT LOG -->
+  <div role="tabpanel" class="tab-pane" id="audit-log">
+    <div class="row">
+      <div class="col-md-12">
+        <div class="bg-primary metricBox">
+          <h4>Killed Flags <small>(killed locally until explicitly restored)</small></h4>
+          <table id="kill_rows" class="table table-condensed table-hover">
+            <thead>
+              <tr>
+                <th>Feature Flag</th>
+                <th>Default Treatment</th>
+                <th>Reason</th>
+                <th>Killed By</th>
+                <th>Killed At</th>
+              </tr>
+            </thead>
+            <tbody></tbody>
+          </table>
+        </div>
+      </div>
+    </div>
+
+    <div class="row">
+      <div class="col-md-12">
+        <div class="bg-primary metricBox">
+          <h4>Audit Log <small>(latest administrative changes, newest first)</small></h4>
+          <table id="audit_rows" class="table table-condensed table-hover">
+            <thead>
+              <tr>
+                <th>Time</th>
+                <th>Actor</th>
+                <th>Action</th>
+                <th>Subject</th>
+                <th>Details</th>
+              </tr>
+            </thead>
+            <tbody></tbody>
+          </table>
+        </div>
+      </div>
+    </div>
+  </div>
+{{end}}
+`
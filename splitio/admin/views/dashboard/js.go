@@ -322,6 +322,135 @@ const mainScript = `
     $('#segment_rows tbody').append(formatted);
   };
 
+  function formatSpool(spool) {
+    return (
+      '<tr>' +
+      '  <td>' + spool.name + '</td>' +
+      '  <td>' + spool.items + '</td>' +
+      '  <td>' + spool.bytes + '</td>' +
+      '  <td>' + (spool.maxBytes > 0 ? spool.maxBytes : 'unbounded') + '</td>' +
+      (spool.dropped > 0 ? '<td class="danger">' + spool.dropped + '</td>' : '<td>0</td>') +
+      '</tr>\n');
+  };
+
+  function updateSpools(spools) {
+    $('#spool_rows tbody').empty();
+    if (spools == null || spools.length == 0) {
+      $('#spool_rows tbody').append('<tr><td colspan="5">Spool disabled</td></tr>');
+      return;
+    }
+    $('#spool_rows tbody').append(spools.map(formatSpool).join('\n'));
+  };
+
+  function formatPipelineSetting(current, min, max, autoscaling) {
+    if (current == 0) {
+      return 'n/a';
+    }
+    return autoscaling ? current + ' <small>(' + min + ' - ' + max + ')</small>' : current;
+  };
+
+  function formatPipeline(pipeline) {
+    return (
+      '<tr>' +
+      '  <td>' + pipeline.name + '</td>' +
+      '  <td>' + (pipeline.autoscaling ? 'enabled' : 'disabled') + '</td>' +
+      '  <td>' + formatPipelineSetting(pipeline.fetchSize, pipeline.minFetchSize, pipeline.maxFetchSize, pipeline.autoscaling) + '</td>' +
+      '  <td>' + formatPipelineSetting(pipeline.processConcurrency, pipeline.minProcessConcurrency, pipeline.maxProcessConcurrency, pipeline.autoscaling) + '</td>' +
+      '  <td>' + formatPipelineSetting(pipeline.postConcurrency, pipeline.minPostConcurrency, pipeline.maxPostConcurrency, pipeline.autoscaling) + '</td>' +
+      '</tr>\n');
+  };
+
+  function formatPipelineAdjustment(adjustment) {
+    return (
+      '<tr>' +
+      '  <td>' + adjustment.time + '</td>' +
+      '  <td>' + adjustment.pipeline + '</td>' +
+      (adjustment.lambda < 1 ? '<td class="danger">' : '<td>') + adjustment.lambda.toFixed(2) + '</td>' +
+      '  <td>' + adjustment.reason + '</td>' +
+      '  <td>' + adjustment.from + '</td>' +
+      '  <td>' + adjustment.to + '</td>' +
+      '</tr>\n');
+  };
+
+  function updatePipelines(pipelines, adjustments) {
+    $('#pipeline_rows tbody').empty();
+    if (pipelines != null) {
+      $('#pipeline_rows tbody').append(pipelines.map(formatPipeline).join('\n'));
+    }
+
+    $('#pipeline_adjustment_rows tbody').empty();
+    if (adjustments == null || adjustments.length == 0) {
+      $('#pipeline_adjustment_rows tbody').append('<tr><td colspan="6">No adjustments made</td></tr>');
+      return;
+    }
+    $('#pipeline_adjustment_rows tbody').append(adjustments.map(formatPipelineAdjustment).join('\n'));
+  };
+
+  function formatOverride(override) {
+    return (
+      '<tr>' +
+      '  <td>' + override.name + '</td>' +
+      '  <td>' + (override.kill ? 'killed, serving ' + override.treatment : override.treatment) + '</td>' +
+      '  <td>' + escapeHtml(override.reason) + '</td>' +
+      '  <td>' + escapeHtml(override.createdBy) + '</td>' +
+      '  <td>' + override.createdAt + '</td>' +
+      '  <td>' + (override.expiresAt != '' ? override.expiresAt : 'never') + '</td>' +
+      '</tr>\n');
+  };
+
+  function updateOverrides(overrides) {
+    $('#override_rows tbody').empty();
+    if (overrides == null || overrides.length == 0) {
+      $('#override_rows tbody').append('<tr><td colspan="6">No overrides</td></tr>');
+      return;
+    }
+    $('#override_rows tbody').append(overrides.map(formatOverride).join('\n'));
+  };
+
+  function escapeHtml(text) {
+    return $('<div>').text(text).html();
+  };
+
+  function formatKill(kill) {
+    return (
+      '<tr>' +
+      '  <td>' + kill.name + '</td>' +
+      '  <td>' + kill.defaultTreatment + '</td>' +
+      '  <td>' + escapeHtml(kill.reason) + '</td>' +
+      '  <td>' + escapeHtml(kill.killedBy) + '</td>' +
+      '  <td>' + kill.killedAt + '</td>' +
+      '</tr>\n');
+  };
+
+  function updateKills(kills) {
+    $('#kill_rows tbody').empty();
+    if (kills == null || kills.length == 0) {
+      $('#kill_rows tbody').append('<tr><td colspan="5">No flags killed locally</td></tr>');
+      return;
+    }
+    $('#kill_rows tbody').append(kills.map(formatKill).join('\n'));
+  };
+
+  function formatAuditEntry(entry) {
+    return (
+      '<tr>' +
+      '  <td>' + entry.time + '</td>' +
+      '  <td>' + escapeHtml(entry.actor) + '</td>' +
+      '  <td>' + entry.action + '</td>' +
+      '  <td>' + escapeHtml(entry.subject) + '</td>' +
+      '  <td>' + escapeHtml(entry.details) + '</td>' +
+      '</tr>\n');
+  };
+
+  function updateAuditLog(entries) {
+    $('#audit_rows tbody').empty();
+    if (entries == null || entries.length == 0) {
+      $('#audit_rows tbody').append('<tr><td colspan="5">No administrative changes</td></tr>');
+      return;
+    }
+    $('#audit_rows tbody').append(entries.map(formatAuditEntry).join('\n'));
+  };
+
   function updateMetricCards(stats) {
     $('#impressions_queue_value_section').html(stats.impressionsQueueSize);
     $('#impressions_lambda_section').html(stats.impressionsLambda);
@@ -429,10 +558,16 @@ const mainScript = `
     updateSegments(stats.segments);
     updateLogEntries(stats.loggedMessages);
     updateFlagSets(stats.flagSets)
+    updateKills(stats.kills);
+    updateAuditLog(stats.auditEntries);
 
     renderBackendStatsChart(stats.backendLatencies);
     {{if .ProxyMode}}
         renderSDKChart(stats.latencies);
+        updateSpools(stats.spools);
+        updateOverrides(stats.overrides);
+    {{else}}
+        updatePipelines(stats.pipelines, stats.pipelineAdjustments);
     {{end}}
   };
 
@@ -67,6 +67,7 @@ const main = `
       {{if .ProxyMode}}{{template "SdkStats" .}}{{end}}
       {{if not .ProxyMode}}{{template "QueueManager" .}}{{end}}
       {{template "DataInspector" .}}
+      {{template "AuditLog" .}}
     </div>
   </div>
    {{template "MainScript" .}}
@@ -88,24 +89,94 @@ type RootObject struct {
 
 // GlobalStats runtime stats used to render the dashboard
 type GlobalStats struct {
-	BackendTotalRequests   int64             `json:"backendTotalRequests"`
-	RequestsOk             int64             `json:"requestsOk"`
-	RequestsErrored        int64             `json:"requestsErrored"`
-	BackendRequestsOk      int64             `json:"backendRequestsOk"`
-	BackendRequestsErrored int64             `json:"backendRequestsErrored"`
-	SdksTotalRequests      int64             `json:"sdksTotalRequests"`
-	LoggedErrors           int64             `json:"loggedErrors"`
-	LoggedMessages         []string          `json:"loggedMessages"`
-	FeatureFlags           []SplitSummary    `json:"featureFlags"`
-	Segments               []SegmentSummary  `json:"segments"`
-	Latencies              []ChartJSData     `json:"latencies"`
-	BackendLatencies       []ChartJSData     `json:"backendLatencies"`
-	ImpressionsQueueSize   int64             `json:"impressionsQueueSize"`
-	ImpressionsLambda      float64           `json:"impressionsLambda"`
-	EventsQueueSize        int64             `json:"eventsQueueSize"`
-	EventsLambda           float64           `json:"eventsLambda"`
-	Uptime                 int64             `json:"uptime"`
-	FlagSets               []FlagSetsSummary `json:"flagSets"`
+	BackendTotalRequests   int64                       `json:"backendTotalRequests"`
+	RequestsOk             int64                       `json:"requestsOk"`
+	RequestsErrored        int64                       `json:"requestsErrored"`
+	BackendRequestsOk      int64                       `json:"backendRequestsOk"`
+	BackendRequestsErrored int64                       `json:"backendRequestsErrored"`
+	SdksTotalRequests      int64                       `json:"sdksTotalRequests"`
+	LoggedErrors           int64                       `json:"loggedErrors"`
+	LoggedMessages         []string                    `json:"loggedMessages"`
+	FeatureFlags           []SplitSummary              `json:"featureFlags"`
+	Segments               []SegmentSummary            `json:"segments"`
+	Latencies              []ChartJSData               `json:"latencies"`
+	BackendLatencies       []ChartJSData               `json:"backendLatencies"`
+	ImpressionsQueueSize   int64                       `json:"impressionsQueueSize"`
+	ImpressionsLambda      float64                     `json:"impressionsLambda"`
+	EventsQueueSize        int64                       `json:"eventsQueueSize"`
+	EventsLambda           float64                     `json:"eventsLambda"`
+	Uptime                 int64                       `json:"uptime"`
+	FlagSets               []FlagSetsSummary           `json:"flagSets"`
+	Spools                 []SpoolSummary              `json:"spools"`
+	Overrides              []OverrideSummary           `json:"overrides"`
+	Kills                  []KillSummary               `json:"kills"`
+	AuditEntries           []AuditEntrySummary         `json:"auditEntries"`
+	Pipelines              []PipelineSummary           `json:"pipelines"`
+	PipelineAdjustments    []PipelineAdjustmentSummary `json:"pipelineAdjustments"`
+}
+
+// PipelineSummary encapsulates the current settings of an impressions/events pipeline & the bounds they're adjusted within
+type PipelineSummary struct {
+	Name                  string `json:"name"`
+	Autoscaling           bool   `json:"autoscaling"`
+	FetchSize             int    `json:"fetchSize"`
+	MinFetchSize          int    `json:"minFetchSize"`
+	MaxFetchSize          int    `json:"maxFetchSize"`
+	ProcessConcurrency    int    `json:"processConcurrency"`
+	MinProcessConcurrency int    `json:"minProcessConcurrency"`
+	MaxProcessConcurrency int    `json:"maxProcessConcurrency"`
+	PostConcurrency       int    `json:"postConcurrency"`
+	MinPostConcurrency    int    `json:"minPostConcurrency"`
+	MaxPostConcurrency    int    `json:"maxPostConcurrency"`
+}
+
+// PipelineAdjustmentSummary encapsulates a change made by the autoscaler to the settings of a pipeline
+type PipelineAdjustmentSummary struct {
+	Time     string  `json:"time"`
+	Pipeline string  `json:"pipeline"`
+	Lambda   float64 `json:"lambda"`
+	Reason   string  `json:"reason"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+}
+
+// KillSummary encapsulates a feature flag killed locally
+type KillSummary struct {
+	Name             string `json:"name"`
+	DefaultTreatment string `json:"defaultTreatment"`
+	Reason           string `json:"reason"`
+	KilledBy         string `json:"killedBy"`
+	KilledAt         string `json:"killedAt"`
+}
+
+// AuditEntrySummary encapsulates an administrative change performed at runtime
+type AuditEntrySummary struct {
+	Time    string `json:"time"`
+	Actor   string `json:"actor"`
+	Action  string `json:"action"`
+	Subject string `json:"subject"`
+	Details string `json:"details"`
+}
+
+// OverrideSummary encapsulates a local override forcing a feature flag to serve a single treatment
+type OverrideSummary struct {
+	Name         string `json:"name"`
+	Treatment    string `json:"treatment"`
+	Kill         bool   `json:"kill"`
+	Reason       string `json:"reason"`
+	CreatedBy    string `json:"createdBy"`
+	CreatedAt    string `json:"createdAt"`
+	ExpiresAt    string `json:"expiresAt"`
+	ChangeNumber int64  `json:"changeNumber"`
+}
+
+// SpoolSummary encapsulates the state of an on-disk queue holding data that didn't fit in memory
+type SpoolSummary struct {
+	Name     string `json:"name"`
+	Items    int64  `json:"items"`
+	Bytes    int64  `json:"bytes"`
+	MaxBytes int64  `json:"maxBytes"`
+	Dropped  int64  `json:"dropped"`
 }
 
 // SplitSummary encapsulates a minimalistic view of feature flag properties to be presented in the dashboard
@@ -185,6 +256,7 @@ func AssembleDashboardTemplate() (*template.Template, error) {
 		upstreamStats,
 		queueManager,
 		dataInspector,
+		auditLog,
 		menu,
 		mainScript,
 		// Main layout
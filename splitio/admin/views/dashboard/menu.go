@@ -32,6 +32,11 @@ const menu = `
         <span class="glyphicon glyphicon-search" aria-hidden="true"></span>&nbsp;Data inspector
       </a>
     </li>
+    <li role="presentation">
+      <a href="#audit-log" aria-controls="audit-log" role="tab" data-toggle="tab">
+        <span class="glyphicon glyphicon-list-alt" aria-hidden="true"></span>&nbsp;Audit log
+      </a>
+    </li>
   </ul>
 {{end}}
 `
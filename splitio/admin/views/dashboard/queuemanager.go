@@ -33,6 +33,47 @@ const queueManager = `
         </div>
       </div>
     </div>
+
+    <div class="row">
+      <div class="col-md-12">
+        <div class="gray1Box metricBox">
+          <h4>Pipelines <small>(current settings, adjusted within bounds when autoscaling)</small></h4>
+          <table id="pipeline_rows" class="table table-condensed table-hover">
+            <thead>
+              <tr>
+                <th>Pipeline</th>
+                <th>Autoscaling</th>
+                <th>Fetch Size</th>
+                <th>Process Concurrency</th>
+                <th>Post Concurrency</th>
+              </tr>
+            </thead>
+            <tbody></tbody>
+          </table>
+        </div>
+      </div>
+    </div>
+
+    <div class="row">
+      <div class="col-md-12">
+        <div class="gray1Box metricBox">
+          <h4>Autoscaling Adjustments <small>(newest first)</small></h4>
+          <table id="pipeline_adjustment_rows" class="table table-condensed table-hover">
+            <thead>
+              <tr>
+                <th>Time</th>
+                <th>Pipeline</th>
+                <th>Lambda</th>
+                <th>Reason</th>
+                <th>From</th>
+                <th>To</th>
+              </tr>
+            </thead>
+            <tbody></tbody>
+          </table>
+        </div>
+      </div>
+    </div>
     </br>
     </br>
     </br>
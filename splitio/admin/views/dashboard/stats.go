@@ -331,6 +331,47 @@ const sdkStats = `
         </div>
       </div>
     </div>
+
+    <div class="row">
+      <div class="col-md-12">
+        <div class="bg-primary metricBox">
+          <h4>Disk Spool <small>(data that didn't fit in memory)</small></h4>
+          <table id="spool_rows" class="table table-condensed table-hover">
+            <thead>
+              <tr>
+                <th>Queue</th>
+                <th>Items</th>
+                <th>Size (bytes)</th>
+                <th>Max Size (bytes)</th>
+                <th>Dropped</th>
+              </tr>
+            </thead>
+            <tbody></tbody>
+          </table>
+        </div>
+      </div>
+    </div>
+
+    <div class="row">
+      <div class="col-md-12">
+        <div class="bg-primary metricBox">
+          <h4>Flag Overrides <small>(treatments forced locally on all traffic)</small></h4>
+          <table id="override_rows" class="table table-condensed table-hover">
+            <thead>
+              <tr>
+                <th>Feature Flag</th>
+                <th>Treatment</th>
+                <th>Reason</th>
+                <th>Set By</th>
+                <th>Set At</th>
+                <th>Expires At</th>
+              </tr>
+            </thead>
+            <tbody></tbody>
+          </table>
+        </div>
+      </div>
+    </div>
   </div>
 {{end}}
 `
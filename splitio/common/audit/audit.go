@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// Entry is a record of an administrative change performed at runtime
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	Actor   string            `json:"actor"`
+	Action  string            `json:"action"`
+	Subject string            `json:"subject"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Log defines the interface for a component recording administrative changes
+type Log interface {
+	Record(actor string, action string, subject string, details map[string]string)
+	Entries() []Entry
+}
+
+// LogImpl keeps the latest `maxEntries` entries in memory, and optionally appends all of them to a file as json lines
+type LogImpl struct {
+	logger     logging.LoggerInterface
+	entries    []Entry
+	maxEntries int
+	file       *os.File
+	mutex      sync.Mutex
+}
+
+// NewLog constructs a new audit log. If `path` is empty, entries are only kept in memory
+func NewLog(logger logging.LoggerInterface, maxEntries int, path string) (*LogImpl, error) {
+	toRet := &LogImpl{logger: logger, maxEntries: maxEntries}
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("error opening audit log file: %w", err)
+		}
+		toRet.file = file
+	}
+	return toRet, nil
+}
+
+// Record adds a new entry to the audit log
+func (l *LogImpl) Record(actor string, action string, subject string, details map[string]string) {
+	entry := Entry{Time: time.Now(), Actor: actor, Action: action, Subject: subject, Details: details}
+	l.logger.Info(fmt.Sprintf("[audit] %s: %s %s %v", actor, action, subject, details))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.maxEntries > 0 {
+		if len(l.entries) >= l.maxEntries {
+			l.entries = append(l.entries[:0:0], l.entries[len(l.entries)-l.maxEntries+1:]...)
+		}
+		l.entries = append(l.entries, entry)
+	}
+
+	if l.file == nil {
+		return
+	}
+
+	serialized, err := json.Marshal(entry)
+	if err != nil {
+		l.logger.Error("error serializing audit log entry: ", err)
+		return
+	}
+	if _, err := l.file.Write(append(serialized, '\n')); err != nil {
+		l.logger.Error("error writing audit log entry to file: ", err)
+	}
+}
+
+// Entries returns the entries kept in memory, oldest first
+func (l *LogImpl) Entries() []Entry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// taggedLog decorates a log adding the same details to every entry recorded through it
+type taggedLog struct {
+	Log
+	tags map[string]string
+}
+
+// WithDetails returns a log that records entries in the supplied one, including `tags` in their details
+func WithDetails(log Log, tags map[string]string) Log {
+	return &taggedLog{Log: log, tags: tags}
+}
+
+// Record adds a new entry to the underlying log, along with the tags
+func (l *taggedLog) Record(actor string, action string, subject string, details map[string]string) {
+	merged := make(map[string]string, len(details)+len(l.tags))
+	for key, value := range details {
+		merged[key] = value
+	}
+	for key, value := range l.tags {
+		merged[key] = value
+	}
+	l.Log.Record(actor, action, subject, merged)
+}
+
+var _ Log = (*LogImpl)(nil)
+var _ Log = (*taggedLog)(nil)
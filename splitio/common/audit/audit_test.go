@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := NewLog(logging.NewLogger(nil), 2, path)
+	assert.Nil(t, err)
+
+	log.Record("admin", "add", "key1", nil)
+	log.Record("admin", "revoke", "key1", map[string]string{"reason": "rotation"})
+	log.Record("file", "add", "key2", nil)
+
+	entries := log.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "revoke", entries[0].Action)
+	assert.Equal(t, "rotation", entries[0].Details["reason"])
+	assert.Equal(t, "file", entries[1].Actor)
+
+	// all entries are persisted, regardless of how many are kept in memory
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+	var persisted []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &entry))
+		persisted = append(persisted, entry)
+	}
+	assert.Len(t, persisted, 3)
+	assert.Equal(t, "key1", persisted[0].Subject)
+}
+
+func TestAuditLogWithDetails(t *testing.T) {
+	log, err := NewLog(logging.NewLogger(nil), 10, "")
+	assert.Nil(t, err)
+
+	tagged := WithDetails(log, map[string]string{"environment": "staging"})
+	tagged.Record("admin", "flag.kill", "f1", map[string]string{"reason": "incident"})
+	tagged.Record("admin", "flag.restore", "f1", nil)
+
+	entries := tagged.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, map[string]string{"environment": "staging", "reason": "incident"}, entries[0].Details)
+	assert.Equal(t, map[string]string{"environment": "staging"}, entries[1].Details)
+}
@@ -12,7 +12,7 @@ type Logging struct {
 type Admin struct {
 	Host     string `json:"host" s-cli:"admin-host" s-def:"0.0.0.0" s-desc:"Host where the admin server will listen"`
 	Port     int64  `json:"port" s-cli:"admin-port" s-def:"3010" s-desc:"Admin port where incoming connections will be accepted"`
-	Username string `json:"username" s-cli:"admin-username" s-def:"" s-desc:"HTTP basic auth username for admin endpoints"`
+	Username string `json:"username" s-cli:"admin-username" s-def:"" s-desc:"HTTP basic auth username for admin endpoints. Endpoints that change the app state are disabled unless username & password are set"`
 	Password string `json:"password" s-cli:"admin-password" s-def:"" s-desc:"HTTP basic auth password for admin endpoints"`
 	SecureHC bool   `json:"secureChecks" s-cli:"admin-secure-hc" s-def:"false" s-desc:"Secure Healthcheck endpoints as well."`
 	TLS      TLS    `json:"tls" s-nested:"true" s-cli-prefix:"admin"`
@@ -47,3 +47,15 @@ type TLS struct {
 	MinTLSVersion            string `json:"minTlsVersion" s-cli:"tls-min-tls-version" s-def:"1.3" s-desc:"Minimum TLS version to allow X.Y"`
 	AllowedCipherSuites      string `json:"allowedCipherSuites" s-cli:"tls-allowed-cipher-suites" s-def:"" s-desc:"Comma-separated list of cipher suites to allow"`
 }
+
+// AuditLog configuration options
+type AuditLog struct {
+	Filename   string `json:"filename" s-cli:"audit-log-fn" s-def:"" s-desc:"File to append administrative changes to. (Default: only kept in memory)"`
+	MaxEntries int64  `json:"maxEntries" s-cli:"audit-log-max-entries" s-def:"1000" s-desc:"How many administrative changes to keep in memory"`
+}
+
+// SnapshotSigning configuration options
+type SnapshotSigning struct {
+	PrivateKeyFN string `json:"privateKeyFn" s-cli:"snapshot-signing-key-fn" s-def:"" s-desc:"PEM Ed25519 private key used to sign the snapshots generated"`
+	PublicKeyFN  string `json:"publicKeyFn" s-cli:"snapshot-verification-key-fn" s-def:"" s-desc:"PEM Ed25519 public key. If set, snapshots not signed with the matching private key are rejected"`
+}
@@ -0,0 +1,36 @@
+package killswitch
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUnknownFlag is returned when attempting to kill a feature flag that's not present in the storage
+	ErrUnknownFlag = errors.New("unknown feature flag")
+
+	// ErrNotKilled is returned when attempting to restore a feature flag that hasn't been killed locally
+	ErrNotKilled = errors.New("feature flag is not killed locally")
+)
+
+// Kill is a feature flag killed locally, which keeps serving its default treatment until it's explicitly restored
+type Kill struct {
+	Split            string    `json:"split"`
+	DefaultTreatment string    `json:"defaultTreatment"`
+	Reason           string    `json:"reason,omitempty"`
+	KilledBy         string    `json:"killedBy"`
+	KilledAt         time.Time `json:"killedAt"`
+}
+
+// Switch defines the interface of a component used to kill & restore feature flags locally.
+// If `defaultTreatment` is empty when killing a flag, the flag's current default treatment is used
+type Switch interface {
+	List() []Kill
+	Kill(split string, defaultTreatment string, reason string, actor string) (*Kill, error)
+	Restore(split string, reason string, actor string) error
+}
+
+// AuditLog defines the interface used to record every kill & restore
+type AuditLog interface {
+	Record(actor string, action string, subject string, details map[string]string)
+}
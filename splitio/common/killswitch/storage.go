@@ -0,0 +1,229 @@
+package killswitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// FlagStorage is the subset of the feature flag storage used to apply kills
+type FlagStorage interface {
+	Split(name string) *dtos.SplitDTO
+	ChangeNumber() (int64, error)
+	Update(toAdd []dtos.SplitDTO, toRemove []dtos.SplitDTO, changeNumber int64)
+}
+
+// record is a kill along with the upstream definition of the flag, used to restore it
+type record struct {
+	Kill
+	Original dtos.SplitDTO `json:"original"`
+}
+
+// StorageSwitch kills feature flags by writing a killed version of them to the storage read by SDKs, and saves kills
+// to a file (if a path is supplied) so that they're applied again after a restart.
+// Since upstream changes overwrite the stored flags, kills must be re-applied after every sync (see SplitUpdater)
+type StorageSwitch struct {
+	path     string
+	storage  FlagStorage
+	auditLog AuditLog
+	logger   logging.LoggerInterface
+	clock    func() time.Time
+	kills    map[string]*record
+	mutex    sync.Mutex
+}
+
+// NewStorageSwitch constructs a new kill switch operating on the supplied storage, loading previous kills from `path`
+func NewStorageSwitch(path string, storage FlagStorage, auditLog AuditLog, logger logging.LoggerInterface) (*StorageSwitch, error) {
+	toRet := &StorageSwitch{
+		path:     path,
+		storage:  storage,
+		auditLog: auditLog,
+		logger:   logger,
+		clock:    time.Now,
+		kills:    make(map[string]*record),
+	}
+	if err := toRet.load(); err != nil {
+		return nil, err
+	}
+	return toRet, nil
+}
+
+// List returns the flags killed locally, sorted by name
+func (s *StorageSwitch) List() []Kill {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	toRet := make([]Kill, 0, len(s.kills))
+	for _, r := range s.kills {
+		toRet = append(toRet, r.Kill)
+	}
+	sort.Slice(toRet, func(i, j int) bool { return toRet[i].Split < toRet[j].Split })
+	return toRet
+}
+
+// Kill kills a feature flag locally, or updates the default treatment of one already killed
+func (s *StorageSwitch) Kill(split string, defaultTreatment string, reason string, actor string) (*Kill, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	current := s.storage.Split(split)
+	if current == nil {
+		return nil, ErrUnknownFlag
+	}
+
+	// if the flag is already killed, the stored version is the killed one
+	previous, existed := s.kills[split]
+	original := *current
+	if existed && current.ChangeNumber == previous.Original.ChangeNumber {
+		original = previous.Original
+	}
+
+	if defaultTreatment == "" {
+		defaultTreatment = original.DefaultTreatment
+	}
+
+	r := &record{
+		Kill: Kill{
+			Split:            split,
+			DefaultTreatment: defaultTreatment,
+			Reason:           reason,
+			KilledBy:         actor,
+			KilledAt:         s.clock(),
+		},
+		Original: original,
+	}
+	s.kills[split] = r
+	if err := s.save(); err != nil {
+		delete(s.kills, split)
+		if existed {
+			s.kills[split] = previous
+		}
+		return nil, err
+	}
+
+	s.apply(r)
+	s.auditLog.Record(actor, "flag.kill", split, map[string]string{"defaultTreatment": defaultTreatment, "reason": reason})
+	return &r.Kill, nil
+}
+
+// Restore lifts the kill of a feature flag, writing back its upstream definition
+func (s *StorageSwitch) Restore(split string, reason string, actor string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r, ok := s.kills[split]
+	if !ok {
+		return ErrNotKilled
+	}
+
+	delete(s.kills, split)
+	if err := s.save(); err != nil {
+		s.kills[split] = r
+		return err
+	}
+
+	// only write the original definition back if it hasn't changed upstream in the meantime
+	if current := s.storage.Split(split); current != nil && current.ChangeNumber == r.Original.ChangeNumber {
+		cn, _ := s.storage.ChangeNumber()
+		s.storage.Update([]dtos.SplitDTO{r.Original}, nil, cn)
+	}
+
+	s.auditLog.Record(actor, "flag.restore", split, map[string]string{"reason": reason})
+	return nil
+}
+
+// -- internal, must be called with the lock held
+
+// reapply kills flags again after they've been overwritten, keeping their latest upstream definition for restoring them
+func (s *StorageSwitch) reapply() {
+	var changed bool
+	for name, r := range s.kills {
+		current := s.storage.Split(name)
+		if current == nil {
+			continue // archived upstream. The kill is kept in case the flag comes back
+		}
+
+		if current.ChangeNumber != r.Original.ChangeNumber {
+			r.Original = *current
+			changed = true
+			s.apply(r)
+		} else if !current.Killed || current.DefaultTreatment != r.DefaultTreatment {
+			s.apply(r)
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := s.save(); err != nil {
+		s.logger.Error("error saving killed feature flags: ", err)
+	}
+}
+
+// apply writes the killed version of a flag, keeping its change number so that upstream updates can be told apart
+func (s *StorageSwitch) apply(r *record) {
+	killed := r.Original
+	killed.Killed = true
+	killed.DefaultTreatment = r.DefaultTreatment
+	cn, _ := s.storage.ChangeNumber()
+	s.storage.Update([]dtos.SplitDTO{killed}, nil, cn)
+}
+
+func (s *StorageSwitch) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading killed feature flags file: %w", err)
+	}
+
+	var records []*record
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return fmt.Errorf("error parsing killed feature flags file: %w", err)
+	}
+
+	for _, r := range records {
+		s.kills[r.Split] = r
+	}
+	s.logger.Info(fmt.Sprintf("Loaded %d locally killed feature flags from %s", len(s.kills), s.path))
+	return nil
+}
+
+// save writes the kills to a temporary file which then replaces the previous one, so that it's never left half-written
+func (s *StorageSwitch) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	records := make([]*record, 0, len(s.kills))
+	for _, r := range s.kills {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Split < records[j].Split })
+
+	serialized, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing killed feature flags: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, serialized, 0600); err != nil {
+		return fmt.Errorf("error writing killed feature flags file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error replacing killed feature flags file: %w", err)
+	}
+	return nil
+}
+
+var _ Switch = (*StorageSwitch)(nil)
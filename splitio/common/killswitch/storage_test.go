@@ -0,0 +1,99 @@
+package killswitch
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+)
+
+type flagStorageMock struct {
+	flags map[string]dtos.SplitDTO
+	cn    int64
+}
+
+func (m *flagStorageMock) Split(name string) *dtos.SplitDTO {
+	flag, ok := m.flags[name]
+	if !ok {
+		return nil
+	}
+	return &flag
+}
+
+func (m *flagStorageMock) ChangeNumber() (int64, error) { return m.cn, nil }
+
+func (m *flagStorageMock) Update(toAdd []dtos.SplitDTO, toRemove []dtos.SplitDTO, changeNumber int64) {
+	for _, flag := range toAdd {
+		m.flags[flag.Name] = flag
+	}
+	for _, flag := range toRemove {
+		delete(m.flags, flag.Name)
+	}
+	m.cn = changeNumber
+}
+
+func TestStorageSwitch(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	storage := &flagStorageMock{cn: 10, flags: map[string]dtos.SplitDTO{
+		"f1": {Name: "f1", ChangeNumber: 5, DefaultTreatment: "on"},
+		"f2": {Name: "f2", ChangeNumber: 10, DefaultTreatment: "off"},
+	}}
+
+	path := filepath.Join(t.TempDir(), "kills.json")
+	kills, err := NewStorageSwitch(path, storage, auditLog, logger)
+	assert.Nil(t, err)
+
+	_, err = kills.Kill("nonexistent", "", "", "someone")
+	assert.ErrorIs(t, err, ErrUnknownFlag)
+	assert.ErrorIs(t, kills.Restore("f1", "", "someone"), ErrNotKilled)
+
+	// the flag keeps its change number & the current default treatment is used if none is supplied
+	kill, err := kills.Kill("f1", "", "incident", "someone")
+	assert.Nil(t, err)
+	assert.Equal(t, "on", kill.DefaultTreatment)
+	assert.Equal(t, "someone", kill.KilledBy)
+	assert.True(t, storage.flags["f1"].Killed)
+	assert.Equal(t, int64(5), storage.flags["f1"].ChangeNumber)
+	assert.Equal(t, int64(10), storage.cn)
+
+	// killing it again updates the default treatment, but the original definition is kept
+	_, err = kills.Kill("f1", "off", "incident", "someone")
+	assert.Nil(t, err)
+	assert.Equal(t, "off", storage.flags["f1"].DefaultTreatment)
+	assert.Equal(t, "on", kills.kills["f1"].Original.DefaultTreatment)
+	assert.Len(t, kills.List(), 1)
+
+	// upstream updates are killed again, & become the definition to restore
+	storage.Update([]dtos.SplitDTO{{Name: "f1", ChangeNumber: 11, DefaultTreatment: "v2"}}, nil, 11)
+	kills.reapply()
+	assert.True(t, storage.flags["f1"].Killed)
+	assert.Equal(t, "off", storage.flags["f1"].DefaultTreatment)
+	assert.Equal(t, int64(11), kills.kills["f1"].Original.ChangeNumber)
+
+	// kills survive restarts
+	reloaded, err := NewStorageSwitch(path, storage, auditLog, logger)
+	assert.Nil(t, err)
+	restored := reloaded.List()
+	assert.Len(t, restored, 1)
+	assert.Equal(t, "f1", restored[0].Split)
+	assert.Equal(t, "off", restored[0].DefaultTreatment)
+	assert.Equal(t, "incident", restored[0].Reason)
+
+	assert.Nil(t, reloaded.Restore("f1", "fixed", "other"))
+	assert.Equal(t, dtos.SplitDTO{Name: "f1", ChangeNumber: 11, DefaultTreatment: "v2"}, storage.flags["f1"])
+	assert.Empty(t, reloaded.List())
+
+	entries := auditLog.Entries()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "flag.kill", entries[0].Action)
+	assert.Equal(t, "flag.restore", entries[2].Action)
+	assert.Equal(t, "other", entries[2].Actor)
+	assert.Equal(t, "fixed", entries[2].Details["reason"])
+}
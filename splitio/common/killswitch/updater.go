@@ -0,0 +1,44 @@
+package killswitch
+
+import (
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
+)
+
+// SplitUpdater wraps a feature flag updater and kills flags again after every sync, so that local kills win over
+// upstream changes until they're explicitly restored
+type SplitUpdater struct {
+	wrapped split.Updater
+	kills   *StorageSwitch
+}
+
+// NewSplitUpdater constructs a new feature flag updater that keeps local kills applied
+func NewSplitUpdater(wrapped split.Updater, kills *StorageSwitch) *SplitUpdater {
+	return &SplitUpdater{wrapped: wrapped, kills: kills}
+}
+
+// SynchronizeSplits forwards the call to the wrapped updater & re-applies the kills.
+// Kills & restores are blocked while syncing, so that they don't race with the flags being updated
+func (u *SplitUpdater) SynchronizeSplits(till *int64) (*split.UpdateResult, error) {
+	u.kills.mutex.Lock()
+	defer u.kills.mutex.Unlock()
+	result, err := u.wrapped.SynchronizeSplits(till)
+	u.kills.reapply()
+	return result, err
+}
+
+// SynchronizeFeatureFlags forwards the call to the wrapped updater & re-applies the kills
+func (u *SplitUpdater) SynchronizeFeatureFlags(ffChange *dtos.SplitChangeUpdate) (*split.UpdateResult, error) {
+	u.kills.mutex.Lock()
+	defer u.kills.mutex.Unlock()
+	result, err := u.wrapped.SynchronizeFeatureFlags(ffChange)
+	u.kills.reapply()
+	return result, err
+}
+
+// LocalKill forwards the call to the wrapped updater
+func (u *SplitUpdater) LocalKill(splitName string, defaultTreatment string, changeNumber int64) {
+	u.wrapped.LocalKill(splitName, defaultTreatment, changeNumber)
+}
+
+var _ split.Updater = (*SplitUpdater)(nil)
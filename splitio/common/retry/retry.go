@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// Policy determines how many times & how often a failed post to Split servers is retried
+type Policy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	RetryableStatusCodes []int
+}
+
+// NewPolicy builds a policy from config options, where backoffs are expressed in milliseconds & status codes as
+// strings. Invalid status codes are logged & ignored
+func NewPolicy(maxAttempts int64, initialBackoffMs int64, maxBackoffMs int64, statusCodes []string, logger logging.LoggerInterface) *Policy {
+	codes := make([]int, 0, len(statusCodes))
+	for _, raw := range statusCodes {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		code, err := strconv.Atoi(raw)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("ignoring invalid retryable status code '%s'", raw))
+			continue
+		}
+		codes = append(codes, code)
+	}
+
+	return &Policy{
+		MaxAttempts:          int(maxAttempts),
+		InitialBackoff:       time.Duration(initialBackoffMs) * time.Millisecond,
+		MaxBackoff:           time.Duration(maxBackoffMs) * time.Millisecond,
+		RetryableStatusCodes: codes,
+	}
+}
+
+// Retryable returns true if the supplied error is worth retrying. Errors not carrying an http status code
+// (ie: network errors) are always considered retryable
+func (p *Policy) Retryable(err error) bool {
+	code := StatusCodeOf(err)
+	if code == 0 {
+		return true
+	}
+	for _, retryable := range p.RetryableStatusCodes {
+		if code == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns how long to wait after the n-th failed attempt (starting at 1)
+func (p *Policy) Backoff(attempt int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// StatusCodeOf returns the http status code carried by an error, or 0 if there's none
+func StatusCodeOf(err error) int {
+	var httpErr *dtos.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return 0
+}
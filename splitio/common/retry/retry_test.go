@@ -0,0 +1,36 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, RetryableStatusCodes: []int{500, 503}}
+
+	assert.True(t, policy.Retryable(errors.New("connection refused")))
+	assert.True(t, policy.Retryable(&dtos.HTTPError{Code: 503}))
+	assert.True(t, policy.Retryable(errors.Join(errors.New("wrapped"), &dtos.HTTPError{Code: 500})))
+	assert.False(t, policy.Retryable(&dtos.HTTPError{Code: 400}))
+
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 5*time.Second, policy.Backoff(4))
+	assert.Equal(t, 5*time.Second, policy.Backoff(10))
+}
+
+func TestNewPolicy(t *testing.T) {
+	policy := NewPolicy(3, 1000, 30000, []string{"429", " 503 ", "", "nope"}, logging.NewLogger(nil))
+	assert.Equal(t, &Policy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Second,
+		MaxBackoff:           30 * time.Second,
+		RetryableStatusCodes: []int{429, 503},
+	}, policy)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"os/signal"
+	gosync "sync"
 	"syscall"
 	"time"
 
@@ -41,6 +42,8 @@ type RuntimeImpl struct {
 	osSignals          chan os.Signal
 	appMonitor         application.MonitorIterface
 	servicesMonitor    services.MonitorIterface
+	shutdownHooks      []func()
+	hooksMutex         gosync.Mutex
 }
 
 // NewRuntime constructs a RuntimeImpl object
@@ -88,6 +91,14 @@ func (r *RuntimeImpl) RegisterShutdownHandler() error {
 	return nil
 }
 
+// RegisterShutdownHook adds a function to be invoked upon graceful shutdown, before stopping the synchronization.
+// Hooks are invoked in the order they were registered
+func (r *RuntimeImpl) RegisterShutdownHook(hook func()) {
+	r.hooksMutex.Lock()
+	defer r.hooksMutex.Unlock()
+	r.shutdownHooks = append(r.shutdownHooks, hook)
+}
+
 // Uptime returns how long the sync has been running
 func (r *RuntimeImpl) Uptime() time.Duration {
 	return time.Now().Sub(r.startup)
@@ -101,6 +112,14 @@ func (r *RuntimeImpl) Shutdown() {
 		message, attachments := buildSlackShutdownMessage(r.dashboardTitle, false)
 		r.slackWriter.PostNow(message, attachments)
 	}
+
+	r.hooksMutex.Lock()
+	hooks := r.shutdownHooks
+	r.hooksMutex.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+
 	r.syncManager.Stop()
 	if r.impListener != nil {
 		r.impListener.Stop(true)
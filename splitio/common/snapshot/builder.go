@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/storage"
+)
+
+// FileName returns the name used for a snapshot file, given its creation time (in unix millis)
+func FileName(createdAt int64) string {
+	return fmt.Sprintf("split.proxy.%d.snapshot", createdAt)
+}
+
+// Builder generates encoded snapshots with the contents of a db
+type Builder struct {
+	db       storage.Snapshotter
+	key      ed25519.PrivateKey
+	describe func() (Metadata, error)
+	clock    func() time.Time
+	mutex    sync.Mutex
+}
+
+// NewBuilder constructs a new snapshot builder. Snapshots are signed with `key` unless it's nil,
+// and `describe` (if not nil) provides the metadata describing the data included in them
+func NewBuilder(db storage.Snapshotter, key ed25519.PrivateKey, describe func() (Metadata, error)) *Builder {
+	return &Builder{db: db, key: key, describe: describe, clock: time.Now}
+}
+
+// Build dumps the db & returns it encoded as a snapshot, along with the snapshot metadata
+func (b *Builder) Build() ([]byte, *Metadata, error) {
+	now := b.clock()
+	raw, meta, err := b.dump()
+	if err != nil {
+		return nil, nil, err
+	}
+	meta.Version = 1
+	meta.Storage = StorageBoltDB
+	meta.CreatedAt = now.UnixMilli()
+
+	snap, err := New(meta, raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building snapshot: %w", err)
+	}
+
+	encoded, err := snap.EncodeSigned(b.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding snapshot: %w", err)
+	}
+	return encoded, &meta, nil
+}
+
+// dump reads the db & describes the data read. Concurrent builds are serialized so that the metadata
+// of a snapshot always describes the dump it's built from and not one taken by another build
+func (b *Builder) dump() ([]byte, Metadata, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	raw, err := b.db.GetRawSnapshot()
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("error getting contents from db: %w", err)
+	}
+
+	var meta Metadata
+	if b.describe != nil {
+		if meta, err = b.describe(); err != nil {
+			return nil, Metadata{}, fmt.Errorf("error describing snapshot contents: %w", err)
+		}
+	}
+	return raw, meta, nil
+}
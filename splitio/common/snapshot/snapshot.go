@@ -3,8 +3,13 @@ package snapshot
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
 	"encoding/binary"
 	"encoding/gob"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -21,6 +26,20 @@ const (
 	StorageBoltDB
 )
 
+// Snapshot format constants
+const (
+	_ = iota
+
+	// FormatV1 snapshots carry no integrity information
+	FormatV1
+
+	// FormatV2 snapshots carry a checksum of the data & optionally an Ed25519 signature
+	FormatV2
+)
+
+// formatV2Magic prefixes FormatV2 snapshots. FormatV1 ones start with the metadata size instead, which is never this big
+var formatV2Magic = []byte("SPLTSNP\x02")
+
 // ErrNonexistantFile represents an error when the snapshot passed in to be decoded is missing
 var ErrNonexistantFile = errors.New("cannot find snapshot file")
 
@@ -36,16 +55,47 @@ var ErrMetadataSizeRead = errors.New("snapshot metadata size cannot be decoded")
 // ErrMetadataRead represents an error when metadata cannot be decoded
 var ErrMetadataRead = errors.New("snapshot metadata cannot be decoded")
 
-// Metadata represents the Snapshot metadata object
+// ErrChecksumMismatch represents an error when the snapshot data doesn't match its checksum
+var ErrChecksumMismatch = errors.New("snapshot checksum mismatch, the file is either corrupted or truncated")
+
+// ErrUnsigned represents an error when a signature is required but the snapshot is not signed
+var ErrUnsigned = errors.New("snapshot is not signed")
+
+// ErrInvalidSignature represents an error when the snapshot signature doesn't match the verification key
+var ErrInvalidSignature = errors.New("snapshot signature is invalid")
+
+// ErrInvalidKey represents an error when a signing or verification key cannot be loaded
+var ErrInvalidKey = errors.New("invalid snapshot key")
+
+// Metadata represents the Snapshot metadata object.
+// Fields other than Version & Storage are empty in snapshots generated by older versions
 type Metadata struct {
-	Version uint64
-	Storage uint64
+	Version      uint64
+	Storage      uint64
+	Checksum     []byte
+	CreatedAt    int64 // unix millis
+	SpecVersion  string
+	Environments []EnvironmentMetadata
+}
+
+// EnvironmentMetadata describes the data of one of the environments included in a snapshot.
+// The default environment has an empty name
+type EnvironmentMetadata struct {
+	Name                 string
+	SDKKeyHash           uint32
+	FlagSets             []string
+	FlagsChangeNumber    int64
+	SegmentsChangeNumber int64
+	Flags                int
+	Segments             int
 }
 
 // Snapshot represents a snapshot struct with metadata and data
 type Snapshot struct {
-	meta Metadata
-	data []byte
+	meta      Metadata
+	data      []byte
+	format    int
+	signature []byte
 }
 
 // New returns an instance of Snapshot object with the parameter set
@@ -59,7 +109,7 @@ func New(meta Metadata, data []byte) (*Snapshot, error) {
 	gw.Write(data)
 	gw.Close()
 
-	return &Snapshot{meta: meta, data: b.Bytes()}, nil
+	return &Snapshot{meta: meta, data: b.Bytes(), format: FormatV2}, nil
 }
 
 // Meta returns a copy of the Snapshot Metadata object
@@ -67,9 +117,22 @@ func (s *Snapshot) Meta() Metadata {
 	return s.meta
 }
 
+// Format returns the format the snapshot was decoded from
+func (s *Snapshot) Format() int {
+	return s.format
+}
+
+// Signed returns whether the snapshot was signed when encoded
+func (s *Snapshot) Signed() bool {
+	return len(s.signature) > 0
+}
+
 // Data returns the unzipped Snapshot data
 func (s *Snapshot) Data() ([]byte, error) {
 	gz, err := gzip.NewReader(bytes.NewBuffer(s.data))
+	if err != nil {
+		return nil, fmt.Errorf("error reading gzip data: %w", err)
+	}
 	defer gz.Close()
 	data, err := ioutil.ReadAll(gz)
 	if err != nil {
@@ -78,47 +141,53 @@ func (s *Snapshot) Data() ([]byte, error) {
 	return data, nil
 }
 
-// Encode returns the bytes slice snapshot representation
+// Encode returns the bytes slice snapshot representation, without a signature
+func (s *Snapshot) Encode() ([]byte, error) {
+	return s.EncodeSigned(nil)
+}
+
+// EncodeSigned returns the bytes slice snapshot representation, signed with `key` if it's not nil.
+// Snapshots are always encoded using the latest format.
 // Snapshot Layout:
 //
-//				         |metadata-size|metadata|data|
+//				         |magic|metadata-size|metadata|signature-size|signature|data|
 //
+//	        magic: 8 bytes identifying the format
 //	        metadata-size: uint64 (8 bytes) specifies the amount of metadata bytes
-//	        metadata: Gob encoded of Metadata struct
+//	        metadata: Gob encoded of Metadata struct, including the SHA-256 checksum of the data
+//	        signature-size: uint64 (8 bytes) specifies the amount of signature bytes (0 if the snapshot is not signed)
+//	        signature: Ed25519 signature of the metadata bytes. Since they include the checksum, the data is signed as well
 //	        data: Proxy data, byte slice. The Metadata have information about it, Storage, Gzipped and version.
-func (s *Snapshot) Encode() ([]byte, error) {
-
-	metaBytes, err := metaToBytes(s.meta)
-	if err != nil {
-		return nil, fmt.Errorf("%w | %s", ErrEncMetadata, err)
-	}
+//
+// FormatV1 snapshots have neither the magic, the checksum nor the signature-size & signature fields
+func (s *Snapshot) EncodeSigned(key ed25519.PrivateKey) ([]byte, error) {
+	meta := s.meta
+	checksum := sha256.Sum256(s.data)
+	meta.Checksum = checksum[:]
 
-	metaBytesLen, err := lenToBytes(int64(len(metaBytes)))
+	metaBytes, err := metaToBytes(meta)
 	if err != nil {
 		return nil, fmt.Errorf("%w | %s", ErrEncMetadata, err)
 	}
 
-	totalBytes := len(metaBytesLen) + len(metaBytes) + len(s.data)
-	var snapbytes = make([]byte, totalBytes, totalBytes)
-
-	// copying metadata-size
-	for i := 0; i < len(metaBytesLen); i++ {
-		snapbytes[i] = metaBytesLen[i]
-	}
-
-	// copying metadata
-	metadataOffset := len(metaBytesLen)
-	for i := 0; i < len(metaBytes); i++ {
-		snapbytes[metadataOffset+i] = metaBytes[i]
+	var signature []byte
+	if key != nil {
+		signature = ed25519.Sign(key, metaBytes)
 	}
 
-	// copying data
-	dataOffset := len(metaBytesLen) + len(metaBytes)
-	for i := 0; i < len(s.data); i++ {
-		snapbytes[dataOffset+i] = s.data[i]
+	var b bytes.Buffer
+	b.Grow(len(formatV2Magic) + 16 + len(metaBytes) + len(signature) + len(s.data))
+	b.Write(formatV2Magic)
+	for _, section := range [][]byte{metaBytes, signature} {
+		sectionLen, err := lenToBytes(int64(len(section)))
+		if err != nil {
+			return nil, fmt.Errorf("%w | %s", ErrEncMetadata, err)
+		}
+		b.Write(sectionLen)
+		b.Write(section)
 	}
-
-	return snapbytes, nil
+	b.Write(s.data)
+	return b.Bytes(), nil
 }
 
 // WriteDataToTmpFile writes the data field (unzipped) to a temporal file
@@ -149,6 +218,11 @@ func (s *Snapshot) WriteDataToFile(path string) error {
 
 // DecodeFromFile decodes a snapshot file from a given path
 func DecodeFromFile(path string) (*Snapshot, error) {
+	return DecodeFromFileVerified(path, nil)
+}
+
+// DecodeFromFileVerified decodes a snapshot file from a given path, verifying its signature if `key` is not nil
+func DecodeFromFileVerified(path string, key ed25519.PublicKey) (*Snapshot, error) {
 	snapshotFilePath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path to snapshot: %w", err)
@@ -163,30 +237,131 @@ func DecodeFromFile(path string) (*Snapshot, error) {
 		return nil, fmt.Errorf("error reading snapshot file")
 	}
 
-	return Decode(snapshotBytes)
+	return DecodeVerified(snapshotBytes, key)
 }
 
-// Decode decode a byte slice and returns the Snapshot object
+// Decode decode a byte slice and returns the Snapshot object. The checksum of FormatV2 snapshots is verified,
+// but signatures are not
 func Decode(snap []byte) (*Snapshot, error) {
+	return DecodeVerified(snap, nil)
+}
 
-	if len(snap) < 8 {
-		return nil, ErrSnapshotSize
+// DecodeVerified decodes a byte slice and returns the Snapshot object. If `key` is not nil, the snapshot must have
+// been signed with the matching private key, which means that FormatV1 snapshots are rejected
+func DecodeVerified(snap []byte, key ed25519.PublicKey) (*Snapshot, error) {
+	format := FormatV1
+	if bytes.HasPrefix(snap, formatV2Magic) {
+		format = FormatV2
+		snap = snap[len(formatV2Magic):]
 	}
 
-	metadataSize, err := bytesToUint64(snap[0:8])
+	metaBytes, rest, err := readSection(snap)
 	if err != nil {
-		return nil, fmt.Errorf("%w | %s", ErrMetadataSizeRead, err)
+		return nil, err
 	}
 
-	if len(snap) < int(metadataSize) {
-		return nil, ErrSnapshotSize
-	}
-	metadata, err := bytesToMetadata(snap[8 : int(metadataSize)+8])
+	metadata, err := bytesToMetadata(metaBytes)
 	if err != nil {
 		return nil, fmt.Errorf("%w | %s", ErrMetadataRead, err)
 	}
 
-	return &Snapshot{meta: *metadata, data: snap[8+int(metadataSize):]}, nil
+	if format == FormatV1 {
+		if key != nil {
+			return nil, fmt.Errorf("%w: legacy snapshots cannot be signed", ErrUnsigned)
+		}
+		return &Snapshot{meta: *metadata, data: rest, format: format}, nil
+	}
+
+	signature, data, err := readSection(rest)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot signature: %w", err)
+	}
+
+	checksum := sha256.Sum256(data)
+	if subtle.ConstantTimeCompare(checksum[:], metadata.Checksum) != 1 {
+		return nil, ErrChecksumMismatch
+	}
+
+	if key != nil {
+		if len(signature) == 0 {
+			return nil, ErrUnsigned
+		}
+		if !ed25519.Verify(key, metaBytes, signature) {
+			return nil, ErrInvalidSignature
+		}
+	}
+
+	return &Snapshot{meta: *metadata, data: data, format: format, signature: signature}, nil
+}
+
+// LoadSigningKey reads an Ed25519 private key from a PEM encoded PKCS #8 file,
+// such as the ones generated by `openssl genpkey -algorithm ed25519`
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an Ed25519 private key", ErrInvalidKey, path)
+	}
+	return key, nil
+}
+
+// LoadVerificationKey reads an Ed25519 public key from a PEM encoded PKIX file,
+// such as the ones generated by `openssl pkey -pubout`
+func LoadVerificationKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an Ed25519 public key", ErrInvalidKey, path)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot key file: %w", err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found in %s", ErrInvalidKey, path)
+	}
+	return block, nil
+}
+
+// readSection reads a size-prefixed section, returning it along with the remaining bytes
+func readSection(b []byte) ([]byte, []byte, error) {
+	if len(b) < 8 {
+		return nil, nil, ErrSnapshotSize
+	}
+
+	size, err := bytesToUint64(b[0:8])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w | %s", ErrMetadataSizeRead, err)
+	}
+
+	if size > uint64(len(b)-8) {
+		return nil, nil, ErrSnapshotSize
+	}
+	return b[8 : 8+size], b[8+size:], nil
 }
 
 func metaToBytes(meta Metadata) ([]byte, error) {
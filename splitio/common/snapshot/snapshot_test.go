@@ -1,6 +1,19 @@
 package snapshot
 
-import "testing"
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func TestSnapshot(t *testing.T) {
 	data4Test := []byte("Some Snapshot Data")
@@ -41,3 +54,176 @@ func TestSnapshot(t *testing.T) {
 	}
 
 }
+
+func TestSnapshotV1(t *testing.T) {
+	// the fixture was encoded before checksums & signatures were added
+	snap, err := DecodeFromFile("../../../test/snapshot/proxy.snapshot")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if snap.Format() != FormatV1 || snap.Signed() {
+		t.Error("fixture should be an unsigned v1 snapshot")
+	}
+
+	if _, err := snap.Data(); err != nil {
+		t.Error(err)
+	}
+
+	public, _, _ := ed25519.GenerateKey(nil)
+	if _, err := DecodeFromFileVerified("../../../test/snapshot/proxy.snapshot", public); !errors.Is(err, ErrUnsigned) {
+		t.Error("v1 snapshots should be rejected when a signature is required. Got: ", err)
+	}
+}
+
+func TestSnapshotChecksum(t *testing.T) {
+	snap, _ := New(Metadata{Storage: StorageBoltDB}, []byte("Some Snapshot Data"))
+	encoded, err := snap.Encode()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if decoded.Format() != FormatV2 || len(decoded.Meta().Checksum) != sha256.Size || decoded.Signed() {
+		t.Error("invalid metadata: ", decoded.Meta())
+	}
+
+	tampered := append([]byte(nil), encoded...)
+	tampered[len(tampered)-1] ^= 0xFF
+	if _, err := Decode(tampered); !errors.Is(err, ErrChecksumMismatch) {
+		t.Error("tampered data should be rejected. Got: ", err)
+	}
+
+	if _, err := Decode(encoded[:len(encoded)-5]); !errors.Is(err, ErrChecksumMismatch) {
+		t.Error("truncated data should be rejected. Got: ", err)
+	}
+
+	if _, err := Decode(encoded[:30]); !errors.Is(err, ErrSnapshotSize) {
+		t.Error("truncated metadata should be rejected. Got: ", err)
+	}
+
+	if _, err := Decode(encoded[:12]); !errors.Is(err, ErrSnapshotSize) {
+		t.Error("truncated header should be rejected. Got: ", err)
+	}
+}
+
+func TestSnapshotSignature(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	otherPublic, _, _ := ed25519.GenerateKey(nil)
+
+	snap, _ := New(Metadata{Storage: StorageBoltDB}, []byte("Some Snapshot Data"))
+	signed, err := snap.EncodeSigned(private)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	decoded, err := DecodeVerified(signed, public)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !decoded.Signed() {
+		t.Error("snapshot should be signed")
+	}
+
+	// signatures are only verified if a key is supplied
+	if _, err := Decode(signed); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := DecodeVerified(signed, otherPublic); !errors.Is(err, ErrInvalidSignature) {
+		t.Error("a different key should be rejected. Got: ", err)
+	}
+
+	unsigned, _ := snap.Encode()
+	if _, err := DecodeVerified(unsigned, public); !errors.Is(err, ErrUnsigned) {
+		t.Error("unsigned snapshots should be rejected. Got: ", err)
+	}
+
+	// metadata is signed as well
+	tampered := append([]byte(nil), signed...)
+	tampered[len(tampered)-len(decoded.data)-ed25519.SignatureSize-9] ^= 0xFF
+	if _, err := DecodeVerified(tampered, public); err == nil {
+		t.Error("tampered metadata should be rejected")
+	}
+}
+
+func TestSnapshotKeys(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	dir := t.TempDir()
+
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(private)
+	publicDER, _ := x509.MarshalPKIXPublicKey(public)
+	os.WriteFile(filepath.Join(dir, "private.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)
+	os.WriteFile(filepath.Join(dir, "public.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600)
+	os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("not a key"), 0600)
+
+	loadedPrivate, err := LoadSigningKey(filepath.Join(dir, "private.pem"))
+	if err != nil || !loadedPrivate.Equal(private) {
+		t.Error("error loading private key: ", err)
+	}
+
+	loadedPublic, err := LoadVerificationKey(filepath.Join(dir, "public.pem"))
+	if err != nil || !loadedPublic.Equal(public) {
+		t.Error("error loading public key: ", err)
+	}
+
+	if _, err := LoadSigningKey(filepath.Join(dir, "public.pem")); !errors.Is(err, ErrInvalidKey) {
+		t.Error("a public key should not be accepted as signing key. Got: ", err)
+	}
+
+	if _, err := LoadVerificationKey(filepath.Join(dir, "garbage.pem")); !errors.Is(err, ErrInvalidKey) {
+		t.Error("garbage should be rejected. Got: ", err)
+	}
+}
+
+// countingSnapshotter returns a different dump every time & remembers the last one, like the redis snapshotter does
+type countingSnapshotter struct {
+	dumps atomic.Int64
+	last  atomic.Int64
+}
+
+func (c *countingSnapshotter) GetRawSnapshot() ([]byte, error) {
+	n := c.dumps.Add(1)
+	c.last.Store(n)
+	return []byte(strconv.FormatInt(n, 10)), nil
+}
+
+func (c *countingSnapshotter) describe() (Metadata, error) {
+	time.Sleep(time.Millisecond) // give other builds a chance to dump in the meantime
+	return Metadata{Environments: []EnvironmentMetadata{{FlagsChangeNumber: c.last.Load()}}}, nil
+}
+
+func TestBuildConcurrently(t *testing.T) {
+	db := &countingSnapshotter{}
+	builder := NewBuilder(db, nil, db.describe)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			encoded, meta, err := builder.Build()
+			if err != nil {
+				t.Error("no error should be returned. Got: ", err)
+				return
+			}
+
+			snap, _ := Decode(encoded)
+			data, _ := snap.Data()
+			if string(data) != strconv.FormatInt(meta.Environments[0].FlagsChangeNumber, 10) {
+				t.Error("the metadata should describe the dump included in the snapshot. Got: ", meta.Environments[0], string(data))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -7,17 +7,20 @@ import (
 
 // Main configuration options
 type Main struct {
-	Apikey           string            `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
-	IPAddressEnabled bool              `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
-	FlagSetsFilter   []string          `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
-	Initialization   Initialization    `json:"initialization" s-nested:"true"`
-	Storage          Storage           `json:"storage" s-nested:"true"`
-	Sync             Sync              `json:"sync" s-nested:"true"`
-	Admin            conf.Admin        `json:"admin" s-nested:"true"`
-	Integrations     conf.Integrations `json:"integrations" s-nested:"true"`
-	Logging          conf.Logging      `json:"logging" s-nested:"true"`
-	Healthcheck      Healthcheck       `json:"healthcheck" s-nested:"true"`
-	FlagSpecVersion  string            `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.1" s-desc:"Spec version for flags"`
+	Apikey           string               `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
+	IPAddressEnabled bool                 `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
+	FlagSetsFilter   []string             `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
+	Initialization   Initialization       `json:"initialization" s-nested:"true"`
+	Storage          Storage              `json:"storage" s-nested:"true"`
+	Sync             Sync                 `json:"sync" s-nested:"true"`
+	Admin            conf.Admin           `json:"admin" s-nested:"true"`
+	Integrations     conf.Integrations    `json:"integrations" s-nested:"true"`
+	Logging          conf.Logging         `json:"logging" s-nested:"true"`
+	Healthcheck      Healthcheck          `json:"healthcheck" s-nested:"true"`
+	FlagSpecVersion  string               `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.1" s-desc:"Spec version for flags"`
+	KilledFlagsFile  string               `json:"killedFlagsFile" s-cli:"killed-flags-fn" s-def:"" s-desc:"File where locally killed flags are saved, so that they're killed again after a restart. (Default: only kept in memory)"`
+	AuditLog         conf.AuditLog        `json:"auditLog" s-nested:"true"`
+	SnapshotSigning  conf.SnapshotSigning `json:"snapshotSigning" s-nested:"true"`
 }
 
 // BuildAdvancedConfig generates a commons-compatible advancedconfig with default + overriden parameters
@@ -32,16 +35,25 @@ func (m *Main) BuildAdvancedConfig() *cconf.AdvancedConfig {
 
 // Initialization configuration options
 type Initialization struct {
-	TimeoutMs int64 `json:"timeoutMS" s-cli:"timeout-ms" s-def:"10000" s-desc:"How long to wait until the synchronizer is ready"`
-	// Coming soon
-	// Snapshot          string `json:"snapshot" s-cli:"snapshot" s-def:"" s-desc:"Snapshot file to use as a starting point"`
-	ForceFreshStartup bool `json:"forceFreshStartup" s-cli:"force-fresh-startup" s-def:"false" s-desc:"Wipe storage before starting the synchronizer"`
+	TimeoutMs         int64  `json:"timeoutMS" s-cli:"timeout-ms" s-def:"10000" s-desc:"How long to wait until the synchronizer is ready"`
+	Snapshot          string `json:"snapshot" s-cli:"snapshot" s-def:"" s-desc:"Snapshot file to seed redis with before synchronizing (data already stored is only replaced if older)"`
+	ForceFreshStartup bool   `json:"forceFreshStartup" s-cli:"force-fresh-startup" s-def:"false" s-desc:"Wipe storage before starting the synchronizer"`
 }
 
 // Storage configuration options
 type Storage struct {
-	Type  string `json:"type" s-cli:"storage-type" s-def:"redis" s-desc:"Storage driver to use for caching feature flags/segments and user-generated data"`
-	Redis Redis  `json:"redis" s-nested:"true"`
+	Type   string      `json:"type" s-cli:"storage-type" s-def:"redis" s-desc:"Storage driver to use for caching feature flags/segments and user-generated data"`
+	Redis  Redis       `json:"redis" s-nested:"true"`
+	Queues QueueLimits `json:"queues" s-nested:"true"`
+}
+
+// QueueLimits configuration options for the queues written by sdks in redis
+type QueueLimits struct {
+	ImpressionsMaxLength int64  `json:"impressionsMaxLength" s-cli:"impressions-queue-max-length" s-def:"0" s-desc:"Max #impressions kept in redis, the excess is removed (0 = unbounded)"`
+	EventsMaxLength      int64  `json:"eventsMaxLength" s-cli:"events-queue-max-length" s-def:"0" s-desc:"Max #events kept in redis, the excess is removed (0 = unbounded)"`
+	UniqueKeysMaxLength  int64  `json:"uniqueKeysMaxLength" s-cli:"unique-keys-queue-max-length" s-def:"0" s-desc:"Max #unique keys bulks kept in redis, the excess is removed (0 = unbounded)"`
+	OverflowStrategy     string `json:"overflowStrategy" s-cli:"queue-overflow-strategy" s-def:"trim" s-desc:"How to remove the excess of a queue: 'trim' (drop the oldest items) or 'sample' (thin the oldest items out evenly)"`
+	CheckRateMs          int64  `json:"checkRateMs" s-cli:"queue-check-rate-ms" s-def:"10000" s-desc:"How often to check the length of the queues"`
 }
 
 // Sync configuration options
@@ -50,32 +62,59 @@ type Sync struct {
 	SegmentRefreshRateMs int64        `json:"segmentRefreshRateMs" s-cli:"segment-refresh-rate-ms" s-def:"60000" s-desc:"How often to refresh segments"`
 	ImpressionsMode      string       `json:"impressionsMode" s-cli:"impressions-mode" s-def:"optimized" s-desc:"whether to send all impressions for debugging"`
 	Advanced             AdvancedSync `json:"advanced" s-nested:"true"`
+	Retry                Retry        `json:"retry" s-nested:"true"`
+}
+
+// Retry configuration options for impressions, events & unique keys posted to Split servers
+type Retry struct {
+	MaxAttempts          int64    `json:"maxAttempts" s-cli:"post-max-attempts" s-def:"3" s-desc:"How many times to try posting impressions, events & unique keys to Split servers (1 = no retries)"`
+	InitialBackoffMs     int64    `json:"initialBackoffMs" s-cli:"post-initial-backoff-ms" s-def:"1000" s-desc:"How long to wait before the first retry. Doubled on every subsequent one"`
+	MaxBackoffMs         int64    `json:"maxBackoffMs" s-cli:"post-max-backoff-ms" s-def:"30000" s-desc:"Max time to wait between retries"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes" s-cli:"post-retryable-status-codes" s-def:"408,429,500,502,503,504" s-desc:"HTTP status codes worth retrying. Network errors are always retried"`
+	DeadLetterMaxItems   int64    `json:"deadLetterMaxItems" s-cli:"dead-letter-max-items" s-def:"1000" s-desc:"How many batches that couldn't be posted to keep in redis for inspection & replay (0 = disabled)"`
+	DeadLetterMaxBytes   int64    `json:"deadLetterMaxBytes" s-cli:"dead-letter-max-bytes" s-def:"52428800" s-desc:"Max amount of bytes used by the batches kept in redis, which are loaded in memory when listed or replayed (0 = unbounded)"`
 }
 
 // AdvancedSync configuration options
 type AdvancedSync struct {
-	StreamingEnabled                 bool  `json:"streamingEnabled" s-cli:"streaming-enabled" s-def:"true" s-desc:"Enable/disable streaming functionality"`
-	HTTPTimeoutMs                    int64 `json:"httpTimeoutMs" s-cli:"http-timeout-ms" s-def:"30000" s-desc:"Total http request timeout"`
-	InternalMetricsRateMs            int64 `json:"internalTelemetryRateMs" s-cli:"internal-metrics-rate-ms" s-def:"3600000" s-desc:"How often to send internal metrics"`
-	TelemetryPushRateMs              int64 `json:"telemetryPushRateMs" s-cli:"telemetry-push-rate-ms" s-def:"60000" s-desc:"how often to flush sdk telemetry"`
-	ImpressionsFetchSize             int64 `json:"impressionsFetchSize" s-cli:"impressions-fetch-size" s-def:"0" s-desc:"Impression fetch bulk size"`
-	ImpressionsProcessConcurrency    int   `json:"impressionsProcessConcurrency" s-cli:"impressions-process-concurrency" s-def:"0" s-desc:"#Threads for processing imps"`
-	ImpressionsProcessBatchSize      int   `json:"impressionsProcessBatchSize" s-cli:"impressions-process-batch-size" s-def:"0" s-desc:"Size of imp processing batchs"`
-	ImpressionsPostConcurrency       int   `json:"impressionsPostConcurrency" s-cli:"impressions-post-concurrency" s-def:"0" s-desc:"#concurrent imp post threads"`
-	ImpressionsPostSize              int   `json:"impressionsPostSize" s-cli:"impressions-post-size" s-def:"0" s-desc:"Max #impressions to send per POST"`
-	ImpressionsAccumWaitMs           int64 `json:"impressionsAccumWaitMs" s-cli:"impressions-accum-wait-ms" s-def:"0" s-desc:"Max ms to wait to close an impressions bulk"`
-	EventsFetchSize                  int64 `json:"eventsFetchSize" s-cli:"events-fetch-size" s-def:"0" s-desc:"How many impressions to pop from storage at once"`
-	EventsProcessConcurrency         int   `json:"eventsProcessConcurrency" s-cli:"events-process-concurrency" s-def:"0" s-desc:"#Threads for processing imps"`
-	EventsProcessBatchSize           int   `json:"eventsProcessBatchSize" s-cli:"events-process-batch-size" s-def:"0" s-desc:"Size of imp processing batchs"`
-	EventsPostConcurrency            int   `json:"eventsPostConcurrency" s-cli:"events-post-concurrency" s-def:"0" s-desc:"#concurrent imp post threads"`
-	EventsPostSize                   int   `json:"eventsPostSize" s-cli:"events-post-size" s-def:"0" s-desc:"Max #impressions to send per POST"`
-	EventsAccumWaitMs                int64 `json:"eventsAccumWaitMs" s-cli:"events-accum-wait-ms" s-def:"0" s-desc:"Max ms to wait to close an events bulk"`
-	UniqueKeysFetchSize              int64 `json:"uniqueKeysFetchSize" s-cli:"unique-keys-fetch-size" s-def:"0" s-desc:"How many unique keys to pop from storage at once"`
-	UniqueKeysProcessConcurrency     int   `json:"uniqueKeysProcessConcurrency" s-cli:"unique-keys-process-concurrency" s-def:"0" s-desc:"#Threads for processing uniques"`
-	UniqueKeysProcessBatchSize       int   `json:"uniqueKeysProcessBatchSize" s-cli:"unique-keys-process-batch-size" s-def:"0" s-desc:"Size of uniques processing batchs"`
-	UniqueKeysPostConcurrency        int   `json:"uniqueKeysPostConcurrency" s-cli:"unique-keys-post-concurrency" s-def:"0" s-desc:"#concurrent uniques post threads"`
-	UniqueKeysAccumWaitMs            int64 `json:"uniqueKeysAccumWaitMs" s-cli:"unique-keys-accum-wait-ms" s-def:"0" s-desc:"Max ms to wait to close an uniques bulk"`
-	ImpressionsCountWorkerReadRateMs int64 `json:"impressionsCountWorkerReadRateMs" s-cli:"impressions-count-worker-read-rate-ms" s-def:"60000" s-desc:"how often read in redis impression count comming from sdks"`
+	StreamingEnabled                 bool        `json:"streamingEnabled" s-cli:"streaming-enabled" s-def:"true" s-desc:"Enable/disable streaming functionality"`
+	HTTPTimeoutMs                    int64       `json:"httpTimeoutMs" s-cli:"http-timeout-ms" s-def:"30000" s-desc:"Total http request timeout"`
+	InternalMetricsRateMs            int64       `json:"internalTelemetryRateMs" s-cli:"internal-metrics-rate-ms" s-def:"3600000" s-desc:"How often to send internal metrics"`
+	TelemetryPushRateMs              int64       `json:"telemetryPushRateMs" s-cli:"telemetry-push-rate-ms" s-def:"60000" s-desc:"how often to flush sdk telemetry"`
+	ImpressionsFetchSize             int64       `json:"impressionsFetchSize" s-cli:"impressions-fetch-size" s-def:"0" s-desc:"Impression fetch bulk size"`
+	ImpressionsProcessConcurrency    int         `json:"impressionsProcessConcurrency" s-cli:"impressions-process-concurrency" s-def:"0" s-desc:"#Threads for processing imps"`
+	ImpressionsProcessBatchSize      int         `json:"impressionsProcessBatchSize" s-cli:"impressions-process-batch-size" s-def:"0" s-desc:"Size of imp processing batchs"`
+	ImpressionsPostConcurrency       int         `json:"impressionsPostConcurrency" s-cli:"impressions-post-concurrency" s-def:"0" s-desc:"#concurrent imp post threads"`
+	ImpressionsPostSize              int         `json:"impressionsPostSize" s-cli:"impressions-post-size" s-def:"0" s-desc:"Max #impressions to send per POST"`
+	ImpressionsAccumWaitMs           int64       `json:"impressionsAccumWaitMs" s-cli:"impressions-accum-wait-ms" s-def:"0" s-desc:"Max ms to wait to close an impressions bulk"`
+	ImpressionsCompression           string      `json:"impressionsCompression" s-cli:"impressions-compression" s-def:"none" s-desc:"Algorithm used to compress impressions posts (none, gzip, zstd)"`
+	EventsFetchSize                  int64       `json:"eventsFetchSize" s-cli:"events-fetch-size" s-def:"0" s-desc:"How many impressions to pop from storage at once"`
+	EventsProcessConcurrency         int         `json:"eventsProcessConcurrency" s-cli:"events-process-concurrency" s-def:"0" s-desc:"#Threads for processing imps"`
+	EventsProcessBatchSize           int         `json:"eventsProcessBatchSize" s-cli:"events-process-batch-size" s-def:"0" s-desc:"Size of imp processing batchs"`
+	EventsPostConcurrency            int         `json:"eventsPostConcurrency" s-cli:"events-post-concurrency" s-def:"0" s-desc:"#concurrent imp post threads"`
+	EventsPostSize                   int         `json:"eventsPostSize" s-cli:"events-post-size" s-def:"0" s-desc:"Max #impressions to send per POST"`
+	EventsAccumWaitMs                int64       `json:"eventsAccumWaitMs" s-cli:"events-accum-wait-ms" s-def:"0" s-desc:"Max ms to wait to close an events bulk"`
+	EventsCompression                string      `json:"eventsCompression" s-cli:"events-compression" s-def:"none" s-desc:"Algorithm used to compress events posts (none, gzip, zstd)"`
+	UniqueKeysFetchSize              int64       `json:"uniqueKeysFetchSize" s-cli:"unique-keys-fetch-size" s-def:"0" s-desc:"How many unique keys to pop from storage at once"`
+	UniqueKeysProcessConcurrency     int         `json:"uniqueKeysProcessConcurrency" s-cli:"unique-keys-process-concurrency" s-def:"0" s-desc:"#Threads for processing uniques"`
+	UniqueKeysProcessBatchSize       int         `json:"uniqueKeysProcessBatchSize" s-cli:"unique-keys-process-batch-size" s-def:"0" s-desc:"Size of uniques processing batchs"`
+	UniqueKeysPostConcurrency        int         `json:"uniqueKeysPostConcurrency" s-cli:"unique-keys-post-concurrency" s-def:"0" s-desc:"#concurrent uniques post threads"`
+	UniqueKeysAccumWaitMs            int64       `json:"uniqueKeysAccumWaitMs" s-cli:"unique-keys-accum-wait-ms" s-def:"0" s-desc:"Max ms to wait to close an uniques bulk"`
+	UniqueKeysCompression            string      `json:"uniqueKeysCompression" s-cli:"unique-keys-compression" s-def:"none" s-desc:"Algorithm used to compress unique keys posts (none, gzip, zstd)"`
+	ImpressionsCountWorkerReadRateMs int64       `json:"impressionsCountWorkerReadRateMs" s-cli:"impressions-count-worker-read-rate-ms" s-def:"60000" s-desc:"how often read in redis impression count comming from sdks"`
+	Autoscaling                      Autoscaling `json:"autoscaling" s-nested:"true"`
+}
+
+// Autoscaling configuration options for the impressions & events pipelines
+type Autoscaling struct {
+	Enabled               bool  `json:"enabled" s-cli:"autoscaling-enabled" s-def:"false" s-desc:"Adjust fetch size & concurrency of impressions & events based on their eviction lambda"`
+	PeriodMs              int64 `json:"periodMs" s-cli:"autoscaling-period-ms" s-def:"60000" s-desc:"How often to check the eviction lambda & adjust the settings"`
+	MinFetchSize          int   `json:"minFetchSize" s-cli:"autoscaling-min-fetch-size" s-def:"1000" s-desc:"Min #items to pop from storage at once"`
+	MaxFetchSize          int   `json:"maxFetchSize" s-cli:"autoscaling-max-fetch-size" s-def:"200000" s-desc:"Max #items to pop from storage at once"`
+	MinProcessConcurrency int   `json:"minProcessConcurrency" s-cli:"autoscaling-min-process-concurrency" s-def:"1" s-desc:"Min #threads for processing"`
+	MaxProcessConcurrency int   `json:"maxProcessConcurrency" s-cli:"autoscaling-max-process-concurrency" s-def:"0" s-desc:"Max #threads for processing (0 = #cpus)"`
+	MinPostConcurrency    int   `json:"minPostConcurrency" s-cli:"autoscaling-min-post-concurrency" s-def:"1" s-desc:"Min #concurrent post threads"`
+	MaxPostConcurrency    int   `json:"maxPostConcurrency" s-cli:"autoscaling-max-post-concurrency" s-def:"2000" s-desc:"Max #concurrent post threads"`
 }
 
 // Redis configuration options
@@ -19,15 +19,20 @@ import (
 	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
 	"github.com/splitio/go-split-commons/v6/tasks"
 	"github.com/splitio/go-split-commons/v6/telemetry"
+	"github.com/splitio/go-toolkit/v5/backoff"
 	"github.com/splitio/go-toolkit/v5/logging"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/admin"
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 	ssync "github.com/splitio/split-synchronizer/v5/splitio/common/sync"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/snapshots"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/worker"
@@ -60,9 +65,16 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 	// Setup fetchers & recorders
 	splitAPI := api.NewSplitAPI(cfg.Apikey, *advanced, logger, metadata)
 
-	// Check if SDK key is valid
-	if !isValidApikey(splitAPI.SplitFetcher) {
-		return common.NewInitError(errors.New("invalid SDK key"), common.ExitInvalidApikey)
+	// Check if SDK key is valid. If split can't be reached but a snapshot is supplied, redis is seeded from it and
+	// the initial sync is retried in BG
+	apikeyErr := validateApikey(splitAPI.SplitFetcher)
+	if apikeyErr != nil && (cfg.Initialization.Snapshot == "" || errors.Is(apikeyErr, errInvalidApikey)) {
+		return common.NewInitError(errInvalidApikey, common.ExitInvalidApikey)
+	}
+
+	snapshotSigningKey, err := snapshotSigningKey(cfg)
+	if err != nil {
+		return err
 	}
 
 	// Redis Storages
@@ -101,6 +113,17 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 	if err != nil {
 		return fmt.Errorf("error instantiating observable segment storage: %w", err)
 	}
+
+	if snapFile := cfg.Initialization.Snapshot; snapFile != "" {
+		if err := seedFromSnapshot(cfg, snapFile, flagSetsFilter, splitStorage, segmentStorage, logger); err != nil {
+			return err
+		}
+	}
+	if apikeyErr != nil {
+		logger.Warning(fmt.Sprintf("Could not reach Split servers (%s). Redis has been seeded from the snapshot, "+
+			"serving its data until the initial sync succeeds", apikeyErr))
+	}
+
 	storages := adminCommon.Storages{
 		SplitStorage:          splitStorage,
 		SegmentStorage:        segmentStorage,
@@ -115,17 +138,49 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 	appMonitor := hcApplication.NewMonitorImp(splitsConfig, segmentsConfig, &storageConfig, logger)
 	servicesMonitor := hcServices.NewMonitorImp(getServicesCountersConfig(advanced), logger)
 
+	// Queue overflow protection
+	limits, err := queueLimits(&cfg.Storage.Queues)
+	if err != nil {
+		return common.NewInitError(err, common.ExitInvalidConfiguration)
+	}
+	queueCapWorker := worker.NewQueueCapWorker(
+		limits,
+		cfg.Storage.Queues.OverflowStrategy,
+		storage.NewRedisQueueCapper(redisClient),
+		appMonitor,
+		overflowAlerter(&cfg.Integrations.Slack),
+		logger,
+	)
+	if queueCapWorker.Enabled() {
+		appMonitor.EnableQueuesCounter(getQueuesCounterConfig())
+	}
+
 	impressionsCounter := strategy.NewImpressionsCounter()
 	impressionObserver, err := strategy.NewImpressionObserver(impressionObserverSize)
 	if err != nil {
 		return common.NewInitError(fmt.Errorf("error instantiating impression observer: %w", err), common.ExitTaskInitialization)
 	}
 
+	// Feature flags can be killed locally through the admin API. Kills are applied again after every sync
+	// so that they win over upstream changes, and every one of them is recorded in an audit log
+	auditLog, err := audit.NewLog(logger, int(cfg.AuditLog.MaxEntries), cfg.AuditLog.Filename)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up audit log: %w", err), common.ExitTaskInitialization)
+	}
+
+	killSwitch, err := killswitch.NewStorageSwitch(cfg.KilledFlagsFile, storages.SplitStorage, auditLog, logger)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error loading killed feature flags: %w", err), common.ExitInvalidConfiguration)
+	}
+
 	// Creating Workers and Tasks
 	eventEvictionMonitor := evcalc.New(1)
 
 	workers := synchronizer.Workers{
-		SplitUpdater: split.NewSplitUpdater(storages.SplitStorage, splitAPI.SplitFetcher, logger, syncTelemetryStorage, appMonitor, flagSetsFilter),
+		SplitUpdater: killswitch.NewSplitUpdater(
+			split.NewSplitUpdater(storages.SplitStorage, splitAPI.SplitFetcher, logger, syncTelemetryStorage, appMonitor, flagSetsFilter),
+			killSwitch,
+		),
 		SegmentUpdater: segment.NewSegmentUpdater(storages.SplitStorage, storages.SegmentStorage, splitAPI.SegmentFetcher,
 			logger, syncTelemetryStorage, appMonitor),
 		ImpressionsCountRecorder: impressionscount.NewRecorderSingle(impressionsCounter, splitAPI.ImpressionRecorder,
@@ -175,6 +230,16 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		return common.NewInitError(fmt.Errorf("error instantiating impressions worker: %w", err), common.ExitTaskInitialization)
 	}
 
+	// Batches that can't be posted after exhausting all retries are kept in redis for inspection & replay
+	retryCfg := &cfg.Sync.Retry
+	postRetryPolicy := retry.NewPolicy(retryCfg.MaxAttempts, retryCfg.InitialBackoffMs, retryCfg.MaxBackoffMs, retryCfg.RetryableStatusCodes, logger)
+	var deadLetters storage.DeadLetterStore
+	var deadLetterReplayer *task.DeadLetterReplayer
+	if cfg.Sync.Retry.DeadLetterMaxItems > 0 {
+		deadLetters = storage.NewRedisDeadLetterStore(redisClient, retryCfg.DeadLetterMaxItems, retryCfg.DeadLetterMaxBytes)
+		deadLetterReplayer = task.NewDeadLetterReplayer(deadLetters, cfg.Apikey, time.Millisecond*time.Duration(cfg.Sync.Advanced.HTTPTimeoutMs), logger)
+	}
+
 	impTask, err := task.NewPipelinedTask(&task.Config{
 		Name:               "impressions",
 		Logger:             logger,
@@ -184,6 +249,10 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		PostConcurrency:    cfg.Sync.Advanced.ImpressionsPostConcurrency,
 		MaxAccumWait:       time.Duration(cfg.Sync.Advanced.ImpressionsAccumWaitMs) * time.Millisecond,
 		HTTPTimeout:        time.Millisecond * time.Duration(cfg.Sync.Advanced.HTTPTimeoutMs),
+		Autoscale:          autoscaleConfig(&cfg.Sync.Advanced.Autoscaling, impressionEvictionMonitor),
+		Retry:              postRetryPolicy,
+		DeadLetters:        deadLetters,
+		Compression:        cfg.Sync.Advanced.ImpressionsCompression,
 	})
 	if err != nil {
 		return common.NewInitError(fmt.Errorf("error instantiating impressions pipelined task: %w", err), common.ExitTaskInitialization)
@@ -210,6 +279,10 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		PostConcurrency:    cfg.Sync.Advanced.ImpressionsPostConcurrency,
 		MaxAccumWait:       time.Duration(cfg.Sync.Advanced.EventsAccumWaitMs) * time.Millisecond,
 		HTTPTimeout:        time.Millisecond * time.Duration(cfg.Sync.Advanced.HTTPTimeoutMs),
+		Autoscale:          autoscaleConfig(&cfg.Sync.Advanced.Autoscaling, eventEvictionMonitor),
+		Retry:              postRetryPolicy,
+		DeadLetters:        deadLetters,
+		Compression:        cfg.Sync.Advanced.EventsCompression,
 	})
 	if err != nil {
 		return common.NewInitError(fmt.Errorf("error instantiating events pipelined task: %w", err), common.ExitTaskInitialization)
@@ -236,6 +309,9 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		PostConcurrency:    cfg.Sync.Advanced.UniqueKeysPostConcurrency,
 		MaxAccumWait:       time.Duration(cfg.Sync.Advanced.UniqueKeysAccumWaitMs) * time.Millisecond,
 		HTTPTimeout:        time.Millisecond * time.Duration(cfg.Sync.Advanced.HTTPTimeoutMs),
+		Retry:              postRetryPolicy,
+		DeadLetters:        deadLetters,
+		Compression:        cfg.Sync.Advanced.UniqueKeysCompression,
 	})
 	if err != nil {
 		return common.NewInitError(fmt.Errorf("error instantiating uniques pipelined task: %w", err), common.ExitTaskInitialization)
@@ -253,7 +329,11 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 
 	sdkTelemetryWorker := worker.NewTelemetryMultiWorker(logger, sdkTelemetryStorage, splitAPI.TelemetryRecorder)
 	sdkTelemetryTask := task.NewTelemetrySyncTask(sdkTelemetryWorker, logger, int(cfg.Sync.Advanced.TelemetryPushRateMs/1000))
-	syncImpl := ssync.NewSynchronizer(*advanced, splitTasks, workers, logger, nil, []tasks.Task{sdkTelemetryTask})
+	recordingTasks := []tasks.Task{sdkTelemetryTask}
+	if queueCapWorker.Enabled() {
+		recordingTasks = append(recordingTasks, task.NewQueueCapTask(queueCapWorker, logger, int(cfg.Storage.Queues.CheckRateMs/1000)))
+	}
+	syncImpl := ssync.NewSynchronizer(*advanced, splitTasks, workers, logger, nil, recordingTasks)
 	managerStatus := make(chan int, 1)
 	syncManager, err := synchronizer.NewSynchronizerManager(
 		syncImpl,
@@ -281,26 +361,34 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
 	}
 
+	snapshotBuilder := snapshots.NewBuilder(splitStorage, segmentStorage, snapshotSigningKey, snapshotEnvironment(cfg), logger)
+
 	cfgForAdmin := *cfg
 	cfgForAdmin.Apikey = logging.ObfuscateAPIKey(cfgForAdmin.Apikey)
 	cfgForAdmin.Storage.Redis.Pass = "xxxxxxxxxxxxxxx"
 	adminServer, err := admin.NewServer(&admin.Options{
-		Host:              cfg.Admin.Host,
-		Port:              int(cfg.Admin.Port),
-		Name:              "Split Synchronizer dashboard",
-		Proxy:             false,
-		Username:          cfg.Admin.Username,
-		Password:          cfg.Admin.Password,
-		Logger:            logger,
-		Storages:          storages,
-		ImpressionsEvCalc: impressionEvictionMonitor,
-		EventsEvCalc:      eventEvictionMonitor,
-		Runtime:           rtm,
-		HcAppMonitor:      appMonitor,
-		HcServicesMonitor: servicesMonitor,
-		FullConfig:        cfgForAdmin,
-		TLS:               adminTLSConfig,
-		FlagSpecVersion:   cfg.FlagSpecVersion,
+		Host:                cfg.Admin.Host,
+		Port:                int(cfg.Admin.Port),
+		Name:                "Split Synchronizer dashboard",
+		Proxy:               false,
+		Username:            cfg.Admin.Username,
+		Password:            cfg.Admin.Password,
+		Logger:              logger,
+		Storages:            storages,
+		ImpressionsEvCalc:   impressionEvictionMonitor,
+		EventsEvCalc:        eventEvictionMonitor,
+		Runtime:             rtm,
+		HcAppMonitor:        appMonitor,
+		HcServicesMonitor:   servicesMonitor,
+		FullConfig:          cfgForAdmin,
+		TLS:                 adminTLSConfig,
+		FlagSpecVersion:     cfg.FlagSpecVersion,
+		AuditLog:            auditLog,
+		KillSwitch:          killSwitch,
+		Snapshots:           snapshotBuilder,
+		Pipelines:           []task.Tunable{impTask, evTask},
+		PipelineDeadLetters: deadLetters,
+		DeadLetterReplayer:  deadLetterReplayer,
 	})
 	if err != nil {
 		panic(err.Error())
@@ -309,33 +397,46 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 
 	// Run Sync Manager
 	before := time.Now()
-	go syncManager.Start()
-	select {
-	case status := <-managerStatus:
-		switch status {
-		case synchronizer.Ready:
-			logger.Info("Synchronizer tasks started")
-			appMonitor.Start()
-			servicesMonitor.Start()
-			workers.TelemetryRecorder.SynchronizeConfig(
-				telemetry.InitConfig{
-					AdvancedConfig: *advanced,
-					TaskPeriods: cconf.TaskPeriods{
-						SplitSync:     int(cfg.Sync.SplitRefreshRateMs / 1000),
-						SegmentSync:   int(cfg.Sync.SegmentRefreshRateMs / 1000),
-						TelemetrySync: int(cfg.Sync.Advanced.InternalMetricsRateMs / 1000),
-					},
-					ImpressionsMode: cfg.Sync.ImpressionsMode,
-					ListenerEnabled: impListener != nil,
+	attemptInit := func() bool {
+		go syncManager.Start()
+		if status := <-managerStatus; status != synchronizer.Ready {
+			return false
+		}
+		logger.Info("Synchronizer tasks started")
+		appMonitor.Start()
+		servicesMonitor.Start()
+		workers.TelemetryRecorder.SynchronizeConfig(
+			telemetry.InitConfig{
+				AdvancedConfig: *advanced,
+				TaskPeriods: cconf.TaskPeriods{
+					SplitSync:     int(cfg.Sync.SplitRefreshRateMs / 1000),
+					SegmentSync:   int(cfg.Sync.SegmentRefreshRateMs / 1000),
+					TelemetrySync: int(cfg.Sync.Advanced.InternalMetricsRateMs / 1000),
 				},
-				time.Now().Sub(before).Milliseconds(),
-				map[string]int64{cfg.Apikey: 1},
-				nil,
-			)
-		case synchronizer.Error:
+				ImpressionsMode: cfg.Sync.ImpressionsMode,
+				ListenerEnabled: impListener != nil,
+			},
+			time.Now().Sub(before).Milliseconds(),
+			map[string]int64{cfg.Apikey: 1},
+			nil,
+		)
+		return true
+	}
+
+	if !attemptInit() {
+		if cfg.Initialization.Snapshot == "" {
 			logger.Error("Initial synchronization failed. Either Split is unreachable or the SDK key is incorrect. Aborting execution.")
-			return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
+			return common.NewInitError(errors.New("initial synchronization failed"), common.ExitTaskInitialization)
 		}
+
+		// redis holds the snapshot's data, so keep running degraded until split can be reached
+		logger.Warning("Failed to perform initial sync with Split servers but continuing from the snapshot. Will keep retrying in BG")
+		go func() {
+			boff := backoff.New(2, 10*time.Minute)
+			for !attemptInit() {
+				time.Sleep(boff.Next())
+			}
+		}()
 	}
 
 	rtm.RegisterShutdownHandler()
@@ -78,6 +78,25 @@ func TestIsApikeyValidNotOk(t *testing.T) {
 	}
 }
 
+func TestValidateApikeyUnreachable(t *testing.T) {
+	var fetchErr error
+	httpSplitFetcher := mocks.MockSplitFetcher{
+		FetchCall: func(fetchOptions *service.FlagRequestParams) (*dtos.SplitChangesDTO, error) {
+			return nil, fetchErr
+		},
+	}
+
+	fetchErr = &dtos.HTTPError{Code: http.StatusUnauthorized, Message: "unauthorized"}
+	if err := validateApikey(httpSplitFetcher); !errors.Is(err, errInvalidApikey) {
+		t.Error("rejected SDK keys should be invalid. Got: ", err)
+	}
+
+	fetchErr = errors.New("connection refused")
+	if err := validateApikey(httpSplitFetcher); err == nil || errors.Is(err, errInvalidApikey) {
+		t.Error("network errors should be reported as such. Got: ", err)
+	}
+}
+
 func TestSanitizeRedisWithForcedCleanup(t *testing.T) {
 	cfg := getDefaultConf()
 	cfg.Apikey = "983564etyrudhijfgknf9i08euh"
@@ -0,0 +1,100 @@
+package producer
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-split-commons/v6/storage/redis"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/snapshots"
+	"github.com/splitio/split-synchronizer/v5/splitio/util"
+)
+
+// ExportSnapshot connects to the redis instance in the config & returns a snapshot with the feature flags & segments stored,
+// along with its metadata
+func ExportSnapshot(cfg *conf.Main, logger logging.LoggerInterface) ([]byte, *snapshot.Metadata, error) {
+	key, err := snapshotSigningKey(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	redisOptions, err := parseRedisOptions(&cfg.Storage.Redis)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing redis config: %w", err)
+	}
+	redisClient, err := redis.NewRedisClient(redisOptions, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error instantiating redis client: %w", err)
+	}
+
+	splitStorage := redis.NewSplitStorage(redisClient, logger, flagsets.NewFlagSetFilter(cfg.FlagSetsFilter))
+	segmentStorage := redis.NewSegmentStorage(redisClient, logger)
+	return snapshots.NewBuilder(splitStorage, segmentStorage, key, snapshotEnvironment(cfg), logger).Build()
+}
+
+// snapshotEnvironment describes the environment synchronized in the snapshots generated
+func snapshotEnvironment(cfg *conf.Main) snapshots.Environment {
+	return snapshots.Environment{
+		SDKKeyHash:  util.HashAPIKey(cfg.Apikey),
+		FlagSets:    cfg.FlagSetsFilter,
+		SpecVersion: cfg.FlagSpecVersion,
+	}
+}
+
+func snapshotSigningKey(cfg *conf.Main) (ed25519.PrivateKey, error) {
+	keyFile := cfg.SnapshotSigning.PrivateKeyFN
+	if keyFile == "" {
+		return nil, nil
+	}
+
+	key, err := snapshot.LoadSigningKey(keyFile)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error loading snapshot signing key: %w", err), common.ExitInvalidConfiguration)
+	}
+	return key, nil
+}
+
+func snapshotVerificationKey(cfg *conf.Main) (ed25519.PublicKey, error) {
+	keyFile := cfg.SnapshotSigning.PublicKeyFN
+	if keyFile == "" {
+		return nil, nil
+	}
+
+	key, err := snapshot.LoadVerificationKey(keyFile)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error loading snapshot verification key: %w", err), common.ExitInvalidConfiguration)
+	}
+	return key, nil
+}
+
+// seedFromSnapshot writes the feature flags & segments in a snapshot to redis, unless the ones already stored are newer
+func seedFromSnapshot(
+	cfg *conf.Main,
+	snapFile string,
+	flagSetsFilter flagsets.FlagSetFilter,
+	splitStorage snapshots.SplitStorage,
+	segmentStorage snapshots.SegmentStorage,
+	logger logging.LoggerInterface,
+) error {
+	key, err := snapshotVerificationKey(cfg)
+	if err != nil {
+		return err
+	}
+
+	result, err := snapshots.SeedFromFile(snapFile, key, util.HashAPIKey(cfg.Apikey), flagSetsFilter, splitStorage, segmentStorage, logger)
+	if err != nil {
+		return fmt.Errorf("error seeding redis from snapshot: %w", err)
+	}
+
+	if result.Flags == 0 && result.Segments == 0 {
+		logger.Info("Redis already holds data newer than the one in the snapshot. Nothing was seeded")
+		return nil
+	}
+	logger.Info(fmt.Sprintf("Redis seeded from snapshot: %d feature flags (change number %d) & %d segments", result.Flags, result.ChangeNumber, result.Segments))
+	return nil
+}
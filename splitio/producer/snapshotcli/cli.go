@@ -0,0 +1,90 @@
+package snapshotcli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer"
+	pconf "github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshotcli"
+)
+
+// Command is the name of the subcommand handled by this package
+const Command = snapshotcli.Command
+
+const usage = `Usage: split-sync snapshot export [options]
+
+Export the feature flags & segments stored in redis as a snapshot, which can be used to seed another synchronizer or to start
+a proxy. Snapshots can be inspected, compared & extracted with the same commands as the proxy's (split-sync snapshot inspect ...)
+
+Options:
+`
+
+// exportSnapshot is overridden in tests to avoid connecting to redis
+var exportSnapshot = producer.ExportSnapshot
+
+// Run executes a snapshot subcommand (`args` should not include the subcommand name itself), writing its results
+// to `stdout` & errors to `stderr`. The exit code is returned
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	// snapshots have the same format in both modes, so everything but exporting is handled by the proxy tooling
+	if len(args) == 0 || args[0] != "export" {
+		return snapshotcli.Run(args, stdout, stderr)
+	}
+
+	var configFile, output string
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&configFile, "config", "", "Synchronizer config file, used to connect to redis & sign the snapshot")
+	fs.StringVar(&output, "output", "", "File to write the snapshot to (Default: split.proxy.<timestamp>.snapshot in the current dir)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return snapshotcli.ExitInvalidUse
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return snapshotcli.ExitInvalidUse
+	}
+
+	path, err := export(configFile, output)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err.Error())
+		return snapshotcli.ExitError
+	}
+	fmt.Fprintln(stdout, "Snapshot written to:", path)
+	return snapshotcli.ExitSuccess
+}
+
+func export(configFile string, output string) (string, error) {
+	var cfg pconf.Main
+	conf.PopulateDefaults(&cfg)
+	if configFile != "" {
+		if err := conf.PopulateConfigFromFile(configFile, &cfg); err != nil {
+			return "", fmt.Errorf("error parsing config file: %w", err)
+		}
+	}
+
+	encoded, meta, err := exportSnapshot(&cfg, logging.NewLogger(&logging.LoggerOptions{LogLevel: logging.LevelNone}))
+	if err != nil {
+		return "", err
+	}
+
+	if output == "" {
+		output = snapshot.FileName(meta.CreatedAt)
+	}
+	if err := os.WriteFile(output, encoded, 0644); err != nil {
+		return "", fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,73 @@
+package snapshotcli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	pconf "github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshotcli"
+)
+
+const fixture = "../../../test/snapshot/proxy.snapshot"
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExport(t *testing.T) {
+	original := exportSnapshot
+	defer func() { exportSnapshot = original }()
+
+	var exported *pconf.Main
+	exportSnapshot = func(cfg *pconf.Main, _ logging.LoggerInterface) ([]byte, *snapshot.Metadata, error) {
+		exported = cfg
+		return []byte("some snapshot"), &snapshot.Metadata{CreatedAt: 123}, nil
+	}
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	os.WriteFile(configFile, []byte(`{"apikey": "someKey", "storage": {"redis": {"host": "some-host"}}}`), 0644)
+	output := filepath.Join(dir, "exported.snapshot")
+
+	code, stdout, stderr := run("export", "-config", configFile, "-output", output)
+	if code != snapshotcli.ExitSuccess || !strings.Contains(stdout, output) {
+		t.Fatal("the snapshot should be exported. Got: ", code, stdout, stderr)
+	}
+	if exported.Apikey != "someKey" || exported.Storage.Redis.Host != "some-host" || exported.Storage.Redis.Port != 6379 {
+		t.Error("the config file should be read on top of the defaults. Got: ", exported)
+	}
+	if written, _ := os.ReadFile(output); string(written) != "some snapshot" {
+		t.Error("the snapshot should be written to the output file. Got: ", string(written))
+	}
+
+	exportSnapshot = func(*pconf.Main, logging.LoggerInterface) ([]byte, *snapshot.Metadata, error) {
+		return nil, nil, errors.New("redis is down")
+	}
+	if code, _, stderr := run("export", "-output", output); code != snapshotcli.ExitError || !strings.Contains(stderr, "redis is down") {
+		t.Error("export errors should be reported. Got: ", code, stderr)
+	}
+
+	if code, _, _ := run("export", "unexpected"); code != snapshotcli.ExitInvalidUse {
+		t.Error("positional arguments should be rejected. Got: ", code)
+	}
+}
+
+func TestOtherCommandsAreForwarded(t *testing.T) {
+	code, stdout, stderr := run("inspect", fixture)
+	if code != snapshotcli.ExitSuccess || !strings.Contains(stdout, "Feature flags") {
+		t.Error("inspecting should be handled by the proxy tooling. Got: ", code, stdout, stderr)
+	}
+
+	if code, _, _ := run(); code != snapshotcli.ExitInvalidUse {
+		t.Error("a command should be required. Got: ", code)
+	}
+}
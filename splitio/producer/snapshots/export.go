@@ -0,0 +1,142 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+// SplitStorage is the subset of the (redis) feature flag storage used to export & seed snapshots
+type SplitStorage interface {
+	All() []dtos.SplitDTO
+	ChangeNumber() (int64, error)
+	SegmentNames() *set.ThreadUnsafeSet
+	UpdateWithErrors(toAdd []dtos.SplitDTO, toRemove []dtos.SplitDTO, changeNumber int64) error
+}
+
+// SegmentStorage is the subset of the (redis) segment storage used to export & seed snapshots
+type SegmentStorage interface {
+	ChangeNumber(segmentName string) (int64, error)
+	Keys(segmentName string) *set.ThreadUnsafeSet
+	Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet, till int64) error
+}
+
+// Environment describes the environment synchronized, to be included in the metadata of the snapshots generated
+type Environment struct {
+	SDKKeyHash  uint32
+	FlagSets    []string
+	SpecVersion string
+}
+
+// RedisSnapshotter dumps the feature flags & segments stored in redis into a boltdb file, the same way the proxy stores them,
+// so that the snapshots generated can be used to start (or be loaded into) a proxy as well as to seed a synchronizer
+type RedisSnapshotter struct {
+	splits   SplitStorage
+	segments SegmentStorage
+	logger   logging.LoggerInterface
+	last     *persistent.Contents
+	mutex    sync.Mutex
+}
+
+// NewRedisSnapshotter constructs a new snapshotter reading from the supplied storages
+func NewRedisSnapshotter(splits SplitStorage, segments SegmentStorage, logger logging.LoggerInterface) *RedisSnapshotter {
+	return &RedisSnapshotter{splits: splits, segments: segments, logger: logger}
+}
+
+// NewBuilder constructs a snapshot builder with the data currently stored in redis, stored as the default environment
+func NewBuilder(splits SplitStorage, segments SegmentStorage, key ed25519.PrivateKey, env Environment, logger logging.LoggerInterface) *snapshot.Builder {
+	snapshotter := NewRedisSnapshotter(splits, segments, logger)
+	return snapshot.NewBuilder(snapshotter, key, snapshotter.describe(env))
+}
+
+// GetRawSnapshot reads all the feature flags & segments from redis & returns the raw contents of a boltdb file holding them
+func (r *RedisSnapshotter) GetRawSnapshot() ([]byte, error) {
+	contents := r.read()
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary db: %w", err)
+	}
+	defer db.Close()
+
+	if err := persistent.NewSplitChangesCollection(db, r.logger).Replace(contents.Flags); err != nil {
+		return nil, fmt.Errorf("error storing feature flags: %w", err)
+	}
+	if err := persistent.NewSegmentChangesCollection(db, r.logger).Replace(contents.Segments); err != nil {
+		return nil, fmt.Errorf("error storing segments: %w", err)
+	}
+
+	raw, err := db.GetRawSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	r.last = contents
+	r.mutex.Unlock()
+	return raw, nil
+}
+
+// read fetches the feature flags & the segments they reference from redis. Since redis doesn't keep track of when each
+// key was added to a segment, all of them are stored with the change number of the segment
+func (r *RedisSnapshotter) read() *persistent.Contents {
+	contents := &persistent.Contents{Flags: r.splits.All()}
+	for _, name := range r.splits.SegmentNames().List() {
+		segmentName, ok := name.(string)
+		if !ok {
+			continue
+		}
+
+		cn, err := r.segments.ChangeNumber(segmentName)
+		if err != nil || cn <= 0 {
+			// segment not yet synchronized
+			continue
+		}
+
+		item := persistent.SegmentChangesItem{Name: segmentName, Keys: make(map[string]persistent.SegmentKey)}
+		if keys := r.segments.Keys(segmentName); keys != nil {
+			for _, key := range keys.List() {
+				if strKey, ok := key.(string); ok {
+					item.Keys[strKey] = persistent.SegmentKey{Name: strKey, ChangeNumber: cn}
+				}
+			}
+		}
+		contents.Segments = append(contents.Segments, item)
+	}
+	return contents
+}
+
+// describe returns a function describing the data included in the last dump taken. The builder serializes dumps & descriptions,
+// so that the last dump is always the one the snapshot being built includes
+func (r *RedisSnapshotter) describe(env Environment) func() (snapshot.Metadata, error) {
+	return func() (snapshot.Metadata, error) {
+		r.mutex.Lock()
+		contents := r.last
+		r.mutex.Unlock()
+		if contents == nil {
+			return snapshot.Metadata{}, errors.New("no data has been read from redis yet")
+		}
+
+		return snapshot.Metadata{
+			SpecVersion: env.SpecVersion,
+			Environments: []snapshot.EnvironmentMetadata{{
+				SDKKeyHash:           env.SDKKeyHash,
+				FlagSets:             env.FlagSets,
+				FlagsChangeNumber:    contents.FlagsChangeNumber(),
+				SegmentsChangeNumber: contents.SegmentsChangeNumber(),
+				Flags:                contents.ActiveFlags(),
+				Segments:             len(contents.Segments),
+			}},
+		}, nil
+	}
+}
+
+var _ storage.Snapshotter = (*RedisSnapshotter)(nil)
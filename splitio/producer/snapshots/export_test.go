@@ -0,0 +1,188 @@
+package snapshots
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
+)
+
+// splitStorageMock mimics the redis feature flag storage
+type splitStorageMock struct {
+	flags map[string]dtos.SplitDTO
+	cn    int64
+}
+
+func newSplitStorageMock(cn int64, flags ...dtos.SplitDTO) *splitStorageMock {
+	s := &splitStorageMock{flags: make(map[string]dtos.SplitDTO), cn: cn}
+	for _, flag := range flags {
+		s.flags[flag.Name] = flag
+	}
+	return s
+}
+
+func (s *splitStorageMock) All() []dtos.SplitDTO {
+	all := make([]dtos.SplitDTO, 0, len(s.flags))
+	for _, flag := range s.flags {
+		all = append(all, flag)
+	}
+	return all
+}
+
+func (s *splitStorageMock) ChangeNumber() (int64, error) {
+	if s.cn == -1 {
+		return -1, os.ErrNotExist
+	}
+	return s.cn, nil
+}
+
+func (s *splitStorageMock) SegmentNames() *set.ThreadUnsafeSet {
+	names := set.NewSet()
+	for _, flag := range s.flags {
+		for _, condition := range flag.Conditions {
+			for _, matcher := range condition.MatcherGroup.Matchers {
+				if matcher.UserDefinedSegment != nil {
+					names.Add(matcher.UserDefinedSegment.SegmentName)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func (s *splitStorageMock) UpdateWithErrors(toAdd []dtos.SplitDTO, toRemove []dtos.SplitDTO, changeNumber int64) error {
+	for _, flag := range toAdd {
+		s.flags[flag.Name] = flag
+	}
+	for _, flag := range toRemove {
+		delete(s.flags, flag.Name)
+	}
+	s.cn = changeNumber
+	return nil
+}
+
+// segmentStorageMock mimics the redis segment storage
+type segmentStorageMock struct {
+	keys map[string]*set.ThreadUnsafeSet
+	cns  map[string]int64
+}
+
+func newSegmentStorageMock() *segmentStorageMock {
+	return &segmentStorageMock{keys: make(map[string]*set.ThreadUnsafeSet), cns: make(map[string]int64)}
+}
+
+func (s *segmentStorageMock) ChangeNumber(segmentName string) (int64, error) {
+	cn, ok := s.cns[segmentName]
+	if !ok {
+		return -1, os.ErrNotExist
+	}
+	return cn, nil
+}
+
+func (s *segmentStorageMock) Keys(segmentName string) *set.ThreadUnsafeSet {
+	return s.keys[segmentName]
+}
+
+func (s *segmentStorageMock) Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet, till int64) error {
+	if _, ok := s.keys[name]; !ok {
+		s.keys[name] = set.NewSet()
+	}
+	s.keys[name].Add(toAdd.List()...)
+	s.keys[name].Remove(toRemove.List()...)
+	s.cns[name] = till
+	return nil
+}
+
+func flagWithSegment(name string, cn int64, segment string, sets ...string) dtos.SplitDTO {
+	return dtos.SplitDTO{
+		Name:            name,
+		ChangeNumber:    cn,
+		Status:          "ACTIVE",
+		TrafficTypeName: "user",
+		Sets:            sets,
+		Conditions: []dtos.ConditionDTO{{
+			MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{
+				MatcherType:        "IN_SEGMENT",
+				UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: segment},
+			}}},
+		}},
+	}
+}
+
+func TestExportSnapshot(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	splits := newSplitStorageMock(20, flagWithSegment("f1", 10, "s1"), flagWithSegment("f2", 20, "s2"))
+	segments := newSegmentStorageMock()
+	segments.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 15)
+
+	encoded, meta, err := NewBuilder(splits, segments, nil, Environment{SDKKeyHash: 123, FlagSets: []string{"a"}, SpecVersion: "1.1"}, logger).Build()
+	if err != nil {
+		t.Fatal("no error should be returned. Got: ", err)
+	}
+
+	expected := snapshot.EnvironmentMetadata{SDKKeyHash: 123, FlagSets: []string{"a"}, FlagsChangeNumber: 20, SegmentsChangeNumber: 15, Flags: 2, Segments: 1}
+	if meta.SpecVersion != "1.1" || len(meta.Environments) != 1 || !reflect.DeepEqual(meta.Environments[0], expected) {
+		t.Error("unexpected metadata: ", meta)
+	}
+
+	// the snapshot holds the data as a proxy would, so that it can be read (& loaded) by one
+	snap, err := snapshot.Decode(encoded)
+	if err != nil {
+		t.Fatal("the snapshot should be decoded. Got: ", err)
+	}
+	contents, err := snapshots.ReadSnapshotContents(snap, logger)
+	if err != nil {
+		t.Fatal("the snapshot contents should be read. Got: ", err)
+	}
+
+	env, ok := contents[""]
+	if !ok || len(contents) != 1 {
+		t.Fatal("data should be stored as the default environment. Got: ", contents)
+	}
+	if len(env.Flags) != 2 || env.FlagsChangeNumber() != 20 {
+		t.Error("all feature flags should be included. Got: ", env.Flags)
+	}
+	// s2 was never synchronized, so it's not included
+	if len(env.Segments) != 1 || env.Segments[0].Name != "s1" || env.Segments[0].ChangeNumber() != 15 || len(env.Segments[0].ActiveKeys()) != 2 {
+		t.Error("synchronized segments should be included. Got: ", env.Segments)
+	}
+}
+
+func TestExportAndSeed(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	splits := newSplitStorageMock(20, flagWithSegment("f1", 10, "s1", "a"), flagWithSegment("f2", 20, "s1", "b"))
+	segments := newSegmentStorageMock()
+	segments.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 15)
+
+	encoded, _, err := NewBuilder(splits, segments, nil, Environment{SDKKeyHash: 123}, logger).Build()
+	if err != nil {
+		t.Fatal("no error should be returned. Got: ", err)
+	}
+	path := filepath.Join(t.TempDir(), "split.snapshot")
+	os.WriteFile(path, encoded, 0644)
+
+	seeded := newSplitStorageMock(-1)
+	seededSegments := newSegmentStorageMock()
+	result, err := SeedFromFile(path, nil, 123, flagsets.NewFlagSetFilter([]string{"a"}), seeded, seededSegments, logger)
+	if err != nil {
+		t.Fatal("no error should be returned. Got: ", err)
+	}
+
+	if *result != (SeedResult{Flags: 1, Segments: 1, ChangeNumber: 20}) {
+		t.Error("unexpected result: ", result)
+	}
+	if _, ok := seeded.flags["f1"]; !ok || len(seeded.flags) != 1 || seeded.cn != 20 {
+		t.Error("only the feature flags matching the flag sets filter should be seeded. Got: ", seeded.flags, seeded.cn)
+	}
+	if keys := seededSegments.keys["s1"]; keys == nil || !keys.IsEqual(set.NewSet("k1", "k2")) || seededSegments.cns["s1"] != 15 {
+		t.Error("segments should be seeded. Got: ", keys, seededSegments.cns)
+	}
+}
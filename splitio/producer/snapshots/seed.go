@@ -0,0 +1,161 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+var (
+	// ErrNoMatchingEnvironment is returned when the snapshot has no data for the sdk key used by the synchronizer
+	ErrNoMatchingEnvironment = errors.New("snapshot has no data for the sdk key used")
+
+	// ErrSDKKeyMismatch is returned when the default environment of the snapshot was synchronized with a different sdk key
+	ErrSDKKeyMismatch = errors.New("snapshot was taken with a different sdk key")
+)
+
+// SeedResult describes the data written to redis when seeding it from a snapshot
+type SeedResult struct {
+	Environment  string // environment of the snapshot the data was taken from (empty for the default one)
+	Flags        int
+	Segments     int
+	ChangeNumber int64
+}
+
+// SeedFromFile writes the feature flags & segments in a snapshot file to redis. Snapshots generated by a proxy serving many
+// environments are supported, in which case the one synchronized with the same sdk key is used. Data already in redis is
+// only replaced if it's older than the one in the snapshot. If `key` is not nil, only snapshots signed with the matching
+// private key are accepted
+func SeedFromFile(
+	path string,
+	key ed25519.PublicKey,
+	sdkKeyHash uint32,
+	flagSetsFilter flagsets.FlagSetFilter,
+	splits SplitStorage,
+	segments SegmentStorage,
+	logger logging.LoggerInterface,
+) (*SeedResult, error) {
+	snap, err := snapshot.DecodeFromFileVerified(path, key)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding snapshot: %w", err)
+	}
+
+	meta := snap.Meta()
+	if meta.Storage != snapshot.StorageBoltDB {
+		return nil, fmt.Errorf("unsupported snapshot storage type %d", meta.Storage)
+	}
+
+	contents, err := snapshots.ReadSnapshotContents(snap, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	environment, err := selectEnvironment(&meta, contents, sdkKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := seed(contents[environment], &flagSetsFilter, splits, segments)
+	if err != nil {
+		return nil, err
+	}
+	result.Environment = environment
+	return result, nil
+}
+
+// selectEnvironment returns the namespace of the environment in the snapshot synchronized with the same sdk key or,
+// if there's none, the default one (as long as it's not known to have been synchronized with a different sdk key)
+func selectEnvironment(meta *snapshot.Metadata, contents map[string]*persistent.Contents, sdkKeyHash uint32) (string, error) {
+	for idx := range meta.Environments {
+		if env := &meta.Environments[idx]; env.SDKKeyHash == sdkKeyHash {
+			if _, ok := contents[env.Name]; ok {
+				return env.Name, nil
+			}
+		}
+	}
+
+	if _, ok := contents[""]; !ok {
+		return "", ErrNoMatchingEnvironment
+	}
+
+	for idx := range meta.Environments {
+		if env := &meta.Environments[idx]; env.Name == "" && env.SDKKeyHash != 0 {
+			return "", ErrSDKKeyMismatch
+		}
+	}
+	return "", nil
+}
+
+// seed writes the feature flags (matching the flag sets filter) & segments of an environment to redis, skipping those
+// already stored with the same or a newer change number
+func seed(contents *persistent.Contents, filter *flagsets.FlagSetFilter, splits SplitStorage, segments SegmentStorage) (*SeedResult, error) {
+	result := &SeedResult{}
+	if incomingCN, currentCN := contents.FlagsChangeNumber(), currentChangeNumber(splits.ChangeNumber()); incomingCN > currentCN {
+		toAdd := make([]dtos.SplitDTO, 0, len(contents.Flags))
+		names := set.NewSet()
+		for _, flag := range contents.Flags {
+			if flag.Status == "ACTIVE" && filter.Instersect(flag.Sets) {
+				toAdd = append(toAdd, flag)
+				names.Add(flag.Name)
+			}
+		}
+
+		var toRemove []dtos.SplitDTO
+		for _, flag := range splits.All() {
+			if !names.Has(flag.Name) {
+				toRemove = append(toRemove, flag)
+			}
+		}
+
+		if err := splits.UpdateWithErrors(toAdd, toRemove, incomingCN); err != nil {
+			return nil, fmt.Errorf("error storing feature flags: %w", err)
+		}
+		result.Flags = len(toAdd)
+		result.ChangeNumber = incomingCN
+	}
+
+	for idx := range contents.Segments {
+		segment := &contents.Segments[idx]
+		incomingCN := segment.ChangeNumber()
+		if incomingCN <= currentChangeNumber(segments.ChangeNumber(segment.Name)) {
+			continue
+		}
+
+		toAdd := set.NewSet()
+		for _, key := range segment.ActiveKeys() {
+			toAdd.Add(key)
+		}
+
+		toRemove := set.NewSet()
+		if current := segments.Keys(segment.Name); current != nil {
+			for _, key := range current.List() {
+				if !toAdd.Has(key) {
+					toRemove.Add(key)
+				}
+			}
+		}
+
+		if err := segments.Update(segment.Name, toAdd, toRemove, incomingCN); err != nil {
+			return nil, fmt.Errorf("error storing segment %s: %w", segment.Name, err)
+		}
+		result.Segments++
+	}
+	return result, nil
+}
+
+// currentChangeNumber treats missing change numbers (reported as errors by redis) as nothing being stored yet
+func currentChangeNumber(cn int64, err error) int64 {
+	if err != nil {
+		return -1
+	}
+	return cn
+}
@@ -0,0 +1,128 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+// proxySnapshot writes a snapshot as generated by a proxy serving a default & a `staging` environment
+func proxySnapshot(t *testing.T, key ed25519.PrivateKey, environments ...snapshot.EnvironmentMetadata) string {
+	t.Helper()
+	logger := logging.NewLogger(nil)
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	if err != nil {
+		t.Fatal("error creating db: ", err)
+	}
+	defer db.Close()
+
+	for _, env := range []struct {
+		db   persistent.DBWrapper
+		name string
+	}{{db, "default"}, {persistent.NewNamespacedDB(db, "staging"), "staging"}} {
+		persistent.NewSplitChangesCollection(env.db, logger).Update([]dtos.SplitDTO{
+			{Name: env.name + "_flag", ChangeNumber: 10, Status: "ACTIVE"},
+			{Name: env.name + "_archived", ChangeNumber: 10, Status: "ARCHIVED"},
+		}, nil, 10)
+		segments := persistent.NewSegmentChangesCollection(env.db, logger)
+		segments.Update("s1", set.NewSet(env.name+"_key", "removed_key"), set.NewSet(), 5)
+		segments.Update("s1", set.NewSet(), set.NewSet("removed_key"), 8)
+	}
+
+	describe := func() (snapshot.Metadata, error) { return snapshot.Metadata{Environments: environments}, nil }
+	encoded, _, err := snapshot.NewBuilder(db, key, describe).Build()
+	if err != nil {
+		t.Fatal("error building snapshot: ", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "split.proxy.snapshot")
+	os.WriteFile(path, encoded, 0644)
+	return path
+}
+
+func TestSeedFromProxySnapshot(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	path := proxySnapshot(t, nil, snapshot.EnvironmentMetadata{Name: "", SDKKeyHash: 1}, snapshot.EnvironmentMetadata{Name: "staging", SDKKeyHash: 2})
+
+	splits := newSplitStorageMock(5, dtos.SplitDTO{Name: "stale_flag", ChangeNumber: 5})
+	segments := newSegmentStorageMock()
+	segments.Update("s1", set.NewSet("stale_key"), set.NewSet(), 1)
+	result, err := SeedFromFile(path, nil, 2, flagsets.NewFlagSetFilter(nil), splits, segments, logger)
+	if err != nil {
+		t.Fatal("no error should be returned. Got: ", err)
+	}
+
+	if *result != (SeedResult{Environment: "staging", Flags: 1, Segments: 1, ChangeNumber: 10}) {
+		t.Error("the environment synchronized with the same sdk key should be seeded. Got: ", result)
+	}
+	if _, ok := splits.flags["staging_flag"]; !ok || len(splits.flags) != 1 || splits.cn != 10 {
+		t.Error("active feature flags should replace the stored ones. Got: ", splits.flags)
+	}
+	if keys := segments.keys["s1"]; !keys.IsEqual(set.NewSet("staging_key")) || segments.cns["s1"] != 8 {
+		t.Error("active segment keys should replace the stored ones. Got: ", keys, segments.cns["s1"])
+	}
+}
+
+func TestSeedSkipsNewerData(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	path := proxySnapshot(t, nil)
+
+	splits := newSplitStorageMock(20, dtos.SplitDTO{Name: "newer_flag", ChangeNumber: 20})
+	segments := newSegmentStorageMock()
+	segments.Update("s1", set.NewSet("newer_key"), set.NewSet(), 8)
+	result, err := SeedFromFile(path, nil, 1, flagsets.NewFlagSetFilter(nil), splits, segments, logger)
+	if err != nil {
+		t.Fatal("no error should be returned. Got: ", err)
+	}
+
+	if result.Flags != 0 || result.Segments != 0 {
+		t.Error("nothing should be seeded. Got: ", result)
+	}
+	if _, ok := splits.flags["newer_flag"]; !ok || len(splits.flags) != 1 || splits.cn != 20 {
+		t.Error("newer feature flags should be kept. Got: ", splits.flags)
+	}
+	if keys := segments.keys["s1"]; !keys.IsEqual(set.NewSet("newer_key")) {
+		t.Error("segments as new as the snapshot's should be kept. Got: ", keys)
+	}
+}
+
+func TestSeedEnvironmentSelection(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	filter := flagsets.NewFlagSetFilter(nil)
+
+	// legacy snapshots have no environment metadata, so the default one is used
+	result, err := SeedFromFile(proxySnapshot(t, nil), nil, 3, filter, newSplitStorageMock(-1), newSegmentStorageMock(), logger)
+	if err != nil || result.Environment != "" {
+		t.Error("the default environment should be seeded. Got: ", result, err)
+	}
+
+	path := proxySnapshot(t, nil, snapshot.EnvironmentMetadata{Name: "", SDKKeyHash: 1})
+	if _, err := SeedFromFile(path, nil, 3, filter, newSplitStorageMock(-1), newSegmentStorageMock(), logger); !errors.Is(err, ErrSDKKeyMismatch) {
+		t.Error("snapshots taken with a different sdk key should be rejected. Got: ", err)
+	}
+}
+
+func TestSeedVerification(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	filter := flagsets.NewFlagSetFilter(nil)
+	public, private, _ := ed25519.GenerateKey(nil)
+
+	if _, err := SeedFromFile(proxySnapshot(t, nil), public, 1, filter, newSplitStorageMock(-1), newSegmentStorageMock(), logger); err == nil {
+		t.Error("unsigned snapshots should be rejected when a key is supplied")
+	}
+
+	splits := newSplitStorageMock(-1)
+	if _, err := SeedFromFile(proxySnapshot(t, private), public, 1, filter, splits, newSegmentStorageMock(), logger); err != nil || len(splits.flags) != 1 {
+		t.Error("signed snapshots should be seeded. Got: ", err)
+	}
+}
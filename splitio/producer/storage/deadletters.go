@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/redis"
+)
+
+// Keys used to store the batches that could not be posted to Split servers
+const (
+	KeyDeadLetters        = "SPLITIO.deadletters"
+	KeyDeadLettersSeq     = "SPLITIO.deadletters.seq"
+	KeyDeadLettersDropped = "SPLITIO.deadletters.dropped"
+	KeyDeadLettersBytes   = "SPLITIO.deadletters.bytes"
+)
+
+// addDeadLetterScript pushes a dead letter & removes the oldest ones while the list exceeds its max length or size,
+// keeping count of them. KEYS: list, dropped counter, size counter. ARGV: serialized letter, max length, max bytes
+const addDeadLetterScript = `
+local len = redis.call('RPUSH', KEYS[1], ARGV[1])
+local size = redis.call('INCRBY', KEYS[3], string.len(ARGV[1]))
+local maxItems = tonumber(ARGV[2])
+local maxBytes = tonumber(ARGV[3])
+local dropped = 0
+while len > 0 and (len > maxItems or (maxBytes > 0 and size > maxBytes)) do
+	size = redis.call('DECRBY', KEYS[3], string.len(redis.call('LPOP', KEYS[1])))
+	len = len - 1
+	dropped = dropped + 1
+end
+if len == 0 then
+	redis.call('SET', KEYS[3], 0)
+end
+if dropped > 0 then
+	redis.call('INCRBY', KEYS[2], dropped)
+end
+return dropped
+`
+
+// removeDeadLettersScript removes the supplied dead letters from the list. KEYS: list, size counter.
+// ARGV: serialized letters
+const removeDeadLettersScript = `
+for _, letter in ipairs(ARGV) do
+	if redis.call('LREM', KEYS[1], 1, letter) > 0 then
+		redis.call('DECRBY', KEYS[2], string.len(letter))
+	end
+end
+if redis.call('LLEN', KEYS[1]) == 0 then
+	redis.call('SET', KEYS[2], 0)
+end
+return 0
+`
+
+// DeadLetter is a batch that could not be posted to Split servers after exhausting all of its attempts.
+// The authorization header is not stored, and should be added back when posting it again
+type DeadLetter struct {
+	ID         int64       `json:"id"`
+	Pipeline   string      `json:"pipeline"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Headers    http.Header `json:"headers"`
+	Body       []byte      `json:"body"`
+	Attempts   int         `json:"attempts"`
+	LastError  string      `json:"lastError"`
+	StatusCode int         `json:"statusCode,omitempty"`
+	FailedAt   time.Time   `json:"failedAt"`
+
+	raw string // as stored in redis, used to remove it
+}
+
+// DeadLetterStore defines the methods to keep batches that could not be posted, for inspection & replay
+type DeadLetterStore interface {
+	Add(letter *DeadLetter) error
+	List() ([]DeadLetter, error)
+	Get(id int64) (*DeadLetter, error)
+	Remove(filter func(*DeadLetter) bool) ([]DeadLetter, error)
+	Dropped() (int64, error)
+}
+
+// RedisDeadLetterStore keeps up to `maxItems` dead letters in a redis list, using at most `maxBytes` (0 = unbounded).
+// When full, the oldest ones are discarded. Since batches are listed & replayed as a whole, `maxBytes` also bounds
+// the memory used by the synchronizer when doing so
+type RedisDeadLetterStore struct {
+	client   *redis.PrefixedRedisClient
+	maxItems int64
+	maxBytes int64
+}
+
+// NewRedisDeadLetterStore constructs a new dead letter store
+func NewRedisDeadLetterStore(client *redis.PrefixedRedisClient, maxItems int64, maxBytes int64) *RedisDeadLetterStore {
+	return &RedisDeadLetterStore{client: client, maxItems: maxItems, maxBytes: maxBytes}
+}
+
+// Add stores a dead letter, assigning it a new id
+func (r *RedisDeadLetterStore) Add(letter *DeadLetter) error {
+	id, err := r.client.Incr(KeyDeadLettersSeq)
+	if err != nil {
+		return fmt.Errorf("error generating dead letter id: %w", err)
+	}
+	letter.ID = id
+
+	serialized, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("error serializing dead letter: %w", err)
+	}
+
+	keys := []string{
+		prefixed(r.client, KeyDeadLetters),
+		prefixed(r.client, KeyDeadLettersDropped),
+		prefixed(r.client, KeyDeadLettersBytes),
+	}
+	if err := r.client.Eval(addDeadLetterScript, keys, string(serialized), r.maxItems, r.maxBytes); err != nil {
+		return fmt.Errorf("error storing dead letter: %w", err)
+	}
+	return nil
+}
+
+// List returns all the dead letters, oldest first
+func (r *RedisDeadLetterStore) List() ([]DeadLetter, error) {
+	raws, err := r.client.LRange(KeyDeadLetters, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching dead letters: %w", err)
+	}
+
+	letters := make([]DeadLetter, 0, len(raws))
+	for _, raw := range raws {
+		var letter DeadLetter
+		if err := json.Unmarshal([]byte(raw), &letter); err != nil {
+			return nil, fmt.Errorf("error deserializing dead letter: %w", err)
+		}
+		letter.raw = raw
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+// Get returns a dead letter by id, or nil if it doesn't exist
+func (r *RedisDeadLetterStore) Get(id int64) (*DeadLetter, error) {
+	letters, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range letters {
+		if letters[idx].ID == id {
+			return &letters[idx], nil
+		}
+	}
+	return nil, nil
+}
+
+// Remove discards the dead letters matching the filter (all of them if nil) and returns them
+func (r *RedisDeadLetterStore) Remove(filter func(*DeadLetter) bool) ([]DeadLetter, error) {
+	letters, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []DeadLetter
+	var raws []interface{}
+	for idx := range letters {
+		if filter == nil || filter(&letters[idx]) {
+			removed = append(removed, letters[idx])
+			raws = append(raws, letters[idx].raw)
+		}
+	}
+
+	if len(raws) == 0 {
+		return nil, nil
+	}
+
+	keys := []string{prefixed(r.client, KeyDeadLetters), prefixed(r.client, KeyDeadLettersBytes)}
+	if err := r.client.Eval(removeDeadLettersScript, keys, raws...); err != nil {
+		return nil, fmt.Errorf("error removing dead letters: %w", err)
+	}
+	return removed, nil
+}
+
+// Dropped returns the amount of dead letters discarded due to the list being full
+func (r *RedisDeadLetterStore) Dropped() (int64, error) {
+	raw, err := r.client.Get(KeyDeadLettersDropped)
+	if err != nil {
+		if errors.Is(err, redis.Nil) { // nothing dropped yet
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error fetching dropped dead letters count: %w", err)
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+var _ DeadLetterStore = (*RedisDeadLetterStore)(nil)
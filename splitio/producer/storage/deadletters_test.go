@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/splitio/go-toolkit/v5/redis"
+)
+
+func TestRedisDeadLetterStore(t *testing.T) {
+	redisPrefix, _ := getCurrentFuncName()
+	innerClient, _ := redis.NewClient(&redis.UniversalOptions{})
+	client, _ := redis.NewPrefixedRedisClient(innerClient, redisPrefix)
+	defer func() {
+		keys, _ := innerClient.Keys(redisPrefix + "*").Multi()
+		innerClient.Del(keys...)
+	}()
+
+	store := NewRedisDeadLetterStore(client, 2, 0)
+	if dropped, err := store.Dropped(); err != nil || dropped != 0 {
+		t.Error("nothing should be dropped yet. Got: ", dropped, err)
+	}
+
+	for _, pipeline := range []string{"impressions", "events", "uniques"} {
+		err := store.Add(&DeadLetter{Pipeline: pipeline, Method: http.MethodPost, Headers: http.Header{"A": []string{"b"}}, Body: []byte(pipeline)})
+		if err != nil {
+			t.Error("no error should be returned. Got: ", err)
+		}
+	}
+
+	letters, err := store.List()
+	if err != nil || len(letters) != 2 || letters[0].ID != 2 || letters[1].ID != 3 || string(letters[0].Body) != "events" {
+		t.Error("the oldest dead letters should be discarded when full. Got: ", letters, err)
+	}
+	if dropped, _ := store.Dropped(); dropped != 1 {
+		t.Error("discarded dead letters should be counted. Got: ", dropped)
+	}
+
+	if letter, err := store.Get(3); err != nil || letter == nil || letter.Pipeline != "uniques" || letter.Headers.Get("A") != "b" {
+		t.Error("wrong dead letter: ", letter, err)
+	}
+	if letter, err := store.Get(1); err != nil || letter != nil {
+		t.Error("discarded dead letters should not be found. Got: ", letter, err)
+	}
+
+	removed, err := store.Remove(func(letter *DeadLetter) bool { return letter.Pipeline == "events" })
+	if err != nil || len(removed) != 1 || removed[0].ID != 2 {
+		t.Error("wrong removed dead letters: ", removed, err)
+	}
+	if letters, _ := store.List(); len(letters) != 1 || letters[0].ID != 3 {
+		t.Error("only the matching dead letters should be removed. Got: ", letters)
+	}
+
+	// letters are also discarded when exceeding the max size
+	letters, _ = store.List()
+	bounded := NewRedisDeadLetterStore(client, 10, int64(len(letters[0].raw))+1)
+	if err := bounded.Add(&DeadLetter{Pipeline: "big", Method: http.MethodPost, Body: []byte("big")}); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+	if letters, _ := store.List(); len(letters) != 1 || letters[0].Pipeline != "big" {
+		t.Error("the oldest dead letters should be discarded when exceeding the max size. Got: ", letters)
+	}
+	if dropped, _ := store.Dropped(); dropped != 2 {
+		t.Error("discarded dead letters should be counted. Got: ", dropped)
+	}
+
+	if _, err := store.Remove(nil); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+	if size, _ := client.Get(KeyDeadLettersBytes); size != "0" {
+		t.Error("the size should be reset once empty. Got: ", size)
+	}
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+)
+
+// DeadLetterStoreMock is a mock
+type DeadLetterStoreMock struct {
+	AddCall     func(letter *storage.DeadLetter) error
+	ListCall    func() ([]storage.DeadLetter, error)
+	GetCall     func(id int64) (*storage.DeadLetter, error)
+	RemoveCall  func(filter func(*storage.DeadLetter) bool) ([]storage.DeadLetter, error)
+	DroppedCall func() (int64, error)
+}
+
+// Add mock
+func (m *DeadLetterStoreMock) Add(letter *storage.DeadLetter) error {
+	return m.AddCall(letter)
+}
+
+// List mock
+func (m *DeadLetterStoreMock) List() ([]storage.DeadLetter, error) {
+	return m.ListCall()
+}
+
+// Get mock
+func (m *DeadLetterStoreMock) Get(id int64) (*storage.DeadLetter, error) {
+	return m.GetCall(id)
+}
+
+// Remove mock
+func (m *DeadLetterStoreMock) Remove(filter func(*storage.DeadLetter) bool) ([]storage.DeadLetter, error) {
+	return m.RemoveCall(filter)
+}
+
+// Dropped mock
+func (m *DeadLetterStoreMock) Dropped() (int64, error) {
+	return m.DroppedCall()
+}
+
+var _ storage.DeadLetterStore = (*DeadLetterStoreMock)(nil)
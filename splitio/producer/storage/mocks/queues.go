@@ -0,0 +1,23 @@
+package mocks
+
+// QueueCapperMock is a mock
+type QueueCapperMock struct {
+	LengthCall  func(queue string) (int64, error)
+	CapCall     func(queue string, maxLength int64, strategy string) error
+	DroppedCall func() (map[string]int64, error)
+}
+
+// Length mock
+func (m *QueueCapperMock) Length(queue string) (int64, error) {
+	return m.LengthCall(queue)
+}
+
+// Cap mock
+func (m *QueueCapperMock) Cap(queue string, maxLength int64, strategy string) error {
+	return m.CapCall(queue, maxLength, strategy)
+}
+
+// Dropped mock
+func (m *QueueCapperMock) Dropped() (map[string]int64, error) {
+	return m.DroppedCall()
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/splitio/go-toolkit/v5/redis"
+)
+
+const (
+	// KeyQueuesDropped is a hash holding how many items have been dropped from each capped queue
+	KeyQueuesDropped = "SPLITIO.queues.dropped"
+
+	// max number of items sampled on each call, to avoid blocking redis for too long. Anything above it is trimmed
+	maxSampleWindow = 100000
+)
+
+// Strategies to remove the excess of a queue
+const (
+	OverflowTrim   = "trim"   // drop the oldest items
+	OverflowSample = "sample" // thin the oldest items out evenly, so that what's left still spans the same period
+)
+
+// ErrInvalidOverflowStrategy is returned when capping a queue with an unknown strategy
+var ErrInvalidOverflowStrategy = errors.New("invalid overflow strategy")
+
+// capQueueScript removes the excess of a list above a max length & keeps count of the items removed in a hash.
+// All of it is done atomically, so that sdks pushing & the synchronizer popping at the same time don't affect the count.
+// KEYS: queue, dropped counts hash. ARGV: max length, strategy, hash field, max sample window
+const capQueueScript = `
+local len = redis.call('LLEN', KEYS[1])
+local excess = len - tonumber(ARGV[1])
+if excess <= 0 then
+	return 0
+end
+
+if ARGV[2] == 'sample' then
+	local trimmed = excess - math.floor(tonumber(ARGV[4]) / 2)
+	if trimmed > 0 then
+		redis.call('LTRIM', KEYS[1], trimmed, -1)
+		len = len - trimmed
+	else
+		trimmed = 0
+	end
+
+	local window = math.min(len, (excess - trimmed) * 2)
+	local keep = window - (excess - trimmed)
+	local oldest = redis.call('LRANGE', KEYS[1], 0, window - 1)
+	redis.call('LTRIM', KEYS[1], window, -1)
+	for i = keep, 1, -1 do
+		redis.call('LPUSH', KEYS[1], oldest[math.floor((i - 1) * window / keep) + 1])
+	end
+else
+	redis.call('LTRIM', KEYS[1], excess, -1)
+end
+
+redis.call('HINCRBY', KEYS[2], ARGV[3], excess)
+return excess
+`
+
+// QueueCapper defines the methods to keep the queues written by sdks in redis under a max length
+type QueueCapper interface {
+	Length(queue string) (int64, error)
+	Cap(queue string, maxLength int64, strategy string) error
+	Dropped() (map[string]int64, error)
+}
+
+// RedisQueueCapper implements QueueCapper for redis lists
+type RedisQueueCapper struct {
+	client *redis.PrefixedRedisClient
+}
+
+// NewRedisQueueCapper constructs a new queue capper
+func NewRedisQueueCapper(client *redis.PrefixedRedisClient) *RedisQueueCapper {
+	return &RedisQueueCapper{client: client}
+}
+
+// Length returns the number of items in a queue
+func (r *RedisQueueCapper) Length(queue string) (int64, error) {
+	return r.client.LLen(queue)
+}
+
+// Cap removes the items of a queue above `maxLength` according to the supplied strategy
+func (r *RedisQueueCapper) Cap(queue string, maxLength int64, strategy string) error {
+	if strategy != OverflowTrim && strategy != OverflowSample {
+		return fmt.Errorf("%w: %s", ErrInvalidOverflowStrategy, strategy)
+	}
+
+	// keys are not prefixed when running scripts
+	keys := []string{prefixed(r.client, queue), prefixed(r.client, KeyQueuesDropped)}
+	if err := r.client.Eval(capQueueScript, keys, maxLength, strategy, queue, maxSampleWindow); err != nil {
+		return fmt.Errorf("error capping queue %s: %w", queue, err)
+	}
+	return nil
+}
+
+// Dropped returns how many items have been removed from each queue so far
+func (r *RedisQueueCapper) Dropped() (map[string]int64, error) {
+	raw, err := r.client.HGetAll(KeyQueuesDropped)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching dropped items count: %w", err)
+	}
+
+	dropped := make(map[string]int64, len(raw))
+	for queue, count := range raw {
+		parsed, err := strconv.ParseInt(count, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dropped items count '%s' for queue %s: %w", count, queue, err)
+		}
+		dropped[queue] = parsed
+	}
+	return dropped, nil
+}
+
+// prefixed returns a key as stored by the client, for commands that don't prefix their keys (ie: scripts)
+func prefixed(client *redis.PrefixedRedisClient, key string) string {
+	if prefix := client.Prefix(); prefix != "" {
+		return prefix + "." + key
+	}
+	return key
+}
+
+var _ QueueCapper = (*RedisQueueCapper)(nil)
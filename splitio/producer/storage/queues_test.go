@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/splitio/go-toolkit/v5/redis"
+)
+
+func TestRedisQueueCapper(t *testing.T) {
+	redisPrefix, _ := getCurrentFuncName()
+	innerClient, _ := redis.NewClient(&redis.UniversalOptions{})
+	client, _ := redis.NewPrefixedRedisClient(innerClient, redisPrefix)
+	defer func() {
+		keys, _ := innerClient.Keys(redisPrefix + "*").Multi()
+		innerClient.Del(keys...)
+	}()
+
+	for idx := 0; idx < 100; idx++ {
+		client.RPush("trimmed", idx)
+		client.RPush("sampled", idx)
+	}
+
+	capper := NewRedisQueueCapper(client)
+	if err := capper.Cap("trimmed", 10, "unknown"); !errors.Is(err, ErrInvalidOverflowStrategy) {
+		t.Error("unknown strategies should be rejected. Got: ", err)
+	}
+
+	if err := capper.Cap("trimmed", 10, OverflowTrim); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+	if length, _ := capper.Length("trimmed"); length != 10 {
+		t.Error("the queue should be trimmed to its max length. Got: ", length)
+	}
+	if oldest, _ := client.LRange("trimmed", 0, 0); len(oldest) != 1 || oldest[0] != "90" {
+		t.Error("the oldest items should be dropped. Got: ", oldest)
+	}
+
+	if err := capper.Cap("sampled", 80, OverflowSample); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+	sampled, _ := client.LRange("sampled", 0, -1)
+	if len(sampled) != 80 {
+		t.Error("the queue should be sampled down to its max length. Got: ", len(sampled))
+	}
+	if sampled[0] != "0" || sampled[1] != "2" || sampled[19] != "38" || sampled[20] != "40" || sampled[79] != "99" {
+		t.Error("the oldest items should be thinned out evenly, keeping their order. Got: ", sampled)
+	}
+
+	dropped, err := capper.Dropped()
+	if err != nil || dropped["trimmed"] != 90 || dropped["sampled"] != 20 {
+		t.Error("dropped items should be accounted for. Got: ", dropped, err)
+	}
+}
@@ -0,0 +1,294 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+)
+
+const (
+	defaultAutoscalePeriod = time.Minute
+	defaultMinFetchSize    = 1000
+	defaultMaxFetchSize    = 200000
+
+	// how much settings are multiplied (or divided) by on each adjustment
+	scaleFactor = 1.5
+
+	// consecutive periods with a lambda >= 1 & no backlog before scaling down
+	scaleDownAfter = 3
+
+	// adjustments kept to be shown in the dashboard
+	maxAdjustments = 20
+)
+
+// AutoscaleConfig contains the options to adjust a pipeline based on its eviction lambda (flushed vs generated data),
+// aiming to keep it >= 1 with as few resources as possible
+type AutoscaleConfig struct {
+	Monitor               evcalc.Monitor
+	Period                time.Duration
+	MinFetchSize          int
+	MaxFetchSize          int
+	MinProcessConcurrency int
+	MaxProcessConcurrency int
+	MinPostConcurrency    int
+	MaxPostConcurrency    int
+}
+
+func (c *AutoscaleConfig) normalize() {
+	if c.Period <= 0 {
+		c.Period = defaultAutoscalePeriod
+	}
+
+	if c.MaxFetchSize == 0 {
+		c.MaxFetchSize = defaultMaxFetchSize
+	}
+
+	if c.MinFetchSize == 0 {
+		c.MinFetchSize = defaultMinFetchSize
+	}
+
+	if c.MaxProcessConcurrency == 0 {
+		c.MaxProcessConcurrency = runtime.NumCPU()
+	}
+
+	if c.MaxPostConcurrency == 0 {
+		c.MaxPostConcurrency = defaultMaxConcurrency
+	}
+
+	c.MinFetchSize, c.MaxFetchSize = bounds(c.MinFetchSize, c.MaxFetchSize)
+	c.MinProcessConcurrency, c.MaxProcessConcurrency = bounds(c.MinProcessConcurrency, c.MaxProcessConcurrency)
+	c.MinPostConcurrency, c.MaxPostConcurrency = bounds(c.MinPostConcurrency, c.MaxPostConcurrency)
+}
+
+// FetchSizeAdjuster is implemented by workers whose fetch size can be changed while running
+type FetchSizeAdjuster interface {
+	FetchSize() int
+	SetFetchSize(size int)
+}
+
+// Settings are the parameters of a pipeline that are adjusted when autoscaling
+type Settings struct {
+	FetchSize          int `json:"fetchSize"` // 0 if the worker doesn't support adjusting it
+	ProcessConcurrency int `json:"processConcurrency"`
+	PostConcurrency    int `json:"postConcurrency"`
+}
+
+// Adjustment describes a change made to the settings of a pipeline
+type Adjustment struct {
+	Time   time.Time `json:"time"`
+	Lambda float64   `json:"lambda"`
+	Reason string    `json:"reason"`
+	From   Settings  `json:"from"`
+	To     Settings  `json:"to"`
+}
+
+// TuningStatus describes the current settings of a pipeline & the latest adjustments made to them
+type TuningStatus struct {
+	Name        string       `json:"name"`
+	Autoscaling bool         `json:"autoscaling"`
+	Lambda      float64      `json:"lambda"` // only reported when autoscaling
+	Current     Settings     `json:"current"`
+	Min         Settings     `json:"min"`
+	Max         Settings     `json:"max"`
+	Adjustments []Adjustment `json:"adjustments"` // newest first
+}
+
+// Tunable is implemented by tasks whose settings can be inspected
+type Tunable interface {
+	Tuning() TuningStatus
+}
+
+// Tuning returns the current settings of the pipeline, along with the adjustments made by the autoscaler (if enabled)
+func (p *PipelinedSyncTask) Tuning() TuningStatus {
+	if p.autoscaler == nil {
+		current := Settings{ProcessConcurrency: p.processConcurrency, PostConcurrency: p.postConcurrency}
+		if adjuster, ok := p.worker.(FetchSizeAdjuster); ok {
+			current.FetchSize = adjuster.FetchSize()
+		}
+		return TuningStatus{Name: p.name, Current: current, Min: current, Max: current}
+	}
+	return p.autoscaler.status()
+}
+
+// autoscaler periodically adjusts the fetch size & concurrency of a pipeline based on its eviction lambda
+type autoscaler struct {
+	name         string
+	cfg          AutoscaleConfig
+	fetchSizer   FetchSizeAdjuster // nil if the worker doesn't support adjusting it
+	processLimit *concurrencyLimit
+	postLimit    *concurrencyLimit
+	logger       logging.LoggerInterface
+	clock        func() time.Time
+
+	fullFetches atomic.Int64 // fetches since the last evaluation that returned as many items as requested
+
+	mutex       sync.Mutex
+	healthy     int // consecutive evaluations with a lambda >= 1 & no backlog
+	adjustments []Adjustment
+}
+
+func newAutoscaler(name string, cfg *AutoscaleConfig, worker Worker, logger logging.LoggerInterface) *autoscaler {
+	fetchSizer, _ := worker.(FetchSizeAdjuster)
+	return &autoscaler{name: name, cfg: *cfg, fetchSizer: fetchSizer, logger: logger, clock: time.Now}
+}
+
+// init clamps the initial settings within the configured bounds
+func (a *autoscaler) init(processConcurrency int, postConcurrency int, processLimit *concurrencyLimit, postLimit *concurrencyLimit) {
+	a.processLimit, a.postLimit = processLimit, postLimit
+	initial := a.clamp(Settings{ProcessConcurrency: processConcurrency, PostConcurrency: postConcurrency})
+	if a.fetchSizer != nil {
+		initial.FetchSize = clamp(a.fetchSizer.FetchSize(), a.cfg.MinFetchSize, a.cfg.MaxFetchSize)
+	}
+	a.apply(initial)
+}
+
+func (a *autoscaler) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(a.cfg.Period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.evaluate()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// fetched is called after every fetch to keep track of whether there's a backlog of data to evict
+func (a *autoscaler) fetched(count int) {
+	if a.fetchSizer != nil && count >= a.fetchSizer.FetchSize() {
+		a.fullFetches.Add(1)
+	}
+}
+
+// evaluate checks the eviction lambda & adjusts the settings if needed: they're scaled up while lambda < 1, and down
+// after a few periods with lambda >= 1 & no backlog (when the fetch size is known)
+func (a *autoscaler) evaluate() {
+	lambda := a.cfg.Monitor.Lambda()
+	backlog := a.fullFetches.Swap(0) > 0
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	current := a.current()
+	var target Settings
+	var reason string
+	switch {
+	case lambda < 1:
+		a.healthy = 0
+		target = a.clamp(Settings{FetchSize: grow(current.FetchSize), ProcessConcurrency: grow(current.ProcessConcurrency), PostConcurrency: grow(current.PostConcurrency)})
+		reason = "data is generated faster than it's evicted"
+		if target == current {
+			a.logger.Warning(fmt.Sprintf("[pipelined/%s] lambda is %.2f, but the pipeline is already at its max settings", a.name, lambda))
+			return
+		}
+	case backlog:
+		a.healthy = 0
+		return
+	default:
+		if a.healthy++; a.healthy < scaleDownAfter {
+			return
+		}
+		a.healthy = 0
+		target = a.clamp(Settings{FetchSize: shrink(current.FetchSize), ProcessConcurrency: shrink(current.ProcessConcurrency), PostConcurrency: shrink(current.PostConcurrency)})
+		reason = "data is evicted as fast as it's generated"
+		if target == current {
+			return
+		}
+	}
+
+	a.apply(target)
+	a.adjustments = append([]Adjustment{{Time: a.clock(), Lambda: lambda, Reason: reason, From: current, To: target}}, a.adjustments...)
+	if len(a.adjustments) > maxAdjustments {
+		a.adjustments = a.adjustments[:maxAdjustments]
+	}
+	a.logger.Info(fmt.Sprintf(
+		"[pipelined/%s] lambda is %.2f (%s). Adjusting fetch size %d -> %d, process concurrency %d -> %d, post concurrency %d -> %d",
+		a.name, lambda, reason, current.FetchSize, target.FetchSize, current.ProcessConcurrency, target.ProcessConcurrency,
+		current.PostConcurrency, target.PostConcurrency,
+	))
+}
+
+func (a *autoscaler) current() Settings {
+	current := Settings{ProcessConcurrency: a.processLimit.get(), PostConcurrency: a.postLimit.get()}
+	if a.fetchSizer != nil {
+		current.FetchSize = a.fetchSizer.FetchSize()
+	}
+	return current
+}
+
+func (a *autoscaler) apply(settings Settings) {
+	if a.fetchSizer != nil {
+		a.fetchSizer.SetFetchSize(settings.FetchSize)
+	}
+	a.processLimit.set(settings.ProcessConcurrency)
+	a.postLimit.set(settings.PostConcurrency)
+}
+
+// clamp keeps settings within the configured bounds. The fetch size is left untouched if it can't be adjusted
+func (a *autoscaler) clamp(settings Settings) Settings {
+	if a.fetchSizer != nil {
+		settings.FetchSize = clamp(settings.FetchSize, a.cfg.MinFetchSize, a.cfg.MaxFetchSize)
+	}
+	settings.ProcessConcurrency = clamp(settings.ProcessConcurrency, a.cfg.MinProcessConcurrency, a.cfg.MaxProcessConcurrency)
+	settings.PostConcurrency = clamp(settings.PostConcurrency, a.cfg.MinPostConcurrency, a.cfg.MaxPostConcurrency)
+	return settings
+}
+
+func (a *autoscaler) status() TuningStatus {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	status := TuningStatus{
+		Name:        a.name,
+		Autoscaling: true,
+		Lambda:      a.cfg.Monitor.Lambda(),
+		Current:     a.current(),
+		Min:         Settings{ProcessConcurrency: a.cfg.MinProcessConcurrency, PostConcurrency: a.cfg.MinPostConcurrency},
+		Max:         Settings{ProcessConcurrency: a.cfg.MaxProcessConcurrency, PostConcurrency: a.cfg.MaxPostConcurrency},
+		Adjustments: append([]Adjustment(nil), a.adjustments...),
+	}
+	if a.fetchSizer != nil {
+		status.Min.FetchSize, status.Max.FetchSize = a.cfg.MinFetchSize, a.cfg.MaxFetchSize
+	}
+	return status
+}
+
+func grow(value int) int {
+	return int(math.Max(float64(value+1), math.Ceil(float64(value)*scaleFactor)))
+}
+
+func shrink(value int) int {
+	return int(math.Min(float64(value-1), math.Floor(float64(value)/scaleFactor)))
+}
+
+func clamp(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// bounds makes sure the min is at least 1 & not greater than the max
+func bounds(min int, max int) (int, int) {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+var _ Tunable = (*PipelinedSyncTask)(nil)
@@ -0,0 +1,200 @@
+package task
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+)
+
+type monitorMock struct {
+	evcalc.Monitor
+	lambda float64
+}
+
+func (m *monitorMock) Lambda() float64 { return m.lambda }
+
+type adjustableWorkerMock struct {
+	mockWorker
+	fetchSize int
+}
+
+func (w *adjustableWorkerMock) FetchSize() int        { return w.fetchSize }
+func (w *adjustableWorkerMock) SetFetchSize(size int) { w.fetchSize = size }
+
+func TestConcurrencyLimit(t *testing.T) {
+	limit := newConcurrencyLimit(1)
+	if !limit.wait(0) {
+		t.Error("goroutines within the limit should run")
+	}
+
+	allowed := make(chan bool, 1)
+	go func() { allowed <- limit.wait(1) }()
+	select {
+	case <-allowed:
+		t.Error("goroutines above the limit should wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limit.set(2)
+	if !<-allowed {
+		t.Error("goroutines should be allowed to run once the limit is raised")
+	}
+
+	go func() { allowed <- limit.wait(2) }()
+	limit.close()
+	if <-allowed {
+		t.Error("idle goroutines should finish once the limit is closed")
+	}
+	if !limit.wait(1) {
+		t.Error("active goroutines should keep running after closing the limit to drain their input")
+	}
+}
+
+func TestAutoscalerAdjustments(t *testing.T) {
+	monitor := &monitorMock{lambda: 0.5}
+	worker := &adjustableWorkerMock{fetchSize: 500}
+	cfg := &AutoscaleConfig{
+		Monitor:               monitor,
+		MinFetchSize:          1000,
+		MaxFetchSize:          2000,
+		MinProcessConcurrency: 1,
+		MaxProcessConcurrency: 3,
+		MinPostConcurrency:    2,
+		MaxPostConcurrency:    4,
+	}
+	cfg.normalize()
+
+	processLimit, postLimit := newConcurrencyLimit(3), newConcurrencyLimit(4)
+	scaler := newAutoscaler("test", cfg, worker, logging.NewLogger(nil))
+	scaler.init(1, 1, processLimit, postLimit)
+	if current := scaler.current(); current != (Settings{FetchSize: 1000, ProcessConcurrency: 1, PostConcurrency: 2}) {
+		t.Error("initial settings should be clamped within bounds. Got: ", current)
+	}
+
+	scaler.evaluate()
+	if current := scaler.current(); current != (Settings{FetchSize: 1500, ProcessConcurrency: 2, PostConcurrency: 3}) {
+		t.Error("settings should be raised while lambda < 1. Got: ", current)
+	}
+
+	scaler.evaluate()
+	scaler.evaluate()
+	if current := scaler.current(); current != (Settings{FetchSize: 2000, ProcessConcurrency: 3, PostConcurrency: 4}) {
+		t.Error("settings should not be raised above the max. Got: ", current)
+	}
+	if status := scaler.status(); len(status.Adjustments) != 2 || status.Adjustments[0].To.FetchSize != 2000 {
+		t.Error("only actual changes should be recorded, newest first. Got: ", status.Adjustments)
+	}
+
+	// lambda >= 1 but data is still piling up: settings are kept
+	monitor.lambda = 1.5
+	for idx := 0; idx < scaleDownAfter; idx++ {
+		scaler.fetched(2000)
+		scaler.evaluate()
+	}
+	if current := scaler.current(); current.FetchSize != 2000 {
+		t.Error("settings should not be lowered while there's a backlog. Got: ", current)
+	}
+
+	for idx := 0; idx < scaleDownAfter-1; idx++ {
+		scaler.fetched(10)
+		scaler.evaluate()
+	}
+	if current := scaler.current(); current.FetchSize != 2000 {
+		t.Error("settings should only be lowered after a few healthy periods. Got: ", current)
+	}
+
+	scaler.evaluate()
+	if current := scaler.current(); current != (Settings{FetchSize: 1333, ProcessConcurrency: 2, PostConcurrency: 2}) {
+		t.Error("settings should be lowered after a few healthy periods. Got: ", current)
+	}
+	if processLimit.get() != 2 || postLimit.get() != 2 {
+		t.Error("concurrency limits should be updated. Got: ", processLimit.get(), postLimit.get())
+	}
+
+	status := scaler.status()
+	if !status.Autoscaling || status.Lambda != 1.5 || len(status.Adjustments) != 3 || status.Adjustments[0].Lambda != 1.5 {
+		t.Error("unexpected status: ", status)
+	}
+	if status.Min != (Settings{FetchSize: 1000, ProcessConcurrency: 1, PostConcurrency: 2}) ||
+		status.Max != (Settings{FetchSize: 2000, ProcessConcurrency: 3, PostConcurrency: 4}) {
+		t.Error("status should include the configured bounds. Got: ", status.Min, status.Max)
+	}
+}
+
+func TestPipelineTaskAutoscaling(t *testing.T) {
+	var httpCalls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&httpCalls, 1)
+	}))
+	defer server.Close()
+
+	var fetchCalls int64
+	worker := &adjustableWorkerMock{
+		fetchSize: 10,
+		mockWorker: mockWorker{
+			fetchCall: func() ([]string, error) {
+				if atomic.AddInt64(&fetchCalls, 1) > 5 {
+					return nil, nil
+				}
+				return []string{"a", "b", "c"}, nil
+			},
+			processCall: func(rawData [][]byte, sink chan<- interface{}) error {
+				for range rawData {
+					sink <- "item"
+				}
+				return nil
+			},
+			buildRequestCall: func(data interface{}) (*http.Request, error) {
+				return http.NewRequest("GET", server.URL, nil)
+			},
+		},
+	}
+
+	task, err := NewPipelinedTask(&Config{
+		Name:             "test",
+		Worker:           worker,
+		Logger:           logging.NewLogger(nil),
+		ProcessBatchSize: 3,
+		PostConcurrency:  2,
+		MaxAccumWait:     10 * time.Millisecond,
+		Autoscale: &AutoscaleConfig{
+			Monitor:               &monitorMock{lambda: 1},
+			MinFetchSize:          20,
+			MaxFetchSize:          100,
+			MaxProcessConcurrency: 4,
+			MaxPostConcurrency:    8,
+		},
+	})
+	if err != nil {
+		t.Fatal("task init: ", err)
+	}
+
+	status := task.Tuning()
+	if !status.Autoscaling || status.Current != (Settings{FetchSize: 20, ProcessConcurrency: 1, PostConcurrency: 2}) {
+		t.Error("the pipeline should start with the configured settings clamped within bounds. Got: ", status)
+	}
+
+	task.Start()
+	time.Sleep(500 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		task.Stop(true)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle goroutines should finish when stopping the task")
+	}
+
+	if c := atomic.LoadInt64(&httpCalls); c != 15 {
+		t.Error("all fetched items should be posted. Got: ", c)
+	}
+}
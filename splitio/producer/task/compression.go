@@ -0,0 +1,100 @@
+package task
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Algorithms available to compress the bodies posted by pipelined tasks
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// ErrInvalidCompression is returned when an unknown compression algorithm is requested
+var ErrInvalidCompression = errors.New("invalid compression algorithm")
+
+// compressor writes a compressed version of a body into a buffer
+type compressor interface {
+	encoding() string // value of the Content-Encoding header
+	compress(dst *bytes.Buffer, src []byte) error
+}
+
+func newCompressor(algorithm string) (compressor, error) {
+	switch algorithm {
+	case "", CompressionNone:
+		return nil, nil
+	case CompressionGzip:
+		return &gzipCompressor{writers: sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}}, nil
+	case CompressionZstd:
+		// a single encoder is shared, limiting how many bodies are compressed at once to the number of cpus
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(runtime.NumCPU()))
+		if err != nil {
+			return nil, fmt.Errorf("error setting up zstd encoder: %w", err)
+		}
+		return &zstdCompressor{encoder: encoder}, nil
+	}
+	return nil, fmt.Errorf("%w: '%s'. Should be one of: %s, %s, %s", ErrInvalidCompression, algorithm, CompressionNone, CompressionGzip, CompressionZstd)
+}
+
+type gzipCompressor struct {
+	writers sync.Pool
+}
+
+func (g *gzipCompressor) encoding() string { return CompressionGzip }
+
+func (g *gzipCompressor) compress(dst *bytes.Buffer, src []byte) error {
+	writer := g.writers.Get().(*gzip.Writer)
+	defer g.writers.Put(writer)
+
+	writer.Reset(dst)
+	if _, err := writer.Write(src); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+}
+
+func (z *zstdCompressor) encoding() string { return CompressionZstd }
+
+func (z *zstdCompressor) compress(dst *bytes.Buffer, src []byte) error {
+	dst.Write(z.encoder.EncodeAll(src, dst.AvailableBuffer()))
+	return nil
+}
+
+// compressRequest replaces the body of a request with a compressed version of it. `raw` & `compressed` are used to
+// hold the original & resulting bodies, and the latter must not be reused until the request is no longer needed
+func compressRequest(c compressor, req *http.Request, raw *bytes.Buffer, compressed *bytes.Buffer) error {
+	if req.Body == nil {
+		return nil
+	}
+
+	_, err := raw.ReadFrom(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("error reading request body: %w", err)
+	}
+
+	if err := c.compress(compressed, raw.Bytes()); err != nil {
+		return fmt.Errorf("error compressing request body: %w", err)
+	}
+
+	body := compressed.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Encoding", c.encoding())
+	return nil
+}
@@ -0,0 +1,133 @@
+package task
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+)
+
+func decompress(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatal("invalid gzip body: ", err)
+		}
+		reader = gz
+	case CompressionZstd:
+		zs, err := zstd.NewReader(body)
+		if err != nil {
+			t.Fatal("invalid zstd body: ", err)
+		}
+		defer zs.Close()
+		reader = zs
+	default:
+		reader = body
+	}
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal("error decompressing body: ", err)
+	}
+	return string(decompressed)
+}
+
+func TestCompressRequest(t *testing.T) {
+	if _, err := newCompressor("lz4"); !errors.Is(err, ErrInvalidCompression) {
+		t.Error("unknown algorithms should be rejected. Got: ", err)
+	}
+	if c, err := newCompressor(CompressionNone); c != nil || err != nil {
+		t.Error("no compressor should be returned when disabled. Got: ", c, err)
+	}
+
+	original := bytes.Repeat([]byte(`{"k":"some_key","t":"on","m":1234567890}`), 100)
+	for _, algorithm := range []string{CompressionGzip, CompressionZstd} {
+		c, err := newCompressor(algorithm)
+		if err != nil {
+			t.Fatal("compressor init: ", err)
+		}
+
+		for idx := 0; idx < 2; idx++ { // make sure pooled writers/buffers are reusable
+			req, _ := http.NewRequest(http.MethodPost, "http://localhost", bytes.NewReader(original))
+			if err := compressRequest(c, req, new(bytes.Buffer), new(bytes.Buffer)); err != nil {
+				t.Error("no error should be returned. Got: ", err)
+			}
+
+			if req.Header.Get("Content-Encoding") != algorithm || req.ContentLength >= int64(len(original)) {
+				t.Error("the body should be compressed & the encoding set. Got: ", req.Header, req.ContentLength)
+			}
+			if decompressed := decompress(t, algorithm, req.Body); decompressed != string(original) {
+				t.Error("the decompressed body should match the original one")
+			}
+
+			body, _ := req.GetBody()
+			if decompressed := decompress(t, algorithm, body); decompressed != string(original) {
+				t.Error("the body should be readable again for retries")
+			}
+		}
+	}
+}
+
+func TestPipelinedPostCompression(t *testing.T) {
+	var encodings []string
+	accept := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, encoding)
+		if encoding != "" && !accept {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if body := decompress(t, encoding, r.Body); body != "payload" {
+			t.Error("wrong body: ", body)
+		}
+	}))
+	defer server.Close()
+
+	task, err := NewPipelinedTask(&Config{
+		Name:   "test",
+		Logger: logging.NewLogger(nil),
+		Worker: &mockWorker{buildRequestCall: func(data interface{}) (*http.Request, error) {
+			return http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte(data.(string))))
+		}},
+		Retry:       &retry.Policy{MaxAttempts: 1},
+		Compression: CompressionZstd,
+	})
+	if err != nil {
+		t.Fatal("task init: ", err)
+	}
+	poolWrapper := newTaskMemoryPoolWraper(10)
+	task.pool = poolWrapper
+
+	if err := task.post("payload"); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+
+	accept = false
+	if err := task.post("payload"); err != nil {
+		t.Error("the batch should be posted uncompressed without consuming an attempt. Got: ", err)
+	}
+	if err := task.post("payload"); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+
+	if len(encodings) != 4 || encodings[0] != CompressionZstd || encodings[1] != CompressionZstd || encodings[2] != "" || encodings[3] != "" {
+		t.Error("compression should be disabled once rejected by the server. Got: ", encodings)
+	}
+	poolWrapper.validate(t)
+
+	if _, err := NewPipelinedTask(&Config{Worker: &mockWorker{}, Logger: logging.NewLogger(nil), Compression: "lz4"}); !errors.Is(err, ErrInvalidCompression) {
+		t.Error("unknown algorithms should be rejected. Got: ", err)
+	}
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+)
+
+// DeadLetterReplayer posts the batches stored as dead letters by the pipelined tasks again
+type DeadLetterReplayer struct {
+	store      storage.DeadLetterStore
+	httpClient http.Client
+	apikey     string
+	logger     logging.LoggerInterface
+	mutex      sync.Mutex
+}
+
+// NewDeadLetterReplayer constructs a new dead letter replayer
+func NewDeadLetterReplayer(
+	store storage.DeadLetterStore,
+	apikey string,
+	httpTimeout time.Duration,
+	logger logging.LoggerInterface,
+) *DeadLetterReplayer {
+	return &DeadLetterReplayer{
+		store:      store,
+		httpClient: http.Client{Timeout: httpTimeout},
+		apikey:     apikey,
+		logger:     logger,
+	}
+}
+
+// Replay posts the dead letters matching the filter (all of them if nil) once.
+// Only the ones posted successfully are removed from the store
+func (r *DeadLetterReplayer) Replay(filter func(*storage.DeadLetter) bool) (replayed int, failed int, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	letters, err := r.store.List()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	posted := make(map[int64]struct{})
+	for idx := range letters {
+		letter := &letters[idx]
+		if filter != nil && !filter(letter) {
+			continue
+		}
+
+		if err := r.post(letter); err != nil {
+			r.logger.Error(fmt.Sprintf("error replaying dead letter %d (%s): %s", letter.ID, letter.Pipeline, err))
+			failed++
+			continue
+		}
+		posted[letter.ID] = struct{}{}
+	}
+
+	if len(posted) == 0 {
+		return 0, failed, nil
+	}
+
+	removed, err := r.store.Remove(func(letter *storage.DeadLetter) bool {
+		_, ok := posted[letter.ID]
+		return ok
+	})
+	if err != nil {
+		return 0, failed, fmt.Errorf("dead letters were replayed but could not be removed: %w", err)
+	}
+	return len(removed), failed, nil
+}
+
+func (r *DeadLetterReplayer) post(letter *storage.DeadLetter) error {
+	req, err := http.NewRequest(letter.Method, letter.URL, bytes.NewReader(letter.Body))
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+
+	req.Header = letter.Headers.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apikey)
+	return postRequest(&r.httpClient, req)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/splitio/go-split-commons/v6/dtos"
@@ -43,22 +44,23 @@ type EventsPipelineWorker struct {
 
 	url       string
 	apikey    string
-	fetchSize int64
+	fetchSize atomic.Int64
 	pool      eventsMemoryPool
 }
 
 // NewEventsWorker builds a pipeline-suited events worker
 func NewEventsWorker(cfg *EventWorkerConfig) (*EventsPipelineWorker, error) {
 	cfg.normalize()
-	return &EventsPipelineWorker{
+	worker := &EventsPipelineWorker{
 		logger:          cfg.Logger,
 		evictionMonitor: cfg.EvictionMonitor,
 		storage:         cfg.Storage,
 		url:             cfg.URL + "/events/bulk",
 		apikey:          cfg.Apikey,
-		fetchSize:       int64(cfg.FetchSize),
 		pool:            newEventWorkerMemoryPool(cfg.FetchSize, defaultMetasPerBulk, defaultEventsPerBulk),
-	}, nil
+	}
+	worker.fetchSize.Store(int64(cfg.FetchSize))
+	return worker, nil
 }
 
 // Fetch fetches raw events
@@ -67,7 +69,7 @@ func NewEventsWorker(cfg *EventWorkerConfig) (*EventsPipelineWorker, error) {
 // We should eventually revisit the redis client interface and see how feasible it is
 // to return bytes directly.
 func (i *EventsPipelineWorker) Fetch() ([]string, error) {
-	raw, sizeAfterPop, err := i.storage.PopNRaw(i.fetchSize)
+	raw, sizeAfterPop, err := i.storage.PopNRaw(i.fetchSize.Load())
 	if err != nil {
 		return nil, fmt.Errorf("error fetching raw events: %w", err)
 	}
@@ -75,6 +77,16 @@ func (i *EventsPipelineWorker) Fetch() ([]string, error) {
 	return raw, nil
 }
 
+// FetchSize returns the max number of events popped from redis on each fetch
+func (i *EventsPipelineWorker) FetchSize() int {
+	return int(i.fetchSize.Load())
+}
+
+// SetFetchSize updates the max number of events popped from redis on each fetch
+func (i *EventsPipelineWorker) SetFetchSize(size int) {
+	i.fetchSize.Store(int64(size))
+}
+
 // Process parses the raw data and packages the events
 func (i *EventsPipelineWorker) Process(raws [][]byte, sink chan<- interface{}) error {
 	batches := newEventBatches(i.pool)
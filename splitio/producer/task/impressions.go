@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/splitio/go-split-commons/v6/dtos"
@@ -52,7 +53,7 @@ type ImpressionsPipelineWorker struct {
 
 	url       string
 	apikey    string
-	fetchSize int64
+	fetchSize atomic.Int64
 	pool      impressionsMemoryPool
 }
 
@@ -60,17 +61,18 @@ type ImpressionsPipelineWorker struct {
 func NewImpressionWorker(cfg *ImpressionWorkerConfig) (*ImpressionsPipelineWorker, error) {
 	cfg.normalize()
 
-	return &ImpressionsPipelineWorker{
+	worker := &ImpressionsPipelineWorker{
 		logger:          cfg.Logger,
 		storage:         cfg.Storage,
 		impListener:     cfg.ImpressionsListener,
 		impManager:      cfg.ImpressionManager,
 		url:             cfg.URL + "/testImpressions/bulk",
 		apikey:          cfg.Apikey,
-		fetchSize:       int64(cfg.FetchSize),
 		evictionMonitor: cfg.EvictionMonitor,
 		pool:            newImpWorkerMemoryPool(cfg.FetchSize, defaultMetasPerBulk, defaultFeatureCount, defaultImpsPerFeature),
-	}, nil
+	}
+	worker.fetchSize.Store(int64(cfg.FetchSize))
+	return worker, nil
 }
 
 // Fetch fetches raw impressions
@@ -79,7 +81,7 @@ func NewImpressionWorker(cfg *ImpressionWorkerConfig) (*ImpressionsPipelineWorke
 // We should eventually revisit the redis client interface and see how feasible it is
 // to return bytes directly.
 func (i *ImpressionsPipelineWorker) Fetch() ([]string, error) {
-	raw, sizeAfterPop, err := i.storage.PopNRaw(i.fetchSize)
+	raw, sizeAfterPop, err := i.storage.PopNRaw(i.fetchSize.Load())
 	if err != nil {
 		return nil, fmt.Errorf("error fetching raw impressions: %w", err)
 	}
@@ -87,6 +89,16 @@ func (i *ImpressionsPipelineWorker) Fetch() ([]string, error) {
 	return raw, nil
 }
 
+// FetchSize returns the max number of impressions popped from redis on each fetch
+func (i *ImpressionsPipelineWorker) FetchSize() int {
+	return int(i.fetchSize.Load())
+}
+
+// SetFetchSize updates the max number of impressions popped from redis on each fetch
+func (i *ImpressionsPipelineWorker) SetFetchSize(size int) {
+	i.fetchSize.Store(int64(size))
+}
+
 // Process parses the raw data and packages the impressions
 func (i *ImpressionsPipelineWorker) Process(raws [][]byte, sink chan<- interface{}) error {
 	batches := newImpBatches(i.pool)
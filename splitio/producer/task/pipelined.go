@@ -1,6 +1,7 @@
 package task
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,8 +11,10 @@ import (
 
 	tsync "github.com/splitio/go-toolkit/v5/sync"
 
-	"github.com/splitio/go-toolkit/v5/common"
 	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
 )
 
 const (
@@ -33,6 +36,10 @@ type Config struct {
 	PostConcurrency    int
 	MaxAccumWait       time.Duration
 	HTTPTimeout        time.Duration
+	Autoscale          *AutoscaleConfig        // nil to keep the concurrency & fetch size fixed
+	Retry              *retry.Policy           // nil to use the default policy
+	DeadLetters        storage.DeadLetterStore // nil to discard the batches that could not be posted
+	Compression        string                  // algorithm used to compress the posted bodies. Empty or "none" to disable
 }
 
 // Worker defines the methods that should be implemented by pipeline-suited data-flows
@@ -66,6 +73,18 @@ func (c *Config) normalize() {
 	if c.MaxAccumWait == 0 {
 		c.MaxAccumWait = defaultMaxAccumSecs * time.Second
 	}
+
+	if c.Autoscale != nil {
+		c.Autoscale.normalize()
+	}
+
+	if c.Retry == nil {
+		policy := defaultRetryPolicy
+		c.Retry = &policy
+	}
+	if c.Retry.MaxAttempts < 1 {
+		c.Retry.MaxAttempts = 1
+	}
 }
 
 // PipelinedSyncTask implements a fetch-process-evict buffered flow
@@ -73,72 +92,114 @@ func (c *Config) normalize() {
 // steps to be scaled individually in order to maximize throughput
 type PipelinedSyncTask struct {
 	// dependencies
-	logger     logging.LoggerInterface
-	httpClient http.Client
-	worker     Worker
-	pool       taskMemoryPool
+	logger      logging.LoggerInterface
+	httpClient  http.Client
+	worker      Worker
+	pool        taskMemoryPool
+	autoscaler  *autoscaler // nil if autoscaling is disabled
+	retry       *retry.Policy
+	deadLetters storage.DeadLetterStore                   // nil if dead letters are disabled
+	sleep       func(time.Duration, <-chan struct{}) bool // returns false if interrupted by a shutdown
+	compressor  compressor                                // nil if compression is disabled
+	compressing *tsync.AtomicBool                         // unset if split servers don't accept compressed bodies
 
 	// configs
 	name               string
-	postConcurrency    int
-	processConcurrency int
+	postConcurrency    int // goroutines started for posting, not all of them may be active
+	processConcurrency int // goroutines started for processing, not all of them may be active
 	processBatchSize   int
 	maxAccumWait       time.Duration
 
 	// synchronization elements
 	inputBuffer     chan []string
 	preSubmitBuffer chan interface{}
+	processLimit    *concurrencyLimit
+	postLimit       *concurrencyLimit
 	waiter          sync.WaitGroup
 	running         *tsync.AtomicBool
 	shutdown        chan struct{}
+	stopAutoscaling chan struct{}
 }
 
 // NewPipelinedTask constructs a pipelined task
 func NewPipelinedTask(config *Config) (*PipelinedSyncTask, error) {
 	t := http.DefaultTransport.(*http.Transport).Clone()
 	config.normalize()
-	t.MaxConnsPerHost = config.PostConcurrency
-	t.MaxIdleConns = config.PostConcurrency
-	t.MaxIdleConnsPerHost = config.PostConcurrency
-	return &PipelinedSyncTask{
+
+	compressor, err := newCompressor(config.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	// when autoscaling, goroutines are started up to the max concurrency, and only the ones needed are kept active
+	postConcurrency, processConcurrency := config.PostConcurrency, config.ProcessConcurrency
+	var scaler *autoscaler
+	if config.Autoscale != nil {
+		scaler = newAutoscaler(config.Name, config.Autoscale, config.Worker, config.Logger)
+		postConcurrency, processConcurrency = config.Autoscale.MaxPostConcurrency, config.Autoscale.MaxProcessConcurrency
+	}
+
+	t.MaxConnsPerHost = postConcurrency
+	t.MaxIdleConns = postConcurrency
+	t.MaxIdleConnsPerHost = postConcurrency
+	task := &PipelinedSyncTask{
 		name:               config.Name,
 		logger:             config.Logger,
 		worker:             config.Worker,
+		autoscaler:         scaler,
+		retry:              config.Retry,
+		deadLetters:        config.DeadLetters,
+		sleep:              sleepUnlessClosed,
+		compressor:         compressor,
+		compressing:        tsync.NewAtomicBool(compressor != nil),
 		httpClient:         http.Client{Transport: t, Timeout: config.HTTPTimeout},
 		pool:               newTaskMemoryPool(config.ProcessBatchSize),
 		processBatchSize:   config.ProcessBatchSize,
-		postConcurrency:    config.PostConcurrency,
-		processConcurrency: config.ProcessConcurrency,
+		postConcurrency:    postConcurrency,
+		processConcurrency: processConcurrency,
 		maxAccumWait:       config.MaxAccumWait,
 		running:            tsync.NewAtomicBool(true),
 		inputBuffer:        make(chan []string, config.InputBufferSize),
-		preSubmitBuffer:    make(chan interface{}, config.PostConcurrency*4),
-		shutdown:           make(chan struct{}, 1),
-	}, nil
+		preSubmitBuffer:    make(chan interface{}, postConcurrency*4),
+		processLimit:       newConcurrencyLimit(processConcurrency),
+		postLimit:          newConcurrencyLimit(postConcurrency),
+		shutdown:           make(chan struct{}),
+		stopAutoscaling:    make(chan struct{}),
+	}
+
+	if scaler != nil {
+		scaler.init(config.ProcessConcurrency, config.PostConcurrency, task.processLimit, task.postLimit)
+	}
+	return task, nil
 }
 
 // Start begins execution
 func (p *PipelinedSyncTask) Start() {
 	p.waiter.Add(p.postConcurrency + p.processConcurrency + 1)
 	for idx := 0; idx < p.postConcurrency; idx++ {
-		go p.sinker()
+		go p.sinker(idx)
 	}
 
 	processWaiter := &sync.WaitGroup{}
 	processWaiter.Add(p.processConcurrency)
 	for idx := 0; idx < p.processConcurrency; idx++ {
-		go func() {
-			p.processor()
+		go func(idx int) {
+			p.processor(idx)
 			processWaiter.Done()
-		}()
+		}(idx)
 	}
 
 	go func() {
 		processWaiter.Wait()
 		close(p.preSubmitBuffer)
+		p.postLimit.close()
 	}()
 
 	go p.filler()
+
+	if p.autoscaler != nil {
+		go p.autoscaler.run(p.stopAutoscaling)
+	}
 }
 
 // Stop the task and drain the pipe
@@ -146,7 +207,8 @@ func (p *PipelinedSyncTask) Stop(blocking bool) error {
 	if !p.running.TestAndClear() {
 		return errTaskRunning
 	}
-	p.shutdown <- struct{}{}
+	close(p.shutdown)
+	close(p.stopAutoscaling)
 	if blocking {
 		p.waiter.Wait()
 	}
@@ -175,9 +237,14 @@ func (p *PipelinedSyncTask) filler() {
 				continue
 			case <-p.shutdown:
 				close(p.inputBuffer)
+				p.processLimit.close()
 				return
 			}
 		}
+
+		if p.autoscaler != nil {
+			p.autoscaler.fetched(len(raw))
+		}
 		howMany := len(raw)
 		select {
 		case p.inputBuffer <- raw:
@@ -190,7 +257,7 @@ func (p *PipelinedSyncTask) filler() {
 	}
 }
 
-func (p *PipelinedSyncTask) processor() {
+func (p *PipelinedSyncTask) processor(idx int) {
 	p.logger.Debug(fmt.Sprintf("[pipelined/%s] - starting processing task", p.name))
 	defer p.waiter.Done()
 	timer := time.NewTimer(p.maxAccumWait)
@@ -198,6 +265,10 @@ func (p *PipelinedSyncTask) processor() {
 	processing := tsync.NewAtomicBool(true)
 
 	for processing.IsSet() {
+		if !p.processLimit.wait(idx) { // not needed anymore & no more elements to process
+			return
+		}
+
 		func() {
 			batch := p.pool.getRawBuffer() // acquire a buffer from the pool and schedule a release
 			defer p.pool.releaseRawBuffer(batch)
@@ -240,10 +311,13 @@ func (p *PipelinedSyncTask) processor() {
 	}
 }
 
-func (p *PipelinedSyncTask) sinker() {
+func (p *PipelinedSyncTask) sinker(idx int) {
 	p.logger.Debug(fmt.Sprintf("[pipelined/%s] - starting posting task", p.name))
 	defer p.waiter.Done()
 	for {
+		if !p.postLimit.wait(idx) { // not needed anymore & no more processed data available
+			return
+		}
 
 		bulk, ok := <-p.preSubmitBuffer
 		if !ok { // no more processed data available, end this goroutine
@@ -255,33 +329,141 @@ func (p *PipelinedSyncTask) sinker() {
 				defer asRecyblable.recycle()
 			}
 
-			err := common.WithAttempts(3, func() error {
-				p.logger.Debug(fmt.Sprintf("[pipelined/%s] - impressions post ready. making request", p.name))
-				req, err := p.worker.BuildRequest(bulk)
-				if err != nil {
-					return fmt.Errorf(fmt.Sprintf("[pipelined/%s] error building request: %s", p.name, err))
-				}
+			if err := p.post(bulk); err != nil {
+				p.logger.Error(err)
+			}
+		}()
+	}
+}
 
-				resp, err := p.httpClient.Do(req)
-				if err != nil {
-					return fmt.Errorf(fmt.Sprintf("[pipelined/%s] error posting: %s", p.name, err))
-				}
+// post sends a batch to Split servers, retrying according to the policy. Batches that cannot be posted after
+// exhausting all attempts are stored as dead letters, so that they can be replayed later on
+func (p *PipelinedSyncTask) post(bulk interface{}) error {
+	var compressed []*bytes.Buffer // released once the batch is posted or stored as a dead letter
+	defer func() {
+		for _, buffer := range compressed {
+			p.pool.releaseBodyBuffer(buffer)
+		}
+	}()
 
-				if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-					return fmt.Errorf(fmt.Sprintf("[pipelined/%s] bad status code when sinking data: %d", p.name, resp.StatusCode))
-				}
+	var req *http.Request
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		p.logger.Debug(fmt.Sprintf("[pipelined/%s] - post ready. making request", p.name))
+		if req, err = p.worker.BuildRequest(bulk); err != nil {
+			return fmt.Errorf("[pipelined/%s] error building request: %w", p.name, err)
+		}
 
-				if resp.Body != nil {
-					resp.Body.Close()
-				}
-				p.logger.Debug(fmt.Sprintf("[pipelined/%s] - impressions posted successfully", p.name))
-				return nil
-			})
-			if err != nil {
-				p.logger.Error(err)
+		compressing := p.compressing.IsSet()
+		if compressing {
+			buffer := p.pool.getBodyBuffer()
+			compressed = append(compressed, buffer)
+			if err = p.compress(req, buffer); err != nil {
+				return fmt.Errorf("[pipelined/%s] %w", p.name, err)
 			}
-		}()
+		}
+
+		if err = postRequest(&p.httpClient, req); err == nil {
+			p.logger.Debug(fmt.Sprintf("[pipelined/%s] - data posted successfully", p.name))
+			return nil
+		}
+
+		if compressing && retry.StatusCodeOf(err) == http.StatusUnsupportedMediaType {
+			// compressed bodies are not accepted. keep posting them uncompressed without counting this attempt
+			p.logger.Warning(fmt.Sprintf("[pipelined/%s] %s compression not supported by the server. disabling it", p.name, p.compressor.encoding()))
+			p.compressing.Unset()
+			attempt--
+			continue
+		}
+
+		if attempt >= p.retry.MaxAttempts || !p.retry.Retryable(err) {
+			break
+		}
+
+		backoff := p.retry.Backoff(attempt)
+		p.logger.Debug(fmt.Sprintf("[pipelined/%s] attempt %d failed (%s). retrying in %s", p.name, attempt, err, backoff))
+		if !p.sleep(backoff, p.shutdown) {
+			// shutting down, the batch is kept as a dead letter instead of holding up the stop
+			break
+		}
 	}
+
+	if p.deadLetters != nil {
+		letter, dlErr := newDeadLetter(p.name, req, attempt, err)
+		if dlErr == nil {
+			dlErr = p.deadLetters.Add(letter)
+		}
+		if dlErr != nil {
+			p.logger.Error(fmt.Sprintf("[pipelined/%s] error storing dead letter: %s", p.name, dlErr))
+		}
+	}
+	return fmt.Errorf("[pipelined/%s] giving up after %d attempts: %w", p.name, attempt, err)
+}
+
+// compress replaces the body of a request with a compressed version of it, written into the supplied buffer
+func (p *PipelinedSyncTask) compress(req *http.Request, buffer *bytes.Buffer) error {
+	raw := p.pool.getBodyBuffer()
+	defer p.pool.releaseBodyBuffer(raw)
+	return compressRequest(p.compressor, req, raw, buffer)
+}
+
+// sleepUnlessClosed waits for `d` to elapse, returning false if `cancel` gets closed before
+func sleepUnlessClosed(d time.Duration, cancel <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-cancel:
+		return false
+	}
+}
+
+// concurrencyLimit caps how many of the goroutines started for a pipeline step are active. Goroutines are identified
+// by an index, and only those with an index lower than the limit are allowed to run
+type concurrencyLimit struct {
+	active int
+	closed bool
+	mutex  sync.Mutex
+	cond   *sync.Cond
+}
+
+func newConcurrencyLimit(active int) *concurrencyLimit {
+	l := &concurrencyLimit{active: active}
+	l.cond = sync.NewCond(&l.mutex)
+	return l
+}
+
+func (l *concurrencyLimit) get() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.active
+}
+
+func (l *concurrencyLimit) set(active int) {
+	l.mutex.Lock()
+	l.active = active
+	l.mutex.Unlock()
+	l.cond.Broadcast()
+}
+
+// close signals that the input of the step has been closed, so that idle goroutines can finish
+func (l *concurrencyLimit) close() {
+	l.mutex.Lock()
+	l.closed = true
+	l.mutex.Unlock()
+	l.cond.Broadcast()
+}
+
+// wait blocks the goroutine with index `idx` while it's not allowed to run. It returns false if it should finish
+func (l *concurrencyLimit) wait(idx int) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for idx >= l.active && !l.closed {
+		l.cond.Wait()
+	}
+	return idx < l.active
 }
 
 type rawBuffer = [][]byte
@@ -289,15 +471,19 @@ type rawBuffer = [][]byte
 type taskMemoryPool interface {
 	getRawBuffer() rawBuffer
 	releaseRawBuffer(b rawBuffer)
+	getBodyBuffer() *bytes.Buffer
+	releaseBodyBuffer(b *bytes.Buffer)
 }
 
 type taskMemoryPoolImpl struct {
 	processBatchSlicePool *sync.Pool
+	bodyBufferPool        *sync.Pool
 }
 
 func newTaskMemoryPool(processBatchSize int) *taskMemoryPoolImpl {
 	return &taskMemoryPoolImpl{
 		processBatchSlicePool: &sync.Pool{New: func() interface{} { return make([][]byte, 0, processBatchSize) }},
+		bodyBufferPool:        &sync.Pool{New: func() interface{} { return new(bytes.Buffer) }},
 	}
 }
 
@@ -309,6 +495,16 @@ func (t *taskMemoryPoolImpl) releaseRawBuffer(b rawBuffer) {
 	t.processBatchSlicePool.Put(b)
 }
 
+func (t *taskMemoryPoolImpl) getBodyBuffer() *bytes.Buffer {
+	b := t.bodyBufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func (t *taskMemoryPoolImpl) releaseBodyBuffer(b *bytes.Buffer) {
+	t.bodyBufferPool.Put(b)
+}
+
 type recyclable interface {
 	recycle()
 }
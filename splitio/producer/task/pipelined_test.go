@@ -1,6 +1,7 @@
 package task
 
 import (
+	"bytes"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
@@ -40,8 +41,9 @@ func randSeq(n int) string {
 }
 
 type taskMemoryPoolWrapper struct {
-	wrapped    *taskMemoryPoolImpl
-	rawBuffers int64
+	wrapped     *taskMemoryPoolImpl
+	rawBuffers  int64
+	bodyBuffers int64
 }
 
 func newTaskMemoryPoolWraper(size int) *taskMemoryPoolWrapper {
@@ -53,6 +55,9 @@ func (p *taskMemoryPoolWrapper) validate(t *testing.T) {
 	if r := atomic.LoadInt64(&p.rawBuffers); r != 0 {
 		t.Error("possible leak in raw buffer: ", r)
 	}
+	if r := atomic.LoadInt64(&p.bodyBuffers); r != 0 {
+		t.Error("possible leak in body buffer: ", r)
+	}
 }
 
 func (p *taskMemoryPoolWrapper) getRawBuffer() rawBuffer {
@@ -65,6 +70,16 @@ func (p *taskMemoryPoolWrapper) releaseRawBuffer(b rawBuffer) {
 	p.wrapped.releaseRawBuffer(b)
 }
 
+func (p *taskMemoryPoolWrapper) getBodyBuffer() *bytes.Buffer {
+	atomic.AddInt64(&p.bodyBuffers, 1)
+	return p.wrapped.getBodyBuffer()
+}
+
+func (p *taskMemoryPoolWrapper) releaseBodyBuffer(b *bytes.Buffer) {
+	atomic.AddInt64(&p.bodyBuffers, -1)
+	p.wrapped.releaseBodyBuffer(b)
+}
+
 func TestPipelineTask(t *testing.T) {
 	var fetchChalls int64
 	var processCalls int64
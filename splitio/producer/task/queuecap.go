@@ -0,0 +1,18 @@
+package task
+
+import (
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/worker"
+)
+
+// NewQueueCapTask constructs a task used to periodically remove the excess of the queues written by sdks in redis
+func NewQueueCapTask(wrk *worker.QueueCapWorker, logger logging.LoggerInterface, period int) *asynctask.AsyncTask {
+	doWork := func(l logging.LoggerInterface) error {
+		if err := wrk.Process(); err != nil {
+			l.Error("error capping redis queues: ", err)
+		}
+		return nil
+	}
+	return asynctask.NewAsyncTask("cap-queues", doWork, period, nil, nil, logger)
+}
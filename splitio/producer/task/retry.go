@@ -0,0 +1,63 @@
+package task
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+)
+
+// defaultRetryPolicy is used when no policy is supplied, & matches the behavior prior to retries being configurable
+var defaultRetryPolicy = retry.Policy{MaxAttempts: 3, RetryableStatusCodes: []int{408, 429, 500, 502, 503, 504}}
+
+// postRequest performs a request, returning an error if it fails or the response has a non-2xx status code
+func postRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting: %w", err)
+	}
+
+	if resp.Body != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &dtos.HTTPError{Code: resp.StatusCode, Message: fmt.Sprintf("bad status code when sinking data: %d", resp.StatusCode)}
+	}
+	return nil
+}
+
+// newDeadLetter builds a dead letter from the last request that failed. The authorization header is left out
+func newDeadLetter(pipeline string, req *http.Request, attempts int, err error) (*storage.DeadLetter, error) {
+	var body []byte
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+		defer reader.Close()
+		if body, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+
+	headers := req.Header.Clone()
+	headers.Del("Authorization")
+	return &storage.DeadLetter{
+		Pipeline:   pipeline,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Headers:    headers,
+		Body:       body,
+		Attempts:   attempts,
+		LastError:  err.Error(),
+		StatusCode: retry.StatusCodeOf(err),
+		FailedAt:   time.Now(),
+	}, nil
+}
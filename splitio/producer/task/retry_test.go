@@ -0,0 +1,165 @@
+package task
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage/mocks"
+)
+
+// memDeadLetters returns a mocked store backed by a slice
+func memDeadLetters() (*mocks.DeadLetterStoreMock, *[]storage.DeadLetter) {
+	stored := &[]storage.DeadLetter{}
+	return &mocks.DeadLetterStoreMock{
+		AddCall: func(letter *storage.DeadLetter) error {
+			letter.ID = int64(len(*stored) + 1)
+			*stored = append(*stored, *letter)
+			return nil
+		},
+		ListCall: func() ([]storage.DeadLetter, error) {
+			return append([]storage.DeadLetter(nil), *stored...), nil
+		},
+		RemoveCall: func(filter func(*storage.DeadLetter) bool) ([]storage.DeadLetter, error) {
+			var removed, kept []storage.DeadLetter
+			for _, letter := range *stored {
+				if filter == nil || filter(&letter) {
+					removed = append(removed, letter)
+				} else {
+					kept = append(kept, letter)
+				}
+			}
+			*stored = kept
+			return removed, nil
+		},
+	}, stored
+}
+
+func TestPipelinedPostRetriesAndDeadLetters(t *testing.T) {
+	var calls int64
+	statuses := []int{503, 503, 200, 503, 503, 503, 400}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[atomic.AddInt64(&calls, 1)-1])
+	}))
+	defer server.Close()
+
+	store, stored := memDeadLetters()
+	task, _ := NewPipelinedTask(&Config{
+		Name:   "test",
+		Logger: logging.NewLogger(nil),
+		Worker: &mockWorker{buildRequestCall: func(data interface{}) (*http.Request, error) {
+			req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte(data.(string))))
+			req.Header.Set("Authorization", "Bearer some")
+			req.Header.Set("SplitSDKVersion", "go-1.2.3")
+			return req, nil
+		}},
+		Retry:       &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Second, RetryableStatusCodes: []int{503}},
+		DeadLetters: store,
+	})
+	var backoffs []time.Duration
+	task.sleep = func(d time.Duration, _ <-chan struct{}) bool { backoffs = append(backoffs, d); return true }
+
+	if err := task.post("first"); err != nil || calls != 3 {
+		t.Error("the post should succeed after retrying. Got: ", err, calls)
+	}
+	if len(backoffs) != 2 || backoffs[1] != 2*time.Second {
+		t.Error("retries should be backed off. Got: ", backoffs)
+	}
+
+	if err := task.post("second"); err == nil || calls != 6 {
+		t.Error("the post should fail after exhausting all attempts. Got: ", err, calls)
+	}
+	if err := task.post("third"); err == nil || calls != 7 {
+		t.Error("non retryable errors should not be retried. Got: ", err, calls)
+	}
+
+	if len(*stored) != 2 {
+		t.Fatal("failed batches should be stored as dead letters. Got: ", *stored)
+	}
+	letter := (*stored)[0]
+	if letter.Pipeline != "test" || string(letter.Body) != "second" || letter.Attempts != 3 || letter.StatusCode != 503 {
+		t.Error("unexpected dead letter: ", letter)
+	}
+	if letter.Headers.Get("Authorization") != "" || letter.Headers.Get("SplitSDKVersion") != "go-1.2.3" {
+		t.Error("all headers but the authorization one should be kept. Got: ", letter.Headers)
+	}
+	if (*stored)[1].Attempts != 1 || (*stored)[1].StatusCode != 400 {
+		t.Error("unexpected dead letter: ", (*stored)[1])
+	}
+}
+
+func TestPipelinedPostBackoffInterruptedByShutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store, stored := memDeadLetters()
+	task, _ := NewPipelinedTask(&Config{
+		Name:   "test",
+		Logger: logging.NewLogger(nil),
+		Worker: &mockWorker{buildRequestCall: func(data interface{}) (*http.Request, error) {
+			return http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte(data.(string))))
+		}},
+		Retry:       &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Hour, RetryableStatusCodes: []int{503}},
+		DeadLetters: store,
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- task.post("pending") }()
+	time.Sleep(100 * time.Millisecond)
+	task.Stop(false)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("the post should fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the backoff should be interrupted by the shutdown")
+	}
+	if len(*stored) != 1 || (*stored)[0].Attempts != 1 || string((*stored)[0].Body) != "pending" {
+		t.Error("the interrupted batch should be stored as a dead letter. Got: ", *stored)
+	}
+}
+
+func TestDeadLetterReplayer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer apikey" || r.Header.Get("SplitSDKVersion") != "go-1.2.3" {
+			t.Error("the authorization header should be added back to the original ones. Got: ", r.Header)
+		}
+		if string(body) == "fails" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	store, stored := memDeadLetters()
+	for _, body := range []string{"ok", "fails", "skipped"} {
+		store.Add(&storage.DeadLetter{
+			Pipeline: body,
+			Method:   http.MethodPost,
+			URL:      server.URL,
+			Headers:  http.Header{"Splitsdkversion": []string{"go-1.2.3"}},
+			Body:     []byte(body),
+		})
+	}
+
+	replayer := NewDeadLetterReplayer(store, "apikey", time.Second, logging.NewLogger(nil))
+	replayed, failed, err := replayer.Replay(func(letter *storage.DeadLetter) bool { return letter.Pipeline != "skipped" })
+	if err != nil || replayed != 1 || failed != 1 {
+		t.Error("wrong replay result: ", replayed, failed, err)
+	}
+	if len(*stored) != 2 || (*stored)[0].Pipeline != "fails" || (*stored)[1].Pipeline != "skipped" {
+		t.Error("only the dead letters posted successfully should be removed. Got: ", *stored)
+	}
+}
@@ -7,20 +7,28 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	config "github.com/splitio/go-split-commons/v6/conf"
+	"github.com/splitio/go-split-commons/v6/dtos"
 	"github.com/splitio/go-split-commons/v6/provisional"
 	"github.com/splitio/go-split-commons/v6/provisional/strategy"
 	"github.com/splitio/go-split-commons/v6/service"
 	storageCommon "github.com/splitio/go-split-commons/v6/storage"
 	"github.com/splitio/go-split-commons/v6/storage/redis"
 	"github.com/splitio/go-toolkit/v5/logging"
+	cconf "github.com/splitio/split-synchronizer/v5/splitio/common/conf"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	slog "github.com/splitio/split-synchronizer/v5/splitio/log"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/worker"
 	hcAppCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application/counter"
 	hcServicesCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services/counter"
 	"github.com/splitio/split-synchronizer/v5/splitio/util"
@@ -110,9 +118,20 @@ func parseRedisOptions(cfg *conf.Redis) (*config.RedisConfig, error) {
 	return redisCfg, nil
 }
 
+var errInvalidApikey = errors.New("invalid SDK key")
+
 func isValidApikey(splitFetcher service.SplitFetcher) bool {
+	return validateApikey(splitFetcher) == nil
+}
+
+// validateApikey returns errInvalidApikey if split rejects the sdk key, or the error that prevented it from being checked
+func validateApikey(splitFetcher service.SplitFetcher) error {
 	_, err := splitFetcher.Fetch(service.MakeFlagRequestParams().WithCacheControl(false).WithChangeNumber(time.Now().UnixNano() / int64(time.Millisecond)))
-	return err == nil
+	var httpErr *dtos.HTTPError
+	if errors.As(err, &httpErr) && (httpErr.Code == http.StatusUnauthorized || httpErr.Code == http.StatusForbidden) {
+		return errInvalidApikey
+	}
+	return err
 }
 
 func sanitizeRedis(cfg *conf.Main, miscStorage *redis.MiscStorage, logger logging.LoggerInterface) error {
@@ -161,6 +180,15 @@ func getAppCounterConfigs(storage storageCommon.SplitStorage) (hcAppCounter.Thre
 	return splitsConfig, segmentsConfig, storageConfig
 }
 
+func getQueuesCounterConfig() hcAppCounter.PeriodicConfig {
+	return hcAppCounter.PeriodicConfig{
+		Name:                     "Queues",
+		MaxErrorsAllowedInPeriod: 1,
+		Period:                   3600,
+		Severity:                 hcAppCounter.Low,
+	}
+}
+
 func getServicesCountersConfig(advanced *config.AdvancedConfig) []hcServicesCounter.Config {
 	var cfgs []hcServicesCounter.Config
 
@@ -202,3 +230,42 @@ func buildImpressionManager(
 		return provisional.NewImpressionManager(strategy)
 	}
 }
+
+// autoscaleConfig returns the options to autoscale a pipeline driven by the supplied eviction monitor, or nil if disabled
+func autoscaleConfig(cfg *conf.Autoscaling, monitor evcalc.Monitor) *task.AutoscaleConfig {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	return &task.AutoscaleConfig{
+		Monitor:               monitor,
+		Period:                time.Duration(cfg.PeriodMs) * time.Millisecond,
+		MinFetchSize:          cfg.MinFetchSize,
+		MaxFetchSize:          cfg.MaxFetchSize,
+		MinProcessConcurrency: cfg.MinProcessConcurrency,
+		MaxProcessConcurrency: cfg.MaxProcessConcurrency,
+		MinPostConcurrency:    cfg.MinPostConcurrency,
+		MaxPostConcurrency:    cfg.MaxPostConcurrency,
+	}
+}
+
+// queueLimits returns the max length of each queue written by sdks in redis
+func queueLimits(cfg *conf.QueueLimits) ([]worker.QueueLimit, error) {
+	if cfg.OverflowStrategy != storage.OverflowTrim && cfg.OverflowStrategy != storage.OverflowSample {
+		return nil, fmt.Errorf("%w: '%s'. Should be one of: %s, %s", storage.ErrInvalidOverflowStrategy, cfg.OverflowStrategy, storage.OverflowTrim, storage.OverflowSample)
+	}
+
+	return []worker.QueueLimit{
+		{Name: "impressions", Key: redis.KeyImpressionsQueue, MaxLength: cfg.ImpressionsMaxLength},
+		{Name: "events", Key: redis.KeyEvents, MaxLength: cfg.EventsMaxLength},
+		{Name: "unique keys", Key: redis.KeyUniquekeys, MaxLength: cfg.UniqueKeysMaxLength},
+	}, nil
+}
+
+// overflowAlerter returns a slack writer to post queue overflow alerts to, or nil if slack is not configured
+func overflowAlerter(cfg *cconf.Slack) worker.OverflowAlerter {
+	if _, err := url.ParseRequestURI(cfg.Webhook); err != nil || cfg.Channel == "" {
+		return nil
+	}
+	return slog.NewSlackWriter(cfg.Webhook, cfg.Channel)
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/healthcheck/application"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/log"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application/counter"
+)
+
+const defaultOverflowAlertInterval = time.Hour
+
+// QueueLimit defines the max length of a queue written by sdks in redis
+type QueueLimit struct {
+	Name      string // used in logs & alerts
+	Key       string
+	MaxLength int64
+}
+
+// OverflowAlerter is used to notify when data is dropped from a queue
+type OverflowAlerter interface {
+	PostNow(msg []byte, attachments []log.SlackMessageAttachment) error
+}
+
+// QueueCapWorker keeps the queues written by sdks in redis under their max length, so that they don't grow until redis
+// runs out of memory (& evicts feature flags) when data is not evicted fast enough
+type QueueCapWorker struct {
+	queues        []QueueLimit
+	strategy      string
+	storage       storage.QueueCapper
+	appMonitor    application.MonitorProducerInterface
+	alerter       OverflowAlerter // nil if alerts are disabled
+	alertInterval time.Duration
+	lastAlerts    map[string]time.Time
+	logger        logging.LoggerInterface
+	clock         func() time.Time
+}
+
+// NewQueueCapWorker constructs a new worker capping the supplied queues. Queues without a max length are ignored
+func NewQueueCapWorker(
+	queues []QueueLimit,
+	strategy string,
+	store storage.QueueCapper,
+	appMonitor application.MonitorProducerInterface,
+	alerter OverflowAlerter,
+	logger logging.LoggerInterface,
+) *QueueCapWorker {
+	limited := make([]QueueLimit, 0, len(queues))
+	for _, queue := range queues {
+		if queue.MaxLength > 0 {
+			limited = append(limited, queue)
+		}
+	}
+
+	return &QueueCapWorker{
+		queues:        limited,
+		strategy:      strategy,
+		storage:       store,
+		appMonitor:    appMonitor,
+		alerter:       alerter,
+		alertInterval: defaultOverflowAlertInterval,
+		lastAlerts:    make(map[string]time.Time),
+		logger:        logger,
+		clock:         time.Now,
+	}
+}
+
+// Enabled returns whether any queue has a max length
+func (w *QueueCapWorker) Enabled() bool {
+	return len(w.queues) > 0
+}
+
+// Process removes the excess of every queue above its max length
+func (w *QueueCapWorker) Process() error {
+	var overflowing []QueueLimit
+	lengths := make(map[string]int64, len(w.queues))
+	for _, queue := range w.queues {
+		length, err := w.storage.Length(queue.Key)
+		if err != nil {
+			return fmt.Errorf("error reading length of the %s queue: %w", queue.Name, err)
+		}
+		if length > queue.MaxLength {
+			overflowing = append(overflowing, queue)
+			lengths[queue.Key] = length
+		}
+	}
+
+	if len(overflowing) == 0 {
+		return nil
+	}
+
+	before, err := w.storage.Dropped()
+	if err != nil {
+		return err
+	}
+
+	for _, queue := range overflowing {
+		if err := w.storage.Cap(queue.Key, queue.MaxLength, w.strategy); err != nil {
+			return err
+		}
+	}
+
+	after, err := w.storage.Dropped()
+	if err != nil {
+		return err
+	}
+
+	for _, queue := range overflowing {
+		dropped := after[queue.Key] - before[queue.Key]
+		if dropped <= 0 { // evicted by the synchronizer in the meantime
+			continue
+		}
+
+		w.logger.Debug(fmt.Sprintf("%d items dropped from the %s queue (%s). %d dropped so far", dropped, queue.Name, w.strategy, after[queue.Key]))
+		w.appMonitor.NotifyEvent(counter.Queues)
+		w.alert(&queue, lengths[queue.Key], dropped, after[queue.Key])
+	}
+	return nil
+}
+
+// alert logs & posts a message when a queue starts overflowing, and periodically while it keeps doing so
+func (w *QueueCapWorker) alert(queue *QueueLimit, length int64, dropped int64, total int64) {
+	now := w.clock()
+	if last, ok := w.lastAlerts[queue.Key]; ok && now.Sub(last) < w.alertInterval {
+		return
+	}
+	w.lastAlerts[queue.Key] = now
+
+	message := fmt.Sprintf(
+		"The %s queue in redis reached %d items (max: %d). %d of them have been dropped (%d so far). Data is not evicted as fast as it's generated",
+		queue.Name, length, queue.MaxLength, dropped, total,
+	)
+	w.logger.Warning(message)
+
+	if w.alerter == nil {
+		return
+	}
+
+	err := w.alerter.PostNow([]byte("*[Warning]* Redis queue overflow"), []log.SlackMessageAttachment{{
+		Fallback: message,
+		Color:    "warning",
+		Fields: []log.SlackMessageAttachmentFields{
+			{Title: "Queue", Value: queue.Name, Short: true},
+			{Title: "Length", Value: fmt.Sprintf("%d (max: %d)", length, queue.MaxLength), Short: true},
+			{Title: "Dropped", Value: fmt.Sprintf("%d (%d so far)", dropped, total), Short: true},
+			{Title: "Strategy", Value: w.strategy, Short: true},
+		},
+	}})
+	if err != nil {
+		w.logger.Error("error posting queue overflow alert: ", err)
+	}
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	hcMocks "github.com/splitio/go-split-commons/v6/healthcheck/mocks"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/log"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
+	storageMocks "github.com/splitio/split-synchronizer/v5/splitio/producer/storage/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application/counter"
+)
+
+type alerterMock struct {
+	posts int
+}
+
+func (a *alerterMock) PostNow(msg []byte, attachments []log.SlackMessageAttachment) error {
+	a.posts++
+	return nil
+}
+
+func TestQueueCapWorker(t *testing.T) {
+	lengths := map[string]int64{"imps": 150, "evs": 10, "uks": 1000}
+	dropped := map[string]int64{"imps": 20}
+	var capped []string
+	store := &storageMocks.QueueCapperMock{
+		LengthCall: func(queue string) (int64, error) { return lengths[queue], nil },
+		CapCall: func(queue string, maxLength int64, strategy string) error {
+			if strategy != storage.OverflowSample {
+				t.Error("wrong strategy: ", strategy)
+			}
+			capped = append(capped, queue)
+			dropped[queue] += lengths[queue] - maxLength
+			lengths[queue] = maxLength
+			return nil
+		},
+		DroppedCall: func() (map[string]int64, error) {
+			copied := make(map[string]int64, len(dropped))
+			for queue, count := range dropped {
+				copied[queue] = count
+			}
+			return copied, nil
+		},
+	}
+
+	notifications := 0
+	appMonitor := hcMocks.MockApplicationMonitor{
+		NotifyEventCall: func(counterType int) {
+			if counterType != counter.Queues {
+				t.Error("wrong counter type: ", counterType)
+			}
+			notifications++
+		},
+	}
+
+	alerter := &alerterMock{}
+	worker := NewQueueCapWorker([]QueueLimit{
+		{Name: "impressions", Key: "imps", MaxLength: 100},
+		{Name: "events", Key: "evs", MaxLength: 100},
+		{Name: "unique keys", Key: "uks", MaxLength: 0},
+	}, storage.OverflowSample, store, appMonitor, alerter, logging.NewLogger(nil))
+	now := time.Now()
+	worker.clock = func() time.Time { return now }
+
+	if !worker.Enabled() || len(worker.queues) != 2 {
+		t.Error("queues without a max length should be ignored")
+	}
+
+	if err := worker.Process(); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+	if len(capped) != 1 || capped[0] != "imps" {
+		t.Error("only the overflowing queues should be capped. Got: ", capped)
+	}
+	if dropped["imps"] != 70 || notifications != 1 || alerter.posts != 1 {
+		t.Error("overflows should be accounted for & notified. Got: ", dropped, notifications, alerter.posts)
+	}
+
+	// not overflowing anymore
+	if err := worker.Process(); err != nil || len(capped) != 1 || notifications != 1 {
+		t.Error("queues within their max length should not be capped")
+	}
+
+	lengths["imps"] = 200
+	worker.Process()
+	if notifications != 2 || alerter.posts != 1 {
+		t.Error("alerts should be throttled. Got: ", notifications, alerter.posts)
+	}
+
+	now = now.Add(2 * time.Hour)
+	lengths["imps"] = 200
+	worker.Process()
+	if notifications != 3 || alerter.posts != 2 {
+		t.Error("alerts should be posted again after the interval. Got: ", notifications, alerter.posts)
+	}
+}
+
+func TestQueueCapWorkerDisabled(t *testing.T) {
+	worker := NewQueueCapWorker([]QueueLimit{{Name: "impressions", Key: "imps"}}, storage.OverflowTrim, nil, nil, nil, logging.NewLogger(nil))
+	if worker.Enabled() {
+		t.Error("the worker should be disabled when no queue has a max length")
+	}
+}
@@ -22,6 +22,10 @@ const (
 	Segments
 	// Storage counter type
 	Storage
+	// reserved for the sync errors type defined in go-split-commons
+	_
+	// Queues counter type
+	Queues
 )
 
 // HealthyResult description
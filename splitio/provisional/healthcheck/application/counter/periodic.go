@@ -97,6 +97,11 @@ func (c *PeriodicImp) Start() {
 	c.task.Start()
 	c.running.Set()
 
+	if c.validationFunc == nil { // errors are only notified from outside
+		c.logger.Debug(fmt.Sprintf("%s periodic counter started.", c.name))
+		return
+	}
+
 	go func() {
 		for c.running.IsSet() {
 			time.Sleep(time.Duration(c.validationFuncPeriod) * time.Second)
@@ -24,6 +24,7 @@ type MonitorImp struct {
 	splitsCounter   counter.ThresholdCounterInterface
 	segmentsCounter counter.ThresholdCounterInterface
 	storageCounter  counter.PeriodicCounterInterface
+	queuesCounter   counter.PeriodicCounterInterface
 	producerMode    toolkitsync.AtomicBool
 	healthySince    *time.Time
 	lock            sync.RWMutex
@@ -77,6 +78,10 @@ func (m *MonitorImp) GetHealthStatus() HealthDto {
 		results = append(results, m.storageCounter.IsHealthy())
 	}
 
+	if m.queuesCounter != nil {
+		results = append(results, m.queuesCounter.IsHealthy())
+	}
+
 	for _, res := range results {
 		items = append(items, ItemDto{
 			Name:       res.Name,
@@ -109,6 +114,10 @@ func (m *MonitorImp) NotifyEvent(counterType int) {
 		m.splitsCounter.NotifyHit()
 	case counter.Segments:
 		m.segmentsCounter.NotifyHit()
+	case counter.Queues:
+		if m.queuesCounter != nil {
+			m.queuesCounter.NotifyError()
+		}
 	}
 }
 
@@ -137,6 +146,9 @@ func (m *MonitorImp) Start() {
 	if m.producerMode.IsSet() {
 		m.storageCounter.Start()
 	}
+	if m.queuesCounter != nil {
+		m.queuesCounter.Start()
+	}
 
 	m.logger.Debug("Application Monitor started.")
 }
@@ -152,6 +164,19 @@ func (m *MonitorImp) Stop() {
 	if m.producerMode.IsSet() {
 		m.storageCounter.Stop()
 	}
+
+	if m.queuesCounter != nil {
+		m.queuesCounter.Stop()
+	}
+}
+
+// EnableQueuesCounter adds an item tracking whether data has been dropped from the queues in redis. Should be called
+// before starting the monitor
+func (m *MonitorImp) EnableQueuesCounter(config counter.PeriodicConfig) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.queuesCounter = counter.NewPeriodicCounter(config, m.logger)
 }
 
 // NewMonitorImp create a new application monitor
@@ -80,3 +80,37 @@ func TestMonitor(t *testing.T) {
 	assertItemsHealthy(t, res.Items, false, true, false)
 	monitor.Stop()
 }
+
+func TestMonitorQueues(t *testing.T) {
+	splitsCfg := counter.ThresholdConfig{Name: "Splits", Period: 10, Severity: counter.Critical}
+	segmentsCfg := counter.ThresholdConfig{Name: "Segments", Period: 10, Severity: counter.Critical}
+
+	monitor := NewMonitorImp(splitsCfg, segmentsCfg, nil, logging.NewLogger(nil))
+	monitor.EnableQueuesCounter(counter.PeriodicConfig{
+		Name:                     "Queues",
+		Period:                   10,
+		MaxErrorsAllowedInPeriod: 1,
+		Severity:                 counter.Low,
+	})
+	monitor.Start()
+	defer monitor.Stop()
+
+	queuesHealthy := func() bool {
+		for _, item := range monitor.GetHealthStatus().Items {
+			if item.Name == "Queues" {
+				return item.Healthy
+			}
+		}
+		t.Error("the queues item should be present")
+		return false
+	}
+
+	if !queuesHealthy() {
+		t.Error("Queues should be healthy")
+	}
+
+	monitor.NotifyEvent(counter.Queues)
+	if queuesHealthy() {
+		t.Error("Queues should be unhealthy once data is dropped")
+	}
+}
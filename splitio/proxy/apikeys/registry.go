@@ -0,0 +1,229 @@
+package apikeys
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"golang.org/x/exp/slices"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+)
+
+// Sources a client apikey can come from
+const (
+	SourceConfig = "config"
+	SourceAdmin  = "admin"
+	SourceFile   = "file"
+)
+
+var (
+	// ErrInvalidAPIKey is returned when attempting to add an empty or malformed apikey
+	ErrInvalidAPIKey = errors.New("invalid apikey")
+
+	// ErrInvalidFlagSets is returned when none of the flag sets an apikey should be restricted to is valid
+	ErrInvalidFlagSets = errors.New("no valid flag sets supplied")
+
+	// ErrReservedAPIKey is returned when attempting to add an apikey that belongs to another environment
+	ErrReservedAPIKey = errors.New("apikey is used by another environment")
+
+	// ErrUnknownAPIKey is returned when attempting to revoke an apikey that's not registered
+	ErrUnknownAPIKey = errors.New("unknown apikey")
+)
+
+// Entry is a client apikey accepted by the proxy
+type Entry struct {
+	Apikey   string
+	FlagSets []string
+	Source   string
+}
+
+// Registry defines the interface for a component managing client apikeys at runtime
+type Registry interface {
+	List() []Entry
+	Set(apikey string, flagSets []string, source string, actor string) error
+	Revoke(apikey string, actor string) error
+}
+
+// CacheEvictor is the subset of the http cache used to discard responses no longer reachable after a revocation
+type CacheEvictor interface {
+	EvictBySurrogate(surrogate string)
+	EvictAll()
+}
+
+// RegistryImpl updates the apikeys accepted by a validator, evicting cached responses & recording every change
+// in an audit log
+type RegistryImpl struct {
+	validator *middleware.APIKeyValidator
+	cache     CacheEvictor
+	auditLog  audit.Log
+	logger    logging.LoggerInterface
+	sources   map[string]string
+	others    []*middleware.APIKeyValidator
+	mutex     sync.Mutex
+}
+
+// NewRegistry constructs a registry around an apikey validator. Keys already present in the validator are
+// considered to come from the config. `others` are the validators of other environments: keys accepted by any of them
+// cannot be added, so that a client is never routed to two environments
+func NewRegistry(
+	validator *middleware.APIKeyValidator,
+	cache CacheEvictor,
+	auditLog audit.Log,
+	others []*middleware.APIKeyValidator,
+	logger logging.LoggerInterface,
+) *RegistryImpl {
+	toRet := &RegistryImpl{
+		validator: validator,
+		cache:     cache,
+		auditLog:  auditLog,
+		logger:    logger,
+		sources:   make(map[string]string),
+		others:    others,
+	}
+	for apikey := range validator.All() {
+		toRet.sources[apikey] = SourceConfig
+	}
+	return toRet
+}
+
+// List returns all the registered apikeys, sorted
+func (r *RegistryImpl) List() []Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	all := r.validator.All()
+	toRet := make([]Entry, 0, len(all))
+	for apikey, flagSets := range all {
+		toRet = append(toRet, Entry{Apikey: apikey, FlagSets: flagSets, Source: r.sources[apikey]})
+	}
+	sort.Slice(toRet, func(i, j int) bool { return toRet[i].Apikey < toRet[j].Apikey })
+	return toRet
+}
+
+// Set adds an apikey or updates the flag sets it's restricted to (nil or empty = unrestricted).
+// Apikeys keep the source they were first added from, so that a key set up in the config or through the admin API
+// is never handed over to the file watcher (which would revoke it once it's missing from the file)
+func (r *RegistryImpl) Set(apikey string, flagSets []string, source string, actor string) error {
+	apikey = strings.TrimSpace(apikey)
+	if apikey == "" || strings.ContainsAny(apikey, " \t:") {
+		return ErrInvalidAPIKey
+	}
+
+	for _, other := range r.others {
+		if other.IsValid(apikey) {
+			return ErrReservedAPIKey
+		}
+	}
+
+	var sets []string
+	if len(flagSets) > 0 {
+		if sets, _ = flagsets.SanitizeMany(flagSets); len(sets) == 0 {
+			return ErrInvalidFlagSets
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	previous, existed := r.validator.All()[apikey]
+	if existed && sameScope(previous, sets) {
+		return nil
+	}
+
+	r.validator.Set(apikey, sets)
+	action := "apikey.add"
+	if !existed {
+		r.sources[apikey] = source
+	} else {
+		action = "apikey.update"
+		r.evictIfUnused(previous)
+	}
+	r.auditLog.Record(actor, action, logging.ObfuscateAPIKey(apikey), map[string]string{
+		"source":   source,
+		"flagSets": strings.Join(sets, ","),
+	})
+	return nil
+}
+
+// Revoke removes an apikey & discards the cached responses no other apikey can reach
+func (r *RegistryImpl) Revoke(apikey string, actor string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	flagSets, ok := r.validator.Remove(apikey)
+	if !ok {
+		return ErrUnknownAPIKey
+	}
+
+	source := r.sources[apikey]
+	delete(r.sources, apikey)
+	r.evictIfUnused(flagSets)
+	r.auditLog.Record(actor, "apikey.revoke", logging.ObfuscateAPIKey(apikey), map[string]string{"source": source})
+	return nil
+}
+
+// Sync makes the set of apikeys coming from `source` match the supplied ones, adding, updating & revoking as required
+func (r *RegistryImpl) Sync(entries map[string][]string, source string, actor string) {
+	r.mutex.Lock()
+	var stale []string
+	for apikey, current := range r.sources {
+		if _, ok := entries[apikey]; !ok && current == source {
+			stale = append(stale, apikey)
+		}
+	}
+	r.mutex.Unlock()
+
+	for _, apikey := range stale {
+		if err := r.Revoke(apikey, actor); err != nil && !errors.Is(err, ErrUnknownAPIKey) {
+			r.logger.Error(fmt.Sprintf("error revoking apikey %s: %s", logging.ObfuscateAPIKey(apikey), err.Error()))
+		}
+	}
+
+	for apikey, flagSets := range entries {
+		if err := r.Set(apikey, flagSets, source, actor); err != nil {
+			r.logger.Error(fmt.Sprintf("error registering apikey %s: %s", logging.ObfuscateAPIKey(apikey), err.Error()))
+		}
+	}
+}
+
+// evictIfUnused discards cached responses for a flag set scope when no apikey can reach them anymore.
+// Since responses other than splitChanges are shared by all apikeys, the whole cache is dropped when none is left.
+// Must be called with the lock held
+func (r *RegistryImpl) evictIfUnused(flagSets []string) {
+	if len(r.validator.All()) == 0 {
+		r.cache.EvictAll()
+		return
+	}
+
+	if !r.validator.InUse(flagSets) {
+		r.cache.EvictBySurrogate(caching.MakeSurrogateForFlagSetScope(flagSets))
+	}
+}
+
+// ParseEntry parses an `apikey[:set1|set2]` entry. Flag sets are nil if none is specified
+func ParseEntry(entry string) (string, []string, error) {
+	apikey, rawSets, hasSets := strings.Cut(strings.TrimSpace(entry), ":")
+	if apikey = strings.TrimSpace(apikey); apikey == "" {
+		return "", nil, ErrInvalidAPIKey
+	}
+
+	if !hasSets {
+		return apikey, nil, nil
+	}
+
+	sets, _ := flagsets.SanitizeMany(strings.Split(rawSets, "|"))
+	if len(sets) == 0 {
+		return "", nil, ErrInvalidFlagSets
+	}
+	return apikey, sets, nil
+}
+
+func sameScope(s1 []string, s2 []string) bool {
+	return (s1 == nil) == (s2 == nil) && slices.Equal(s1, s2)
+}
+
+var _ Registry = (*RegistryImpl)(nil)
@@ -0,0 +1,122 @@
+package apikeys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+)
+
+type evictorMock struct {
+	surrogates []string
+	all        int
+}
+
+func (e *evictorMock) EvictBySurrogate(surrogate string) {
+	e.surrogates = append(e.surrogates, surrogate)
+}
+func (e *evictorMock) EvictAll() { e.all++ }
+
+func TestRegistry(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, _ := audit.NewLog(logger, 100, "")
+	validator := middleware.NewAPIKeyValidator([]string{"web1", "mobile1"}, map[string][]string{"mobile1": {"mobile"}})
+	var cache evictorMock
+	registry := NewRegistry(validator, &cache, auditLog, []*middleware.APIKeyValidator{middleware.NewAPIKeyValidator([]string{"staging1"}, nil)}, logger)
+
+	assert.Equal(t, []Entry{
+		{Apikey: "mobile1", FlagSets: []string{"mobile"}, Source: SourceConfig},
+		{Apikey: "web1", Source: SourceConfig},
+	}, registry.List())
+
+	assert.ErrorIs(t, registry.Set("", nil, SourceAdmin, "admin"), ErrInvalidAPIKey)
+	assert.ErrorIs(t, registry.Set("staging1", nil, SourceAdmin, "admin"), ErrReservedAPIKey)
+	assert.ErrorIs(t, registry.Set("mobile2", []string{"$$"}, SourceAdmin, "admin"), ErrInvalidFlagSets)
+
+	assert.Nil(t, registry.Set("mobile2", []string{"Mobile"}, SourceAdmin, "admin"))
+	assert.True(t, validator.IsValid("mobile2"))
+	assert.Equal(t, []string{"mobile"}, validator.FlagSets("mobile2"))
+
+	// mobile2 still uses the mobile scope, so nothing is evicted
+	assert.Nil(t, registry.Revoke("mobile1", "admin"))
+	assert.False(t, validator.IsValid("mobile1"))
+	assert.Empty(t, cache.surrogates)
+
+	// last key with that scope
+	assert.Nil(t, registry.Revoke("mobile2", "admin"))
+	assert.Equal(t, []string{"fs::mobile"}, cache.surrogates)
+
+	assert.ErrorIs(t, registry.Revoke("mobile2", "admin"), ErrUnknownAPIKey)
+
+	// last key overall
+	assert.Nil(t, registry.Revoke("web1", "admin"))
+	assert.Equal(t, 1, cache.all)
+
+	entries := auditLog.Entries()
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "apikey.add", entries[0].Action)
+	assert.Equal(t, "apikey.revoke", entries[3].Action)
+	assert.NotContains(t, entries[0].Subject, "mobile2") // apikeys are never recorded in full
+}
+
+func TestFileWatcher(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, _ := audit.NewLog(logger, 100, "")
+	validator := middleware.NewAPIKeyValidator([]string{"config1"}, nil)
+	registry := NewRegistry(validator, &evictorMock{}, auditLog, nil, logger)
+
+	path := filepath.Join(t.TempDir(), "apikeys")
+	assert.Nil(t, os.WriteFile(path, []byte("# client keys\nfile1\nfile2:mobile|web\n\n"), 0600))
+	watcher := NewFileWatcher(path, 1, registry, logger)
+
+	reloaded, err := watcher.Reload()
+	assert.True(t, reloaded)
+	assert.Nil(t, err)
+	assert.True(t, validator.IsValid("config1"))
+	assert.True(t, validator.IsValid("file1"))
+	assert.Equal(t, []string{"mobile", "web"}, validator.FlagSets("file2"))
+
+	reloaded, err = watcher.Reload()
+	assert.False(t, reloaded)
+	assert.Nil(t, err)
+
+	// invalid files are rejected as a whole
+	assert.Nil(t, os.WriteFile(path, []byte("file3:\n"), 0600))
+	_, err = watcher.Reload()
+	assert.NotNil(t, err)
+	assert.True(t, validator.IsValid("file1"))
+
+	// keys removed from the file are revoked, others are left untouched
+	assert.Nil(t, os.WriteFile(path, []byte("file2\nfile3\n"), 0600))
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	reloaded, err = watcher.Reload()
+	assert.True(t, reloaded)
+	assert.Nil(t, err)
+	assert.True(t, validator.IsValid("config1"))
+	assert.False(t, validator.IsValid("file1"))
+	assert.Nil(t, validator.FlagSets("file2"))
+	assert.True(t, validator.IsValid("file3"))
+
+	// keys added from elsewhere are never revoked by the watcher, even if they were listed in the file at some point
+	assert.Nil(t, registry.Set("admin1", nil, SourceAdmin, "admin"))
+	assert.Nil(t, os.WriteFile(path, []byte("config1\nadmin1:mobile\n"), 0600))
+	os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second))
+	_, err = watcher.Reload()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mobile"}, validator.FlagSets("admin1"))
+
+	assert.Nil(t, os.WriteFile(path, []byte("file3\n"), 0600))
+	os.Chtimes(path, time.Now(), time.Now().Add(3*time.Second))
+	_, err = watcher.Reload()
+	assert.Nil(t, err)
+	assert.True(t, validator.IsValid("config1"))
+	assert.True(t, validator.IsValid("admin1"))
+	assert.Contains(t, registry.List(), Entry{Apikey: "config1", Source: SourceConfig})
+	assert.Contains(t, registry.List(), Entry{Apikey: "admin1", FlagSets: []string{"mobile"}, Source: SourceAdmin})
+}
@@ -0,0 +1,92 @@
+package apikeys
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// FileWatcher periodically checks a file containing client apikeys (one `apikey[:set1|set2]` entry per line,
+// `#` for comments) and syncs the registry with it whenever it changes
+type FileWatcher struct {
+	path     string
+	registry *RegistryImpl
+	logger   logging.LoggerInterface
+	task     *asynctask.AsyncTask
+	modTime  time.Time
+	size     int64
+}
+
+// NewFileWatcher constructs a new file watcher checking for changes every `periodSecs` seconds
+func NewFileWatcher(path string, periodSecs int, registry *RegistryImpl, logger logging.LoggerInterface) *FileWatcher {
+	watcher := &FileWatcher{path: path, registry: registry, logger: logger}
+	watcher.task = asynctask.NewAsyncTask("apikeys-file-watcher", func(logging.LoggerInterface) error {
+		_, err := watcher.Reload()
+		return err
+	}, periodSecs, nil, nil, logger)
+	return watcher
+}
+
+// Reload syncs the registry with the file contents if it has changed since the last time it was loaded
+func (w *FileWatcher) Reload() (bool, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false, fmt.Errorf("error accessing apikeys file: %w", err)
+	}
+
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false, nil
+	}
+
+	raw, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading apikeys file: %w", err)
+	}
+
+	entries, err := parseFile(raw)
+	if err != nil {
+		return false, fmt.Errorf("error parsing apikeys file: %w", err)
+	}
+
+	w.logger.Info(fmt.Sprintf("Loading %d client apikeys from %s", len(entries), w.path))
+	w.registry.Sync(entries, SourceFile, SourceFile)
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return true, nil
+}
+
+// Start begins watching the file for changes
+func (w *FileWatcher) Start() {
+	w.task.Start()
+}
+
+// Stop stops watching the file
+func (w *FileWatcher) Stop() {
+	w.task.Stop(false)
+}
+
+// parseFile reads all the entries of an apikeys file. If any of them is invalid, the whole file is rejected,
+// to avoid revoking keys because of a typo
+func parseFile(raw []byte) (map[string][]string, error) {
+	entries := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		apikey, flagSets, err := ParseEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries[apikey] = flagSets
+	}
+	return entries, scanner.Err()
+}
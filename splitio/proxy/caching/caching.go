@@ -1,6 +1,8 @@
 package caching
 
 import (
+	"hash/fnv"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -22,16 +24,27 @@ const (
 	// AuthSurrogate key (having push disabled, it's safe to cache this and return it on all requests)
 	AuthSurrogate = "au"
 
+	// FlagSetScopeContextKey is the gin context key used to store the flag sets the requesting apikey is restricted to
+	FlagSetScopeContextKey = "flagSetScope"
+
 	segmentPrefix = "se::"
+	scopePrefix   = "fs::"
 )
 
-const cacheSize = 1000000
-
 // MakeSurrogateForSegmentChanges creates a surrogate key for the segment being queried
 func MakeSurrogateForSegmentChanges(segmentName string) string {
 	return segmentPrefix + segmentName
 }
 
+// MakeSurrogateForFlagSetScope creates a surrogate key for splitChanges responses served to apikeys restricted to
+// the supplied flag sets (nil = unrestricted)
+func MakeSurrogateForFlagSetScope(scope []string) string {
+	if scope == nil {
+		return scopePrefix + "*"
+	}
+	return scopePrefix + strings.Join(scope, ",")
+}
+
 // MakeSurrogateForMySegments creates a list surrogate keys for all the segments involved
 func MakeSurrogateForMySegments(mysegments []dtos.MySegmentDTO) []string {
 	// Since we are now evicting individually for every updated key, we don't need surrogates for mySegments
@@ -46,11 +59,12 @@ func MakeMySegmentsEntries(key string) []string {
 	}
 }
 
-// MakeProxyCache creates and configures a split-proxy-ready cache
-func MakeProxyCache() *gincache.Middleware {
-	return gincache.New(&gincache.Options{
+// MakeProxyCache creates and configures a split-proxy-ready cache, bounded by entries and/or bytes (0 = unbounded)
+func MakeProxyCache(maxEntries int64, maxBytes int64) *Middleware {
+	return NewMiddleware(&Options{
 		SuccessfulOnly: true, // we're not interested in caching non-200 responses
-		Size:           cacheSize,
+		MaxEntries:     maxEntries,
+		MaxBytes:       maxBytes,
 		KeyFactory:     keyFactoryFN,
 		// we make each request handler responsible for generating the surrogates.
 		// this way we can use segment names as surrogates for mysegments & segment changes
@@ -71,5 +85,21 @@ func keyFactoryFN(ctx *gin.Context) string {
 		// so we strip the query-string which contains the user-list
 		return encodingPrefix + ctx.Request.URL.Path
 	}
-	return encodingPrefix + ctx.Request.URL.Path + ctx.Request.URL.RawQuery
+
+	// splitChanges responses depend on the flag sets the apikey has access to, so they cannot be shared across scopes
+	var scopeSuffix string
+	if scope := ctx.GetStringSlice(FlagSetScopeContextKey); scope != nil && strings.HasPrefix(ctx.Request.URL.Path, "/api/splitChanges") {
+		scopeSuffix = "::scope=" + strings.Join(scope, ",")
+	}
+	return encodingPrefix + ctx.Request.URL.Path + ctx.Request.URL.RawQuery + scopeSuffix
+}
+
+// MakeETag builds a strong entity tag from the values a response is derived from
+func MakeETag(parts ...string) string {
+	hasher := fnv.New64a()
+	for _, part := range parts {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	return `"` + strconv.FormatUint(hasher.Sum64(), 16) + `"`
 }
@@ -21,6 +21,25 @@ func TestCacheKeysDoNotOverlap(t *testing.T) {
 	assert.NotEqual(t, keyFactoryFN(c1), keyFactoryFN(c2))
 }
 
+func TestCacheKeysIncludeFlagSetScope(t *testing.T) {
+	url1, _ := url.Parse("http://proxy.split.io/api/splitChanges?since=-1")
+	unscoped := &gin.Context{Request: &http.Request{URL: url1}}
+	mobile := &gin.Context{Request: &http.Request{URL: url1}}
+	mobile.Set(FlagSetScopeContextKey, []string{"mobile"})
+	web := &gin.Context{Request: &http.Request{URL: url1}}
+	web.Set(FlagSetScopeContextKey, []string{"web"})
+
+	assert.NotEqual(t, keyFactoryFN(unscoped), keyFactoryFN(mobile))
+	assert.NotEqual(t, keyFactoryFN(mobile), keyFactoryFN(web))
+
+	// scopes only affect splitChanges
+	url2, _ := url.Parse("http://proxy.split.io/api/segmentChanges/s1?since=-1")
+	unscoped = &gin.Context{Request: &http.Request{URL: url2}}
+	mobile = &gin.Context{Request: &http.Request{URL: url2}}
+	mobile.Set(FlagSetScopeContextKey, []string{"mobile"})
+	assert.Equal(t, keyFactoryFN(unscoped), keyFactoryFN(mobile))
+}
+
 func TestSegmentSurrogates(t *testing.T) {
 	assert.Equal(t, segmentPrefix+"segment1", MakeSurrogateForSegmentChanges("segment1"))
 	assert.NotEqual(t, MakeSurrogateForSegmentChanges("segment1"), MakeSurrogateForSegmentChanges("segment2"))
@@ -0,0 +1,160 @@
+package caching
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/gincache"
+)
+
+// headers that depend on the request & are set by other middlewares, so they're not replayed from cache
+var headersToIgnore = map[string]struct{}{
+	"Access-Control-Allow-Credentials": {},
+	"Access-Control-Expose-Headers":    {},
+	"Access-Control-Allow-Origin":      {},
+	"Vary":                             {},
+}
+
+// Admin defines the interface used to inspect & purge the http cache
+type Admin interface {
+	gincache.CacheFlusher
+	Stats() Stats
+	Surrogates() map[string]int
+}
+
+// Options wraps all parameters used to configure the caching middleware
+type Options struct {
+	MaxEntries       int64
+	MaxBytes         int64
+	KeyFactory       func(ctx *gin.Context) string
+	SurrogateFactory func(ctx *gin.Context) []string
+	SuccessfulOnly   bool
+}
+
+// Middleware is a gin middleware caching whole responses, bounded by entries and/or bytes. Entries can be purged
+// individually, all at once, or by any of the surrogate keys associated to them
+type Middleware struct {
+	keyFactory       func(ctx *gin.Context) string
+	surrogateFactory func(ctx *gin.Context) []string
+	successOnly      bool
+	store            *boundedStore
+}
+
+// NewMiddleware constructs a new caching middleware
+func NewMiddleware(options *Options) *Middleware {
+	return &Middleware{
+		keyFactory:       options.KeyFactory,
+		surrogateFactory: options.SurrogateFactory,
+		successOnly:      options.SuccessfulOnly,
+		store:            newBoundedStore(options.MaxEntries, options.MaxBytes),
+	}
+}
+
+// Handle is the function to be invoked for every request being handled
+func (m *Middleware) Handle(ctx *gin.Context) {
+	if ctx.Request.Method == http.MethodOptions {
+		return
+	}
+
+	key := m.keyFactory(ctx)
+	if entry := m.store.get(key); entry != nil {
+		for k, v := range entry.headers {
+			if _, shouldIgnore := headersToIgnore[k]; !shouldIgnore {
+				ctx.Writer.Header().Add(k, v)
+			}
+		}
+		ctx.Writer.WriteHeader(entry.status)
+		ctx.Writer.Write(entry.body)
+		ctx.Abort()
+		return
+	}
+
+	// intercept the response written by the rest of the chain, so that it can be stored
+	original := ctx.Writer
+	writer := &cacheWriter{ResponseWriter: original}
+	ctx.Writer = writer
+	ctx.Next()
+	ctx.Writer = original
+
+	body := append([]byte(nil), writer.body.Bytes()...)
+	writer.flush()
+	if m.successOnly && writer.statusCode != http.StatusOK {
+		return
+	}
+
+	headers := make(map[string]string)
+	for k := range writer.Header() {
+		headers[k] = writer.Header().Get(k)
+	}
+
+	var surrogates []string
+	if m.surrogateFactory != nil {
+		surrogates = m.surrogateFactory(ctx)
+	}
+
+	m.store.trySet(&cacheEntry{
+		key:        key,
+		status:     writer.statusCode,
+		body:       body,
+		headers:    headers,
+		surrogates: surrogates,
+		sticky:     ctx.GetBool(StickyContextKey),
+	})
+}
+
+// EvictAll clears all the cached entries
+func (m *Middleware) EvictAll() {
+	m.store.evictAll()
+}
+
+// Evict a single entry
+func (m *Middleware) Evict(key string) {
+	m.store.evict(key)
+}
+
+// EvictBySurrogate evicts all the entries referenced by a surrogate
+func (m *Middleware) EvictBySurrogate(surrogate string) {
+	m.store.evictBySurrogate(surrogate)
+}
+
+// Stats returns a snapshot of the cache usage
+func (m *Middleware) Stats() Stats {
+	return m.store.stats()
+}
+
+// Surrogates returns how many entries are referenced by each surrogate
+func (m *Middleware) Surrogates() map[string]int {
+	return m.store.surrogateCounts()
+}
+
+// cacheWriter accumulates the response body so that it can be stored once the handler is done
+type cacheWriter struct {
+	gin.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *cacheWriter) flush() {
+	w.ResponseWriter.Write(w.body.Bytes())
+	w.body.Reset()
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *cacheWriter) WriteString(data string) (int, error) {
+	return w.body.WriteString(data)
+}
+
+func (w *cacheWriter) Size() int {
+	return w.body.Len()
+}
+
+var _ Admin = (*Middleware)(nil)
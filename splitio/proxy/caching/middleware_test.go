@@ -0,0 +1,119 @@
+package caching
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareCachesSuccessfulResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewMiddleware(&Options{
+		MaxEntries:       10,
+		SuccessfulOnly:   true,
+		KeyFactory:       func(ctx *gin.Context) string { return ctx.Request.URL.Path },
+		SurrogateFactory: func(ctx *gin.Context) []string { return ctx.GetStringSlice(SurrogateContextKey) },
+	})
+
+	calls := 0
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.Use(cache.Handle)
+	router.GET("/ok", func(ctx *gin.Context) {
+		calls++
+		ctx.Header("ETag", `"v1"`)
+		ctx.String(http.StatusOK, "some body")
+		ctx.Set(SurrogateContextKey, []string{"s1"})
+	})
+	router.GET("/fail", func(ctx *gin.Context) {
+		calls++
+		ctx.String(http.StatusInternalServerError, "error")
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for i := 0; i < 3; i++ {
+		resp := serve("/ok")
+		assert.Equal(t, 200, resp.Code)
+		assert.Equal(t, "some body", resp.Body.String())
+		assert.Equal(t, `"v1"`, resp.Header().Get("ETag"))
+	}
+	assert.Equal(t, 1, calls)
+
+	serve("/fail")
+	serve("/fail")
+	assert.Equal(t, 3, calls)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.Equal(t, map[string]int{"s1": 1}, cache.Surrogates())
+
+	cache.EvictBySurrogate("s1")
+	assert.Equal(t, int64(0), cache.Stats().Entries)
+	assert.Equal(t, map[string]int{}, cache.Surrogates())
+	serve("/ok")
+	assert.Equal(t, 4, calls)
+
+	cache.Evict("/ok")
+	serve("/ok")
+	assert.Equal(t, 5, calls)
+
+	cache.EvictAll()
+	assert.Equal(t, int64(0), cache.Stats().Bytes)
+	serve("/ok")
+	assert.Equal(t, 6, calls)
+}
+
+func TestStoreBoundedByEntries(t *testing.T) {
+	store := newBoundedStore(3, 0)
+	store.trySet(&cacheEntry{key: "sticky", body: []byte("a"), sticky: true})
+	store.trySet(&cacheEntry{key: "e1", body: []byte("a")})
+	store.trySet(&cacheEntry{key: "e2", body: []byte("a")})
+	store.get("e1") // e2 becomes the least recently used non-sticky entry
+
+	assert.True(t, store.trySet(&cacheEntry{key: "e3", body: []byte("a")}))
+	assert.Nil(t, store.get("e2"))
+	assert.NotNil(t, store.get("e1"))
+	assert.NotNil(t, store.get("sticky"))
+	assert.NotNil(t, store.get("e3"))
+	assert.False(t, store.trySet(&cacheEntry{key: "e3", body: []byte("b")}))
+	assert.Equal(t, int64(1), store.stats().Evictions)
+}
+
+func TestStoreBoundedByBytes(t *testing.T) {
+	store := newBoundedStore(0, 100)
+	body := []byte(strings.Repeat("x", 38))
+	store.trySet(&cacheEntry{key: "e1", body: body, surrogates: []string{"s"}})
+	store.trySet(&cacheEntry{key: "e2", body: body, surrogates: []string{"s"}})
+	assert.Equal(t, int64(82), store.stats().Bytes) // key + body + surrogates
+
+	store.trySet(&cacheEntry{key: "e3", body: body})
+	assert.Nil(t, store.get("e1"))
+	assert.Equal(t, int64(2), store.stats().Entries)
+	assert.Equal(t, int64(81), store.stats().Bytes)
+	assert.Equal(t, map[string]int{"s": 1}, store.surrogateCounts())
+
+	// entries that don't fit at all are not stored, nor cause evictions
+	assert.False(t, store.trySet(&cacheEntry{key: "huge", body: make([]byte, 200)}))
+	stats := store.stats()
+	assert.Equal(t, int64(1), stats.Rejections)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(2), stats.SizeCounts[0])
+}
+
+func TestSizeBuckets(t *testing.T) {
+	assert.Equal(t, 0, sizeBucket(10))
+	assert.Equal(t, 0, sizeBucket(1024))
+	assert.Equal(t, 1, sizeBucket(1025))
+	assert.Equal(t, len(entrySizeBuckets), sizeBucket(10<<20))
+}
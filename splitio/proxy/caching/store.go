@@ -0,0 +1,227 @@
+package caching
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Upper bounds (in bytes) of the buckets used to build the entry size histogram. The last bucket holds everything larger
+var entrySizeBuckets = []int64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// Stats is a snapshot of the cache usage
+type Stats struct {
+	Entries     int64   `json:"entries"`
+	Bytes       int64   `json:"bytes"`
+	MaxEntries  int64   `json:"maxEntries"`
+	MaxBytes    int64   `json:"maxBytes"`
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	Evictions   int64   `json:"evictions"`
+	Rejections  int64   `json:"rejections"`
+	SizeBuckets []int64 `json:"sizeBucketsUpperBounds"`
+	SizeCounts  []int64 `json:"sizeCounts"`
+}
+
+type cacheEntry struct {
+	key        string
+	status     int
+	body       []byte
+	headers    map[string]string
+	surrogates []string
+	sticky     bool
+	size       int64
+	element    *list.Element
+}
+
+// boundedStore holds responses up to a max number of entries and/or bytes (0 = unbounded).
+// When making room, the least recently used non-sticky entry is evicted first
+type boundedStore struct {
+	maxEntries int64
+	maxBytes   int64
+	data       map[string]*cacheEntry
+	surrogates map[string]map[string]struct{}
+	lru        *list.List
+	bytes      int64
+	hits       int64
+	misses     int64
+	evictions  int64
+	rejections int64
+	sizeCounts []int64
+	mutex      sync.Mutex
+}
+
+func newBoundedStore(maxEntries int64, maxBytes int64) *boundedStore {
+	return &boundedStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		data:       make(map[string]*cacheEntry),
+		surrogates: make(map[string]map[string]struct{}),
+		lru:        list.New(),
+		sizeCounts: make([]int64, len(entrySizeBuckets)+1),
+	}
+}
+
+func (s *boundedStore) get(key string) *cacheEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.data[key]
+	if !ok {
+		s.misses++
+		return nil
+	}
+	s.hits++
+	s.lru.MoveToFront(entry.element)
+	return entry
+}
+
+// trySet adds an entry if it's not present. Entries that would not fit even in an empty cache are rejected
+func (s *boundedStore) trySet(entry *cacheEntry) bool {
+	entry.size = sizeOf(entry)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.data[entry.key]; ok {
+		return false
+	}
+
+	if s.maxBytes > 0 && entry.size > s.maxBytes {
+		s.rejections++
+		return false
+	}
+
+	for s.isFull(entry.size) {
+		s.makeRoom()
+	}
+
+	entry.element = s.lru.PushFront(entry)
+	s.data[entry.key] = entry
+	s.bytes += entry.size
+	s.sizeCounts[sizeBucket(entry.size)]++
+	for _, surrogate := range entry.surrogates {
+		keys, ok := s.surrogates[surrogate]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.surrogates[surrogate] = keys
+		}
+		keys[entry.key] = struct{}{}
+	}
+	return true
+}
+
+func (s *boundedStore) evict(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(key)
+}
+
+func (s *boundedStore) evictBySurrogate(surrogate string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key := range s.surrogates[surrogate] {
+		s.remove(key)
+	}
+	delete(s.surrogates, surrogate)
+}
+
+func (s *boundedStore) evictAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data = make(map[string]*cacheEntry)
+	s.surrogates = make(map[string]map[string]struct{})
+	s.lru.Init()
+	s.bytes = 0
+	s.sizeCounts = make([]int64, len(entrySizeBuckets)+1)
+}
+
+// surrogateCounts returns how many entries are referenced by each surrogate
+func (s *boundedStore) surrogateCounts() map[string]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	toRet := make(map[string]int, len(s.surrogates))
+	for surrogate, keys := range s.surrogates {
+		toRet[surrogate] = len(keys)
+	}
+	return toRet
+}
+
+func (s *boundedStore) stats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return Stats{
+		Entries:     int64(len(s.data)),
+		Bytes:       s.bytes,
+		MaxEntries:  s.maxEntries,
+		MaxBytes:    s.maxBytes,
+		Hits:        s.hits,
+		Misses:      s.misses,
+		Evictions:   s.evictions,
+		Rejections:  s.rejections,
+		SizeBuckets: append([]int64(nil), entrySizeBuckets...),
+		SizeCounts:  append([]int64(nil), s.sizeCounts...),
+	}
+}
+
+// -- internal, must be called with the lock held
+
+func (s *boundedStore) isFull(incoming int64) bool {
+	if len(s.data) == 0 {
+		return false
+	}
+	return (s.maxEntries > 0 && int64(len(s.data)) >= s.maxEntries) || (s.maxBytes > 0 && s.bytes+incoming > s.maxBytes)
+}
+
+func (s *boundedStore) makeRoom() {
+	for element := s.lru.Back(); element != nil; element = element.Prev() {
+		if entry := element.Value.(*cacheEntry); !entry.sticky {
+			s.remove(entry.key)
+			s.evictions++
+			return
+		}
+	}
+
+	// only sticky entries are left, so the least recently used one has to go
+	s.remove(s.lru.Back().Value.(*cacheEntry).key)
+	s.evictions++
+}
+
+// remove deletes an entry and any reference to it from the surrogates pointing to it, so that if another entry with
+// the same key is added later, it's not wiped by a purge of the old surrogates
+func (s *boundedStore) remove(key string) {
+	entry, ok := s.data[key]
+	if !ok {
+		return
+	}
+
+	delete(s.data, key)
+	s.lru.Remove(entry.element)
+	s.bytes -= entry.size
+	s.sizeCounts[sizeBucket(entry.size)]--
+	for _, surrogate := range entry.surrogates {
+		if keys, ok := s.surrogates[surrogate]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(s.surrogates, surrogate)
+			}
+		}
+	}
+}
+
+func sizeOf(entry *cacheEntry) int64 {
+	size := len(entry.key) + len(entry.body)
+	for k, v := range entry.headers {
+		size += len(k) + len(v)
+	}
+	for _, surrogate := range entry.surrogates {
+		size += len(surrogate)
+	}
+	return int64(size)
+}
+
+func sizeBucket(size int64) int {
+	for idx, upper := range entrySizeBuckets {
+		if size <= upper {
+			return idx
+		}
+	}
+	return len(entrySizeBuckets)
+}
@@ -7,20 +7,24 @@ import (
 
 // Main configuration options
 type Main struct {
-	Apikey                string            `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
-	IPAddressEnabled      bool              `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
-	FlagSetsFilter        []string          `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
-	FlagSetStrictMatching bool              `json:"flagSetStrictMatching" s-cli:"flag-sets-strict-matching" s-def:"false" s-desc:"filter sets not present in cache when building splitChanges responses"`
-	Initialization        Initialization    `json:"initialization" s-nested:"true"`
-	Server                Server            `json:"server" s-nested:"true"`
-	Admin                 conf.Admin        `json:"admin" s-nested:"true"`
-	Storage               Storage           `json:"storage" s-nested:"true"`
-	Sync                  Sync              `json:"sync" s-nested:"true"`
-	Integrations          conf.Integrations `json:"integrations" s-nested:"true"`
-	Logging               conf.Logging      `json:"logging" s-nested:"true"`
-	Healthcheck           Healthcheck       `json:"healthcheck" s-nested:"true"`
-	Observability         Observability     `json:"observability" s-nested:"true"`
-	FlagSpecVersion       string            `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.1" s-desc:"Spec version for flags"`
+	Apikey                string               `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
+	IPAddressEnabled      bool                 `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
+	FlagSetsFilter        []string             `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
+	FlagSetStrictMatching bool                 `json:"flagSetStrictMatching" s-cli:"flag-sets-strict-matching" s-def:"false" s-desc:"filter sets not present in cache when building splitChanges responses"`
+	Initialization        Initialization       `json:"initialization" s-nested:"true"`
+	Server                Server               `json:"server" s-nested:"true"`
+	Admin                 conf.Admin           `json:"admin" s-nested:"true"`
+	Storage               Storage              `json:"storage" s-nested:"true"`
+	Sync                  Sync                 `json:"sync" s-nested:"true"`
+	Integrations          conf.Integrations    `json:"integrations" s-nested:"true"`
+	Logging               conf.Logging         `json:"logging" s-nested:"true"`
+	Healthcheck           Healthcheck          `json:"healthcheck" s-nested:"true"`
+	Observability         Observability        `json:"observability" s-nested:"true"`
+	FlagSpecVersion       string               `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.1" s-desc:"Spec version for flags"`
+	ShutdownTimeoutMs     int64                `json:"shutdownTimeoutMs" s-cli:"shutdown-timeout-ms" s-def:"30000" s-desc:"Max time to wait, overall, for in-flight requests to complete & queued data to be posted when shutting down"`
+	AuditLog              conf.AuditLog        `json:"auditLog" s-nested:"true"`
+	SnapshotSigning       conf.SnapshotSigning `json:"snapshotSigning" s-nested:"true"`
+	Environments          []Environment        `json:"environments"`
 }
 
 // BuildAdvancedConfig generates a commons-compatible advancedconfig with default + overriden parameters
@@ -35,30 +39,93 @@ func (m *Main) BuildAdvancedConfig() *cconf.AdvancedConfig {
 	return tmp
 }
 
+// Environment configuration options for an additional Split environment served by this proxy.
+// Each one is synchronized independently with its own SDK key, and clients are routed to it based on their apikey.
+// Only available through the JSON config file
+type Environment struct {
+	Name                 string   `json:"name"`
+	Apikey               string   `json:"apikey"`
+	ClientApikeys        []string `json:"apikeys"`
+	ClientApikeyFlagSets []string `json:"apikeyFlagSets"`
+	FlagSetsFilter       []string `json:"flagSetsFilter"`
+	FlagOverridesFile    string   `json:"flagOverridesFile"`
+}
+
 // Initialization configuration options
 type Initialization struct {
 	TimeoutMs         int64  `json:"timeoutMS" s-cli:"timeout-ms" s-def:"10000" s-desc:"How long to wait until the synchronizer is ready"`
 	Snapshot          string `json:"snapshot" s-cli:"snapshot" s-def:"" s-desc:"Snapshot file to use as a starting point"`
+	SnapshotFallback  bool   `json:"snapshotFallback" s-cli:"snapshot-fallback" s-def:"false" s-desc:"Start from the latest valid snapshot in the snapshots dir when there's no other data to start from"`
 	ForceFreshStartup bool   `json:"forceFreshStartup" s-cli:"force-fresh-startup" s-def:"false" s-desc:"Wipe storage before starting the synchronizer"`
 }
 
 // Server configuration options
 type Server struct {
-	ClientApikeys []string `json:"apikeys" s-cli:"client-apikeys" s-def:"SDK_API_KEY" s-desc:"Apikeys that clients connecting to this proxy will use."`
-	Host          string   `json:"host" s-cli:"server-host" s-def:"0.0.0.0" s-desc:"Host/IP to start the proxy server on"`
-	Port          int64    `json:"port" s-cli:"server-port" s-def:"3000" s-desc:"Port to listten for incoming requests from SDKs"`
-	CacheSize     int64    `json:"httpCacheSize" s-cli:"http-cache-size" s-def:"1000000" s-desc:"How many responses to cache"`
-	TLS           conf.TLS `json:"tls" s-nested:"true" s-cli-prefix:"server"`
+	ClientApikeys         []string        `json:"apikeys" s-cli:"client-apikeys" s-def:"SDK_API_KEY" s-desc:"Apikeys that clients connecting to this proxy will use."`
+	ClientApikeyFlagSets  []string        `json:"apikeyFlagSets" s-cli:"client-apikey-flag-sets" s-def:"" s-desc:"Restrict client apikeys to some flag sets, as 'apikey:set1|set2' entries"`
+	ClientApikeysFile     string          `json:"apikeysFile" s-cli:"client-apikeys-file" s-def:"" s-desc:"File with additional client apikeys (one 'apikey[:set1|set2]' per line), reloaded when modified"`
+	ClientApikeysPollSecs int64           `json:"apikeysFilePollSecs" s-cli:"client-apikeys-file-poll-secs" s-def:"5" s-desc:"How often to check the client apikeys file for changes"`
+	FlagOverridesFile     string          `json:"flagOverridesFile" s-cli:"flag-overrides-file" s-def:"" s-desc:"File where local feature flag overrides are loaded from & saved to"`
+	Host                  string          `json:"host" s-cli:"server-host" s-def:"0.0.0.0" s-desc:"Host/IP to start the proxy server on"`
+	Port                  int64           `json:"port" s-cli:"server-port" s-def:"3000" s-desc:"Port to listten for incoming requests from SDKs"`
+	CacheSize             int64           `json:"httpCacheSize" s-cli:"http-cache-size" s-def:"1000000" s-desc:"How many responses to cache, split evenly across environments (0 = unbounded)"`
+	CacheMaxBytes         int64           `json:"httpCacheMaxBytes" s-cli:"http-cache-max-bytes" s-def:"0" s-desc:"Max amount of bytes used by cached responses, split evenly across environments (0 = unbounded)"`
+	TrustedProxies        []string        `json:"trustedProxies" s-cli:"server-trusted-proxies" s-def:"" s-desc:"IPs/CIDRs of reverse proxies allowed to set the client ip through X-Forwarded-For, used for per-ip rate limits"`
+	TLS                   conf.TLS        `json:"tls" s-nested:"true" s-cli-prefix:"server"`
+	Streaming             ServerStreaming `json:"streaming" s-nested:"true"`
+	Evaluation            Evaluation      `json:"evaluation" s-nested:"true"`
+	RateLimit             RateLimit       `json:"rateLimit" s-nested:"true"`
+}
+
+// RateLimit configuration options
+type RateLimit struct {
+	SDK    RateLimitRules `json:"sdk" s-nested:"true" s-cli-prefix:"sdk"`
+	Events RateLimitRules `json:"events" s-nested:"true" s-cli-prefix:"events"`
+}
+
+// RateLimitRules configuration options for a group of endpoints
+type RateLimitRules struct {
+	APIKeyPerMin int64 `json:"apikeyRequestsPerMin" s-cli:"rate-limit-apikey-per-min" s-def:"0" s-desc:"Max requests per minute accepted from each client apikey (0 = unlimited)"`
+	APIKeyBurst  int64 `json:"apikeyBurst" s-cli:"rate-limit-apikey-burst" s-def:"20" s-desc:"Max requests accepted at once from each client apikey"`
+	IPPerMin     int64 `json:"ipRequestsPerMin" s-cli:"rate-limit-ip-per-min" s-def:"0" s-desc:"Max requests per minute accepted from each source ip (0 = unlimited)"`
+	IPBurst      int64 `json:"ipBurst" s-cli:"rate-limit-ip-burst" s-def:"20" s-desc:"Max requests accepted at once from each source ip"`
+}
+
+// Evaluation configuration options
+type Evaluation struct {
+	Enabled           bool `json:"enabled" s-cli:"server-evaluation-enabled" s-def:"false" s-desc:"Serve treatments to clients that cannot embed an SDK on /api/evaluate & /api/evaluateAll"`
+	RecordImpressions bool `json:"recordImpressions" s-cli:"server-evaluation-record-impressions" s-def:"true" s-desc:"Send impressions to Split for evaluations performed by the proxy"`
+}
+
+// ServerStreaming configuration options
+type ServerStreaming struct {
+	Enabled              bool   `json:"enabled" s-cli:"server-streaming-enabled" s-def:"false" s-desc:"Serve push notifications to SDKs from the proxy"`
+	TokenSecret          string `json:"tokenSecret" s-cli:"server-streaming-token-secret" s-def:"" s-desc:"Secret used to sign push tokens. (Default: random)"`
+	TokenTTLSecs         int64  `json:"tokenTtlSecs" s-cli:"server-streaming-token-ttl-secs" s-def:"3600" s-desc:"How long push tokens are valid for"`
+	KeepAliveSecs        int64  `json:"keepAliveSecs" s-cli:"server-streaming-keepalive-secs" s-def:"30" s-desc:"How often to send keepalives on idle connections"`
+	SubscriberBufferSize int64  `json:"subscriberBufferSize" s-cli:"server-streaming-subscriber-buffer-size" s-def:"100" s-desc:"#notifications to buffer per connection"`
 }
 
 // Storage configuration options
 type Storage struct {
 	Volatile   Volatile   `json:"volatile" s-nested:"true"`
 	Persistent Persistent `json:"persistent" s-nested:"true"`
+	Spool      Spool      `json:"spool" s-nested:"true"`
+	Snapshots  Snapshots  `json:"snapshots" s-nested:"true"`
+}
+
+// Snapshots configuration options
+type Snapshots struct {
+	Dir          string `json:"dir" s-cli:"snapshots-dir" s-def:"" s-desc:"Directory where snapshots are automatically saved to. (Default: disabled)"`
+	PeriodSecs   int64  `json:"periodSecs" s-cli:"snapshots-period-secs" s-def:"3600" s-desc:"How often to save a snapshot (0 = only after changes)"`
+	AfterChanges int64  `json:"afterChanges" s-cli:"snapshots-after-changes" s-def:"0" s-desc:"Save a snapshot after this many feature flag & segment updates (0 = disabled)"`
+	MaxCount     int64  `json:"maxCount" s-cli:"snapshots-max-count" s-def:"24" s-desc:"How many snapshots to keep (0 = unlimited)"`
+	MaxAgeSecs   int64  `json:"maxAgeSecs" s-cli:"snapshots-max-age-secs" s-def:"604800" s-desc:"Delete snapshots older than this (0 = never)"`
 }
 
 // Volatile storage configuration options
 type Volatile struct {
+	SegmentHistoryRetentionSecs int64 `json:"segmentHistoryRetentionSecs" s-cli:"segment-history-retention-secs" s-def:"0" s-desc:"How long to report removed segment keys to SDKs (0 = forever). Older requests get the current keys only, which cannot clear the keys removed in the meantime from SDK caches"`
 }
 
 // Persistent storage configuration options
@@ -66,24 +133,43 @@ type Persistent struct {
 	Filename string `json:"filename" s-cli:"persistent-storage-fn" s-def:"" s-desc:"Where to store flags & user-generated data. (Default: temporary file)"`
 }
 
+// Spool configuration options
+type Spool struct {
+	Enabled  bool   `json:"enabled" s-cli:"spool-enabled" s-def:"false" s-desc:"Store impressions, events & telemetry that don't fit in memory on disk instead of rejecting them"`
+	Filename string `json:"filename" s-cli:"spool-fn" s-def:"" s-desc:"Where to store data that doesn't fit in memory. (Default: temporary file)"`
+	MaxBytes int64  `json:"maxBytes" s-cli:"spool-max-bytes" s-def:"104857600" s-desc:"Max amount of bytes to store on disk for each kind of data (0 = unbounded)"`
+}
+
 // Sync configuration options
 type Sync struct {
 	SplitRefreshRateMs   int64        `json:"splitRefreshRateMs" s-cli:"split-refresh-rate-ms" s-def:"60000" s-desc:"How often to refresh feature flags"`
 	SegmentRefreshRateMs int64        `json:"segmentRefreshRateMs" s-cli:"segment-refresh-rate-ms" s-def:"60000" s-desc:"How often to refresh segments"`
 	Advanced             AdvancedSync `json:"advanced" s-nested:"true"`
+	Retry                Retry        `json:"retry" s-nested:"true"`
+}
+
+// Retry configuration options for data forwarded to Split servers
+type Retry struct {
+	MaxAttempts          int64    `json:"maxAttempts" s-cli:"forwarding-max-attempts" s-def:"5" s-desc:"How many times to try posting impressions, events & telemetry to Split servers (1 = no retries)"`
+	InitialBackoffMs     int64    `json:"initialBackoffMs" s-cli:"forwarding-initial-backoff-ms" s-def:"1000" s-desc:"How long to wait before the first retry. Doubled on every subsequent one"`
+	MaxBackoffMs         int64    `json:"maxBackoffMs" s-cli:"forwarding-max-backoff-ms" s-def:"30000" s-desc:"Max time to wait between retries"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes" s-cli:"forwarding-retryable-status-codes" s-def:"408,429,500,502,503,504" s-desc:"HTTP status codes worth retrying. Network errors are always retried"`
+	DeadLetterMaxItems   int64    `json:"deadLetterMaxItems" s-cli:"forwarding-dead-letter-max-items" s-def:"1000" s-desc:"How many payloads that couldn't be posted to keep for inspection & replay (0 = disabled). Kept in the spool db if spooling is enabled, in memory otherwise"`
 }
 
 // AdvancedSync configuration options
 type AdvancedSync struct {
-	StreamingEnabled      bool  `json:"streamingEnabled" s-cli:"streaming-enabled" s-def:"true" s-desc:"Enable/disable streaming functionality"`
-	HTTPTimeoutMs         int64 `json:"httpTimeoutMs" s-cli:"http-timeout-ms" s-def:"30000" s-desc:"Total http request timeout"`
-	ImpressionsBuffer     int64 `json:"impressionsBufferSize" s-cli:"impressions-buffer-size" s-def:"500" s-dec:"How many impressions bulks to keep in memory"`
-	EventsBuffer          int64 `json:"eventsBufferSize" s-cli:"events-buffer-size" s-def:"500" s-dec:"How many events bulks to keep in memory"`
-	TelemetryBuffer       int64 `json:"telemetryBufferSize" s-cli:"telemetry-buffer-size" s-def:"500" s-dec:"How many telemetry bulks to keep in memory"`
-	ImpressionsWorkers    int64 `json:"impressionsWorkers" s-cli:"impressions-workers" s-def:"10" s-desc:"#workers to forward impressions to Split servers"`
-	EventsWorkers         int64 `json:"eventsWorkers" s-cli:"events-workers" s-def:"10" s-desc:"#workers to forward events to Split servers"`
-	TelemetryWorkers      int64 `json:"telemetryWorkers" s-cli:"telemetry-workers" s-def:"10" s-desc:"#workers to forward telemetry to Split servers"`
-	InternalMetricsRateMs int64 `json:"internalTelemetryRateMs" s-cli:"internal-metrics-rate-ms" s-def:"3600000" s-desc:"How often to send internal metrics"`
+	StreamingEnabled        bool  `json:"streamingEnabled" s-cli:"streaming-enabled" s-def:"true" s-desc:"Enable/disable streaming functionality"`
+	HTTPTimeoutMs           int64 `json:"httpTimeoutMs" s-cli:"http-timeout-ms" s-def:"30000" s-desc:"Total http request timeout"`
+	ImpressionsBuffer       int64 `json:"impressionsBufferSize" s-cli:"impressions-buffer-size" s-def:"500" s-dec:"How many impressions bulks to keep in memory"`
+	EventsBuffer            int64 `json:"eventsBufferSize" s-cli:"events-buffer-size" s-def:"500" s-dec:"How many events bulks to keep in memory"`
+	TelemetryBuffer         int64 `json:"telemetryBufferSize" s-cli:"telemetry-buffer-size" s-def:"500" s-dec:"How many telemetry bulks to keep in memory"`
+	ImpressionsWorkers      int64 `json:"impressionsWorkers" s-cli:"impressions-workers" s-def:"10" s-desc:"#workers to forward impressions to Split servers"`
+	EventsWorkers           int64 `json:"eventsWorkers" s-cli:"events-workers" s-def:"10" s-desc:"#workers to forward events to Split servers"`
+	TelemetryWorkers        int64 `json:"telemetryWorkers" s-cli:"telemetry-workers" s-def:"10" s-desc:"#workers to forward telemetry to Split servers"`
+	InternalMetricsRateMs   int64 `json:"internalTelemetryRateMs" s-cli:"internal-metrics-rate-ms" s-def:"3600000" s-desc:"How often to send internal metrics"`
+	SplitChangesCollapseMs  int64 `json:"splitChangesCollapseMs" s-cli:"split-changes-collapse-ms" s-def:"1000" s-desc:"How long to share upstream splitChanges responses among SDKs with the same since"`
+	MaxUpstreamSplitFetches int64 `json:"maxUpstreamSplitFetches" s-cli:"max-upstream-split-fetches" s-def:"10" s-desc:"Max #concurrent splitChanges requests sent upstream on behalf of SDKs"`
 }
 
 // Healthcheck configuration options
@@ -4,14 +4,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
 )
 
 // AuthServerController bundles all request handler for sdk-server apis
-type AuthServerController struct{}
+type AuthServerController struct {
+	logger logging.LoggerInterface
+	issuer streaming.TokenIssuer
+}
 
-// NewAuthServerController instantiates a new sdk server controller
-func NewAuthServerController() *AuthServerController {
-	return &AuthServerController{}
+// NewAuthServerController instantiates a new sdk server controller.
+// If no token issuer is supplied, push will be reported as disabled to all SDKs
+func NewAuthServerController(logger logging.LoggerInterface, issuer streaming.TokenIssuer) *AuthServerController {
+	return &AuthServerController{logger: logger, issuer: issuer}
 }
 
 // Register mounts the sdk-server endpoints onto the supplied router
@@ -20,7 +27,19 @@ func (c *AuthServerController) Register(router gin.IRouter) {
 	router.GET("/v2/auth", c.AuthV1)
 }
 
-// AuthV1 always returns pushEnabled = false and no token
+// AuthV1 returns a proxy-issued push token if streaming is enabled. Otherwise returns pushEnabled = false and no token
 func (c *AuthServerController) AuthV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"pushEnabled": false, "token": ""})
+	if c.issuer == nil {
+		ctx.JSON(http.StatusOK, gin.H{"pushEnabled": false, "token": ""})
+		return
+	}
+
+	token, err := c.issuer.Issue(ctx.QueryArray("users"))
+	if err != nil {
+		c.logger.Error("error issuing push token: ", err)
+		ctx.JSON(http.StatusOK, gin.H{"pushEnabled": false, "token": ""})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"pushEnabled": true, "token": token, "connDelay": 0})
 }
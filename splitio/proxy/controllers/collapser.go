@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/service"
+)
+
+var errFetchAborted = errors.New("upstream feature flags fetch aborted")
+
+type splitChangesKey struct {
+	since int64
+	sets  string
+	spec  string
+}
+
+type splitChangesFetch struct {
+	done      chan struct{}
+	result    *dtos.SplitChangesDTO
+	err       error
+	expiresAt time.Time // zero while the fetch is in progress
+}
+
+// splitChangesCollapser sits in front of the upstream feature flag fetcher and makes sure that concurrent requests
+// for the same (since, sets, spec) tuple result in a single upstream call. Successful results are kept for a short
+// period of time so that SDKs arriving right after the fetch completes are served the same response, and the number of
+// concurrent upstream fetches is bounded.
+type splitChangesCollapser struct {
+	fetcher service.SplitFetcher
+	ttl     time.Duration
+	slots   chan struct{}
+	fetches map[splitChangesKey]*splitChangesFetch
+	mutex   sync.Mutex
+}
+
+func newSplitChangesCollapser(fetcher service.SplitFetcher, ttl time.Duration, maxInFlight int) *splitChangesCollapser {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+
+	return &splitChangesCollapser{
+		fetcher: fetcher,
+		ttl:     ttl,
+		slots:   make(chan struct{}, maxInFlight),
+		fetches: make(map[splitChangesKey]*splitChangesFetch),
+	}
+}
+
+// Fetch returns the upstream response for the supplied parameters, either by joining an in-progress fetch, reusing a
+// recent result or performing a new request. `sets` are expected to be sanitized & sorted.
+// The returned DTO is a copy that callers are free to modify
+func (c *splitChangesCollapser) Fetch(since int64, sets []string, spec string) (*dtos.SplitChangesDTO, error) {
+	key := splitChangesKey{since: since, sets: strings.Join(sets, ","), spec: spec}
+
+	c.mutex.Lock()
+	now := time.Now()
+	if current, ok := c.fetches[key]; ok && (current.expiresAt.IsZero() || now.Before(current.expiresAt)) {
+		c.mutex.Unlock()
+		<-current.done
+		return copySplitChanges(current.result), current.err
+	}
+	c.evictExpired(now)
+	current := &splitChangesFetch{done: make(chan struct{})}
+	c.fetches[key] = current
+	c.mutex.Unlock()
+
+	c.run(key, current, since)
+	return copySplitChanges(current.result), current.err
+}
+
+// run performs the upstream fetch for a freshly registered entry. The slot is released, the entry resolved & waiters
+// woken up in a deferred block so that a panicking fetcher doesn't leave them blocked forever
+func (c *splitChangesCollapser) run(key splitChangesKey, current *splitChangesFetch, since int64) {
+	current.err = errFetchAborted // overwritten unless the fetcher panics
+	c.slots <- struct{}{}
+	defer func() {
+		<-c.slots
+		c.mutex.Lock()
+		if current.err != nil || c.ttl <= 0 {
+			// errors are not cached, the next request should hit the BE again
+			delete(c.fetches, key)
+		} else {
+			current.expiresAt = time.Now().Add(c.ttl)
+		}
+		c.mutex.Unlock()
+		close(current.done)
+	}()
+
+	fetchOptions := service.MakeFlagRequestParams().WithChangeNumber(since).WithFlagSetsFilter(key.sets)
+	current.result, current.err = c.fetcher.Fetch(fetchOptions)
+}
+
+// evictExpired removes stale results. must be called with the lock held
+func (c *splitChangesCollapser) evictExpired(now time.Time) {
+	for key, fetch := range c.fetches {
+		if !fetch.expiresAt.IsZero() && !now.Before(fetch.expiresAt) {
+			delete(c.fetches, key)
+		}
+	}
+}
+
+func copySplitChanges(dto *dtos.SplitChangesDTO) *dtos.SplitChangesDTO {
+	if dto == nil {
+		return nil
+	}
+
+	cloned := *dto
+	cloned.Splits = make([]dtos.SplitDTO, len(dto.Splits))
+	copy(cloned.Splits, dto.Splits)
+	return &cloned
+}
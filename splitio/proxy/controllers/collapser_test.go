@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/service"
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingFetcher struct {
+	calls    int64
+	inFlight int64
+	maxSeen  int64
+	release  chan struct{}
+	err      error
+}
+
+func (f *blockingFetcher) Fetch(fetchOptions *service.FlagRequestParams) (*dtos.SplitChangesDTO, error) {
+	atomic.AddInt64(&f.calls, 1)
+	current := atomic.AddInt64(&f.inFlight, 1)
+	for {
+		seen := atomic.LoadInt64(&f.maxSeen)
+		if current <= seen || atomic.CompareAndSwapInt64(&f.maxSeen, seen, current) {
+			break
+		}
+	}
+	defer atomic.AddInt64(&f.inFlight, -1)
+
+	if f.release != nil {
+		<-f.release
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dtos.SplitChangesDTO{
+		Since:  fetchOptions.ChangeNumber(),
+		Till:   fetchOptions.ChangeNumber() + 10,
+		Splits: []dtos.SplitDTO{{Name: "s1", Status: "ACTIVE"}},
+	}, nil
+}
+
+func TestCollapserConcurrentIdenticalRequests(t *testing.T) {
+	fetcher := &blockingFetcher{release: make(chan struct{})}
+	collapser := newSplitChangesCollapser(fetcher, time.Minute, 10)
+
+	var wg sync.WaitGroup
+	results := make([]*dtos.SplitChangesDTO, 50)
+	for idx := range results {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			res, err := collapser.Fetch(5, []string{"a", "b"}, "1.1")
+			assert.Nil(t, err)
+			results[idx] = res
+		}(idx)
+	}
+
+	time.Sleep(100 * time.Millisecond) // let all goroutines queue up
+	close(fetcher.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fetcher.calls))
+	for _, res := range results {
+		assert.Equal(t, int64(5), res.Since)
+		assert.Equal(t, int64(15), res.Till)
+	}
+
+	// callers get independent copies
+	results[0].Splits[0].Name = "modified"
+	assert.Equal(t, "s1", results[1].Splits[0].Name)
+
+	// recent results are reused
+	res, err := collapser.Fetch(5, []string{"a", "b"}, "1.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "s1", res.Splits[0].Name)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fetcher.calls))
+
+	// different parameters yield different fetches
+	collapser.Fetch(5, []string{"a"}, "1.1")
+	collapser.Fetch(5, []string{"a", "b"}, "1.0")
+	collapser.Fetch(6, []string{"a", "b"}, "1.1")
+	assert.Equal(t, int64(4), atomic.LoadInt64(&fetcher.calls))
+}
+
+func TestCollapserResultsExpire(t *testing.T) {
+	fetcher := &blockingFetcher{}
+	collapser := newSplitChangesCollapser(fetcher, 50*time.Millisecond, 10)
+
+	collapser.Fetch(5, nil, "1.1")
+	collapser.Fetch(5, nil, "1.1")
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fetcher.calls))
+
+	time.Sleep(100 * time.Millisecond)
+	collapser.Fetch(5, nil, "1.1")
+	assert.Equal(t, int64(2), atomic.LoadInt64(&fetcher.calls))
+
+	// no ttl means results are only shared among concurrent requests
+	collapser = newSplitChangesCollapser(fetcher, 0, 10)
+	collapser.Fetch(5, nil, "1.1")
+	collapser.Fetch(5, nil, "1.1")
+	assert.Equal(t, int64(4), atomic.LoadInt64(&fetcher.calls))
+}
+
+func TestCollapserErrorsAreNotCached(t *testing.T) {
+	fetcher := &blockingFetcher{err: errors.New("something")}
+	collapser := newSplitChangesCollapser(fetcher, time.Minute, 10)
+
+	res, err := collapser.Fetch(5, nil, "1.1")
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, fetcher.err)
+
+	fetcher.err = nil
+	res, err = collapser.Fetch(5, nil, "1.1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(15), res.Till)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&fetcher.calls))
+}
+
+func TestCollapserBoundsInFlightFetches(t *testing.T) {
+	fetcher := &blockingFetcher{release: make(chan struct{})}
+	collapser := newSplitChangesCollapser(fetcher, time.Minute, 3)
+
+	var wg sync.WaitGroup
+	for since := int64(0); since < 20; since++ {
+		wg.Add(1)
+		go func(since int64) {
+			defer wg.Done()
+			collapser.Fetch(since, nil, "1.1")
+		}(since)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&fetcher.inFlight))
+	close(fetcher.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(20), atomic.LoadInt64(&fetcher.calls))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&fetcher.maxSeen))
+}
+
+type panickingFetcher struct {
+	release chan struct{}
+}
+
+func (f *panickingFetcher) Fetch(fetchOptions *service.FlagRequestParams) (*dtos.SplitChangesDTO, error) {
+	<-f.release
+	panic("boom")
+}
+
+func TestCollapserPanickingFetcherReleasesWaiters(t *testing.T) {
+	fetcher := &panickingFetcher{release: make(chan struct{})}
+	collapser := newSplitChangesCollapser(fetcher, time.Minute, 1)
+
+	go func() {
+		defer func() { recover() }()
+		collapser.Fetch(5, nil, "1.1")
+	}()
+	time.Sleep(50 * time.Millisecond) // let the leader register the fetch
+
+	waiterDone := make(chan error, 1)
+	go func() {
+		_, err := collapser.Fetch(5, nil, "1.1")
+		waiterDone <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(fetcher.release)
+
+	select {
+	case err := <-waiterDone:
+		assert.ErrorIs(t, err, errFetchAborted)
+	case <-time.After(time.Second):
+		t.Fatal("waiter should be released when the fetcher panics")
+	}
+
+	// the slot & entry are released, so a new fetch is attempted
+	assert.Panics(t, func() { collapser.Fetch(5, nil, "1.1") })
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-split-commons/v6/conf"
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/engine/evaluator"
+	"github.com/splitio/go-split-commons/v6/engine/evaluator/impressionlabels"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+)
+
+// EvaluationServerController bundles endpoints used by clients that cannot embed an sdk to get treatments from the proxy
+type EvaluationServerController struct {
+	logger            logging.LoggerInterface
+	evaluator         evaluator.Interface
+	allFlagNames      func() []string
+	flagNamesBySets   func(sets []string) map[string][]string
+	impressionsSink   tasks.DeferredRecordingTask
+	listener          impressionlistener.ImpressionBulkListener
+	recordImpressions bool
+}
+
+// EvaluationRequest is the body accepted by POST evaluation endpoints
+type EvaluationRequest struct {
+	Key          string                 `json:"key"`
+	BucketingKey *string                `json:"bucketingKey,omitempty"`
+	FeatureFlags []string               `json:"featureFlags,omitempty"`
+	FlagSets     []string               `json:"sets,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// EvaluationResult is the outcome of evaluating a single feature flag
+type EvaluationResult struct {
+	Treatment    string  `json:"treatment"`
+	Config       *string `json:"config"`
+	Label        string  `json:"label"`
+	ChangeNumber int64   `json:"changeNumber"`
+}
+
+// NewEvaluationServerController instantiates a new evaluation controller.
+// `allFlagNames` is used to evaluate every cached flag when no flag sets are supplied to evaluateAll, and
+// `flagNamesBySets` to check which flags can be evaluated with apikeys restricted to some flag sets.
+// If `recordImpressions` is true, an impression is queued through `impressionsSink` for each evaluation of an existing flag,
+// and forwarded to `listener` if not nil
+func NewEvaluationServerController(
+	logger logging.LoggerInterface,
+	evaluator evaluator.Interface,
+	allFlagNames func() []string,
+	flagNamesBySets func(sets []string) map[string][]string,
+	impressionsSink tasks.DeferredRecordingTask,
+	listener impressionlistener.ImpressionBulkListener,
+	recordImpressions bool,
+) *EvaluationServerController {
+	return &EvaluationServerController{
+		logger:            logger,
+		evaluator:         evaluator,
+		allFlagNames:      allFlagNames,
+		flagNamesBySets:   flagNamesBySets,
+		impressionsSink:   impressionsSink,
+		listener:          listener,
+		recordImpressions: recordImpressions,
+	}
+}
+
+// Register mounts the evaluation endpoints onto the supplied router
+func (c *EvaluationServerController) Register(router gin.IRouter) {
+	router.GET("/evaluate", c.Evaluate)
+	router.POST("/evaluate", c.Evaluate)
+	router.GET("/evaluateAll", c.EvaluateAll)
+	router.POST("/evaluateAll", c.EvaluateAll)
+}
+
+// Evaluate returns the treatments for the requested feature flags
+func (c *EvaluationServerController) Evaluate(ctx *gin.Context) {
+	req, ok := c.parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	if len(req.FeatureFlags) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one feature flag is required"})
+		return
+	}
+
+	// apikeys restricted to some flag sets can only evaluate flags belonging to them. Others are treated as missing
+	flags := req.FeatureFlags
+	if scope := ctx.GetStringSlice(caching.FlagSetScopeContextKey); scope != nil {
+		flags = c.restrictToScope(flags, scope)
+		if len(flags) == 0 {
+			c.respond(ctx, req, nil)
+			return
+		}
+	}
+
+	results := c.evaluator.EvaluateFeatures(req.Key, req.BucketingKey, flags, req.Attributes)
+	c.respond(ctx, req, results.Evaluations)
+}
+
+// EvaluateAll returns the treatments for every flag in the requested flag sets, or all cached flags if none is supplied
+func (c *EvaluationServerController) EvaluateAll(ctx *gin.Context) {
+	req, ok := c.parseRequest(ctx)
+	if !ok {
+		return
+	}
+
+	// apikeys restricted to some flag sets only ever get those, regardless of what's requested
+	if scope := ctx.GetStringSlice(caching.FlagSetScopeContextKey); scope != nil {
+		req.FlagSets = flagsets.Restrict(req.FlagSets, scope)
+		if len(req.FlagSets) == 0 {
+			c.respond(ctx, req, nil)
+			return
+		}
+	}
+
+	var results evaluator.Results
+	if len(req.FlagSets) > 0 {
+		results = c.evaluator.EvaluateFeatureByFlagSets(req.Key, req.BucketingKey, req.FlagSets, req.Attributes)
+	} else {
+		results = c.evaluator.EvaluateFeatures(req.Key, req.BucketingKey, c.allFlagNames(), req.Attributes)
+	}
+	c.respond(ctx, req, results.Evaluations)
+}
+
+// restrictToScope returns the flags that belong to at least one of the flag sets in scope
+func (c *EvaluationServerController) restrictToScope(flags []string, scope []string) []string {
+	allowed := make(map[string]struct{})
+	for _, names := range c.flagNamesBySets(scope) {
+		for _, name := range names {
+			allowed[name] = struct{}{}
+		}
+	}
+
+	toRet := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		if _, ok := allowed[flag]; ok {
+			toRet = append(toRet, flag)
+		}
+	}
+	return toRet
+}
+
+func (c *EvaluationServerController) parseRequest(ctx *gin.Context) (*EvaluationRequest, bool) {
+	var req EvaluationRequest
+	if ctx.Request.Method == http.MethodPost {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %s", err.Error())})
+			return nil, false
+		}
+	} else {
+		req.Key = ctx.Query("key")
+		if bk, ok := ctx.GetQuery("bucketingKey"); ok {
+			req.BucketingKey = &bk
+		}
+		req.FeatureFlags = splitQueryList(ctx.QueryArray("featureFlags"))
+		req.FlagSets = splitQueryList(ctx.QueryArray("sets"))
+		if raw := ctx.Query("attributes"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Attributes); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid attributes: %s", err.Error())})
+				return nil, false
+			}
+		}
+	}
+
+	if req.Key == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return nil, false
+	}
+	return &req, true
+}
+
+func (c *EvaluationServerController) respond(ctx *gin.Context, req *EvaluationRequest, evaluations map[string]evaluator.Result) {
+	treatments := make(map[string]EvaluationResult, len(evaluations))
+	for name, result := range evaluations {
+		treatments[name] = EvaluationResult{
+			Treatment:    result.Treatment,
+			Config:       result.Config,
+			Label:        result.Label,
+			ChangeNumber: result.SplitChangeNumber,
+		}
+	}
+
+	if c.recordImpressions && c.impressionsSink != nil && len(evaluations) > 0 {
+		c.stageImpressions(ctx, req, evaluations)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"treatments": treatments})
+}
+
+// stageImpressions queues one impression per evaluation, skipping flags that don't exist (as sdks do), and forwards them to the
+// impression listener if there's one. Failing to do so is logged but doesn't affect the response
+func (c *EvaluationServerController) stageImpressions(ctx *gin.Context, req *EvaluationRequest, evaluations map[string]evaluator.Result) {
+	bucketingKey := ""
+	if req.BucketingKey != nil {
+		bucketingKey = *req.BucketingKey
+	}
+
+	now := time.Now().UnixMilli()
+	bulk := make([]dtos.ImpressionsDTO, 0, len(evaluations))
+	for name, result := range evaluations {
+		if result.Label == impressionlabels.SplitNotFound {
+			continue
+		}
+		bulk = append(bulk, dtos.ImpressionsDTO{
+			TestName: name,
+			KeyImpressions: []dtos.ImpressionDTO{{
+				KeyName:      req.Key,
+				Treatment:    result.Treatment,
+				Time:         now,
+				ChangeNumber: result.SplitChangeNumber,
+				Label:        result.Label,
+				BucketingKey: bucketingKey,
+			}},
+		})
+	}
+
+	if len(bulk) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(bulk)
+	if err != nil {
+		c.logger.Error("error serializing impressions for proxy evaluations: ", err)
+		return
+	}
+
+	metadata := metadataFromHeaders(ctx)
+	if metadata.SDKVersion == "" {
+		metadata = dtos.Metadata{SDKVersion: "proxy-evaluator-" + splitio.Version, MachineIP: "NA", MachineName: "NA"}
+	}
+	if c.listener != nil {
+		if err := c.listener.Submit(impressionsForListener(bulk), &metadata); err != nil {
+			c.logger.Error("error forwarding impressions for proxy evaluations to the listener: ", err)
+		}
+	}
+	if err := c.impressionsSink.Stage(internal.NewRawImpressions(metadata, conf.ImpressionsModeDebug, payload)); err != nil {
+		c.logger.Error("error queueing impressions for proxy evaluations: ", err)
+	}
+}
+
+// splitQueryList accepts both repeated & comma-separated query params
+func splitQueryList(values []string) []string {
+	var toReturn []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				toReturn = append(toReturn, item)
+			}
+		}
+	}
+	return toReturn
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/engine/evaluator"
+	evalMocks "github.com/splitio/go-split-commons/v6/engine/evaluator/mocks"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	ilMocks "github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks/mocks"
+)
+
+type evaluationResponse struct {
+	Treatments map[string]EvaluationResult `json:"treatments"`
+}
+
+func TestEvaluate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := `{"color":"blue"}`
+	eval := evalMocks.MockEvaluator{
+		EvaluateFeaturesCall: func(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+			assert.Equal(t, "user1", key)
+			assert.Nil(t, bucketingKey)
+			assert.Equal(t, map[string]interface{}{"age": float64(30)}, attributes)
+			results := evaluator.Results{Evaluations: make(map[string]evaluator.Result)}
+			for _, feature := range features {
+				results.Evaluations[feature] = evaluator.Result{Treatment: "on", Label: "default rule", SplitChangeNumber: 123, Config: &config}
+			}
+			return results
+		},
+	}
+
+	var staged []*internal.RawImpressions
+	sink := &mocks.MockDeferredRecordingTask{StageCall: func(rawData interface{}) error {
+		staged = append(staged, rawData.(*internal.RawImpressions))
+		return nil
+	}}
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	controller := NewEvaluationServerController(logging.NewLogger(nil), eval, nil, nil, sink, nil, true)
+	controller.Register(router.Group("/api"))
+
+	query := url.Values{"key": {"user1"}, "featureFlags": {"f1,f2"}, "attributes": {`{"age":30}`}}
+	req, _ := http.NewRequest(http.MethodGet, "/api/evaluate?"+query.Encode(), nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var parsed evaluationResponse
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 2)
+	assert.Equal(t, "on", parsed.Treatments["f1"].Treatment)
+	assert.Equal(t, config, *parsed.Treatments["f1"].Config)
+	assert.Equal(t, int64(123), parsed.Treatments["f2"].ChangeNumber)
+
+	assert.Len(t, staged, 1)
+	assert.Equal(t, "debug", staged[0].Mode)
+	assert.Equal(t, "NA", staged[0].Metadata.MachineIP)
+	var impressions []dtos.ImpressionsDTO
+	assert.Nil(t, json.Unmarshal(staged[0].Payload, &impressions))
+	assert.Len(t, impressions, 2)
+	assert.Equal(t, "user1", impressions[0].KeyImpressions[0].KeyName)
+	assert.Equal(t, "on", impressions[0].KeyImpressions[0].Treatment)
+
+	// same thing via POST, with sdk metadata headers
+	body, _ := json.Marshal(EvaluationRequest{Key: "user1", FeatureFlags: []string{"f3"}, Attributes: map[string]interface{}{"age": 30}})
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/evaluate", bytes.NewReader(body))
+	req.Header.Set("SplitSDKVersion", "iot-1.0.0")
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	parsed = evaluationResponse{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 1)
+	assert.Len(t, staged, 2)
+	assert.Equal(t, "iot-1.0.0", staged[1].Metadata.SDKVersion)
+
+	// validations
+	for _, path := range []string{"/api/evaluate?featureFlags=f1", "/api/evaluate?key=user1", "/api/evaluate?key=user1&featureFlags=f1&attributes=nojson"} {
+		resp = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, path)
+	}
+}
+
+func TestEvaluateImpressions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eval := evalMocks.MockEvaluator{
+		EvaluateFeaturesCall: func(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+			return evaluator.Results{Evaluations: map[string]evaluator.Result{
+				"f1":      {Treatment: "on", Label: "default rule", SplitChangeNumber: 123},
+				"missing": {Treatment: "control", Label: "definition not found"},
+			}}
+		},
+	}
+
+	var staged []*internal.RawImpressions
+	sink := &mocks.MockDeferredRecordingTask{StageCall: func(rawData interface{}) error {
+		staged = append(staged, rawData.(*internal.RawImpressions))
+		return nil
+	}}
+	var forwarded [][]impressionlistener.ImpressionsForListener
+	listener := &ilMocks.ImpressionBulkListenerMock{SubmitCall: func(imps []impressionlistener.ImpressionsForListener, metadata *dtos.Metadata) error {
+		assert.Equal(t, "go-1.2.3", metadata.SDKVersion)
+		forwarded = append(forwarded, imps)
+		return nil
+	}}
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	controller := NewEvaluationServerController(logging.NewLogger(nil), eval, nil, nil, sink, listener, true)
+	controller.Register(router.Group("/api"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/evaluate?key=user1&featureFlags=f1,missing", nil)
+	req.Header.Set("SplitSDKVersion", "go-1.2.3")
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var parsed evaluationResponse
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 2)
+
+	// flags that don't exist are evaluated as control, but no impression is recorded for them
+	assert.Len(t, staged, 1)
+	var impressions []dtos.ImpressionsDTO
+	assert.Nil(t, json.Unmarshal(staged[0].Payload, &impressions))
+	assert.Len(t, impressions, 1)
+	assert.Equal(t, "f1", impressions[0].TestName)
+
+	assert.Len(t, forwarded, 1)
+	assert.Len(t, forwarded[0], 1)
+	assert.Equal(t, "f1", forwarded[0][0].TestName)
+	assert.Equal(t, "user1", forwarded[0][0].KeyImpressions[0].KeyName)
+	assert.Equal(t, int64(123), forwarded[0][0].KeyImpressions[0].ChangeNumber)
+
+	// nothing is recorded if none of the flags exist
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/evaluate?key=user1&featureFlags=missing", nil)
+	eval.EvaluateFeaturesCall = func(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+		return evaluator.Results{Evaluations: map[string]evaluator.Result{"missing": {Treatment: "control", Label: "definition not found"}}}
+	}
+	controller = NewEvaluationServerController(logging.NewLogger(nil), eval, nil, nil, sink, listener, true)
+	router = gin.New()
+	controller.Register(router.Group("/api"))
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, staged, 1)
+	assert.Len(t, forwarded, 1)
+}
+
+func TestEvaluateAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eval := evalMocks.MockEvaluator{
+		EvaluateFeaturesCall: func(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+			assert.Equal(t, []string{"f1", "f2", "f3"}, features)
+			assert.Equal(t, "bk1", *bucketingKey)
+			return evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}, "f2": {Treatment: "off"}, "f3": {Treatment: "control"}}}
+		},
+		EvaluateFeatureByFlagSetsCall: func(key string, bucketingKey *string, flagSets []string, attributes map[string]interface{}) evaluator.Results {
+			assert.Equal(t, []string{"s1", "s2"}, flagSets)
+			return evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}}}
+		},
+	}
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	allFlags := func() []string { return []string{"f1", "f2", "f3"} }
+	controller := NewEvaluationServerController(logging.NewLogger(nil), eval, allFlags, nil, nil, nil, true)
+	controller.Register(router.Group("/api"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/evaluateAll?key=user1&bucketingKey=bk1", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var parsed evaluationResponse
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 3)
+	assert.Equal(t, "off", parsed.Treatments["f2"].Treatment)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/evaluateAll?key=user1&sets=s1&sets=s2", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	parsed = evaluationResponse{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 1)
+}
+
+func TestEvaluateWithScopedApikey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eval := evalMocks.MockEvaluator{
+		EvaluateFeaturesCall: func(key string, bucketingKey *string, features []string, attributes map[string]interface{}) evaluator.Results {
+			assert.Equal(t, []string{"f1"}, features)
+			return evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}}}
+		},
+		EvaluateFeatureByFlagSetsCall: func(key string, bucketingKey *string, flagSets []string, attributes map[string]interface{}) evaluator.Results {
+			assert.Equal(t, []string{"s1"}, flagSets)
+			return evaluator.Results{Evaluations: map[string]evaluator.Result{"f1": {Treatment: "on"}}}
+		},
+	}
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	router.Use(func(ctx *gin.Context) { ctx.Set(caching.FlagSetScopeContextKey, []string{"s1"}) })
+	allFlags := func() []string { return []string{"f1", "f2"} }
+	bySets := func(sets []string) map[string][]string {
+		assert.Equal(t, []string{"s1"}, sets)
+		return map[string][]string{"s1": {"f1"}}
+	}
+	controller := NewEvaluationServerController(logging.NewLogger(nil), eval, allFlags, bySets, nil, nil, true)
+	controller.Register(router.Group("/api"))
+
+	// flags outside the scope are left out
+	req, _ := http.NewRequest(http.MethodGet, "/api/evaluate?key=user1&featureFlags=f1,f2", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var parsed evaluationResponse
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 1)
+	assert.Equal(t, "on", parsed.Treatments["f1"].Treatment)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/evaluate?key=user1&featureFlags=f2", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	parsed = evaluationResponse{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 0)
+
+	// evaluating all flags only evaluates the ones in scope
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/evaluateAll?key=user1", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	parsed = evaluationResponse{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 1)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/evaluateAll?key=user1&sets=s1&sets=s2", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/evaluateAll?key=user1&sets=s2", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	parsed = evaluationResponse{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &parsed))
+	assert.Len(t, parsed.Treatments, 0)
+}
@@ -268,6 +268,11 @@ func (c *EventsServerController) submitImpressionsToListener(raw []byte, metadat
 		return
 	}
 
+	c.listener.Submit(impressionsForListener(parsed), metadata)
+}
+
+// impressionsForListener converts an impressions bulk into the format posted to the impression listener
+func impressionsForListener(parsed []dtos.ImpressionsDTO) []impressionlistener.ImpressionsForListener {
 	forListener := make([]impressionlistener.ImpressionsForListener, 0, len(parsed))
 	for _, group := range parsed {
 		kis := make([]impressionlistener.ImpressionForListener, 0, len(group.KeyImpressions))
@@ -287,8 +292,7 @@ func (c *EventsServerController) submitImpressionsToListener(raw []byte, metadat
 			KeyImpressions: kis,
 		})
 	}
-
-	c.listener.Submit(forListener, metadata)
+	return forListener
 }
 
 // private dtos
@@ -23,7 +23,7 @@ func TestPostImpressionsbulk(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
@@ -125,7 +125,7 @@ func TestPostEventsBulk(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
@@ -184,7 +184,7 @@ func TestPostImpressionsCounts(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
@@ -245,7 +245,7 @@ func TestPostLegacyMetrics(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
@@ -315,7 +315,7 @@ func TestPostBeaconImpressionsbulk(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
@@ -414,7 +414,7 @@ func TestPostBeaconEventsBulk(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
@@ -474,7 +474,7 @@ func TestPostBeaconImpressionsCounts(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewEventsServerController(
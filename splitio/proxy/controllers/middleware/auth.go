@@ -2,21 +2,27 @@ package middleware
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slices"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
 )
 
-// APIKeyValidator is a small component that validates apikeys
+// APIKeyValidator is a small component that validates apikeys. Keys can be added & removed at runtime
 type APIKeyValidator struct {
-	apikeys map[string]struct{}
+	apikeys map[string][]string
+	mutex   sync.RWMutex
 }
 
-// NewAPIKeyValidator instantiates an apikey validation component
-func NewAPIKeyValidator(apikeys []string) *APIKeyValidator {
-	toRet := &APIKeyValidator{apikeys: make(map[string]struct{})}
+// NewAPIKeyValidator instantiates an apikey validation component.
+// `flagSets` optionally restricts some of the apikeys to a subset of flag sets
+func NewAPIKeyValidator(apikeys []string, flagSets map[string][]string) *APIKeyValidator {
+	toRet := &APIKeyValidator{apikeys: make(map[string][]string)}
 
 	for _, key := range apikeys {
-		toRet.apikeys[key] = struct{}{}
+		toRet.apikeys[key] = flagSets[key]
 	}
 
 	return toRet
@@ -24,14 +30,76 @@ func NewAPIKeyValidator(apikeys []string) *APIKeyValidator {
 
 // IsValid checks if an apikey is valid
 func (v *APIKeyValidator) IsValid(apikey string) bool {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
 	_, ok := v.apikeys[apikey]
 	return ok
 }
 
-// AsMiddleware is a function to be used as a gin middleware
+// FlagSets returns the flag sets an apikey is restricted to, or nil if it can access all of them
+func (v *APIKeyValidator) FlagSets(apikey string) []string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	return v.apikeys[apikey]
+}
+
+// Set adds an apikey or updates the flag sets it's restricted to (nil = all of them)
+func (v *APIKeyValidator) Set(apikey string, flagSets []string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.apikeys[apikey] = flagSets
+}
+
+// Remove revokes an apikey, returning the flag sets it was restricted to and whether it was present
+func (v *APIKeyValidator) Remove(apikey string) ([]string, bool) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	flagSets, ok := v.apikeys[apikey]
+	delete(v.apikeys, apikey)
+	return flagSets, ok
+}
+
+// All returns a copy of the apikeys & the flag sets they're restricted to
+func (v *APIKeyValidator) All() map[string][]string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	toRet := make(map[string][]string, len(v.apikeys))
+	for key, sets := range v.apikeys {
+		toRet[key] = sets
+	}
+	return toRet
+}
+
+// InUse returns true if at least one apikey is restricted to exactly the supplied flag sets (nil = unrestricted)
+func (v *APIKeyValidator) InUse(flagSets []string) bool {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	for _, sets := range v.apikeys {
+		if (sets == nil) == (flagSets == nil) && slices.Equal(sets, flagSets) {
+			return true
+		}
+	}
+	return false
+}
+
+// AsMiddleware is a function to be used as a gin middleware.
+// If the apikey is restricted to a set of flag sets, they're stored in the context for handlers & the cache to use
 func (v *APIKeyValidator) AsMiddleware(ctx *gin.Context) {
 	auth := strings.Split(ctx.Request.Header.Get("Authorization"), " ")
-	if len(auth) != 2 || auth[0] != "Bearer" || !v.IsValid(auth[1]) {
+	if len(auth) != 2 || auth[0] != "Bearer" {
 		ctx.AbortWithStatus(401)
+		return
+	}
+
+	v.mutex.RLock()
+	sets, ok := v.apikeys[auth[1]]
+	v.mutex.RUnlock()
+	if !ok {
+		ctx.AbortWithStatus(401)
+		return
+	}
+
+	if sets != nil {
+		ctx.Set(caching.FlagSetScopeContextKey, sets)
 	}
 }
@@ -12,7 +12,7 @@ func TestAuthMiddleWare(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	resp := httptest.NewRecorder()
 	ctx, router := gin.CreateTestContext(resp)
-	authMW := NewAPIKeyValidator([]string{"apikey1", "apikey2"})
+	authMW := NewAPIKeyValidator([]string{"apikey1", "apikey2"}, nil)
 
 	router.GET("/api/test", authMW.AsMiddleware, func(ctx *gin.Context) {})
 
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConditionalGet answers GET requests with a 304 & no body when the ETag of the response matches one of the
+// supplied in the `If-None-Match` header. It must be mounted before the cache, so that cached responses (which
+// hold their ETag) are validated without reaching the request handlers
+func ConditionalGet(ctx *gin.Context) {
+	if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+		return
+	}
+
+	// the writer is set up even without an If-None-Match header, so that ETags are always tagged with the encoding
+	ctx.Writer = &conditionalWriter{ResponseWriter: ctx.Writer, ifNoneMatch: ctx.Request.Header.Get("If-None-Match")}
+}
+
+// conditionalWriter replaces successful responses with a 304 when the entity tag matches, discarding the body
+type conditionalWriter struct {
+	gin.ResponseWriter
+	ifNoneMatch string
+	notModified bool
+}
+
+func (w *conditionalWriter) WriteHeader(code int) {
+	if code == http.StatusOK {
+		etag := representationETag(w.Header())
+		if etag != "" && w.ifNoneMatch != "" && etagMatches(w.ifNoneMatch, etag) {
+			w.notModified = true
+			w.Header().Del("Content-Length")
+			w.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *conditionalWriter) Write(data []byte) (int, error) {
+	if w.notModified {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *conditionalWriter) WriteString(data string) (int, error) {
+	if w.notModified {
+		return len(data), nil
+	}
+	return w.ResponseWriter.WriteString(data)
+}
+
+// representationETag returns the response ETag, tagged with the content encoding when the body is compressed,
+// since strong validators must differ across representations of the same resource
+func representationETag(headers http.Header) string {
+	etag := headers.Get("ETag")
+	if etag == "" || headers.Get("Content-Encoding") != "gzip" || strings.HasSuffix(etag, `-gzip"`) {
+		return etag
+	}
+	etag = strings.TrimSuffix(etag, `"`) + `-gzip"`
+	headers.Set("ETag", etag)
+	return etag
+}
+
+// etagMatches performs the weak comparison required for If-None-Match against a list of entity tags (or `*`)
+func etagMatches(ifNoneMatch string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETagMatches(t *testing.T) {
+	cases := []struct {
+		ifNoneMatch string
+		etag        string
+		expected    bool
+	}{
+		{`"abc"`, `"abc"`, true},
+		{`"xyz", "abc"`, `"abc"`, true},
+		{`W/"abc"`, `"abc"`, true},
+		{`*`, `"abc"`, true},
+		{`"abc"`, `"abcd"`, false},
+		{`abc`, `"abc"`, false},
+	}
+	for _, tc := range cases {
+		if res := etagMatches(tc.ifNoneMatch, tc.etag); res != tc.expected {
+			t.Errorf("etagMatches(%s, %s) should be %t", tc.ifNoneMatch, tc.etag, tc.expected)
+		}
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.Use(ConditionalGet)
+	router.GET("/api/test", func(ctx *gin.Context) {
+		ctx.Header("ETag", `"v1"`)
+		ctx.JSON(http.StatusOK, gin.H{"some": "body"})
+	})
+	router.GET("/api/gzipped", func(ctx *gin.Context) {
+		ctx.Header("ETag", `"v1"`)
+		ctx.Header("Content-Encoding", "gzip")
+		ctx.String(http.StatusOK, "compressed")
+	})
+	router.GET("/api/error", func(ctx *gin.Context) {
+		ctx.Header("ETag", `"v1"`)
+		ctx.String(http.StatusInternalServerError, "error")
+	})
+
+	serve := func(path string, ifNoneMatch string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	if resp := serve("/api/test", ""); resp.Code != 200 || resp.Body.Len() == 0 {
+		t.Error("unconditional request should get a full response. Got: ", resp.Code)
+	}
+	if resp := serve("/api/test", `"v0"`); resp.Code != 200 || resp.Body.Len() == 0 {
+		t.Error("stale etag should get a full response. Got: ", resp.Code)
+	}
+	if resp := serve("/api/test", `"v1"`); resp.Code != 304 || resp.Body.Len() != 0 {
+		t.Error("matching etag should get a 304 without body. Got: ", resp.Code, resp.Body.String())
+	}
+	if resp := serve("/api/error", `"v1"`); resp.Code != 500 {
+		t.Error("non-200 responses should not be validated. Got: ", resp.Code)
+	}
+
+	resp := serve("/api/gzipped", "")
+	if etag := resp.Header().Get("ETag"); etag != `"v1-gzip"` {
+		t.Error("compressed responses should have their own etag. Got: ", etag)
+	}
+	if resp := serve("/api/gzipped", `"v1"`); resp.Code != 200 {
+		t.Error("uncompressed etag should not match a compressed response. Got: ", resp.Code)
+	}
+	if resp := serve("/api/gzipped", `"v1-gzip"`); resp.Code != 304 {
+		t.Error("compressed etag should match. Got: ", resp.Code)
+	}
+}
@@ -33,6 +33,8 @@ const (
 	pathTelemetryKeysClientSideBeaconV1 = "/api/v1/keys/cs/beacon"
 	pathTelemetryKeysServerSide         = "/api/keys/ss"
 	pathTelemetryKeysServerSideV1       = "/api/v1/keys/ss"
+	pathEvaluate                        = "/api/evaluate"
+	pathEvaluateAll                     = "/api/evaluateAll"
 )
 
 // SetEndpoint stores the endpoint in the context for future middleware querying
@@ -66,6 +68,10 @@ func SetEndpoint(ctx *gin.Context) {
 		ctx.Set(EndpointKey, storage.TelemetryKeysClientSideBeaconEndpoint)
 	case pathTelemetryKeysServerSide, pathTelemetryKeysServerSideV1:
 		ctx.Set(EndpointKey, storage.TelemetryKeysServerSideEndpoint)
+	case pathEvaluate:
+		ctx.Set(EndpointKey, storage.EvaluateEndpoint)
+	case pathEvaluateAll:
+		ctx.Set(EndpointKey, storage.EvaluateAllEndpoint)
 	default:
 		if strings.HasPrefix(path, pathSplitChanges) {
 			ctx.Set(EndpointKey, storage.SplitChangesEndpoint)
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+)
+
+// idle buckets that have already been refilled are discarded at most once every sweepPeriod
+const sweepPeriod = time.Minute
+
+// RateLimitRules defines the limits applied to a group of endpoints. Rates are expressed in requests per minute,
+// and bursts in how many requests can be served at once after being idle. A rate <= 0 disables the limit
+type RateLimitRules struct {
+	APIKeyPerMin int64
+	APIKeyBurst  int64
+	IPPerMin     int64
+	IPBurst      int64
+}
+
+// RateLimiter is a middleware that applies token-bucket limits per client apikey & per source ip.
+// SDK endpoints (splitChanges, segmentChanges & mySegments) and data posting ones (impressions, events & telemetry)
+// are limited separately. Requests exceeding the limit are rejected with a 429 and a Retry-After header, which gets
+// recorded in the endpoint status codes by the metrics middleware.
+// The source ip is the one gin resolves for the request, so forwarding headers are only honored from trusted proxies
+type RateLimiter struct {
+	sdk    limitGroup
+	events limitGroup
+	clock  func() time.Time
+}
+
+type limitGroup struct {
+	byAPIKey *tokenBuckets
+	byIP     *tokenBuckets
+}
+
+// NewRateLimiter constructs a new rate limiting middleware
+func NewRateLimiter(sdk RateLimitRules, events RateLimitRules) *RateLimiter {
+	return &RateLimiter{
+		sdk:    newLimitGroup(&sdk),
+		events: newLimitGroup(&events),
+		clock:  time.Now,
+	}
+}
+
+// Handle applies the per-ip limits. It is to be invoked for every request being handled
+func (l *RateLimiter) Handle(ctx *gin.Context) {
+	if group := l.groupFor(ctx); group != nil {
+		l.limit(ctx, group.byIP, ctx.ClientIP())
+	}
+}
+
+// HandleAPIKey applies the per-apikey limits. It must be invoked after the apikey has been validated, so that
+// requests with made-up apikeys don't get a bucket each
+func (l *RateLimiter) HandleAPIKey(ctx *gin.Context) {
+	if group := l.groupFor(ctx); group != nil {
+		if apikey := bearerToken(ctx); apikey != "" {
+			l.limit(ctx, group.byAPIKey, apikey)
+		}
+	}
+}
+
+// limit takes a token from the bucket associated to `key`, rejecting the request if none is available
+func (l *RateLimiter) limit(ctx *gin.Context, buckets *tokenBuckets, key string) {
+	if wait := buckets.take(key, l.clock()); wait > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ctx.AbortWithStatus(http.StatusTooManyRequests)
+	}
+}
+
+func (l *RateLimiter) groupFor(ctx *gin.Context) *limitGroup {
+	endpoint, _ := ctx.Get(EndpointKey)
+	asInt, ok := endpoint.(int)
+	if !ok {
+		return nil
+	}
+
+	switch asInt {
+	case storage.SplitChangesEndpoint, storage.SegmentChangesEndpoint, storage.MySegmentsEndpoint,
+		storage.EvaluateEndpoint, storage.EvaluateAllEndpoint:
+		return &l.sdk
+	case storage.ImpressionsBulkEndpoint, storage.ImpressionsBulkBeaconEndpoint,
+		storage.ImpressionsCountEndpoint, storage.ImpressionsCountBeaconEndpoint,
+		storage.EventsBulkEndpoint, storage.EventsBulkBeaconEndpoint,
+		storage.TelemetryConfigEndpoint, storage.TelemetryRuntimeEndpoint, storage.TelemetryRuntimeBeaconEndpoint,
+		storage.TelemetryKeysClientSideEndpoint, storage.TelemetryKeysClientSideBeaconEndpoint,
+		storage.TelemetryKeysServerSideEndpoint:
+		return &l.events
+	}
+	return nil
+}
+
+func newLimitGroup(rules *RateLimitRules) limitGroup {
+	return limitGroup{
+		byAPIKey: newTokenBuckets(rules.APIKeyPerMin, rules.APIKeyBurst),
+		byIP:     newTokenBuckets(rules.IPPerMin, rules.IPBurst),
+	}
+}
+
+// bearerToken returns the apikey sent in the authorization header, if any. Beacon requests carry it in the body,
+// so they're only limited by ip
+func bearerToken(ctx *gin.Context) string {
+	auth := strings.Split(ctx.Request.Header.Get("Authorization"), " ")
+	if len(auth) != 2 || auth[0] != "Bearer" {
+		return ""
+	}
+	return auth[1]
+}
+
+// tokenBuckets keeps one bucket per key, refilled at a constant rate up to `burst` tokens
+type tokenBuckets struct {
+	ratePerSec float64
+	burst      float64
+	buckets    map[string]*bucket
+	lastSweep  time.Time
+	mutex      sync.Mutex
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newTokenBuckets(perMin int64, burst int64) *tokenBuckets {
+	if perMin <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBuckets{
+		ratePerSec: float64(perMin) / 60,
+		burst:      float64(burst),
+		buckets:    make(map[string]*bucket),
+	}
+}
+
+// take consumes a token from the bucket associated to `key`. If none is available, it returns how long it will take
+// for the next one to be, otherwise 0
+func (t *tokenBuckets) take(key string, now time.Time) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.sweep(now)
+
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &bucket{tokens: t.burst}
+		t.buckets[key] = b
+	} else {
+		b.tokens = t.refilled(b, now)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / t.ratePerSec * float64(time.Second))
+}
+
+func (t *tokenBuckets) refilled(b *bucket, now time.Time) float64 {
+	return math.Min(t.burst, b.tokens+now.Sub(b.updated).Seconds()*t.ratePerSec)
+}
+
+// sweep discards buckets that are full again, since they're equivalent to a new one. Must be called with the lock held
+func (t *tokenBuckets) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < sweepPeriod {
+		return
+	}
+	t.lastSweep = now
+	for key, b := range t.buckets {
+		if t.refilled(b, now) >= t.burst {
+			delete(t.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+)
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(
+		RateLimitRules{APIKeyPerMin: 60, APIKeyBurst: 2},
+		RateLimitRules{IPPerMin: 30, IPBurst: 1},
+	)
+	limiter.clock = func() time.Time { return now }
+
+	tStorage := storage.NewProxyTelemetryFacade()
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.Use(SetEndpoint)
+	router.Use(NewProxyMetricsMiddleware(tStorage).Track)
+	router.Use(limiter.Handle)
+	router.Use(limiter.HandleAPIKey)
+	router.GET("/api/splitChanges", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.POST("/api/events/bulk", func(ctx *gin.Context) { ctx.Status(http.StatusAccepted) })
+	router.GET("/api/auth", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.GET("/api/evaluate", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	serve := func(method string, path string, apikey string, ip string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		if apikey != "" {
+			req.Header.Set("Authorization", "Bearer "+apikey)
+		}
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// sdk endpoints are limited per apikey only
+	for i := 0; i < 2; i++ {
+		if code := serve(http.MethodGet, "/api/splitChanges", "key1", "10.0.0.1").Code; code != 200 {
+			t.Error("request within burst should be accepted. Got: ", code)
+		}
+	}
+	resp := serve(http.MethodGet, "/api/splitChanges", "key1", "10.0.0.2")
+	if resp.Code != http.StatusTooManyRequests {
+		t.Error("request exceeding burst should be rejected. Got: ", resp.Code)
+	}
+	if ra := resp.Header().Get("Retry-After"); ra != "1" {
+		t.Error("retry-after should be 1 second. Got: ", ra)
+	}
+	if code := serve(http.MethodGet, "/api/splitChanges", "key2", "10.0.0.1").Code; code != 200 {
+		t.Error("other apikeys should not be affected. Got: ", code)
+	}
+
+	now = now.Add(time.Second)
+	if code := serve(http.MethodGet, "/api/splitChanges", "key1", "10.0.0.1").Code; code != 200 {
+		t.Error("bucket should have been refilled. Got: ", code)
+	}
+
+	// evaluations share the sdk limits
+	if code := serve(http.MethodGet, "/api/evaluate", "key4", "10.0.0.1").Code; code != 200 {
+		t.Error("first evaluation should be accepted. Got: ", code)
+	}
+	serve(http.MethodGet, "/api/evaluate", "key4", "10.0.0.1")
+	if code := serve(http.MethodGet, "/api/evaluate", "key4", "10.0.0.1").Code; code != http.StatusTooManyRequests {
+		t.Error("evaluations exceeding burst should be rejected. Got: ", code)
+	}
+
+	// events endpoints are limited per ip only
+	if code := serve(http.MethodPost, "/api/events/bulk", "key1", "10.0.0.1").Code; code != http.StatusAccepted {
+		t.Error("first events request should be accepted. Got: ", code)
+	}
+	resp = serve(http.MethodPost, "/api/events/bulk", "key3", "10.0.0.1")
+	if resp.Code != http.StatusTooManyRequests {
+		t.Error("second events request from same ip should be rejected. Got: ", resp.Code)
+	}
+	if ra := resp.Header().Get("Retry-After"); ra != "2" {
+		t.Error("retry-after should be 2 seconds. Got: ", ra)
+	}
+	if code := serve(http.MethodPost, "/api/events/bulk", "key1", "10.0.0.2").Code; code != http.StatusAccepted {
+		t.Error("other ips should not be affected. Got: ", code)
+	}
+
+	// other endpoints are never limited
+	for i := 0; i < 5; i++ {
+		if code := serve(http.MethodGet, "/api/auth", "key1", "10.0.0.1").Code; code != 200 {
+			t.Error("auth should not be limited. Got: ", code)
+		}
+	}
+
+	if limited := tStorage.PeekEndpointStatus(storage.SplitChangesEndpoint)[http.StatusTooManyRequests]; limited != 1 {
+		t.Error("one rate-limited splitChanges request should be recorded. Got: ", limited)
+	}
+	if limited := tStorage.PeekEndpointStatus(storage.EventsBulkEndpoint)[http.StatusTooManyRequests]; limited != 1 {
+		t.Error("one rate-limited events request should be recorded. Got: ", limited)
+	}
+}
+
+func TestRateLimiterKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewRateLimiter(
+		RateLimitRules{APIKeyPerMin: 60, APIKeyBurst: 1, IPPerMin: 60, IPBurst: 1},
+		RateLimitRules{},
+	)
+	validator := NewAPIKeyValidator([]string{"key1"}, nil)
+
+	_, router := gin.CreateTestContext(httptest.NewRecorder())
+	router.SetTrustedProxies(nil)
+	router.Use(SetEndpoint)
+	router.Use(limiter.Handle)
+	router.Use(validator.AsMiddleware)
+	router.Use(limiter.HandleAPIKey)
+	router.GET("/api/splitChanges", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	serve := func(apikey string, ip string, forwardedFor string) int {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/splitChanges", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("Authorization", "Bearer "+apikey)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// forwarding headers from untrusted peers don't allow picking a new bucket
+	if code := serve("key1", "10.0.0.1", "1.1.1.1"); code != http.StatusOK {
+		t.Error("first request should be accepted. Got: ", code)
+	}
+	if code := serve("key2", "10.0.0.1", "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Error("spoofed forwarding header should be ignored. Got: ", code)
+	}
+
+	// unknown apikeys are rejected before getting an apikey bucket
+	if code := serve("made-up", "10.0.0.2", ""); code != http.StatusUnauthorized {
+		t.Error("invalid apikeys should be rejected. Got: ", code)
+	}
+	if len(limiter.sdk.byAPIKey.buckets) != 1 {
+		t.Error("only the valid apikey should have a bucket. Got: ", len(limiter.sdk.byAPIKey.buckets))
+	}
+
+	// forwarding headers are honored when sent by a trusted proxy
+	router.SetTrustedProxies([]string{"10.0.0.0/8"})
+	limiter.sdk.byAPIKey = nil
+	if code := serve("key1", "10.0.0.1", "3.3.3.3"); code != http.StatusOK {
+		t.Error("client behind a trusted proxy should get its own bucket. Got: ", code)
+	}
+	if code := serve("key1", "10.0.0.1", "3.3.3.3"); code != http.StatusTooManyRequests {
+		t.Error("second request from the same client should be rejected. Got: ", code)
+	}
+}
+
+func TestTokenBucketsSweep(t *testing.T) {
+	buckets := newTokenBuckets(60, 5)
+	now := time.Unix(1700000000, 0)
+	buckets.take("a", now)
+	buckets.take("b", now)
+	if len(buckets.buckets) != 2 {
+		t.Error("there should be 2 buckets")
+	}
+
+	now = now.Add(2 * time.Second)
+	buckets.take("b", now)
+	buckets.take("b", now)
+	buckets.take("b", now)
+
+	now = now.Add(sweepPeriod - 2*time.Second + time.Millisecond)
+	buckets.take("c", now)
+	if _, ok := buckets.buckets["a"]; ok {
+		t.Error("refilled bucket should have been discarded")
+	}
+	if _, ok := buckets.buckets["c"]; !ok {
+		t.Error("new bucket should be present")
+	}
+
+	if newTokenBuckets(0, 10) != nil {
+		t.Error("a rate of 0 should disable the limit")
+	}
+}
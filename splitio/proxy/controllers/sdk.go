@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/splitio/go-split-commons/v6/dtos"
@@ -23,25 +24,28 @@ import (
 // SdkServerController bundles all request handler for sdk-server apis
 type SdkServerController struct {
 	logger              logging.LoggerInterface
-	fetcher             service.SplitFetcher
+	upstream            *splitChangesCollapser
 	proxySplitStorage   storage.ProxySplitStorage
 	proxySegmentStorage storage.ProxySegmentStorage
 	fsmatcher           flagsets.FlagSetMatcher
 	versionFilter       specs.SplitVersionFilter
 }
 
-// NewSdkServerController instantiates a new sdk server controller
+// NewSdkServerController instantiates a new sdk server controller.
+// Identical upstream fetches issued within `collapseTTL` are served from a single request,
+// and no more than `maxUpstreamFetches` requests are sent to the BE concurrently
 func NewSdkServerController(
 	logger logging.LoggerInterface,
 	fetcher service.SplitFetcher,
 	proxySplitStorage storage.ProxySplitStorage,
 	proxySegmentStorage storage.ProxySegmentStorage,
 	fsmatcher flagsets.FlagSetMatcher,
-
+	collapseTTL time.Duration,
+	maxUpstreamFetches int,
 ) *SdkServerController {
 	return &SdkServerController{
 		logger:              logger,
-		fetcher:             fetcher,
+		upstream:            newSplitChangesCollapser(fetcher, collapseTTL, maxUpstreamFetches),
 		proxySplitStorage:   proxySplitStorage,
 		proxySegmentStorage: proxySegmentStorage,
 		fsmatcher:           fsmatcher,
@@ -73,23 +77,44 @@ func (c *SdkServerController) SplitChanges(ctx *gin.Context) {
 		c.logger.Warning(fmt.Sprintf("SDK [%s] is sending flagsets unordered or with duplicates.", ctx.Request.Header.Get("SplitSDKVersion")))
 	}
 
+	// apikeys restricted to some flag sets only ever get those, regardless of what's requested
+	scope := ctx.GetStringSlice(caching.FlagSetScopeContextKey)
+	surrogates := []string{caching.SplitSurrogate, caching.MakeSurrogateForFlagSetScope(scope)}
+	if scope != nil {
+		sets = flagsets.Restrict(sets, scope)
+		if len(sets) == 0 {
+			// the current change number is returned so that SDKs consider themselves synced, with no flags
+			c.logger.Debug("none of the requested flag sets is accessible with the supplied apikey")
+			till, err := c.proxySplitStorage.ChangeNumber()
+			if err != nil || till < since {
+				till = since
+			}
+			ctx.Header("ETag", splitChangesETag(since, till, "", nil))
+			ctx.JSON(http.StatusOK, dtos.SplitChangesDTO{Since: since, Till: till, Splits: []dtos.SplitDTO{}})
+			ctx.Set(caching.SurrogateContextKey, surrogates)
+			return
+		}
+	}
+
 	c.logger.Debug(fmt.Sprintf("SDK Fetches Feature Flags Since: %d", since))
 
-	splits, err := c.fetchSplitChangesSince(since, sets)
+	spec, _ := ctx.GetQuery("s")
+	if spec != specs.FLAG_V1_1 {
+		spec = specs.FLAG_V1_0
+	}
+
+	splits, err := c.fetchSplitChangesSince(since, sets, spec)
 	if err != nil {
 		c.logger.Error("error fetching splitChanges payload from storage: ", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	spec, _ := ctx.GetQuery("s")
-	if spec != specs.FLAG_V1_1 {
-		spec = specs.FLAG_V1_0
-	}
 	splits.Splits = c.patchUnsupportedMatchers(splits.Splits, spec)
 
+	ctx.Header("ETag", splitChangesETag(since, splits.Till, spec, sets))
 	ctx.JSON(http.StatusOK, splits)
-	ctx.Set(caching.SurrogateContextKey, []string{caching.SplitSurrogate})
+	ctx.Set(caching.SurrogateContextKey, surrogates)
 	ctx.Set(caching.StickyContextKey, true)
 }
 
@@ -116,6 +141,7 @@ func (c *SdkServerController) SegmentChanges(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header("ETag", caching.MakeETag("segmentChanges", segmentName, strconv.FormatInt(since, 10), strconv.FormatInt(payload.Till, 10)))
 	ctx.JSON(http.StatusOK, payload)
 	ctx.Set(caching.SurrogateContextKey, []string{caching.MakeSurrogateForSegmentChanges(segmentName)})
 	ctx.Set(caching.StickyContextKey, true)
@@ -131,16 +157,25 @@ func (c *SdkServerController) MySegments(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{})
 	}
 
+	// segments are sorted so that the same memberships always produce the same body & ETag
+	slices.Sort(segmentList)
 	mySegments := make([]dtos.MySegmentDTO, 0, len(segmentList))
 	for _, segmentName := range segmentList {
 		mySegments = append(mySegments, dtos.MySegmentDTO{Name: segmentName})
 	}
 
+	ctx.Header("ETag", caching.MakeETag(append([]string{"mySegments", key}, segmentList...)...))
 	ctx.JSON(http.StatusOK, gin.H{"mySegments": mySegments})
 	ctx.Set(caching.SurrogateContextKey, caching.MakeSurrogateForMySegments(mySegments))
 }
 
-func (c *SdkServerController) fetchSplitChangesSince(since int64, sets []string) (*dtos.SplitChangesDTO, error) {
+// splitChangesETag builds the entity tag of a splitChanges response, which is determined by the requested range,
+// the spec (that determines how unsupported matchers are rendered) & the flag sets included
+func splitChangesETag(since int64, till int64, spec string, sets []string) string {
+	return caching.MakeETag("splitChanges", strconv.FormatInt(since, 10), strconv.FormatInt(till, 10), spec, strings.Join(sets, ","))
+}
+
+func (c *SdkServerController) fetchSplitChangesSince(since int64, sets []string, spec string) (*dtos.SplitChangesDTO, error) {
 	splits, err := c.proxySplitStorage.ChangesSince(since, sets)
 	if err == nil {
 		return splits, nil
@@ -149,10 +184,15 @@ func (c *SdkServerController) fetchSplitChangesSince(since int64, sets []string)
 		return nil, fmt.Errorf("unexpected error fetching feature flag changes from storage: %w", err)
 	}
 
-	// perform a fetch to the BE using the supplied `since`, have the storage process it's response &, retry
-	// TODO(mredolatti): implement basic collapsing here to avoid flooding the BE with requests
-	fetchOptions := service.MakeFlagRequestParams().WithChangeNumber(since).WithFlagSetsFilter(strings.Join(sets, ",")) // at this point the sets have been sanitized & sorted
-	return c.fetcher.Fetch(fetchOptions)
+	// perform a fetch to the BE using the supplied `since`. Concurrent requests with the same parameters are collapsed
+	// into a single upstream call to avoid flooding the BE when many SDKs start with an old `since`
+	splits, err = c.upstream.Fetch(since, sets, spec) // at this point the sets have been sanitized & sorted
+	if err != nil {
+		return nil, err
+	}
+
+	// local overrides must be delivered regardless of where the payload comes from, otherwise SDKs would skip them
+	return c.proxySplitStorage.ApplyOverrides(splits, sets), nil
 }
 
 func (c *SdkServerController) shouldOverrideSplitCondition(split *dtos.SplitDTO, version string) bool {
@@ -7,12 +7,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/splitio/go-split-commons/v6/dtos"
 	"github.com/splitio/go-split-commons/v6/engine/evaluator/impressionlabels"
 	"github.com/splitio/go-split-commons/v6/engine/grammar"
 	"github.com/splitio/go-split-commons/v6/engine/grammar/matchers"
+	commonFlagsets "github.com/splitio/go-split-commons/v6/flagsets"
 	"github.com/splitio/go-split-commons/v6/service"
 	"github.com/splitio/go-split-commons/v6/service/api/specs"
 	"github.com/splitio/go-toolkit/v5/logging"
@@ -20,8 +22,10 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 	psmocks "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 )
 
 func TestSplitChangesRecentSince(t *testing.T) {
@@ -44,6 +48,8 @@ func TestSplitChangesRecentSince(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(false, nil),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -78,9 +84,12 @@ func TestSplitChangesOlderSince(t *testing.T) {
 		Return((*dtos.SplitChangesDTO)(nil), storage.ErrSinceParamTooOld).
 		Once()
 
+	fetched := &dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{{Name: "s1", Status: "ACTIVE"}, {Name: "s2", Status: "ACTIVE"}}}
+	splitStorage.On("ApplyOverrides", fetched, []string(nil)).Return(fetched).Once()
+
 	var splitFetcher splitFetcherMock
 	splitFetcher.On("Fetch", ref(*service.MakeFlagRequestParams().WithChangeNumber(-1))).
-		Return(&dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{{Name: "s1", Status: "ACTIVE"}, {Name: "s2", Status: "ACTIVE"}}}, nil).
+		Return(fetched, nil).
 		Once()
 
 	resp := httptest.NewRecorder()
@@ -95,6 +104,8 @@ func TestSplitChangesOlderSince(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(false, nil),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -121,6 +132,44 @@ func TestSplitChangesOlderSince(t *testing.T) {
 	splitFetcher.AssertExpectations(t)
 }
 
+func TestSplitChangesOlderSinceWithOverrides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	splitStorage := storage.NewProxySplitStorage(dbw, logging.NewLogger(nil), commonFlagsets.NewFlagSetFilter(nil), false)
+	splitStorage.Update([]dtos.SplitDTO{{Name: "f1", ChangeNumber: 100, Status: "ACTIVE", DefaultTreatment: "on"}}, nil, 100)
+	splitStorage.SetOverrides(staticOverrides{
+		Active: map[string]overrides.Override{"f1": {Split: "f1", Treatment: "off", ChangeNumber: 101}},
+	})
+
+	// the sdk's since is older than the one the proxy started from, so the payload comes from split servers
+	var splitFetcher splitFetcherMock
+	splitFetcher.On("Fetch", ref(*service.MakeFlagRequestParams().WithChangeNumber(50))).
+		Return(&dtos.SplitChangesDTO{Since: 50, Till: 100, Splits: []dtos.SplitDTO{{Name: "f1", ChangeNumber: 100, Status: "ACTIVE", DefaultTreatment: "on"}}}, nil).
+		Once()
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	controller := NewSdkServerController(logging.NewLogger(nil), &splitFetcher, splitStorage, nil, flagsets.NewMatcher(false, nil), time.Second, 10)
+	controller.Register(router.Group("/api"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/splitChanges?since=50", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, 200, resp.Code)
+
+	var s dtos.SplitChangesDTO
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &s))
+	assert.Equal(t, int64(101), s.Till)
+	assert.Len(t, s.Splits, 1)
+	assert.Equal(t, "off", s.Splits[0].DefaultTreatment)
+	splitFetcher.AssertExpectations(t)
+}
+
+type staticOverrides overrides.State
+
+func (s staticOverrides) State() overrides.State { return overrides.State(s) }
+
 func TestSplitChangesOlderSinceFetchFails(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -146,6 +195,8 @@ func TestSplitChangesOlderSinceFetchFails(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(false, nil),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -184,6 +235,8 @@ func TestSplitChangesWithFlagSets(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(false, nil),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -231,6 +284,8 @@ func TestSplitChangesWithFlagSetsStrict(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(true, []string{"a", "c"}),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -296,6 +351,8 @@ func TestSplitChangesNewMatcherOldSpec(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(false, nil),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -364,6 +421,8 @@ func TestSplitChangesNewMatcherNewSpec(t *testing.T) {
 		&splitStorage,
 		nil,
 		flagsets.NewMatcher(false, nil),
+		time.Second,
+		10,
 	)
 	controller.Register(group)
 
@@ -414,7 +473,7 @@ func TestSegmentChanges(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil))
+	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil), time.Second, 10)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/segmentChanges/someSegment?since=-1", nil)
@@ -456,7 +515,7 @@ func TestSegmentChangesNotFound(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil))
+	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil), time.Second, 10)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/segmentChanges/someSegment?since=-1", nil)
@@ -488,7 +547,7 @@ func TestMySegments(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil))
+	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil), time.Second, 10)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/mySegments/someKey", nil)
@@ -529,7 +588,7 @@ func TestMySegmentsError(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil))
+	controller := NewSdkServerController(logger, &splitFetcher, &splitStorage, &segmentStorage, flagsets.NewMatcher(false, nil), time.Second, 10)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/mySegments/someKey", nil)
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
+)
+
+// StreamingServerController serves push notifications to SDKs over server-sent events
+type StreamingServerController struct {
+	logger      logging.LoggerInterface
+	issuer      streaming.TokenIssuer
+	broadcaster streaming.Broadcaster
+	keepAlive   time.Duration
+}
+
+// NewStreamingServerController constructs a new streaming controller
+func NewStreamingServerController(
+	logger logging.LoggerInterface,
+	issuer streaming.TokenIssuer,
+	broadcaster streaming.Broadcaster,
+	keepAlive time.Duration,
+) *StreamingServerController {
+	return &StreamingServerController{
+		logger:      logger,
+		issuer:      issuer,
+		broadcaster: broadcaster,
+		keepAlive:   keepAlive,
+	}
+}
+
+// Register mounts the streaming endpoint onto the supplied router
+func (c *StreamingServerController) Register(router gin.IRouter) {
+	router.GET("/sse", c.SSE)
+}
+
+// SSE validates the supplied token & channels and keeps the connection open, forwarding notifications until
+// the client disconnects or the token expires
+func (c *StreamingServerController) SSE(ctx *gin.Context) {
+	claims, err := c.issuer.Validate(ctx.Query("accessToken"))
+	if err != nil {
+		c.logger.Debug("rejecting streaming connection: ", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	channels, controlChannels, err := parseRequestedChannels(ctx.Query("channels"), claims)
+	if err != nil {
+		c.logger.Debug("rejecting streaming connection: ", err)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscriber := c.broadcaster.Subscribe(channels)
+	defer c.broadcaster.Unsubscribe(subscriber)
+
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+
+	// SDKs consider the connection established upon receiving the first event,
+	// and streaming is only used once the control channels report an active publisher
+	for _, channel := range controlChannels {
+		if !c.write(ctx, streaming.NewOccupancyMessage(channel)) {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(c.keepAlive)
+	defer keepAlive.Stop()
+	expiration := time.NewTimer(time.Until(time.Unix(claims.Exp, 0)))
+	defer expiration.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-subscriber.Done():
+			c.logger.Debug("closing streaming connection due to shutdown")
+			return
+		case <-expiration.C:
+			c.logger.Debug("closing streaming connection due to token expiration")
+			return
+		case <-keepAlive.C:
+			if _, err := ctx.Writer.WriteString(":keepalive\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case message := <-subscriber.Messages():
+			if !c.write(ctx, message) {
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+func (c *StreamingServerController) write(ctx *gin.Context, message *streaming.Message) bool {
+	encoded, err := message.Encode()
+	if err != nil {
+		c.logger.Error("error encoding streaming message: ", err)
+		return true // skip this message but keep the connection alive
+	}
+
+	if _, err := ctx.Writer.Write(encoded); err != nil {
+		c.logger.Debug("error writing to streaming connection: ", err)
+		return false
+	}
+	return true
+}
+
+var errChannelNotAllowed = errors.New("requested channel not allowed by token")
+
+// parseRequestedChannels strips the occupancy prefix from the requested channels, checks that all of them are granted
+// by the token, and returns the full list of channels to subscribe to, along with the control ones
+func parseRequestedChannels(raw string, claims *streaming.TokenClaims) ([]string, []string, error) {
+	granted, err := claims.Channels()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed := make(map[string]struct{}, len(granted))
+	for _, channel := range granted {
+		allowed[channel] = struct{}{}
+	}
+
+	var channels []string
+	var controlChannels []string
+	for _, channel := range strings.Split(raw, ",") {
+		if channel == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(channel, streaming.OccupancyPrefix)
+		if _, ok := allowed[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: %s", errChannelNotAllowed, name)
+		}
+
+		if name != channel {
+			controlChannels = append(controlChannels, name)
+		}
+		channels = append(channels, name)
+	}
+
+	return channels, controlChannels, nil
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
+)
+
+func TestAuthPushDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+
+	controller := NewAuthServerController(logging.NewLogger(nil), nil)
+	controller.Register(router.Group("/api"))
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/v2/auth", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, false, body["pushEnabled"])
+	assert.Equal(t, "", body["token"])
+}
+
+func TestAuthPushEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+
+	issuer := streaming.NewHMACTokenIssuer([]byte("secret"), time.Hour, "ns")
+	controller := NewAuthServerController(logging.NewLogger(nil), issuer)
+	controller.Register(router.Group("/api"))
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/v2/auth?users=key1", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, true, body["pushEnabled"])
+
+	claims, err := issuer.Validate(body["token"].(string))
+	assert.Nil(t, err)
+	channels, err := claims.Channels()
+	assert.Nil(t, err)
+	assert.Contains(t, channels, streaming.MySegmentsChannel("ns", "key1"))
+}
+
+func TestSSERejectsInvalidTokensAndChannels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(nil)
+	issuer := streaming.NewHMACTokenIssuer([]byte("secret"), time.Hour, "ns")
+	broadcaster := streaming.NewBroadcaster(logger, 10)
+
+	router := gin.New()
+	NewStreamingServerController(logger, issuer, broadcaster, time.Minute).Register(router)
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sse?accessToken=invalid&channels=ns_splits", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, 401, resp.Code)
+
+	token, _ := issuer.Issue(nil)
+	resp = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sse?accessToken="+token+"&channels=other_splits", nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, 401, resp.Code)
+	assert.Equal(t, 0, broadcaster.SubscriberCount())
+}
+
+func TestSSEForwardsNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(nil)
+	issuer := streaming.NewHMACTokenIssuer([]byte("secret"), time.Hour, "ns")
+	broadcaster := streaming.NewBroadcaster(logger, 10)
+
+	router := gin.New()
+	NewStreamingServerController(logger, issuer, broadcaster, time.Minute).Register(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	token, _ := issuer.Issue(nil)
+	channels := streaming.OccupancyPrefix + "control_pri," + streaming.SplitsChannel("ns")
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet,
+		server.URL+"/sse?accessToken="+token+"&channels="+url.QueryEscape(channels), nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() map[string]interface{} {
+		var data map[string]interface{}
+		for {
+			line, err := reader.ReadString('\n')
+			assert.Nil(t, err)
+			if line == "\n" {
+				return data
+			}
+			if strings.HasPrefix(line, "data: ") {
+				assert.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data))
+			}
+		}
+	}
+
+	occupancy := readEvent()
+	assert.Equal(t, streaming.OccupancyPrefix+"control_pri", occupancy["channel"])
+	assert.Equal(t, "[meta]occupancy", occupancy["name"])
+
+	for broadcaster.SubscriberCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	broadcaster.Publish(streaming.SplitsChannel("ns"), map[string]interface{}{"type": "SPLIT_UPDATE", "changeNumber": 123})
+
+	update := readEvent()
+	assert.Equal(t, streaming.SplitsChannel("ns"), update["channel"])
+	assert.Equal(t, `{"changeNumber":123,"type":"SPLIT_UPDATE"}`, update["data"])
+
+	cancel()
+	for broadcaster.SubscriberCount() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSSEConnectionsDoNotHoldShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(nil)
+	issuer := streaming.NewHMACTokenIssuer([]byte("secret"), time.Hour, "ns")
+	broadcaster := streaming.NewBroadcaster(logger, 10)
+
+	router := gin.New()
+	NewStreamingServerController(logger, issuer, broadcaster, time.Minute).Register(router)
+	server := httptest.NewUnstartedServer(router)
+	server.Config.RegisterOnShutdown(broadcaster.Close)
+	server.Start()
+	defer server.Close()
+
+	token, _ := issuer.Issue(nil)
+	resp, err := http.Get(server.URL + "/sse?accessToken=" + token + "&channels=" + url.QueryEscape(streaming.SplitsChannel("ns")))
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	for broadcaster.SubscriberCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	before := time.Now()
+	assert.Nil(t, server.Config.Shutdown(ctx))
+	assert.Less(t, time.Since(before), time.Second)
+	assert.Equal(t, 0, broadcaster.SubscriberCount())
+}
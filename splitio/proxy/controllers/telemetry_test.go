@@ -21,7 +21,7 @@ func TestPostConfig(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	controller := NewTelemetryServerController(
 		logger,
@@ -84,7 +84,7 @@ func TestPostRuntime(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	controller := NewTelemetryServerController(
 		logger,
@@ -152,7 +152,7 @@ func TestPostKeysClientSide(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	controller := NewTelemetryServerController(
 		logger,
@@ -236,7 +236,7 @@ func TestPostKeysServerSide(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	controller := NewTelemetryServerController(
 		logger,
@@ -320,7 +320,7 @@ func TestPostBeaconKeysClientSide(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewTelemetryServerController(
@@ -405,7 +405,7 @@ func TestPostUsageBeacon(t *testing.T) {
 	ctx, router := gin.CreateTestContext(resp)
 
 	logger := logging.NewLogger(nil)
-	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"})
+	apikeyValidator := middleware.NewAPIKeyValidator([]string{"someApiKey"}, nil)
 
 	group := router.Group("/api")
 	controller := NewTelemetryServerController(
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+)
+
+// maxBeaconBodySize matches the payload limit browsers enforce on navigator.sendBeacon. Beacon bodies are buffered
+// before authenticating the request, so anything bigger is rejected right away
+const maxBeaconBodySize = 64 << 10
+
+// environmentRouter forwards each request to the router of the environment the client apikey belongs to.
+// Requests with an unknown apikey or none at all (ie: sse connections) are handled by the default environment.
+// Apikeys are checked against the validator of each environment on every request, so that keys added or revoked
+// at runtime are routed accordingly.
+// Beacon requests carry the apikey in the body instead of the Authorization header, so it's extracted from there
+type environmentRouter struct {
+	fallback     http.Handler
+	environments []routedEnvironment
+}
+
+type routedEnvironment struct {
+	validator *middleware.APIKeyValidator
+	handler   http.Handler
+}
+
+func newEnvironmentRouter(fallback http.Handler, environments []routedEnvironment) *environmentRouter {
+	return &environmentRouter{fallback: fallback, environments: environments}
+}
+
+// ServeHTTP implements http.Handler
+func (r *environmentRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	apikey, err := apikeyFromRequest(w, req)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "beacon payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+
+	if apikey != "" {
+		for _, env := range r.environments {
+			if env.validator.IsValid(apikey) {
+				env.handler.ServeHTTP(w, req)
+				return
+			}
+		}
+	}
+	r.fallback.ServeHTTP(w, req)
+}
+
+func apikeyFromRequest(w http.ResponseWriter, req *http.Request) (string, error) {
+	if auth := strings.Split(req.Header.Get("Authorization"), " "); len(auth) == 2 && auth[0] == "Bearer" {
+		return auth[1], nil
+	}
+
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/beacon") || req.Body == nil {
+		return "", nil
+	}
+
+	// the body is read in full & replaced with an in-memory copy, so that the beacon handler can still parse it
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBeaconBodySize))
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	var beacon struct {
+		Token string `json:"token"`
+	}
+	if json.Unmarshal(body, &beacon) != nil {
+		return "", nil
+	}
+	return beacon.Token, nil
+}
@@ -46,3 +46,22 @@ func setContains(set map[string]struct{}, item string) bool {
 	_, ok := set[item]
 	return ok
 }
+
+// Restrict returns the subset of the requested flag sets that are also present in `allowed`, sorted.
+// If no flag sets are requested, all the allowed ones are returned
+func Restrict(requested []string, allowed []string) []string {
+	if len(requested) == 0 {
+		out := slices.Clone(allowed)
+		slices.Sort(out)
+		return out
+	}
+
+	out := make([]string, 0, len(requested))
+	for _, item := range requested {
+		if slices.Contains(allowed, item) && !slices.Contains(out, item) {
+			out = append(out, item)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
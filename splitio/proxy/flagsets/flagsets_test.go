@@ -19,3 +19,10 @@ func TestFlagSetsMatcher(t *testing.T) {
 	assert.Equal(t, []string{"s1", "s2"}, m.Sanitize([]string{"s1", "s2", "s7"}))
 	assert.Equal(t, []string{}, m.Sanitize([]string{"s4"}))
 }
+
+func TestRestrict(t *testing.T) {
+	assert.Equal(t, []string{"mobile", "web"}, Restrict(nil, []string{"web", "mobile"}))
+	assert.Equal(t, []string{"mobile"}, Restrict([]string{"mobile", "backend"}, []string{"web", "mobile"}))
+	assert.Equal(t, []string{"mobile", "web"}, Restrict([]string{"web", "mobile", "web"}, []string{"web", "mobile"}))
+	assert.Equal(t, []string{}, Restrict([]string{"backend"}, []string{"mobile"}))
+}
@@ -1,37 +1,56 @@
 package proxy
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"log"
 	"net/url"
-	"time"
-
+	"os"
+	"sort"
 	"strings"
+	gosync "sync"
+	"time"
 
 	"github.com/splitio/go-split-commons/v6/conf"
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/engine"
+	"github.com/splitio/go-split-commons/v6/engine/evaluator"
 	"github.com/splitio/go-split-commons/v6/flagsets"
 	"github.com/splitio/go-split-commons/v6/service/api"
 	"github.com/splitio/go-split-commons/v6/synchronizer"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/segment"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
 	"github.com/splitio/go-split-commons/v6/tasks"
 	"github.com/splitio/go-split-commons/v6/telemetry"
 	"github.com/splitio/go-toolkit/v5/backoff"
 	"github.com/splitio/go-toolkit/v5/logging"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/exp/slices"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/admin"
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
 	ssync "github.com/splitio/split-synchronizer/v5/splitio/common/sync"
 	hcApplication "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
 	hcAppCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application/counter"
 	hcServices "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services"
 	hcServicesCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services/counter"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
 	pconf "github.com/splitio/split-synchronizer/v5/splitio/proxy/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
 	pTasks "github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 	"github.com/splitio/split-synchronizer/v5/splitio/util"
 )
@@ -39,98 +58,559 @@ import (
 // Start initialize in proxy mode
 func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 
-	clientKey, err := util.GetClientKey(cfg.Apikey)
-	if err != nil {
-		return common.NewInitError(fmt.Errorf("error parsing client key from provided apikey: %w", err), common.ExitInvalidApikey)
+	if err := validateEnvironments(cfg); err != nil {
+		return common.NewInitError(err, common.ExitInvalidConfiguration)
 	}
 
 	// Initialization of DB
-	var dbpath = persistent.BoltInMemoryMode
-	if snapFile := cfg.Initialization.Snapshot; snapFile != "" {
-		snap, err := snapshot.DecodeFromFile(snapFile)
+	dbpath, warmStart, err := setupDBPath(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	dbInstance, err := persistent.NewBoltWrapper(dbpath, &bolt.Options{Timeout: dbOpenTimeout})
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error instantiating boltdb: %w", err), common.ExitErrorDB)
+	}
+
+	var snapshotSigningKey ed25519.PrivateKey
+	if keyFile := cfg.SnapshotSigning.PrivateKeyFN; keyFile != "" {
+		if snapshotSigningKey, err = snapshot.LoadSigningKey(keyFile); err != nil {
+			return common.NewInitError(fmt.Errorf("error loading snapshot signing key: %w", err), common.ExitInvalidConfiguration)
+		}
+	}
+
+	// Getting initial config data
+	advanced := cfg.BuildAdvancedConfig()
+	metadata := util.GetMetadata(cfg.IPAddressEnabled, true)
+
+	// Healcheck Monitor
+	splitsConfig, segmentsConfig := getAppCounterConfigs()
+	appMonitor := hcApplication.NewMonitorImp(splitsConfig, segmentsConfig, nil, logger)
+	servicesMonitor := hcServices.NewMonitorImp(getServicesCountersConfig(*advanced), logger)
+
+	// On-disk spools for data that doesn't fit in memory (nil if disabled)
+	spoolDB, err := openSpoolDB(&cfg.Storage.Spool)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up spool: %w", err), common.ExitErrorDB)
+	}
+
+	// Administrative changes (client apikeys & flag overrides) are recorded in an audit log
+	auditLog, err := audit.NewLog(logger, int(cfg.AuditLog.MaxEntries), cfg.AuditLog.Filename)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up audit log: %w", err), common.ExitTaskInitialization)
+	}
+
+	retryCfg := &cfg.Sync.Retry
+	retryPolicy := retry.NewPolicy(retryCfg.MaxAttempts, retryCfg.InitialBackoffMs, retryCfg.MaxBackoffMs, retryCfg.RetryableStatusCodes, logger)
+	deps := &environmentDeps{
+		db:          dbInstance,
+		warmStart:   warmStart,
+		spoolDB:     spoolDB,
+		retryPolicy: retryPolicy,
+		metadata:    metadata,
+		appMonitor:  appMonitor,
+		auditLog:    auditLog,
+	}
+
+	// Updates are counted so that snapshots can also be saved after a number of them, besides periodically
+	if cfg.Storage.Snapshots.Dir != "" {
+		deps.snapshotChanges = &snapshots.ChangeCounter{}
+	}
+
+	// Push notifications served by the proxy itself. The secret & broadcaster are shared by all environments,
+	// since channel names are already namespaced with each environment's SDK key
+	if scfg := cfg.Server.Streaming; scfg.Enabled {
+		deps.pushSecret, err = pushTokenSecret(scfg.TokenSecret)
 		if err != nil {
-			return fmt.Errorf("error parsing snapshot file: %w", err)
+			return common.NewInitError(fmt.Errorf("error setting up push token secret: %w", err), common.ExitTaskInitialization)
 		}
+		deps.pushBroadcaster = streaming.NewBroadcaster(logger, int(scfg.SubscriberBufferSize))
+	}
+
+	primary, err := setupEnvironment(logger, cfg, &pconf.Environment{
+		Apikey:               cfg.Apikey,
+		ClientApikeys:        cfg.Server.ClientApikeys,
+		ClientApikeyFlagSets: cfg.Server.ClientApikeyFlagSets,
+		FlagSetsFilter:       cfg.FlagSetsFilter,
+		FlagOverridesFile:    cfg.Server.FlagOverridesFile,
+	}, deps)
+	if err != nil {
+		return err
+	}
 
-		dbpath, err = snap.WriteDataToTmpFile()
+	extra := make([]*environment, 0, len(cfg.Environments))
+	for idx := range cfg.Environments {
+		env, err := setupEnvironment(logger, cfg, &cfg.Environments[idx], deps)
 		if err != nil {
-			return fmt.Errorf("error writing temporary snapshot file: %w", err)
+			return err
 		}
+		extra = append(extra, env)
+	}
 
-		logger.Debug("Database created from snapshot at", dbpath)
+	snapshotBuilder := snapshot.NewBuilder(dbInstance, snapshotSigningKey, describeSnapshot(cfg, append([]*environment{primary}, extra...), logger))
+	snapshotVerificationKey, err := snapshotVerificationKey(cfg)
+	if err != nil {
+		return err
+	}
+	snapshotLoader := snapshots.NewLoader(snapshotTargets(append([]*environment{primary}, extra...)), snapshotVerificationKey, auditLog, logger)
+	var snapshotScheduler *snapshots.Scheduler
+	if scfg := cfg.Storage.Snapshots; scfg.Dir != "" {
+		writer, err := snapshots.NewWriter(scfg.Dir, snapshotBuilder, int(scfg.MaxCount), time.Duration(scfg.MaxAgeSecs)*time.Second, logger)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error setting up scheduled snapshots: %w", err), common.ExitTaskInitialization)
+		}
+		snapshotScheduler = snapshots.NewScheduler(writer, deps.snapshotChanges, time.Duration(scfg.PeriodSecs)*time.Second, scfg.AfterChanges, logger)
 	}
 
-	dbInstance, err := persistent.NewBoltWrapper(dbpath, nil)
+	// Try to start bg sync in BG with unlimited retries (when data was restored from a snapshot or a previous run),
+	// the passed function is invoked upon initialization completion
+	// If no data was restored and init fails, `errUnrecoverable` is returned and application execution is aborted
+	// health monitors are only started after successful init (otherwise they'll fail if the app doesn't sync correctly within the
+	/// specified refresh period)
+	err = primary.startSync(logger, cfg, func() {
+		logger.Info("Synchronizer tasks started")
+		appMonitor.Start()
+		servicesMonitor.Start()
+		// scheduled snapshots are only saved once there's data to save
+		if snapshotScheduler != nil {
+			snapshotScheduler.Start()
+		}
+	})
 	if err != nil {
-		return common.NewInitError(fmt.Errorf("error instantiating boltdb: %w", err), common.ExitErrorDB)
+		return err
+	}
+
+	managers := syncManagers{primary.syncManager}
+	for _, env := range extra {
+		err := env.startSync(logger, cfg, func() {
+			logger.Info(fmt.Sprintf("Synchronizer tasks started for environment '%s'", env.name))
+		})
+		if err != nil {
+			return err
+		}
+		managers = append(managers, env.syncManager)
+	}
+
+	rtm := common.NewRuntime(false, managers, logger, "Split Proxy", nil, nil, appMonitor, servicesMonitor)
+	storages := adminCommon.Storages{
+		SplitStorage:          primary.splitStorage,
+		SegmentStorage:        primary.segmentStorage,
+		LocalTelemetryStorage: primary.localTelemetry,
+	}
+
+	// Client apikeys can be managed at runtime through the admin API (& an optional file for the default environment).
+	// Keys belonging to other environments are rejected, so that a client cannot be routed to two of them
+	all := append([]*environment{primary}, extra...)
+	apikeyRegistries := make(map[string]*apikeys.RegistryImpl, len(all))
+	for _, env := range all {
+		others := make([]*middleware.APIKeyValidator, 0, len(all)-1)
+		for _, other := range all {
+			if other != env {
+				others = append(others, other.apikeyValidator)
+			}
+		}
+		apikeyRegistries[env.name] = apikeys.NewRegistry(env.apikeyValidator, env.httpCache, env.auditLog, others, logger)
+	}
+	apikeyRegistry := apikeyRegistries[primary.name]
+	var apikeysWatcher *apikeys.FileWatcher
+	if path := cfg.Server.ClientApikeysFile; path != "" {
+		watcher := apikeys.NewFileWatcher(path, int(cfg.Server.ClientApikeysPollSecs), apikeyRegistry, logger)
+		if _, err := watcher.Reload(); err != nil {
+			return common.NewInitError(fmt.Errorf("error loading client apikeys file: %w", err), common.ExitInvalidConfiguration)
+		}
+		watcher.Start()
+		apikeysWatcher = watcher
+	}
+
+	// --------------------------- ADMIN DASHBOARD ------------------------------
+	cfgForAdmin := *cfg
+	cfgForAdmin.Apikey = logging.ObfuscateAPIKey(cfgForAdmin.Apikey)
+	cfgForAdmin.Environments = make([]pconf.Environment, len(cfg.Environments))
+	for idx, env := range cfg.Environments {
+		env.Apikey = logging.ObfuscateAPIKey(env.Apikey)
+		cfgForAdmin.Environments[idx] = env
+	}
+
+	adminTLSConfig, err := util.TLSConfigForServer(&cfg.Admin.TLS)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
+	}
+
+	adminServer, err := admin.NewServer(&admin.Options{
+		Host:              cfg.Admin.Host,
+		Port:              int(cfg.Admin.Port),
+		Name:              "Split Proxy dashboard",
+		Proxy:             true,
+		Username:          cfg.Admin.Username,
+		Password:          cfg.Admin.Password,
+		Logger:            logger,
+		Storages:          storages,
+		Runtime:           rtm,
+		Snapshots:         snapshotBuilder,
+		SnapshotLoader:    snapshotLoader,
+		HcAppMonitor:      appMonitor,
+		HcServicesMonitor: servicesMonitor,
+		FullConfig:        cfgForAdmin,
+		TLS:               adminTLSConfig,
+		FlagSpecVersion:   cfg.FlagSpecVersion,
+		Spools:            allSpools(all),
+		DeadLetters:       primary.deadLetters,
+		DeadLetterSinks:   primary.sinks,
+		APIKeys:           apikeyRegistry,
+		AuditLog:          auditLog,
+		HTTPCache:         primary.httpCache,
+		FlagOverrides:     primary.overrides,
+		KillSwitch:        overrides.NewKillSwitch(primary.overrides, primary.splitStorage.Split),
+		Environments:      adminEnvironments(extra, apikeyRegistries),
+	})
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error starting admin server: %w", err), common.ExitAdminError)
+	}
+	go adminServer.Start()
+
+	tlsConfig, err := util.TLSConfigForServer(&cfg.Server.TLS)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
+	}
+
+	proxyOptions := primary.proxyOptions(logger, cfg)
+	proxyOptions.Host = cfg.Server.Host
+	proxyOptions.Port = int(cfg.Server.Port)
+	proxyOptions.TLSConfig = tlsConfig
+	proxyOptions.PushBroadcaster = deps.pushBroadcaster
+	proxyOptions.PushKeepAlive = time.Duration(cfg.Server.Streaming.KeepAliveSecs) * time.Second
+	proxyOptions.RateLimiter = middleware.NewRateLimiter(
+		middleware.RateLimitRules(cfg.Server.RateLimit.SDK),
+		middleware.RateLimitRules(cfg.Server.RateLimit.Events),
+	)
+	proxyOptions.TrustedProxies = slices.DeleteFunc(slices.Clone(cfg.Server.TrustedProxies), func(p string) bool { return strings.TrimSpace(p) == "" })
+
+	if ilcfg := cfg.Integrations.ImpressionListener; ilcfg.Endpoint != "" {
+		var err error
+		proxyOptions.ImpressionListener, err = impressionlistener.NewImpressionBulkListener(ilcfg.Endpoint, int(ilcfg.QueueSize), nil)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error instantiating impression listener: %w", err), common.ExitTaskInitialization)
+		}
+		proxyOptions.ImpressionListener.Start()
+	}
+
+	for _, env := range extra {
+		envOptions := env.proxyOptions(logger, cfg)
+		envOptions.ImpressionListener = proxyOptions.ImpressionListener
+		envOptions.PushBroadcaster = proxyOptions.PushBroadcaster
+		envOptions.PushKeepAlive = proxyOptions.PushKeepAlive
+		envOptions.RateLimiter = proxyOptions.RateLimiter
+		envOptions.TrustedProxies = proxyOptions.TrustedProxies
+		proxyOptions.Environments = append(proxyOptions.Environments, envOptions)
+	}
+
+	proxyAPI := New(proxyOptions)
+	go proxyAPI.Start()
+
+	// Upon shutdown, stop taking requests & give queued data a chance to be posted before the sync is stopped
+	rtm.RegisterShutdownHook(func() {
+		// every phase gets a slice of a single budget, so that the whole shutdown fits in it. In-flight requests get
+		// up to half of it so that slow ones don't eat up the time needed to post queued data, and a tenth is kept
+		// for stopping the admin server & the synchronization afterwards
+		timeout := time.Duration(cfg.ShutdownTimeoutMs) * time.Millisecond
+		deadline := time.Now().Add(timeout)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline.Add(-timeout/2))
+		defer cancel()
+
+		logger.Info(" * Stopping proxy server")
+		if err := proxyAPI.Stop(ctx); err != nil {
+			logger.Error("error waiting for in-flight proxy requests to complete: ", err)
+		}
+		if apikeysWatcher != nil {
+			apikeysWatcher.Stop()
+		}
+		if snapshotScheduler != nil {
+			snapshotScheduler.Stop()
+		}
+		for _, env := range append([]*environment{primary}, extra...) {
+			env.overrides.Stop()
+		}
+
+		logger.Info(" * Flushing queued impressions, events & telemetry")
+		drainSinks(logger, append([]*environment{primary}, extra...), deadline.Add(-timeout/10))
+		if proxyOptions.ImpressionListener != nil {
+			proxyOptions.ImpressionListener.Stop(true)
+		}
+
+		logger.Info(" * Stopping admin server")
+		adminCtx, adminCancel := context.WithDeadline(context.Background(), deadline)
+		defer adminCancel()
+		if err := adminServer.Stop(adminCtx); err != nil {
+			logger.Error("error waiting for in-flight admin requests to complete: ", err)
+		}
+	})
+
+	rtm.RegisterShutdownHandler()
+	rtm.Block()
+	return nil
+}
+
+// drainSinks flushes the forwarding tasks of all environments concurrently until the deadline,
+// and reports whatever could not be posted
+func drainSinks(logger logging.LoggerInterface, envs []*environment, deadline time.Time) {
+	type outcome struct {
+		name   string
+		result pTasks.DrainResult
 	}
 
+	var wg gosync.WaitGroup
+	var mutex gosync.Mutex
+	var outcomes []outcome
+	for _, env := range envs {
+		for kind, sink := range env.sinks {
+			wg.Add(1)
+			go func(name string, sink pTasks.DeferredRecordingTask) {
+				defer wg.Done()
+				result := sink.Drain(deadline)
+				mutex.Lock()
+				outcomes = append(outcomes, outcome{name: name, result: result})
+				mutex.Unlock()
+			}(kind+env.describe(), sink)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].name < outcomes[j].name })
+	var dropped, spooled int
+	for _, o := range outcomes {
+		dropped += o.result.Dropped
+		spooled += o.result.Spooled
+		if o.result.Pending > 0 || o.result.Spooled > 0 || o.result.Dropped > 0 {
+			logger.Info(fmt.Sprintf("   - %s: %d pending, %d left in spool, %d dropped",
+				o.name, o.result.Pending, o.result.Spooled, o.result.Dropped))
+		}
+		if o.result.WorkersTimedOut {
+			logger.Warning(fmt.Sprintf("   - %s: some posts were still in progress when the shutdown deadline was reached", o.name))
+		}
+	}
+
+	if dropped > 0 {
+		logger.Error(fmt.Sprintf("%d bulks of impressions, events & telemetry were dropped upon shutdown", dropped))
+	}
+	if spooled > 0 {
+		logger.Warning(fmt.Sprintf("%d bulks were left in the on-disk spool", spooled))
+	}
+}
+
+// environmentDeps bundles the components shared by all the environments served by this proxy
+type environmentDeps struct {
+	db              persistent.DBWrapper
+	warmStart       bool
+	spoolDB         *persistent.BoltDBWrapper
+	retryPolicy     *retry.Policy
+	metadata        dtos.Metadata
+	appMonitor      *hcApplication.MonitorImp
+	pushSecret      []byte
+	pushBroadcaster *streaming.BroadcasterImpl
+	auditLog        audit.Log
+	snapshotChanges *snapshots.ChangeCounter
+}
+
+// environment bundles the components used to synchronize & serve data for a single SDK key
+type environment struct {
+	name              string
+	apikey            string
+	clientApikeys     []string
+	apikeyValidator   *middleware.APIKeyValidator
+	flagSetsFilter    []string
+	auditLog          audit.Log
+	db                persistent.DBWrapper
+	advanced          *conf.AdvancedConfig
+	splitAPI          *api.SplitAPI
+	splitStorage      *storage.ProxySplitStorageImpl
+	segmentStorage    *storage.ProxySegmentStorageImpl
+	localTelemetry    *storage.TimeslicedProxyEndpointTelemetryImpl
+	httpCache         *caching.Middleware
+	overrides         *overrides.StoreImpl
+	spools            map[string]pTasks.Spool
+	deadLetters       pTasks.DeadLetterStore
+	sinks             map[string]pTasks.DeferredRecordingTask
+	telemetryRecorder telemetry.TelemetrySynchronizer
+	pushIssuer        streaming.TokenIssuer
+	syncManager       synchronizer.Manager
+	mstatus           chan int
+	warmStart         bool
+}
+
+// setupEnvironment builds the storages, caches, forwarding tasks & sync manager for an environment.
+// Data of the default (unnamed) environment is stored in the same buckets used before multi-environment support,
+// whereas additional environments use buckets prefixed with their name
+func setupEnvironment(
+	logger logging.LoggerInterface,
+	cfg *pconf.Main,
+	envCfg *pconf.Environment,
+	deps *environmentDeps,
+) (*environment, error) {
+	clientKey, err := util.GetClientKey(envCfg.Apikey)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error parsing client key from provided apikey: %w", err), common.ExitInvalidApikey)
+	}
+
+	clientFlagSets, err := parseAPIKeyFlagSets(envCfg.ClientApikeyFlagSets, envCfg.ClientApikeys)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error parsing client apikey flag sets: %w", err), common.ExitInvalidConfiguration)
+	}
+
+	env := &environment{
+		name:            envCfg.Name,
+		apikey:          envCfg.Apikey,
+		clientApikeys:   envCfg.ClientApikeys,
+		apikeyValidator: middleware.NewAPIKeyValidator(envCfg.ClientApikeys, clientFlagSets),
+		flagSetsFilter:  envCfg.FlagSetsFilter,
+		warmStart:       deps.warmStart,
+		mstatus:         make(chan int, 1),
+	}
+
+	env.db = deps.db
+	env.auditLog = deps.auditLog
+	spoolPrefix := ""
+	if env.name != "" {
+		env.db = persistent.NewNamespacedDB(deps.db, env.name)
+		env.auditLog = audit.WithDetails(deps.auditLog, map[string]string{"environment": env.name})
+		spoolPrefix = env.name + "_"
+	}
+	db := env.db
+
 	// Set up the http proxy caching.
-	// We need it fairly early since it's passed to the synchronizers, so that they can evict entries when a change is processed
-	httpCache := caching.MakeProxyCache()
+	// We need it fairly early since it's passed to the synchronizers, so that they can evict entries when a change is processed.
+	// The configured size is split evenly across environments, so that adding environments doesn't multiply the memory used
+	envCount := int64(len(cfg.Environments) + 1)
+	env.httpCache = caching.MakeProxyCache(cacheShare(cfg.Server.CacheSize, envCount), cacheShare(cfg.Server.CacheMaxBytes, envCount))
 
-	// Getting initial config data
-	advanced := cfg.BuildAdvancedConfig()
-	advanced.FlagSetsFilter = cfg.FlagSetsFilter
-	advanced.AuthSpecVersion = cfg.FlagSpecVersion
-	advanced.FlagsSpecVersion = cfg.FlagSpecVersion
-	metadata := util.GetMetadata(cfg.IPAddressEnabled, true)
+	env.advanced = cfg.BuildAdvancedConfig()
+	env.advanced.FlagSetsFilter = env.flagSetsFilter
+	env.advanced.AuthSpecVersion = cfg.FlagSpecVersion
+	env.advanced.FlagsSpecVersion = cfg.FlagSpecVersion
+	advanced := *env.advanced
 
 	// FlagSetsFilter
-	flagSetsFilter := flagsets.NewFlagSetFilter(cfg.FlagSetsFilter)
+	flagSetsFilter := flagsets.NewFlagSetFilter(env.flagSetsFilter)
 
 	// Setup fetchers & recorders
-	splitAPI := api.NewSplitAPI(cfg.Apikey, *advanced, logger, metadata)
+	env.splitAPI = api.NewSplitAPI(env.apikey, advanced, logger, deps.metadata)
 
 	// Proxy storages already implement the observable interface, so no need to wrap them
-	splitStorage := storage.NewProxySplitStorage(dbInstance, logger, flagsets.NewFlagSetFilter(cfg.FlagSetsFilter), cfg.Initialization.Snapshot != "")
-	segmentStorage := storage.NewProxySegmentStorage(dbInstance, logger, cfg.Initialization.Snapshot != "")
+	env.splitStorage = storage.NewProxySplitStorage(db, logger, flagsets.NewFlagSetFilter(env.flagSetsFilter), deps.warmStart)
+	env.segmentStorage = storage.NewProxySegmentStorage(db, logger, deps.warmStart,
+		time.Duration(cfg.Storage.Volatile.SegmentHistoryRetentionSecs)*time.Second)
 
 	// Local telemetry
 	tbufferSize := int(cfg.Sync.Advanced.TelemetryBuffer)
 	tworkers := int(cfg.Sync.Advanced.TelemetryWorkers)
 
-	localTelemetryStorage := storage.NewTimeslicedProxyEndpointTelemetry(
+	env.localTelemetry = storage.NewTimeslicedProxyEndpointTelemetry(
 		storage.NewProxyTelemetryFacade(),
 		cfg.Observability.TimeSliceWidthSecs,
 		int(cfg.Observability.MaxTimeSliceCount),
 	)
 
-	// Healcheck Monitor
-	splitsConfig, segmentsConfig := getAppCounterConfigs()
-	appMonitor := hcApplication.NewMonitorImp(splitsConfig, segmentsConfig, nil, logger)
-	servicesMonitor := hcServices.NewMonitorImp(getServicesCountersConfig(*advanced), logger)
+	env.spools, err = setupSpools(deps.spoolDB, spoolPrefix, cfg.Storage.Spool.MaxBytes, logger)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error setting up spool: %w", err), common.ExitErrorDB)
+	}
+
+	// Retries & dead letters for data that cannot be posted to Split servers
+	retryPolicy := deps.retryPolicy
+	env.deadLetters, err = setupDeadLetters(deps.spoolDB, spoolPrefix, int(cfg.Sync.Retry.DeadLetterMaxItems), logger)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error setting up dead letters: %w", err), common.ExitErrorDB)
+	}
+	deadLetters := env.deadLetters
 
 	// Creating Workers and Tasks
-	telemetryRecorder := api.NewHTTPTelemetryRecorder(cfg.Apikey, *advanced, logger)
-	telemetryConfigTask := pTasks.NewTelemetryConfigFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
-	telemetryUsageTask := pTasks.NewTelemetryUsageFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
-	telemetryKeysClientSideTask := pTasks.NewTelemetryKeysClientSideFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
-	telemetryKeysServerSideTask := pTasks.NewTelemetryKeysServerSideFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
+	telemetryRecorder := api.NewHTTPTelemetryRecorder(env.apikey, advanced, logger)
+	telemetryConfigTask := pTasks.NewTelemetryConfigFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers,
+		env.spools[pTasks.KindTelemetryConfig], retryPolicy, deadLetters)
+	telemetryUsageTask := pTasks.NewTelemetryUsageFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers,
+		env.spools[pTasks.KindTelemetryUsage], retryPolicy, deadLetters)
+	telemetryKeysClientSideTask := pTasks.NewTelemetryKeysClientSideFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers,
+		env.spools[pTasks.KindTelemetryKeysClientSide], retryPolicy, deadLetters)
+	telemetryKeysServerSideTask := pTasks.NewTelemetryKeysServerSideFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers,
+		env.spools[pTasks.KindTelemetryKeysServerSide], retryPolicy, deadLetters)
 
 	// impression bulks & counts - events
 	ibufferSize := int(cfg.Sync.Advanced.ImpressionsBuffer)
 	iworkers := int(cfg.Sync.Advanced.ImpressionsWorkers)
-	impressionRecorder := api.NewHTTPImpressionRecorder(cfg.Apikey, *advanced, logger)
-	impressionTask := pTasks.NewImpressionsFlushTask(impressionRecorder, logger, 1, ibufferSize, iworkers)
-	impressionCountTask := pTasks.NewImpressionCountFlushTask(impressionRecorder, logger, 1, ibufferSize, iworkers)
-	eventsRecorder := api.NewHTTPEventsRecorder(cfg.Apikey, *advanced, logger)
-	eventsTask := pTasks.NewEventsFlushTask(eventsRecorder, logger, 1, int(cfg.Sync.Advanced.EventsBuffer), int(cfg.Sync.Advanced.EventsWorkers))
+	impressionRecorder := api.NewHTTPImpressionRecorder(env.apikey, advanced, logger)
+	impressionTask := pTasks.NewImpressionsFlushTask(impressionRecorder, logger, 1, ibufferSize, iworkers,
+		env.spools[pTasks.KindImpressions], retryPolicy, deadLetters)
+	impressionCountTask := pTasks.NewImpressionCountFlushTask(impressionRecorder, logger, 1, ibufferSize, iworkers,
+		env.spools[pTasks.KindImpressionCounts], retryPolicy, deadLetters)
+	eventsRecorder := api.NewHTTPEventsRecorder(env.apikey, advanced, logger)
+	eventsTask := pTasks.NewEventsFlushTask(eventsRecorder, logger, 1, int(cfg.Sync.Advanced.EventsBuffer), int(cfg.Sync.Advanced.EventsWorkers),
+		env.spools[pTasks.KindEvents], retryPolicy, deadLetters)
+
+	env.sinks = map[string]pTasks.DeferredRecordingTask{
+		pTasks.KindImpressions:             impressionTask,
+		pTasks.KindImpressionCounts:        impressionCountTask,
+		pTasks.KindEvents:                  eventsTask,
+		pTasks.KindTelemetryConfig:         telemetryConfigTask,
+		pTasks.KindTelemetryUsage:          telemetryUsageTask,
+		pTasks.KindTelemetryKeysClientSide: telemetryKeysClientSideTask,
+		pTasks.KindTelemetryKeysServerSide: telemetryKeysServerSideTask,
+	}
 
 	// setup feature flags, segments & local telemetry API interactions
+	var splitUpdater split.Updater = caching.NewCacheAwareSplitSync(env.splitStorage, env.splitAPI.SplitFetcher, logger, env.localTelemetry,
+		env.httpCache, deps.appMonitor, flagSetsFilter)
+	var segmentUpdater segment.Updater = caching.NewCacheAwareSegmentSync(env.splitStorage, env.segmentStorage, env.splitAPI.SegmentFetcher,
+		logger, env.localTelemetry, env.httpCache, deps.appMonitor)
+
+	// Updaters are wrapped so that SDKs are notified after the cache is evicted
+	if deps.pushBroadcaster != nil {
+		namespace := streaming.MakeNamespace(env.apikey)
+		env.pushIssuer = streaming.NewHMACTokenIssuer(deps.pushSecret, time.Duration(cfg.Server.Streaming.TokenTTLSecs)*time.Second, namespace)
+		splitUpdater = streaming.NewNotifyingSplitUpdater(splitUpdater, env.splitStorage, deps.pushBroadcaster, namespace)
+		segmentUpdater = streaming.NewNotifyingSegmentUpdater(segmentUpdater, env.splitStorage, env.segmentStorage, deps.pushBroadcaster, namespace)
+	}
+	if deps.snapshotChanges != nil {
+		splitUpdater = snapshots.NewCountingSplitUpdater(splitUpdater, deps.snapshotChanges)
+		segmentUpdater = snapshots.NewCountingSegmentUpdater(segmentUpdater, deps.snapshotChanges)
+	}
+
+	// Local overrides are merged into the feature flags served to SDKs, which are notified whenever they change
+	env.overrides, err = overrides.NewStore(
+		envCfg.FlagOverridesFile,
+		func() int64 { cn, _ := env.splitStorage.ChangeNumber(); return cn },
+		func(split string) (int64, bool) {
+			if definition := env.splitStorage.Split(split); definition != nil {
+				return definition.ChangeNumber, true
+			}
+			return 0, false
+		},
+		func(changeNumber int64) {
+			env.httpCache.EvictBySurrogate(caching.SplitSurrogate)
+			if deps.pushBroadcaster != nil {
+				streaming.NotifySplitChange(deps.pushBroadcaster, streaming.MakeNamespace(env.apikey), changeNumber)
+			}
+		},
+		env.auditLog,
+		logger,
+	)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error setting up flag overrides%s: %w", env.describe(), err), common.ExitInvalidConfiguration)
+	}
+	env.splitStorage.SetOverrides(env.overrides)
+
 	workers := synchronizer.Workers{
-		SplitUpdater: caching.NewCacheAwareSplitSync(splitStorage, splitAPI.SplitFetcher, logger, localTelemetryStorage, httpCache, appMonitor, flagSetsFilter),
-		SegmentUpdater: caching.NewCacheAwareSegmentSync(splitStorage, segmentStorage, splitAPI.SegmentFetcher, logger, localTelemetryStorage, httpCache,
-			appMonitor),
-		TelemetryRecorder: telemetry.NewTelemetrySynchronizer(localTelemetryStorage, telemetryRecorder, splitStorage, segmentStorage, logger,
-			metadata, localTelemetryStorage),
+		SplitUpdater:   splitUpdater,
+		SegmentUpdater: segmentUpdater,
+		TelemetryRecorder: telemetry.NewTelemetrySynchronizer(env.localTelemetry, telemetryRecorder, env.splitStorage, env.segmentStorage, logger,
+			deps.metadata, env.localTelemetry),
 	}
+	env.telemetryRecorder = workers.TelemetryRecorder
 
 	// setup periodic tasks in case streaming is disabled or we need to fall back to polling
 	stasks := synchronizer.SplitTasks{
 		SplitSyncTask: tasks.NewFetchSplitsTask(workers.SplitUpdater, int(cfg.Sync.SplitRefreshRateMs/1000), logger),
 		SegmentSyncTask: tasks.NewFetchSegmentsTask(workers.SegmentUpdater, int(cfg.Sync.SegmentRefreshRateMs/1000), advanced.SegmentWorkers,
-			advanced.SegmentQueueSize, logger, appMonitor),
+			advanced.SegmentQueueSize, logger, deps.appMonitor),
 		TelemetrySyncTask:        tasks.NewRecordTelemetryTask(workers.TelemetryRecorder, int(cfg.Sync.Advanced.InternalMetricsRateMs), logger),
 		ImpressionSyncTask:       impressionTask,
 		ImpressionsCountSyncTask: impressionCountTask,
@@ -138,150 +618,445 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 	}
 
 	// Creating Synchronizer for tasks
-	sync := ssync.NewSynchronizer(*advanced, stasks, workers, logger, nil, []tasks.Task{telemetryConfigTask, telemetryUsageTask, telemetryKeysClientSideTask, telemetryKeysServerSideTask})
+	sync := ssync.NewSynchronizer(advanced, stasks, workers, logger, nil, []tasks.Task{telemetryConfigTask, telemetryUsageTask, telemetryKeysClientSideTask, telemetryKeysServerSideTask})
 
-	mstatus := make(chan int, 1)
-	syncManager, err := synchronizer.NewSynchronizerManager(
+	env.syncManager, err = synchronizer.NewSynchronizerManager(
 		sync,
 		logger,
-		*advanced,
-		splitAPI.AuthClient,
-		splitStorage,
-		mstatus,
-		localTelemetryStorage,
-		metadata,
+		advanced,
+		env.splitAPI.AuthClient,
+		env.splitStorage,
+		env.mstatus,
+		env.localTelemetry,
+		deps.metadata,
 		&clientKey,
-		appMonitor,
+		deps.appMonitor,
 	)
 	if err != nil {
-		return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
+		return nil, common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
 	}
 
-	// Try to start bg sync in BG with unlimited retries (when a snapshot is provided),
-	// the passed function is invoked upon initialization completion
-	// If no snapshot is provided and init fails, `errUnrecoverable` is returned and application execution is aborted
-	// health monitors are only started after successful init (otherwise they'll fail if the app doesn't sync correctly within the
-	/// specified refresh period)
+	return env, nil
+}
+
+// startSync performs the initial synchronization of the environment, invoking `onReady` upon completion
+func (e *environment) startSync(logger logging.LoggerInterface, cfg *pconf.Main, onReady func()) error {
+	e.overrides.Start()
 	before := time.Now()
-	err = startBGSyng(syncManager, mstatus, cfg.Initialization.Snapshot != "", func() {
-		logger.Info("Synchronizer tasks started")
-		appMonitor.Start()
-		servicesMonitor.Start()
-		flagSetsAfterSanitize, _ := flagsets.SanitizeMany(cfg.FlagSetsFilter)
-		workers.TelemetryRecorder.SynchronizeConfig(
+	err := startBGSyng(e.syncManager, e.mstatus, e.warmStart, func() {
+		onReady()
+		flagSetsAfterSanitize, _ := flagsets.SanitizeMany(e.flagSetsFilter)
+		e.telemetryRecorder.SynchronizeConfig(
 			telemetry.InitConfig{
-				AdvancedConfig: *advanced,
+				AdvancedConfig: *e.advanced,
 				TaskPeriods: conf.TaskPeriods{
 					SplitSync:     int(cfg.Sync.SplitRefreshRateMs / 1000),
 					SegmentSync:   int(cfg.Sync.SegmentRefreshRateMs / 1000),
 					TelemetrySync: int(cfg.Sync.Advanced.InternalMetricsRateMs / 1000),
 				},
 				ListenerEnabled: cfg.Integrations.ImpressionListener.Endpoint != "",
-				FlagSetsTotal:   int64(len(cfg.FlagSetsFilter)),
-				FlagSetsInvalid: int64(len(cfg.FlagSetsFilter) - len(flagSetsAfterSanitize)),
+				FlagSetsTotal:   int64(len(e.flagSetsFilter)),
+				FlagSetsInvalid: int64(len(e.flagSetsFilter) - len(flagSetsAfterSanitize)),
 			},
 			time.Since(before).Milliseconds(),
-			map[string]int64{cfg.Apikey: 1},
+			map[string]int64{e.apikey: 1},
 			nil,
 		)
 	})
 	switch err {
 	case errRetrying:
-		logger.Warning("Failed to perform initial sync with Split servers but continuing from snapshot. Will keep retrying in BG")
+		logger.Warning(fmt.Sprintf("Failed to perform initial sync with Split servers%s but continuing from previously stored data. "+
+			"Will keep retrying in BG", e.describe()))
 	case errUnrecoverable:
-		logger.Error("Initial synchronization failed. Either Split is unreachable or the SDK key is incorrect. Aborting execution.")
+		logger.Error(fmt.Sprintf("Initial synchronization%s failed. Either Split is unreachable or the SDK key is incorrect. "+
+			"Aborting execution.", e.describe()))
 		return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
 	}
+	return nil
+}
 
-	rtm := common.NewRuntime(false, syncManager, logger, "Split Proxy", nil, nil, appMonitor, servicesMonitor)
-	storages := adminCommon.Storages{
-		SplitStorage:          splitStorage,
-		SegmentStorage:        segmentStorage,
-		LocalTelemetryStorage: localTelemetryStorage,
+// proxyOptions returns the environment-specific options used to serve SDK requests
+func (e *environment) proxyOptions(logger logging.LoggerInterface, cfg *pconf.Main) *Options {
+	// Flag evaluation on behalf of clients that cannot embed an sdk, honoring local overrides & kills
+	var flagEvaluator evaluator.Interface
+	if cfg.Server.Evaluation.Enabled {
+		flagEvaluator = evaluator.NewEvaluator(e.splitStorage.Overridden(), e.segmentStorage, engine.NewEngine(logger), logger)
 	}
 
-	// --------------------------- ADMIN DASHBOARD ------------------------------
-	cfgForAdmin := *cfg
-	cfgForAdmin.Apikey = logging.ObfuscateAPIKey(cfgForAdmin.Apikey)
-
-	adminTLSConfig, err := util.TLSConfigForServer(&cfg.Admin.TLS)
-	if err != nil {
-		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
+	return &Options{
+		Logger:                      logger,
+		APIKeys:                     e.clientApikeys,
+		APIKeyValidator:             e.apikeyValidator,
+		DebugOn:                     strings.ToLower(cfg.Logging.Level) == "debug" || strings.ToLower(cfg.Logging.Level) == "verbose",
+		SplitFetcher:                e.splitAPI.SplitFetcher,
+		ProxySplitStorage:           e.splitStorage,
+		ProxySegmentStorage:         e.segmentStorage,
+		ImpressionsSink:             e.sinks[pTasks.KindImpressions],
+		ImpressionCountSink:         e.sinks[pTasks.KindImpressionCounts],
+		EventsSink:                  e.sinks[pTasks.KindEvents],
+		TelemetryConfigSink:         e.sinks[pTasks.KindTelemetryConfig],
+		TelemetryUsageSink:          e.sinks[pTasks.KindTelemetryUsage],
+		TelemetryKeysClientSideSink: e.sinks[pTasks.KindTelemetryKeysClientSide],
+		TelemetryKeysServerSideSink: e.sinks[pTasks.KindTelemetryKeysServerSide],
+		Telemetry:                   e.localTelemetry,
+		Cache:                       e.httpCache,
+		FlagSets:                    e.flagSetsFilter,
+		FlagSetsStrictMatching:      cfg.FlagSetStrictMatching,
+		SplitChangesCollapseTTL:     time.Duration(cfg.Sync.Advanced.SplitChangesCollapseMs) * time.Millisecond,
+		MaxUpstreamSplitFetches:     int(cfg.Sync.Advanced.MaxUpstreamSplitFetches),
+		PushTokenIssuer:             e.pushIssuer,
+		Evaluator:                   flagEvaluator,
+		EvaluationFlagNames:         e.splitStorage.SplitNames,
+		EvaluationFlagNamesBySets:   e.splitStorage.GetNamesByFlagSets,
+		RecordEvaluationImpressions: cfg.Server.Evaluation.RecordImpressions,
 	}
+}
 
-	adminServer, err := admin.NewServer(&admin.Options{
-		Host:              cfg.Admin.Host,
-		Port:              int(cfg.Admin.Port),
-		Name:              "Split Proxy dashboard",
-		Proxy:             true,
-		Username:          cfg.Admin.Username,
-		Password:          cfg.Admin.Password,
-		Logger:            logger,
-		Storages:          storages,
-		Runtime:           rtm,
-		Snapshotter:       dbInstance,
-		HcAppMonitor:      appMonitor,
-		HcServicesMonitor: servicesMonitor,
-		FullConfig:        cfgForAdmin,
-		TLS:               adminTLSConfig,
-		FlagSpecVersion:   cfg.FlagSpecVersion,
-	})
-	if err != nil {
-		return common.NewInitError(fmt.Errorf("error starting admin server: %w", err), common.ExitAdminError)
+// adminEnvironments bundles the resources of additional environments to be managed through the admin API
+func adminEnvironments(extra []*environment, registries map[string]*apikeys.RegistryImpl) map[string]*admin.Environment {
+	toRet := make(map[string]*admin.Environment, len(extra))
+	for _, env := range extra {
+		toRet[env.name] = &admin.Environment{
+			DeadLetters:     env.deadLetters,
+			DeadLetterSinks: env.sinks,
+			APIKeys:         registries[env.name],
+			HTTPCache:       env.httpCache,
+			FlagOverrides:   env.overrides,
+			KillSwitch:      overrides.NewKillSwitch(env.overrides, env.splitStorage.Split),
+		}
 	}
-	go adminServer.Start()
+	return toRet
+}
 
-	tlsConfig, err := util.TLSConfigForServer(&cfg.Server.TLS)
-	if err != nil {
-		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
+// allSpools returns the spools of every environment, prefixing the ones of additional environments with their name
+func allSpools(envs []*environment) map[string]pTasks.Spool {
+	toRet := make(map[string]pTasks.Spool)
+	for _, env := range envs {
+		for kind, spool := range env.spools {
+			if env.name != "" {
+				kind = env.name + "/" + kind
+			}
+			toRet[kind] = spool
+		}
 	}
+	return toRet
+}
 
-	proxyOptions := &Options{
-		Logger:                      logger,
-		Host:                        cfg.Server.Host,
-		Port:                        int(cfg.Server.Port),
-		APIKeys:                     cfg.Server.ClientApikeys,
-		ImpressionListener:          nil,
-		DebugOn:                     strings.ToLower(cfg.Logging.Level) == "debug" || strings.ToLower(cfg.Logging.Level) == "verbose",
-		SplitFetcher:                splitAPI.SplitFetcher,
-		ProxySplitStorage:           splitStorage,
-		ProxySegmentStorage:         segmentStorage,
-		ImpressionsSink:             impressionTask,
-		ImpressionCountSink:         impressionCountTask,
-		EventsSink:                  eventsTask,
-		TelemetryConfigSink:         telemetryConfigTask,
-		TelemetryUsageSink:          telemetryUsageTask,
-		TelemetryKeysClientSideSink: telemetryKeysClientSideTask,
-		TelemetryKeysServerSideSink: telemetryKeysServerSideTask,
-		Telemetry:                   localTelemetryStorage,
-		Cache:                       httpCache,
-		TLSConfig:                   tlsConfig,
-		FlagSets:                    cfg.FlagSetsFilter,
-		FlagSetsStrictMatching:      cfg.FlagSetStrictMatching,
+func (e *environment) describe() string {
+	if e.name == "" {
+		return ""
 	}
+	return fmt.Sprintf(" for environment '%s'", e.name)
+}
 
-	if ilcfg := cfg.Integrations.ImpressionListener; ilcfg.Endpoint != "" {
-		var err error
-		proxyOptions.ImpressionListener, err = impressionlistener.NewImpressionBulkListener(ilcfg.Endpoint, int(ilcfg.QueueSize), nil)
-		if err != nil {
-			return common.NewInitError(fmt.Errorf("error instantiating impression listener: %w", err), common.ExitTaskInitialization)
+// parseAPIKeyFlagSets builds the map of flag sets each client apikey is restricted to,
+// from a list of `apikey:set1|set2` entries. Apikeys without an entry can access all flag sets
+func parseAPIKeyFlagSets(entries []string, apikeys []string) (map[string][]string, error) {
+	toRet := make(map[string][]string)
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
 		}
-		proxyOptions.ImpressionListener.Start()
+
+		apikey, rawSets, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry '%s'. format should be 'apikey:set1|set2'", entry)
+		}
+
+		if !slices.Contains(apikeys, apikey) {
+			return nil, fmt.Errorf("flag sets configured for unknown client apikey '%s'", logging.ObfuscateAPIKey(apikey))
+		}
+
+		sets, _ := flagsets.SanitizeMany(strings.Split(rawSets, "|"))
+		if len(sets) == 0 {
+			return nil, fmt.Errorf("no valid flag sets supplied for client apikey '%s'", logging.ObfuscateAPIKey(apikey))
+		}
+		toRet[apikey] = sets
 	}
+	return toRet, nil
+}
 
-	proxyAPI := New(proxyOptions)
-	go proxyAPI.Start()
+// validateEnvironments checks that additional environments are properly named & that no client apikey is shared
+// between them, since it's used to determine which environment serves each request
+func validateEnvironments(cfg *pconf.Main) error {
+	owners := make(map[string]string)
+	for _, key := range cfg.Server.ClientApikeys {
+		owners[key] = "default"
+	}
 
-	rtm.RegisterShutdownHandler()
-	rtm.Block()
+	names := make(map[string]struct{})
+	for _, env := range cfg.Environments {
+		if env.Name == "" {
+			return errors.New("all additional environments must have a name")
+		}
+		if _, ok := names[env.Name]; ok {
+			return fmt.Errorf("duplicate environment name '%s'", env.Name)
+		}
+		names[env.Name] = struct{}{}
+
+		if env.Apikey == "" || len(env.ClientApikeys) == 0 {
+			return fmt.Errorf("environment '%s' must have an SDK key & at least one client apikey", env.Name)
+		}
+		for _, key := range env.ClientApikeys {
+			if owner, ok := owners[key]; ok {
+				return fmt.Errorf("client apikey '%s' is used by both '%s' & '%s' environments",
+					logging.ObfuscateAPIKey(key), owner, env.Name)
+			}
+			owners[key] = env.Name
+		}
+	}
 	return nil
 }
 
+// syncManagers bundles the sync managers of all environments so that they're all stopped upon shutdown
+type syncManagers []synchronizer.Manager
+
+// Start starts all the wrapped managers
+func (s syncManagers) Start() {
+	for _, manager := range s {
+		go manager.Start()
+	}
+}
+
+// Stop stops all the wrapped managers
+func (s syncManagers) Stop() {
+	for _, manager := range s {
+		manager.Stop()
+	}
+}
+
+// IsRunning returns whether all the environments are being synchronized
+func (s syncManagers) IsRunning() bool {
+	for _, manager := range s {
+		if !manager.IsRunning() {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+const dbOpenTimeout = 5 * time.Second
+
 var (
 	errRetrying      = errors.New("error but snapshot available")
 	errUnrecoverable = errors.New("error and no snapshot available")
 )
 
+// describeSnapshot returns a function describing the data of every environment, to be included in the snapshots generated
+func describeSnapshot(cfg *pconf.Main, envs []*environment, logger logging.LoggerInterface) func() (snapshot.Metadata, error) {
+	return func() (snapshot.Metadata, error) {
+		meta := snapshot.Metadata{SpecVersion: cfg.FlagSpecVersion}
+		for _, env := range envs {
+			contents, err := persistent.ReadContents(env.db, logger)
+			if err != nil {
+				return meta, fmt.Errorf("error reading data of environment '%s': %w", env.name, err)
+			}
+
+			meta.Environments = append(meta.Environments, snapshot.EnvironmentMetadata{
+				Name:                 env.name,
+				SDKKeyHash:           util.HashAPIKey(env.apikey),
+				FlagSets:             env.flagSetsFilter,
+				FlagsChangeNumber:    contents.FlagsChangeNumber(),
+				SegmentsChangeNumber: contents.SegmentsChangeNumber(),
+				Flags:                contents.ActiveFlags(),
+				Segments:             len(contents.Segments),
+			})
+		}
+		return meta, nil
+	}
+}
+
+// snapshotTargets returns the environments whose data can be replaced by loading a snapshot at runtime
+func snapshotTargets(envs []*environment) []snapshots.Target {
+	targets := make([]snapshots.Target, 0, len(envs))
+	for _, env := range envs {
+		targets = append(targets, snapshots.Target{
+			Name:       env.name,
+			SDKKeyHash: util.HashAPIKey(env.apikey),
+			DB:         env.db,
+			Splits:     env.splitStorage,
+			Segments:   env.segmentStorage,
+			Cache:      env.httpCache,
+		})
+	}
+	return targets
+}
+
+// setupDBPath returns the path of the boltdb file to use, and whether it contains data from a previous run that
+// should be restored. A snapshot takes precedence over the persistent storage file. If there's no data to start from,
+// the latest valid snapshot saved automatically is used (when enabled)
+func setupDBPath(cfg *pconf.Main, logger logging.LoggerInterface) (string, bool, error) {
+	if cfg.Initialization.SnapshotFallback && cfg.Storage.Snapshots.Dir == "" {
+		return "", false, common.NewInitError(errors.New("a snapshots dir is required to fall back to snapshots"), common.ExitInvalidConfiguration)
+	}
+
+	if snapFile := cfg.Initialization.Snapshot; snapFile != "" {
+		if cfg.Storage.Persistent.Filename != "" {
+			logger.Warning("Both a snapshot & a persistent storage file were provided. The snapshot will be used & the file ignored")
+		}
+		return restoreSnapshot(cfg, snapFile, persistent.BoltInMemoryMode, logger)
+	}
+
+	dbpath := cfg.Storage.Persistent.Filename
+	if dbpath == "" {
+		return fallbackToSnapshot(cfg, persistent.BoltInMemoryMode, logger)
+	}
+
+	if cfg.Initialization.ForceFreshStartup {
+		logger.Info("Fresh startup requested. Wiping persistent storage file ", dbpath)
+		if err := os.Remove(dbpath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", false, common.NewInitError(fmt.Errorf("error wiping persistent storage file: %w", err), common.ExitErrorDB)
+		}
+		return dbpath, false, nil
+	}
+
+	if _, err := os.Stat(dbpath); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", false, common.NewInitError(fmt.Errorf("error accessing persistent storage file: %w", err), common.ExitErrorDB)
+		}
+		logger.Info("Persistent storage file not found. A new one will be created at ", dbpath)
+		return fallbackToSnapshot(cfg, dbpath, logger)
+	}
+
+	logger.Info("Restoring data from persistent storage file ", dbpath)
+	return dbpath, true, nil
+}
+
+// fallbackToSnapshot restores the latest valid snapshot in the snapshots dir into `dbpath`, if enabled.
+// Otherwise, or if there's no valid snapshot, `dbpath` is used as is with no data to restore
+func fallbackToSnapshot(cfg *pconf.Main, dbpath string, logger logging.LoggerInterface) (string, bool, error) {
+	if !cfg.Initialization.SnapshotFallback {
+		return dbpath, false, nil
+	}
+
+	key, err := snapshotVerificationKey(cfg)
+	if err != nil {
+		return "", false, err
+	}
+
+	snapFile, err := snapshots.LatestGood(cfg.Storage.Snapshots.Dir, key, logger)
+	if err != nil {
+		logger.Warning("No snapshot available to start from: ", err)
+		return dbpath, false, nil
+	}
+
+	logger.Info("Starting from the latest snapshot available: ", snapFile)
+	return restoreSnapshot(cfg, snapFile, dbpath, logger)
+}
+
+// restoreSnapshot writes the data of a snapshot to `dbpath`, or to a temporary file if an in-memory db is used
+func restoreSnapshot(cfg *pconf.Main, snapFile string, dbpath string, logger logging.LoggerInterface) (string, bool, error) {
+	key, err := snapshotVerificationKey(cfg)
+	if err != nil {
+		return "", false, err
+	}
+
+	snap, err := snapshot.DecodeFromFileVerified(snapFile, key)
+	if err != nil {
+		return "", false, fmt.Errorf("error parsing snapshot file: %w", err)
+	}
+
+	if dbpath == persistent.BoltInMemoryMode {
+		if dbpath, err = snap.WriteDataToTmpFile(); err != nil {
+			return "", false, fmt.Errorf("error writing temporary snapshot file: %w", err)
+		}
+	} else if err = snap.WriteDataToFile(dbpath); err != nil {
+		return "", false, common.NewInitError(fmt.Errorf("error writing snapshot data to persistent storage file: %w", err), common.ExitErrorDB)
+	}
+
+	logger.Debug("Database created from snapshot at", dbpath)
+	return dbpath, true, nil
+}
+
+// snapshotVerificationKey loads the key snapshots must be signed with, or returns nil if none is configured
+func snapshotVerificationKey(cfg *pconf.Main) (ed25519.PublicKey, error) {
+	keyFile := cfg.SnapshotSigning.PublicKeyFN
+	if keyFile == "" {
+		return nil, nil
+	}
+
+	key, err := snapshot.LoadVerificationKey(keyFile)
+	if err != nil {
+		return nil, common.NewInitError(fmt.Errorf("error loading snapshot verification key: %w", err), common.ExitInvalidConfiguration)
+	}
+	return key, nil
+}
+
+// openSpoolDB opens the db used for spools (separate from the main one, so that spooled data doesn't end up in snapshots).
+// If spooling is disabled, nil is returned
+func openSpoolDB(cfg *pconf.Spool) (*persistent.BoltDBWrapper, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	path := cfg.Filename
+	if path == "" {
+		path = persistent.BoltInMemoryMode
+	}
+
+	db, err := persistent.NewBoltWrapper(path, &bolt.Options{Timeout: dbOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("error opening spool db: %w", err)
+	}
+	return db, nil
+}
+
+// setupSpools creates one spool for each kind of data posted by SDKs, on buckets prefixed with `prefix`.
+// If spooling is disabled (nil db), a nil map is returned
+func setupSpools(db *persistent.BoltDBWrapper, prefix string, maxBytes int64, logger logging.LoggerInterface) (map[string]pTasks.Spool, error) {
+	if db == nil {
+		return nil, nil
+	}
+
+	names := []string{
+		pTasks.KindImpressions,
+		pTasks.KindImpressionCounts,
+		pTasks.KindEvents,
+		pTasks.KindTelemetryConfig,
+		pTasks.KindTelemetryUsage,
+		pTasks.KindTelemetryKeysClientSide,
+		pTasks.KindTelemetryKeysServerSide,
+	}
+	spools := make(map[string]pTasks.Spool, len(names))
+	for _, name := range names {
+		spool, err := pTasks.NewBoltSpool(db, prefix+name, maxBytes)
+		if err != nil {
+			return nil, err
+		}
+		if stats := spool.Stats(); stats.Items > 0 {
+			logger.Info(fmt.Sprintf("Resuming %d spooled %s%s items from a previous run", stats.Items, prefix, name))
+		}
+		spools[name] = spool
+	}
+	return spools, nil
+}
+
+// cacheShare returns the part of a cache limit that corresponds to each of `envCount` environments.
+// Unbounded limits (<= 0) are kept as-is, and bounded ones never end up unbounded due to rounding
+func cacheShare(limit int64, envCount int64) int64 {
+	if limit <= 0 {
+		return limit
+	}
+	return max(limit/envCount, 1)
+}
+
+// setupDeadLetters creates the store for payloads that cannot be posted. Dead letters are kept on a bucket of the
+// spool db (prefixed with `prefix`) so that they can be inspected & replayed after a restart, or in memory if spooling is disabled
+func setupDeadLetters(db *persistent.BoltDBWrapper, prefix string, maxItems int, logger logging.LoggerInterface) (pTasks.DeadLetterStore, error) {
+	if db == nil {
+		return pTasks.NewInMemoryDeadLetterStore(maxItems), nil
+	}
+
+	store, err := pTasks.NewBoltDeadLetterStore(db, prefix+"deadLetters", maxItems)
+	if err != nil {
+		return nil, err
+	}
+	if items := store.Len(); items > 0 {
+		logger.Info(fmt.Sprintf("Resuming %d %sdead letters from a previous run", items, prefix))
+	}
+	return store, nil
+}
+
 func startBGSyng(m synchronizer.Manager, mstatus chan int, haveSnapshot bool, onReady func()) error {
 
 	attemptInit := func() bool {
@@ -316,6 +1091,20 @@ func startBGSyng(m synchronizer.Manager, mstatus chan int, haveSnapshot bool, on
 
 }
 
+// pushTokenSecret returns the configured secret, or a random one if none was provided.
+// Note: a random secret invalidates all outstanding tokens upon restart, forcing SDKs to re-authenticate
+func pushTokenSecret(configured string) ([]byte, error) {
+	if configured != "" {
+		return []byte(configured), nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("error generating random secret: %w", err)
+	}
+	return secret, nil
+}
+
 func getAppCounterConfigs() (hcAppCounter.ThresholdConfig, hcAppCounter.ThresholdConfig) {
 	splitsConfig := hcAppCounter.DefaultThresholdConfig("Splits")
 	segmentsConfig := hcAppCounter.DefaultThresholdConfig("Segments")
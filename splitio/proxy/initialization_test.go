@@ -1,11 +1,23 @@
 package proxy
 
 import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/splitio/go-split-commons/v6/synchronizer"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"golang.org/x/exp/slices"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	pconf "github.com/splitio/split-synchronizer/v5/splitio/proxy/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 )
 
 type syncManagerMock struct {
@@ -27,6 +39,12 @@ func (m *syncManagerMock) Stop() { panic("unimplemented") }
 
 var _ synchronizer.Manager = (*syncManagerMock)(nil)
 
+type runningManagerMock bool
+
+func (m runningManagerMock) IsRunning() bool { return bool(m) }
+func (m runningManagerMock) Start()          {}
+func (m runningManagerMock) Stop()           {}
+
 func TestSyncManagerInitializationRetriesWithSnapshot(t *testing.T) {
 
 	sm := &syncManagerMock{c: make(chan int, 1)}
@@ -63,3 +81,225 @@ func TestSyncManagerInitializationRetriesWithSnapshot(t *testing.T) {
 		t.Error("there should be 2 executions")
 	}
 }
+
+func TestSetupDBPath(t *testing.T) {
+	logger := logging.NewLogger(nil)
+
+	var cfg pconf.Main
+	path, warmStart, err := setupDBPath(&cfg, logger)
+	if err != nil || path != persistent.BoltInMemoryMode || warmStart {
+		t.Error("no file configured should use a temporary db. Got: ", path, warmStart, err)
+	}
+
+	// configured file that doesn't yet exist
+	cfg.Storage.Persistent.Filename = filepath.Join(t.TempDir(), "proxy.db")
+	path, warmStart, err = setupDBPath(&cfg, logger)
+	if err != nil || path != cfg.Storage.Persistent.Filename || warmStart {
+		t.Error("a new file should be used without restoring. Got: ", path, warmStart, err)
+	}
+
+	// configured file from a previous run
+	if err := os.WriteFile(cfg.Storage.Persistent.Filename, []byte("something"), 0644); err != nil {
+		t.Error("error writing file: ", err)
+	}
+	path, warmStart, err = setupDBPath(&cfg, logger)
+	if err != nil || path != cfg.Storage.Persistent.Filename || !warmStart {
+		t.Error("the existing file should be restored. Got: ", path, warmStart, err)
+	}
+
+	// fresh startup requested
+	cfg.Initialization.ForceFreshStartup = true
+	path, warmStart, err = setupDBPath(&cfg, logger)
+	if err != nil || path != cfg.Storage.Persistent.Filename || warmStart {
+		t.Error("the existing file should be wiped. Got: ", path, warmStart, err)
+	}
+	if _, err := os.Stat(cfg.Storage.Persistent.Filename); !errors.Is(err, os.ErrNotExist) {
+		t.Error("file should have been removed. Got: ", err)
+	}
+}
+
+func TestSetupDBPathVerifiesSnapshots(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dir := t.TempDir()
+
+	public, private, _ := ed25519.GenerateKey(nil)
+	publicDER, _ := x509.MarshalPKIXPublicKey(public)
+	keyFile := filepath.Join(dir, "public.pem")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600)
+
+	snap, _ := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB}, []byte("some data"))
+	signed, _ := snap.EncodeSigned(private)
+	unsigned, _ := snap.Encode()
+	os.WriteFile(filepath.Join(dir, "signed.snapshot"), signed, 0600)
+	os.WriteFile(filepath.Join(dir, "unsigned.snapshot"), unsigned, 0600)
+	os.WriteFile(filepath.Join(dir, "truncated.snapshot"), signed[:len(signed)-3], 0600)
+
+	var cfg pconf.Main
+	cfg.Initialization.Snapshot = filepath.Join(dir, "truncated.snapshot")
+	if _, _, err := setupDBPath(&cfg, logger); !errors.Is(err, snapshot.ErrChecksumMismatch) {
+		t.Error("truncated snapshots should be rejected. Got: ", err)
+	}
+
+	// signatures are only required if a verification key is configured
+	cfg.Initialization.Snapshot = filepath.Join(dir, "unsigned.snapshot")
+	if _, warmStart, err := setupDBPath(&cfg, logger); err != nil || !warmStart {
+		t.Error("unsigned snapshots should be accepted without a key. Got: ", err)
+	}
+
+	cfg.SnapshotSigning.PublicKeyFN = keyFile
+	if _, _, err := setupDBPath(&cfg, logger); !errors.Is(err, snapshot.ErrUnsigned) {
+		t.Error("unsigned snapshots should be rejected. Got: ", err)
+	}
+
+	cfg.Initialization.Snapshot = filepath.Join(dir, "signed.snapshot")
+	if _, warmStart, err := setupDBPath(&cfg, logger); err != nil || !warmStart {
+		t.Error("signed snapshots should be accepted. Got: ", err)
+	}
+
+	cfg.SnapshotSigning.PublicKeyFN = filepath.Join(dir, "missing.pem")
+	if _, _, err := setupDBPath(&cfg, logger); err == nil {
+		t.Error("a missing key should be an error")
+	}
+}
+
+func TestSetupDBPathFallsBackToSnapshots(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dir := t.TempDir()
+
+	var cfg pconf.Main
+	cfg.Initialization.SnapshotFallback = true
+	if _, _, err := setupDBPath(&cfg, logger); err == nil {
+		t.Error("falling back to snapshots without a snapshots dir should be an error")
+	}
+
+	cfg.Storage.Snapshots.Dir = filepath.Join(dir, "snapshots")
+	path, warmStart, err := setupDBPath(&cfg, logger)
+	if err != nil || path != persistent.BoltInMemoryMode || warmStart {
+		t.Error("a missing snapshots dir should mean no data to restore. Got: ", path, warmStart, err)
+	}
+
+	os.Mkdir(cfg.Storage.Snapshots.Dir, 0755)
+	snap, _ := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB}, []byte("some data"))
+	encoded, _ := snap.Encode()
+	os.WriteFile(filepath.Join(cfg.Storage.Snapshots.Dir, snapshot.FileName(1)), encoded, 0600)
+	os.WriteFile(filepath.Join(cfg.Storage.Snapshots.Dir, snapshot.FileName(2)), encoded[:len(encoded)-3], 0600)
+
+	path, warmStart, err = setupDBPath(&cfg, logger)
+	if err != nil || path == persistent.BoltInMemoryMode || !warmStart {
+		t.Error("the latest valid snapshot should be restored to a temporary file. Got: ", path, warmStart, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "some data" {
+		t.Error("unexpected data restored: ", string(data))
+	}
+
+	// configured file that doesn't yet exist is created from the snapshot
+	cfg.Storage.Persistent.Filename = filepath.Join(dir, "proxy.db")
+	path, warmStart, err = setupDBPath(&cfg, logger)
+	if err != nil || path != cfg.Storage.Persistent.Filename || !warmStart {
+		t.Error("the snapshot should be restored into the persistent storage file. Got: ", path, warmStart, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "some data" {
+		t.Error("unexpected data restored: ", string(data))
+	}
+
+	// existing data from a previous run takes precedence
+	os.WriteFile(cfg.Storage.Persistent.Filename, []byte("previous run"), 0644)
+	if path, warmStart, err = setupDBPath(&cfg, logger); err != nil || !warmStart {
+		t.Error("the existing file should be restored. Got: ", path, warmStart, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous run" {
+		t.Error("the existing file should be left untouched. Got: ", string(data))
+	}
+
+	// fresh startups don't fall back to snapshots
+	cfg.Initialization.ForceFreshStartup = true
+	if _, warmStart, err = setupDBPath(&cfg, logger); err != nil || warmStart {
+		t.Error("fresh startups should start with no data. Got: ", warmStart, err)
+	}
+}
+
+func TestValidateEnvironments(t *testing.T) {
+	cfg := pconf.Main{Server: pconf.Server{ClientApikeys: []string{"prod1", "prod2"}}}
+	if err := validateEnvironments(&cfg); err != nil {
+		t.Error("no additional environments should be valid. Got: ", err)
+	}
+
+	cfg.Environments = []pconf.Environment{
+		{Name: "staging", Apikey: "sdkStaging", ClientApikeys: []string{"staging1"}},
+		{Name: "qa", Apikey: "sdkQA", ClientApikeys: []string{"qa1", "qa2"}},
+	}
+	if err := validateEnvironments(&cfg); err != nil {
+		t.Error("environments should be valid. Got: ", err)
+	}
+
+	cfg.Environments[1].ClientApikeys = []string{"qa1", "prod2"}
+	if err := validateEnvironments(&cfg); err == nil {
+		t.Error("client apikeys shared among environments should be rejected")
+	}
+
+	cfg.Environments[1].ClientApikeys = []string{"qa1"}
+	cfg.Environments[1].Name = "staging"
+	if err := validateEnvironments(&cfg); err == nil {
+		t.Error("duplicate environment names should be rejected")
+	}
+
+	cfg.Environments[1].Name = ""
+	if err := validateEnvironments(&cfg); err == nil {
+		t.Error("unnamed environments should be rejected")
+	}
+
+	cfg.Environments[1].Name = "qa"
+	cfg.Environments[1].Apikey = ""
+	if err := validateEnvironments(&cfg); err == nil {
+		t.Error("environments without an sdk key should be rejected")
+	}
+}
+
+func TestParseAPIKeyFlagSets(t *testing.T) {
+	parsed, err := parseAPIKeyFlagSets([]string{""}, []string{"web1"})
+	if err != nil || len(parsed) != 0 {
+		t.Error("empty entries should be ignored. Got: ", parsed, err)
+	}
+
+	parsed, err = parseAPIKeyFlagSets([]string{"mobile1:mobile", "mobile2:Mobile|shared"}, []string{"web1", "mobile1", "mobile2"})
+	if err != nil {
+		t.Error("no error expected. Got: ", err)
+	}
+	if len(parsed) != 2 || !slices.Equal(parsed["mobile1"], []string{"mobile"}) || !slices.Equal(parsed["mobile2"], []string{"mobile", "shared"}) {
+		t.Error("unexpected flag sets: ", parsed)
+	}
+	if _, ok := parsed["web1"]; ok {
+		t.Error("web1 should not be restricted")
+	}
+
+	if _, err := parseAPIKeyFlagSets([]string{"mobile1"}, []string{"mobile1"}); err == nil {
+		t.Error("entries without flag sets should be rejected")
+	}
+	if _, err := parseAPIKeyFlagSets([]string{"other:mobile"}, []string{"mobile1"}); err == nil {
+		t.Error("unknown apikeys should be rejected")
+	}
+	if _, err := parseAPIKeyFlagSets([]string{"mobile1:"}, []string{"mobile1"}); err == nil {
+		t.Error("empty flag set lists should be rejected")
+	}
+}
+
+func TestCacheShare(t *testing.T) {
+	if share := cacheShare(1000, 4); share != 250 {
+		t.Error("the limit should be split evenly. Got: ", share)
+	}
+	if share := cacheShare(0, 4); share != 0 {
+		t.Error("unbounded caches should remain unbounded. Got: ", share)
+	}
+	if share := cacheShare(3, 4); share != 1 {
+		t.Error("bounded caches should remain bounded. Got: ", share)
+	}
+}
+
+func TestSyncManagersIsRunning(t *testing.T) {
+	if !(syncManagers{runningManagerMock(true), runningManagerMock(true)}).IsRunning() {
+		t.Error("should be running if all the environments are")
+	}
+	if (syncManagers{runningManagerMock(true), runningManagerMock(false)}).IsRunning() {
+		t.Error("should not be running if any environment isn't")
+	}
+}
@@ -0,0 +1,73 @@
+package overrides
+
+import (
+	"errors"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
+)
+
+// KillSwitch kills feature flags through overrides, so that kills are served to SDKs with a synthesized change number,
+// survive restarts along with the rest of the overrides & are never overwritten by upstream changes
+type KillSwitch struct {
+	store *StoreImpl
+	flags func(name string) *dtos.SplitDTO
+}
+
+// NewKillSwitch constructs a new kill switch on top of an override store. `flags` returns the upstream definition of a flag
+func NewKillSwitch(store *StoreImpl, flags func(name string) *dtos.SplitDTO) *KillSwitch {
+	return &KillSwitch{store: store, flags: flags}
+}
+
+// List returns the flags killed locally, sorted by name
+func (k *KillSwitch) List() []killswitch.Kill {
+	toRet := make([]killswitch.Kill, 0)
+	for _, override := range k.store.List() {
+		if override.Kill {
+			toRet = append(toRet, killswitch.Kill{
+				Split:            override.Split,
+				DefaultTreatment: override.Treatment,
+				Reason:           override.Reason,
+				KilledBy:         override.CreatedBy,
+				KilledAt:         override.CreatedAt,
+			})
+		}
+	}
+	return toRet
+}
+
+// Kill kills a feature flag locally, replacing any other override it might have
+func (k *KillSwitch) Kill(split string, defaultTreatment string, reason string, actor string) (*killswitch.Kill, error) {
+	flag := k.flags(split)
+	if flag == nil {
+		return nil, killswitch.ErrUnknownFlag
+	}
+
+	if defaultTreatment == "" {
+		defaultTreatment = flag.DefaultTreatment
+	}
+
+	stored, err := k.store.Set(Override{Split: split, Treatment: defaultTreatment, Reason: reason, Kill: true}, actor)
+	if err != nil {
+		return nil, err
+	}
+	return &killswitch.Kill{
+		Split:            stored.Split,
+		DefaultTreatment: stored.Treatment,
+		Reason:           stored.Reason,
+		KilledBy:         stored.CreatedBy,
+		KilledAt:         stored.CreatedAt,
+	}, nil
+}
+
+// Restore lifts the kill of a feature flag
+func (k *KillSwitch) Restore(split string, reason string, actor string) error {
+	err := k.store.remove(split, actor, reason, func(o *Override) bool { return o.Kill })
+	if errors.Is(err, ErrUnknownOverride) {
+		return killswitch.ErrNotKilled
+	}
+	return err
+}
+
+var _ killswitch.Switch = (*KillSwitch)(nil)
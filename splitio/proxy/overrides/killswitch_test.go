@@ -0,0 +1,49 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/killswitch"
+)
+
+func TestKillSwitch(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	store, err := NewStore("", func() int64 { return 100 }, func(string) (int64, bool) { return 0, false }, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+	flags := map[string]*dtos.SplitDTO{"f1": {Name: "f1", DefaultTreatment: "on"}, "f2": {Name: "f2", DefaultTreatment: "off"}}
+	kills := NewKillSwitch(store, func(name string) *dtos.SplitDTO { return flags[name] })
+
+	_, err = kills.Kill("nonexistent", "", "", "someone")
+	assert.ErrorIs(t, err, killswitch.ErrUnknownFlag)
+
+	kill, err := kills.Kill("f1", "", "incident", "someone")
+	assert.Nil(t, err)
+	assert.Equal(t, "on", kill.DefaultTreatment)
+	assert.Equal(t, "someone", kill.KilledBy)
+	assert.True(t, store.State().Active["f1"].Kill)
+
+	// regular overrides are neither listed nor restored as kills
+	_, err = store.Set(Override{Split: "f2", Treatment: "v2"}, "someone")
+	assert.Nil(t, err)
+	assert.Len(t, kills.List(), 1)
+	assert.ErrorIs(t, kills.Restore("f2", "", "someone"), killswitch.ErrNotKilled)
+	assert.Len(t, store.List(), 2)
+
+	assert.Nil(t, kills.Restore("f1", "fixed", "other"))
+	assert.Empty(t, kills.List())
+	assert.ErrorIs(t, kills.Restore("f1", "", "other"), killswitch.ErrNotKilled)
+
+	var actions []string
+	for _, entry := range auditLog.Entries() {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{"flag.kill", "override.set", "flag.restore"}, actions)
+}
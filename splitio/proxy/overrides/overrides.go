@@ -0,0 +1,82 @@
+package overrides
+
+import (
+	"errors"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+)
+
+// OverrideLabel is the label of the condition used to force a treatment, which SDKs attach to impressions
+const OverrideLabel = "local override"
+
+var (
+	// ErrInvalidOverride is returned when attempting to add an override without a flag name or treatment
+	ErrInvalidOverride = errors.New("feature flag name & treatment are required")
+
+	// ErrAlreadyExpired is returned when attempting to add an override whose expiration time has already passed
+	ErrAlreadyExpired = errors.New("expiration time is in the past")
+
+	// ErrUnknownOverride is returned when attempting to remove an override that doesn't exist
+	ErrUnknownOverride = errors.New("unknown override")
+)
+
+// Override forces a feature flag to serve a single treatment to all traffic, regardless of its upstream definition.
+// Kills are overrides too, which keep the flag's conditions & mark it as killed, serving `Treatment` as default
+type Override struct {
+	Split        string     `json:"split"`
+	Treatment    string     `json:"treatment"`
+	Kill         bool       `json:"kill,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+	CreatedBy    string     `json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	ChangeNumber int64      `json:"changeNumber"`
+}
+
+// Expired returns whether the override should no longer be applied at the supplied time
+func (o *Override) Expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// Apply returns a copy of the supplied feature flag serving the forced treatment to all traffic.
+// The change number of the result is the highest between the flag's & the override's
+func (o *Override) Apply(split *dtos.SplitDTO) dtos.SplitDTO {
+	toRet := *split
+	if o.ChangeNumber > toRet.ChangeNumber {
+		toRet.ChangeNumber = o.ChangeNumber
+	}
+
+	if o.Kill {
+		toRet.Killed = true
+		toRet.DefaultTreatment = o.Treatment
+		return toRet
+	}
+
+	toRet.Killed = false
+	toRet.DefaultTreatment = o.Treatment
+	toRet.TrafficAllocation = 100
+	toRet.Conditions = []dtos.ConditionDTO{{
+		ConditionType: "ROLLOUT",
+		MatcherGroup: dtos.MatcherGroupDTO{
+			Combiner: "AND",
+			Matchers: []dtos.MatcherDTO{{
+				KeySelector: &dtos.KeySelectorDTO{TrafficType: split.TrafficTypeName},
+				MatcherType: "ALL_KEYS",
+			}},
+		},
+		Partitions: []dtos.PartitionDTO{{Treatment: o.Treatment, Size: 100}},
+		Label:      OverrideLabel,
+	}}
+	return toRet
+}
+
+// State is a point-in-time view of the overrides, used to patch the responses served to SDKs
+type State struct {
+	// Active overrides, by feature flag name
+	Active map[string]Override
+
+	// Change number at which the override of a feature flag was removed or expired, so that SDKs that got it
+	// receive the upstream definition again
+	Lifted map[string]int64
+}
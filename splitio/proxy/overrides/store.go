@@ -0,0 +1,345 @@
+package overrides
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+)
+
+// expired overrides are lifted at most expirationPeriodSecs after their expiration time
+const expirationPeriodSecs = 1
+
+// actor used in the audit log for changes not performed by a user
+const systemActor = "system"
+
+// Store defines the interface for a component managing local feature flag overrides
+type Store interface {
+	List() []Override
+	Set(override Override, actor string) (*Override, error)
+	Remove(split string, actor string) error
+}
+
+// StoreImpl keeps the overrides in memory and, if a path is supplied, saves them to a file upon every change so that
+// they survive restarts. Every change is assigned a synthesized change number right above the latest known one, so that
+// SDKs fetch the affected feature flags again without skipping upstream changes received afterwards
+type StoreImpl struct {
+	path       string
+	upstreamCN func() int64
+	flagCN     func(split string) (int64, bool)
+	onChange   func(changeNumber int64)
+	auditLog   audit.Log
+	logger     logging.LoggerInterface
+	clock      func() time.Time
+	active     map[string]Override
+	lifted     map[string]int64
+	lastCN     int64
+	task       *asynctask.AsyncTask
+	mutex      sync.RWMutex
+}
+
+// fileContents is the format in which overrides are stored. Hand-written files can omit everything but the flag name,
+// treatment & optionally the expiration time of each override
+type fileContents struct {
+	Overrides []Override       `json:"overrides"`
+	Lifted    map[string]int64 `json:"lifted,omitempty"`
+}
+
+// NewStore constructs a new override store, loading the overrides from `path` if it exists.
+// `upstreamCN` returns the latest change number received from Split servers, `flagCN` the change number of the upstream
+// definition of a feature flag (false if it's not cached), and `onChange` is invoked with the
+// change number assigned to every update, so that cached responses can be evicted & SDKs notified
+func NewStore(
+	path string,
+	upstreamCN func() int64,
+	flagCN func(split string) (int64, bool),
+	onChange func(changeNumber int64),
+	auditLog audit.Log,
+	logger logging.LoggerInterface,
+) (*StoreImpl, error) {
+	store := &StoreImpl{
+		path:       path,
+		upstreamCN: upstreamCN,
+		flagCN:     flagCN,
+		onChange:   onChange,
+		auditLog:   auditLog,
+		logger:     logger,
+		clock:      time.Now,
+		active:     make(map[string]Override),
+		lifted:     make(map[string]int64),
+	}
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	store.task = asynctask.NewAsyncTask("flag-overrides-expiration", func(logging.LoggerInterface) error {
+		store.expire()
+		return nil
+	}, expirationPeriodSecs, nil, nil, logger)
+	return store, nil
+}
+
+// List returns the active overrides, sorted by feature flag name
+func (s *StoreImpl) List() []Override {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	toRet := make([]Override, 0, len(s.active))
+	for _, override := range s.active {
+		toRet = append(toRet, override)
+	}
+	sort.Slice(toRet, func(i, j int) bool { return toRet[i].Split < toRet[j].Split })
+	return toRet
+}
+
+// State returns a copy of the current overrides
+func (s *StoreImpl) State() State {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	toRet := State{
+		Active: make(map[string]Override, len(s.active)),
+		Lifted: make(map[string]int64, len(s.lifted)),
+	}
+	for name, override := range s.active {
+		toRet.Active[name] = override
+	}
+	for name, cn := range s.lifted {
+		toRet.Lifted[name] = cn
+	}
+	return toRet
+}
+
+// Set adds or replaces the override of a feature flag, returning it as stored
+func (s *StoreImpl) Set(override Override, actor string) (*Override, error) {
+	override.Split = strings.TrimSpace(override.Split)
+	override.Treatment = strings.TrimSpace(override.Treatment)
+	if override.Split == "" || override.Treatment == "" {
+		return nil, ErrInvalidOverride
+	}
+
+	now := s.clock()
+	if override.Expired(now) {
+		return nil, ErrAlreadyExpired
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	override.CreatedAt = now
+	override.CreatedBy = actor
+	override.ChangeNumber = s.nextChangeNumber()
+
+	previous, existed := s.active[override.Split]
+	liftedCN, wasLifted := s.lifted[override.Split]
+	s.active[override.Split] = override
+	delete(s.lifted, override.Split)
+	if err := s.save(); err != nil {
+		delete(s.active, override.Split)
+		if existed {
+			s.active[override.Split] = previous
+		}
+		if wasLifted {
+			s.lifted[override.Split] = liftedCN
+		}
+		return nil, err
+	}
+
+	if override.Kill {
+		s.auditLog.Record(actor, "flag.kill", override.Split, map[string]string{"defaultTreatment": override.Treatment, "reason": override.Reason})
+	} else {
+		details := map[string]string{"treatment": override.Treatment, "reason": override.Reason}
+		if override.ExpiresAt != nil {
+			details["expiresAt"] = override.ExpiresAt.Format(time.RFC3339)
+		}
+		s.auditLog.Record(actor, "override.set", override.Split, details)
+	}
+	s.onChange(override.ChangeNumber)
+	return &override, nil
+}
+
+// Remove lifts the override of a feature flag, so that SDKs get its upstream definition again
+func (s *StoreImpl) Remove(split string, actor string) error {
+	return s.remove(split, actor, "", func(*Override) bool { return true })
+}
+
+// remove lifts the override of a feature flag if it satisfies `accept`
+func (s *StoreImpl) remove(split string, actor string, reason string, accept func(*Override) bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	override, ok := s.active[split]
+	if !ok || !accept(&override) {
+		return ErrUnknownOverride
+	}
+
+	cn := s.lift(split)
+	if err := s.save(); err != nil {
+		s.active[split] = override
+		delete(s.lifted, split)
+		return err
+	}
+
+	if override.Kill {
+		s.auditLog.Record(actor, "flag.restore", split, map[string]string{"reason": reason})
+	} else {
+		s.auditLog.Record(actor, "override.remove", split, map[string]string{"treatment": override.Treatment})
+	}
+	s.onChange(cn)
+	return nil
+}
+
+// Start begins lifting overrides as they expire
+func (s *StoreImpl) Start() {
+	s.task.Start()
+}
+
+// Stop stops checking for expired overrides
+func (s *StoreImpl) Stop() {
+	s.task.Stop(false)
+}
+
+// expire lifts all the overrides whose expiration time has passed, and forgets the lifted ones no longer needed
+func (s *StoreImpl) expire() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.clock()
+	var cn int64
+	for name, override := range s.active {
+		if override.Expired(now) {
+			cn = s.lift(name)
+			s.auditLog.Record(systemActor, "override.expire", name, map[string]string{"treatment": override.Treatment})
+		}
+	}
+
+	pruned := s.prune()
+	if cn == 0 && !pruned {
+		return
+	}
+
+	if err := s.save(); err != nil {
+		s.logger.Error("error saving flag overrides after expiring some of them: ", err)
+	}
+	if cn != 0 {
+		s.onChange(cn)
+	}
+}
+
+// -- internal, must be called with the lock held
+
+// nextChangeNumber returns the upstream change number plus the amount of local changes made since it was received, so
+// that the cursor of SDKs stays below the change number of the next upstream update
+func (s *StoreImpl) nextChangeNumber() int64 {
+	cn := s.upstreamCN() + 1
+	if s.lastCN+1 > cn {
+		cn = s.lastCN + 1
+	}
+	s.lastCN = cn
+	return cn
+}
+
+func (s *StoreImpl) lift(split string) int64 {
+	cn := s.nextChangeNumber()
+	delete(s.active, split)
+	s.lifted[split] = cn
+	return cn
+}
+
+// prune forgets the lifted overrides of feature flags that changed upstream afterwards, since SDKs that may still be
+// serving the override get the upstream definition anyway. The same goes for flags removed upstream after the lift.
+// It returns whether any was forgotten
+func (s *StoreImpl) prune() bool {
+	upstream := s.upstreamCN()
+	var pruned bool
+	for name, liftedCN := range s.lifted {
+		cn, ok := s.flagCN(name)
+		if (ok && cn >= liftedCN) || (!ok && upstream >= liftedCN) {
+			delete(s.lifted, name)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+func (s *StoreImpl) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading flag overrides file: %w", err)
+	}
+
+	var contents fileContents
+	if err := json.Unmarshal(raw, &contents); err != nil {
+		return fmt.Errorf("error parsing flag overrides file: %w", err)
+	}
+
+	for name, cn := range contents.Lifted {
+		s.lifted[name] = cn
+		if cn > s.lastCN {
+			s.lastCN = cn
+		}
+	}
+
+	for _, override := range contents.Overrides {
+		if override.Split == "" || override.Treatment == "" {
+			return fmt.Errorf("error parsing flag overrides file: %w", ErrInvalidOverride)
+		}
+		if override.ChangeNumber > s.lastCN {
+			s.lastCN = override.ChangeNumber
+		}
+		s.active[override.Split] = override
+		delete(s.lifted, override.Split)
+	}
+
+	// overrides added by hand have no change number yet
+	for name, override := range s.active {
+		if override.ChangeNumber == 0 {
+			override.ChangeNumber = s.nextChangeNumber()
+			s.active[name] = override
+		}
+	}
+
+	s.logger.Info(fmt.Sprintf("Loaded %d feature flag overrides from %s", len(s.active), s.path))
+	return nil
+}
+
+// save writes the overrides to a temporary file which then replaces the previous one, so that it's never left half-written
+func (s *StoreImpl) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	contents := fileContents{Overrides: make([]Override, 0, len(s.active)), Lifted: s.lifted}
+	for _, override := range s.active {
+		contents.Overrides = append(contents.Overrides, override)
+	}
+	sort.Slice(contents.Overrides, func(i, j int) bool { return contents.Overrides[i].Split < contents.Overrides[j].Split })
+
+	serialized, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing flag overrides: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, serialized, 0600); err != nil {
+		return fmt.Errorf("error writing flag overrides file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error replacing flag overrides file: %w", err)
+	}
+	return nil
+}
+
+var _ Store = (*StoreImpl)(nil)
@@ -0,0 +1,117 @@
+package overrides
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+)
+
+func TestStore(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	now := time.UnixMilli(1000)
+	var upstream int64 = 5000
+	flags := map[string]int64{"f1": 4000, "f2": 4000}
+	flagCN := func(split string) (int64, bool) { cn, ok := flags[split]; return cn, ok }
+	var notified []int64
+	store, err := NewStore(path, func() int64 { return upstream }, flagCN, func(cn int64) { notified = append(notified, cn) }, auditLog, logger)
+	assert.Nil(t, err)
+	store.clock = func() time.Time { return now }
+
+	_, err = store.Set(Override{Split: "f1"}, "someone")
+	assert.ErrorIs(t, err, ErrInvalidOverride)
+	past := now.Add(-time.Second)
+	_, err = store.Set(Override{Split: "f1", Treatment: "off", ExpiresAt: &past}, "someone")
+	assert.ErrorIs(t, err, ErrAlreadyExpired)
+	assert.ErrorIs(t, store.Remove("f1", "someone"), ErrUnknownOverride)
+
+	// change numbers are always higher than the upstream one & the previously synthesized ones
+	expiration := now.Add(time.Minute)
+	stored, err := store.Set(Override{Split: "f1", Treatment: "off", Reason: "incident", ExpiresAt: &expiration}, "someone")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5001), stored.ChangeNumber)
+	assert.Equal(t, "someone", stored.CreatedBy)
+	_, err = store.Set(Override{Split: "f2", Treatment: "v2"}, "someone")
+	assert.Nil(t, err)
+	assert.Nil(t, store.Remove("f2", "other"))
+	assert.Equal(t, []int64{5001, 5002, 5003}, notified)
+
+	state := store.State()
+	assert.Equal(t, "off", state.Active["f1"].Treatment)
+	assert.Equal(t, map[string]int64{"f2": 5003}, state.Lifted)
+
+	// overrides survive restarts
+	reloaded, err := NewStore(path, func() int64 { return -1 }, flagCN, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+	restored := reloaded.List()
+	assert.Len(t, restored, 1)
+	assert.Equal(t, "f1", restored[0].Split)
+	assert.Equal(t, "off", restored[0].Treatment)
+	assert.Equal(t, "incident", restored[0].Reason)
+	assert.Equal(t, int64(5001), restored[0].ChangeNumber)
+	assert.True(t, expiration.Equal(*restored[0].ExpiresAt))
+	assert.Equal(t, state.Lifted, reloaded.State().Lifted)
+	assert.Equal(t, int64(5003), reloaded.lastCN)
+
+	// expired overrides are lifted, right above the latest upstream change number
+	now = expiration
+	upstream = 7000
+	store.expire()
+	assert.Empty(t, store.List())
+	assert.Equal(t, map[string]int64{"f1": 7001, "f2": 5003}, store.State().Lifted)
+	assert.Equal(t, int64(7001), notified[len(notified)-1])
+
+	// lifted overrides are kept until the flag changes upstream, or is removed
+	flags["f2"] = 7000
+	store.expire()
+	assert.Equal(t, map[string]int64{"f1": 7001}, store.State().Lifted)
+	delete(flags, "f1")
+	store.expire()
+	assert.Equal(t, map[string]int64{"f1": 7001}, store.State().Lifted)
+	upstream = 7002
+	store.expire()
+	assert.Empty(t, store.State().Lifted)
+	reloaded, err = NewStore(path, func() int64 { return -1 }, flagCN, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+	assert.Empty(t, reloaded.State().Lifted)
+	assert.Equal(t, int64(7001), notified[len(notified)-1])
+
+	var actions []string
+	for _, entry := range auditLog.Entries() {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{"override.set", "override.set", "override.remove", "override.expire"}, actions)
+}
+
+func TestStoreHandWrittenFile(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	auditLog, err := audit.NewLog(logger, 10, "")
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"overrides": [{"split": "checkout_v2", "treatment": "off"}]}`), 0600))
+	store, err := NewStore(path, func() int64 { return 123 }, func(string) (int64, bool) { return 0, false }, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+	all := store.List()
+	assert.Len(t, all, 1)
+	assert.Equal(t, "off", all[0].Treatment)
+	assert.Greater(t, all[0].ChangeNumber, int64(123))
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"overrides": [{"split": "checkout_v2"}]}`), 0600))
+	_, err = NewStore(path, func() int64 { return 123 }, func(string) (int64, bool) { return 0, false }, func(int64) {}, auditLog, logger)
+	assert.ErrorIs(t, err, ErrInvalidOverride)
+
+	// a missing file is not an error, it's created upon the first change
+	store, err = NewStore(filepath.Join(t.TempDir(), "missing.json"), func() int64 { return 123 }, func(string) (int64, bool) { return 0, false }, func(int64) {}, auditLog, logger)
+	assert.Nil(t, err)
+	assert.Empty(t, store.List())
+}
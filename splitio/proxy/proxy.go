@@ -1,24 +1,28 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/splitio/go-split-commons/v6/engine/evaluator"
 	"github.com/splitio/go-split-commons/v6/service"
 	"github.com/splitio/go-toolkit/v5/logging"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
-	"github.com/splitio/gincache"
 )
 
 // Options struct to set options for Proxy mode.
@@ -35,6 +39,20 @@ type Options struct {
 	// APIKeys used for authenticating proxy requests
 	APIKeys []string
 
+	// flag sets some of the apikeys are restricted to
+	APIKeyFlagSets map[string][]string
+
+	// RateLimiter applied to sdk & data posting endpoints. Shared by all environments, so that per-ip limits are global
+	RateLimiter *middleware.RateLimiter
+
+	// ips/cidrs of reverse proxies whose X-Forwarded-For & X-Real-IP headers are honored when determining the client ip.
+	// If empty, the ip the connection comes from is used
+	TrustedProxies []string
+
+	// APIKeyValidator used for authenticating proxy requests, allowing apikeys to be added & revoked at runtime.
+	// If not set, one is built from APIKeys & APIKeyFlagSets
+	APIKeyValidator *middleware.APIKeyValidator
+
 	// ImpressionListener to forward incoming impression bulks to
 	ImpressionListener impressionlistener.ImpressionBulkListener
 
@@ -75,7 +93,7 @@ type Options struct {
 	Telemetry storage.ProxyEndpointTelemetry
 
 	// HTTP cache
-	Cache *gincache.Middleware
+	Cache *caching.Middleware
 
 	// Proxy TLS configuration
 	TLSConfig *tls.Config
@@ -83,6 +101,37 @@ type Options struct {
 	FlagSets []string
 
 	FlagSetsStrictMatching bool
+
+	// how long upstream splitChanges responses are shared among SDKs requesting the same `since`
+	SplitChangesCollapseTTL time.Duration
+
+	// max number of concurrent splitChanges requests sent upstream on behalf of SDKs
+	MaxUpstreamSplitFetches int
+
+	// used to mint push tokens for SDKs (nil if push notifications are not served by the proxy)
+	PushTokenIssuer streaming.TokenIssuer
+
+	// used to fan out updates to SDKs connected to the SSE endpoint
+	PushBroadcaster streaming.Broadcaster
+
+	// how often to send a keepalive on idle SSE connections
+	PushKeepAlive time.Duration
+
+	// used to evaluate flags on behalf of clients that cannot embed an sdk (nil if evaluation endpoints are disabled)
+	Evaluator evaluator.Interface
+
+	// used to list every cached flag when evaluating without flag sets
+	EvaluationFlagNames func() []string
+
+	// used to list the flags in each flag set, when evaluating with apikeys restricted to some of them
+	EvaluationFlagNamesBySets func(sets []string) map[string][]string
+
+	// whether to generate impressions for evaluations performed by the proxy
+	RecordEvaluationImpressions bool
+
+	// additional environments served by this proxy. Requests are routed to one of them if the client apikey belongs
+	// to it. Server-wide options (host, port, tls, etc) are ignored in these
+	Environments []*Options
 }
 
 // API bundles all components required to answer API calls from Split sdks
@@ -101,27 +150,85 @@ func (s *API) Start() error {
 	return s.server.ListenAndServe()
 }
 
+// Stop stops accepting connections & waits for in-flight requests to complete, until the context is done
+func (s *API) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
 // New instantiates a new Server
 func New(options *Options) *API {
 	if !options.DebugOn {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	apikeyValidator := middleware.NewAPIKeyValidator(options.APIKeys)
-	authController := controllers.NewAuthServerController()
+	router, sdkController, eventsController, telemetryController := setupRouter(options)
+	var handler http.Handler = router
+	if len(options.Environments) > 0 {
+		environments := make([]routedEnvironment, 0, len(options.Environments))
+		for _, env := range options.Environments {
+			if env.APIKeyValidator == nil {
+				env.APIKeyValidator = middleware.NewAPIKeyValidator(env.APIKeys, env.APIKeyFlagSets)
+			}
+			envRouter, _, _, _ := setupRouter(env)
+			environments = append(environments, routedEnvironment{validator: env.APIKeyValidator, handler: envRouter})
+		}
+		handler = newEnvironmentRouter(router, environments)
+	}
+
+	server := &http.Server{
+		Addr:      fmt.Sprintf("0.0.0.0:%d", options.Port),
+		Handler:   handler,
+		TLSConfig: options.TLSConfig,
+	}
+	if options.PushBroadcaster != nil {
+		// Shutdown doesn't cancel the context of active requests, so SSE connections need to be told to finish
+		server.RegisterOnShutdown(options.PushBroadcaster.Close)
+	}
+
+	return &API{
+		server:              server,
+		sdkConroller:        sdkController,
+		eventsConroller:     eventsController,
+		telemetryController: telemetryController,
+	}
+}
+
+// setupRouter builds the router & controllers used to serve a single environment
+func setupRouter(options *Options) (
+	*gin.Engine,
+	*controllers.SdkServerController,
+	*controllers.EventsServerController,
+	*controllers.TelemetryServerController,
+) {
+	apikeyValidator := options.APIKeyValidator
+	if apikeyValidator == nil {
+		apikeyValidator = middleware.NewAPIKeyValidator(options.APIKeys, options.APIKeyFlagSets)
+	}
+	authController := controllers.NewAuthServerController(options.Logger, options.PushTokenIssuer)
 	sdkController := setupSdkController(options)
 	eventsController := setupEventsController(options, apikeyValidator)
 	telemetryController := setupTelemetryController(options, apikeyValidator)
 
 	router := gin.New()
+	if err := router.SetTrustedProxies(options.TrustedProxies); err != nil {
+		options.Logger.Error("invalid trusted proxies, forwarding headers will be ignored: ", err)
+		router.SetTrustedProxies(nil)
+	}
 	router.Use(gin.Recovery())
 	router.Use(setupCorsMiddleware())
 	router.Use(middleware.SetEndpoint)
 	router.Use(middleware.NewProxyMetricsMiddleware(options.Telemetry).Track)
+	if options.RateLimiter != nil {
+		router.Use(options.RateLimiter.Handle)
+	}
 
 	// split the main router into regular & beacon endpoints
 	regular := router.Group("/api")
 	regular.Use(apikeyValidator.AsMiddleware)
+	if options.RateLimiter != nil {
+		regular.Use(options.RateLimiter.HandleAPIKey)
+	}
+	regular.Use(middleware.ConditionalGet)
 	regular.Use(gzip.Gzip(gzip.DefaultCompression))
 
 	// Beacon endpoints group
@@ -133,24 +240,34 @@ func New(options *Options) *API {
 	if options.Cache != nil {
 		cacheableRouter = router.Group("/api")
 		cacheableRouter.Use(apikeyValidator.AsMiddleware)
+		if options.RateLimiter != nil {
+			cacheableRouter.Use(options.RateLimiter.HandleAPIKey)
+		}
+		cacheableRouter.Use(middleware.ConditionalGet) // must go before the cache so that hits are validated too
 		cacheableRouter.Use(options.Cache.Handle)
 		cacheableRouter.Use(gzip.Gzip(gzip.DefaultCompression))
 	}
-	authController.Register(cacheableRouter)
+	if options.PushTokenIssuer != nil && options.PushBroadcaster != nil {
+		// tokens are bound to the user keys supplied in each request, so auth responses cannot be cached
+		authController.Register(regular)
+		streamingController := controllers.NewStreamingServerController(
+			options.Logger,
+			options.PushTokenIssuer,
+			options.PushBroadcaster,
+			options.PushKeepAlive,
+		)
+		streamingController.Register(router) // the sse endpoint authenticates via the token in the query string
+	} else {
+		authController.Register(cacheableRouter)
+	}
 	sdkController.Register(cacheableRouter)
+	if options.Evaluator != nil {
+		setupEvaluationController(options).Register(regular)
+	}
 	eventsController.Register(regular, beacon)
 	telemetryController.Register(regular, beacon)
 
-	return &API{
-		server: &http.Server{
-			Addr:      fmt.Sprintf("0.0.0.0:%d", options.Port),
-			Handler:   router,
-			TLSConfig: options.TLSConfig,
-		},
-		sdkConroller:        sdkController,
-		eventsConroller:     eventsController,
-		telemetryController: telemetryController,
-	}
+	return router, sdkController, eventsController, telemetryController
 }
 
 func setupSdkController(options *Options) *controllers.SdkServerController {
@@ -160,6 +277,20 @@ func setupSdkController(options *Options) *controllers.SdkServerController {
 		options.ProxySplitStorage,
 		options.ProxySegmentStorage,
 		flagsets.NewMatcher(options.FlagSetsStrictMatching, options.FlagSets),
+		options.SplitChangesCollapseTTL,
+		options.MaxUpstreamSplitFetches,
+	)
+}
+
+func setupEvaluationController(options *Options) *controllers.EvaluationServerController {
+	return controllers.NewEvaluationServerController(
+		options.Logger,
+		options.Evaluator,
+		options.EvaluationFlagNames,
+		options.EvaluationFlagNamesBySets,
+		options.ImpressionsSink,
+		options.ImpressionListener,
+		options.RecordEvaluationImpressions,
 	)
 }
 
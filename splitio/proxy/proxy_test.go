@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -147,6 +148,55 @@ func TestSplitChangesWithFlagsetsCaching(t *testing.T) {
 	assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
 }
 
+func TestSplitChangesWithApikeyFlagSetScopes(t *testing.T) {
+	opts := makeOpts()
+	opts.APIKeys = []string{"someApiKey", "mobileApiKey"}
+	opts.APIKeyFlagSets = map[string][]string{"mobileApiKey": {"mobile"}}
+	var splitStorage pstorageMocks.ProxySplitStorageMock
+	opts.ProxySplitStorage = &splitStorage
+	proxy := New(opts)
+	go proxy.Start()
+	time.Sleep(1 * time.Second) // Let the scheduler switch the current thread/gr and start the server
+
+	splitStorage.On("ChangesSince", int64(-1), []string{"mobile"}).
+		Return(&dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{{Name: "mobileSplit"}}}, nil).
+		Twice()
+	splitStorage.On("ChangesSince", int64(-1), []string(nil)).
+		Return(&dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{{Name: "mobileSplit"}, {Name: "webSplit"}}}, nil).
+		Once()
+
+	// no sets requested, only the allowed ones are returned
+	status, body, _ := get("splitChanges?since=-1", opts.Port, map[string]string{"Authorization": "Bearer mobileApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Len(t, toSplitChanges(body).Splits, 1)
+
+	// sets not allowed are ignored
+	status, body, _ = get("splitChanges?since=-1&sets=mobile,web", opts.Port, map[string]string{"Authorization": "Bearer mobileApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Len(t, toSplitChanges(body).Splits, 1)
+
+	// nothing accessible, the sdk is told it's up to date
+	splitStorage.On("ChangeNumber").Return(int64(1), nil).Once()
+	status, body, _ = get("splitChanges?since=-1&sets=web", opts.Port, map[string]string{"Authorization": "Bearer mobileApiKey"})
+	assert.Equal(t, 200, status)
+	changes := toSplitChanges(body)
+	assert.Len(t, changes.Splits, 0)
+	assert.Equal(t, int64(-1), changes.Since)
+	assert.Equal(t, int64(1), changes.Till)
+
+	// same request with an unrestricted apikey should not be served from the scoped cache entry
+	status, body, _ = get("splitChanges?since=-1", opts.Port, map[string]string{"Authorization": "Bearer someApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Len(t, toSplitChanges(body).Splits, 2)
+
+	// and the scoped one is still cached
+	status, body, _ = get("splitChanges?since=-1", opts.Port, map[string]string{"Authorization": "Bearer mobileApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Len(t, toSplitChanges(body).Splits, 1)
+
+	splitStorage.AssertExpectations(t)
+}
+
 func TestSegmentChangesAndMySegmentsEndpoints(t *testing.T) {
 
 	var segmentStorage pstorageMocks.ProxySegmentStorageMock
@@ -236,6 +286,148 @@ func TestSegmentChangesAndMySegmentsEndpoints(t *testing.T) {
 	assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
 }
 
+func TestConditionalRequests(t *testing.T) {
+	var splitStorage pstorageMocks.ProxySplitStorageMock
+	var segmentStorage pstorageMocks.ProxySegmentStorageMock
+	opts := makeOpts()
+	opts.ProxySplitStorage = &splitStorage
+	opts.ProxySegmentStorage = &segmentStorage
+	proxy := New(opts)
+	go proxy.Start()
+	time.Sleep(1 * time.Second) // Let the scheduler switch the current thread/gr and start the server
+
+	auth := map[string]string{"Authorization": "Bearer someApiKey"}
+	conditional := func(etag string) map[string]string {
+		return map[string]string{"Authorization": "Bearer someApiKey", "If-None-Match": etag}
+	}
+
+	// storage is only hit once per resource, validation of cached entries is done by the cache
+	splitStorage.On("ChangesSince", int64(1), []string(nil)).
+		Return(&dtos.SplitChangesDTO{Since: 1, Till: 1, Splits: []dtos.SplitDTO{}}, nil).
+		Once()
+	status, _, headers := get("splitChanges?since=1", opts.Port, auth)
+	assert.Equal(t, 200, status)
+	etag := headers.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	status, body, headers := get("splitChanges?since=1", opts.Port, conditional(etag))
+	assert.Equal(t, 304, status)
+	assert.Empty(t, body)
+	assert.Equal(t, etag, headers.Get("ETag"))
+
+	status, _, _ = get("splitChanges?since=1", opts.Port, conditional(`"other", `+etag))
+	assert.Equal(t, 304, status)
+
+	status, body, _ = get("splitChanges?since=1", opts.Port, conditional(`"outdated"`))
+	assert.Equal(t, 200, status)
+	assert.Equal(t, int64(1), toSplitChanges(body).Till)
+
+	// once the cache is evicted due to a change, the ETag no longer matches
+	splitStorage.On("ChangesSince", int64(1), []string(nil)).
+		Return(&dtos.SplitChangesDTO{Since: 1, Till: 2, Splits: []dtos.SplitDTO{{Name: "split1"}}}, nil).
+		Once()
+	opts.Cache.EvictBySurrogate(caching.SplitSurrogate)
+	status, body, headers = get("splitChanges?since=1", opts.Port, conditional(etag))
+	assert.Equal(t, 200, status)
+	assert.Equal(t, int64(2), toSplitChanges(body).Till)
+	assert.NotEqual(t, etag, headers.Get("ETag"))
+
+	// different flag sets yield different ETags
+	splitStorage.On("ChangesSince", int64(1), []string{"set1"}).
+		Return(&dtos.SplitChangesDTO{Since: 1, Till: 2, Splits: []dtos.SplitDTO{{Name: "split1"}}}, nil).
+		Once()
+	status, _, setsHeaders := get("splitChanges?since=1&sets=set1", opts.Port, conditional(headers.Get("ETag")))
+	assert.Equal(t, 200, status)
+	assert.NotEqual(t, headers.Get("ETag"), setsHeaders.Get("ETag"))
+
+	segmentStorage.On("ChangesSince", "segment1", int64(1)).
+		Return(&dtos.SegmentChangesDTO{Since: 1, Till: 1, Name: "segment1"}, nil).
+		Once()
+	status, _, headers = get("segmentChanges/segment1?since=1", opts.Port, auth)
+	assert.Equal(t, 200, status)
+	status, body, _ = get("segmentChanges/segment1?since=1", opts.Port, conditional(headers.Get("ETag")))
+	assert.Equal(t, 304, status)
+	assert.Empty(t, body)
+
+	segmentStorage.On("SegmentsFor", "k1").Return([]string{"segment2", "segment1"}, nil).Once()
+	status, body, headers = get("mySegments/k1", opts.Port, auth)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, []dtos.MySegmentDTO{{Name: "segment1"}, {Name: "segment2"}}, toMySegments(body))
+	status, _, _ = get("mySegments/k1", opts.Port, conditional(headers.Get("ETag")))
+	assert.Equal(t, 304, status)
+
+	splitStorage.AssertExpectations(t)
+	segmentStorage.AssertExpectations(t)
+}
+
+func TestMultipleEnvironments(t *testing.T) {
+	opts := makeOpts()
+	var splitStorage pstorageMocks.ProxySplitStorageMock
+	opts.ProxySplitStorage = &splitStorage
+
+	stagingOpts := makeOpts()
+	stagingOpts.APIKeys = []string{"stagingApiKey"}
+	var stagingSplitStorage pstorageMocks.ProxySplitStorageMock
+	stagingOpts.ProxySplitStorage = &stagingSplitStorage
+	var stagedEvents []interface{}
+	stagingOpts.EventsSink = &taskMocks.MockDeferredRecordingTask{StageCall: func(raw interface{}) error {
+		stagedEvents = append(stagedEvents, raw)
+		return nil
+	}}
+	opts.Environments = []*Options{stagingOpts}
+
+	proxy := New(opts)
+	go proxy.Start()
+	time.Sleep(1 * time.Second) // Let the scheduler switch the current thread/gr and start the server
+
+	splitStorage.On("ChangesSince", int64(-1), []string(nil)).
+		Return(&dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{{Name: "prodSplit"}}}, nil).
+		Once()
+	stagingSplitStorage.On("ChangesSince", int64(-1), []string(nil)).
+		Return(&dtos.SplitChangesDTO{Since: -1, Till: 2, Splits: []dtos.SplitDTO{{Name: "stagingSplit"}}}, nil).
+		Once()
+
+	status, body, _ := get("splitChanges?since=-1", opts.Port, map[string]string{"Authorization": "Bearer someApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "prodSplit", toSplitChanges(body).Splits[0].Name)
+
+	// same path & query, different environment. caches are not shared either
+	status, body, _ = get("splitChanges?since=-1", opts.Port, map[string]string{"Authorization": "Bearer stagingApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "stagingSplit", toSplitChanges(body).Splits[0].Name)
+
+	status, _, _ = get("splitChanges?since=-1", opts.Port, map[string]string{"Authorization": "Bearer unknownApiKey"})
+	assert.Equal(t, 401, status)
+
+	// keys added at runtime are routed to their environment
+	stagingOpts.APIKeyValidator.Set("newStagingApiKey", nil)
+	stagingSplitStorage.On("ChangesSince", int64(1), []string(nil)).
+		Return(&dtos.SplitChangesDTO{Since: 1, Till: 2, Splits: []dtos.SplitDTO{{Name: "stagingSplit"}}}, nil).
+		Once()
+	status, body, _ = get("splitChanges?since=1", opts.Port, map[string]string{"Authorization": "Bearer newStagingApiKey"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "stagingSplit", toSplitChanges(body).Splits[0].Name)
+
+	// beacons carry the apikey in the body
+	beacon := `{"entries":[{"eventTypeId":"click","key":"k1","timestamp":1}],"token":"stagingApiKey","sdk":"javascript-10.0.0"}`
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/events/beacon", opts.Port), "application/json", strings.NewReader(beacon))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 204, resp.StatusCode)
+	assert.Len(t, stagedEvents, 1)
+
+	// oversized beacons are rejected before being buffered in full
+	oversized := `{"token":"stagingApiKey","entries":"` + strings.Repeat("x", maxBeaconBodySize) + `"}`
+	resp, err = http.Post(fmt.Sprintf("http://localhost:%d/api/events/beacon", opts.Port), "application/json", strings.NewReader(oversized))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 413, resp.StatusCode)
+	assert.Len(t, stagedEvents, 1)
+
+	splitStorage.AssertExpectations(t)
+	stagingSplitStorage.AssertExpectations(t)
+}
+
 func makeOpts() *Options {
 	return &Options{
 		Logger:              logging.NewLogger(nil),
@@ -252,7 +444,7 @@ func makeOpts() *Options {
 		TelemetryConfigSink: &taskMocks.MockDeferredRecordingTask{},
 		TelemetryUsageSink:  &taskMocks.MockDeferredRecordingTask{},
 		Telemetry:           storage.NewProxyTelemetryFacade(),
-		Cache:               caching.MakeProxyCache(),
+		Cache:               caching.MakeProxyCache(1000000, 0),
 	}
 }
 
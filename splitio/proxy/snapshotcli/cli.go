@@ -0,0 +1,200 @@
+package snapshotcli
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+// Command is the name of the subcommand handled by this package
+const Command = "snapshot"
+
+// Exit codes
+const (
+	ExitSuccess    = 0
+	ExitError      = 1
+	ExitInvalidUse = 2
+)
+
+const usage = `Usage: split-proxy snapshot <command> [options] <snapshot>...
+
+Commands:
+  inspect <snapshot>          show the snapshot metadata & list its feature flags & segments
+  diff <old> <new>            show the feature flags & segments added, removed or changed between two snapshots
+  extract <snapshot>          export the feature flags as a splitChanges json payload
+
+Options:
+`
+
+var errInvalidUse = errors.New("invalid usage")
+
+// defaultEnvironment is how the environment with no name is shown
+const defaultEnvironment = "(default)"
+
+// options common to all commands
+type options struct {
+	verificationKeyFN string
+	environment       string
+	output            string
+	keys              bool
+}
+
+// Run executes a snapshot subcommand (`args` should not include the subcommand name itself), writing its results
+// to `stdout` & errors to `stderr`. The exit code is returned
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	var opts options
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.verificationKeyFN, "verification-key-fn", "", "PEM Ed25519 public key. If set, snapshots not signed with the matching private key are rejected")
+	fs.StringVar(&opts.environment, "env", "", "Only show the data of this environment ('"+defaultEnvironment+"' for the default one)")
+	fs.StringVar(&opts.output, "output", "", "File to write the extracted feature flags to (Default: stdout)")
+	fs.BoolVar(&opts.keys, "keys", false, "List the keys of every segment when inspecting a snapshot")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return ExitInvalidUse
+	}
+
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return ExitInvalidUse
+	}
+
+	var err error
+	switch command {
+	case "inspect":
+		err = runWithSnapshots(fs.Args(), 1, &opts, func(s []*loaded) error { return inspect(stdout, s[0], &opts) })
+	case "diff":
+		err = runWithSnapshots(fs.Args(), 2, &opts, func(s []*loaded) error { return diff(stdout, s[0], s[1], &opts) })
+	case "extract":
+		err = runWithSnapshots(fs.Args(), 1, &opts, func(s []*loaded) error { return extract(stdout, s[0], &opts) })
+	default:
+		err = fmt.Errorf("%w: unknown command '%s'", errInvalidUse, command)
+	}
+
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.Is(err, errInvalidUse):
+		fmt.Fprintln(stderr, err.Error())
+		fs.Usage()
+		return ExitInvalidUse
+	default:
+		fmt.Fprintln(stderr, "error:", err.Error())
+		return ExitError
+	}
+}
+
+// loaded is a decoded snapshot along with the db holding its payload
+type loaded struct {
+	path     string
+	snap     *snapshot.Snapshot
+	db       *persistent.BoltDBWrapper
+	dbPath   string
+	contents map[string]*persistent.Contents
+}
+
+func runWithSnapshots(paths []string, expected int, opts *options, f func([]*loaded) error) error {
+	if len(paths) != expected {
+		return fmt.Errorf("%w: expected %d snapshot file(s), got %d", errInvalidUse, expected, len(paths))
+	}
+
+	var key ed25519.PublicKey
+	if opts.verificationKeyFN != "" {
+		var err error
+		if key, err = snapshot.LoadVerificationKey(opts.verificationKeyFN); err != nil {
+			return err
+		}
+	}
+
+	snapshots := make([]*loaded, 0, len(paths))
+	defer func() {
+		for _, s := range snapshots {
+			s.close()
+		}
+	}()
+
+	for _, path := range paths {
+		s, err := load(path, key)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return f(snapshots)
+}
+
+// load decodes a snapshot & reads the data of every environment from its payload
+func load(path string, key ed25519.PublicKey) (*loaded, error) {
+	snap, err := snapshot.DecodeFromFileVerified(path, key)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding snapshot %s: %w", path, err)
+	}
+
+	dbPath, err := snap.WriteDataToTmpFile()
+	if err != nil {
+		return nil, fmt.Errorf("error extracting data from snapshot %s: %w", path, err)
+	}
+
+	db, err := persistent.NewBoltWrapper(dbPath, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		os.Remove(dbPath)
+		return nil, fmt.Errorf("error opening data from snapshot %s: %w", path, err)
+	}
+
+	toRet := &loaded{path: path, snap: snap, db: db, dbPath: dbPath}
+	toRet.contents, err = persistent.ReadAllContents(db, logging.NewLogger(&logging.LoggerOptions{LogLevel: logging.LevelNone}))
+	if err != nil {
+		toRet.close()
+		return nil, fmt.Errorf("error reading data from snapshot %s: %w", path, err)
+	}
+	return toRet, nil
+}
+
+func (l *loaded) close() {
+	l.db.Close()
+	os.Remove(l.dbPath)
+}
+
+// environments returns the names of the environments to show, sorted & filtered by the `-env` option
+func (l *loaded) environments(opts *options) []string {
+	toRet := make([]string, 0, len(l.contents))
+	for _, name := range sortedKeys(l.contents) {
+		if opts.environment == "" || displayName(name) == opts.environment || name == opts.environment {
+			toRet = append(toRet, name)
+		}
+	}
+	return toRet
+}
+
+// environmentMetadata returns the metadata of an environment, or nil if the snapshot doesn't include it
+func (l *loaded) environmentMetadata(name string) *snapshot.EnvironmentMetadata {
+	meta := l.snap.Meta()
+	for idx := range meta.Environments {
+		if meta.Environments[idx].Name == name {
+			return &meta.Environments[idx]
+		}
+	}
+	return nil
+}
+
+func displayName(environment string) string {
+	if environment == "" {
+		return defaultEnvironment
+	}
+	return environment
+}
@@ -0,0 +1,176 @@
+package snapshotcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+const fixture = "../../../test/snapshot/proxy.snapshot"
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// changedSnapshot writes a copy of the fixture with a flag & a segment key added, plus another environment
+func changedSnapshot(t *testing.T) string {
+	snap, err := snapshot.DecodeFromFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbPath, err := snap.WriteDataToTmpFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(dbPath)
+
+	db, err := persistent.NewBoltWrapper(dbPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := logging.NewLogger(nil)
+	persistent.NewSplitChangesCollection(db, logger).Update([]dtos.SplitDTO{{Name: "new_flag", ChangeNumber: 1700000000000, Status: "ACTIVE"}}, nil, 1700000000000)
+	persistent.NewSegmentChangesCollection(db, logger).Update("gold_users", set.NewSet("someone_new"), set.NewSet(), 1700000000000)
+	staging := persistent.NewNamespacedDB(db, "staging")
+	persistent.NewSplitChangesCollection(staging, logger).Update([]dtos.SplitDTO{{Name: "staging_flag", ChangeNumber: 1, Status: "ACTIVE"}}, nil, 1)
+
+	raw, err := db.GetRawSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, _ := snapshot.New(snapshot.Metadata{
+		Version:      1,
+		Storage:      snapshot.StorageBoltDB,
+		CreatedAt:    1700000000000,
+		SpecVersion:  "1.1",
+		Environments: []snapshot.EnvironmentMetadata{{Name: "", SDKKeyHash: 1234, FlagSets: []string{"backend"}}},
+	}, raw)
+	encoded, err := changed.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "changed.snapshot")
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInspect(t *testing.T) {
+	code, stdout, stderr := run("inspect", "-keys", fixture)
+	if code != ExitSuccess {
+		t.Error("unexpected exit code: ", code, stderr)
+	}
+
+	for _, expected := range []string{"v1, no checksum", "Environment (default)", "enable_paywall", "gold_users", "1629225616727"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("'%s' not found in output:\n%s", expected, stdout)
+		}
+	}
+
+	changed := changedSnapshot(t)
+	code, stdout, _ = run("inspect", "-env", "(default)", changed)
+	if code != ExitSuccess {
+		t.Error("unexpected exit code: ", code)
+	}
+
+	for _, expected := range []string{"checksum verified, unsigned", "2023-11-14T22:13:20Z", "SDK key hash: 1234", "Flag sets filter: backend", "new_flag"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("'%s' not found in output:\n%s", expected, stdout)
+		}
+	}
+
+	if strings.Contains(stdout, "staging") {
+		t.Error("only the default environment should be shown:\n", stdout)
+	}
+
+	if code, _, _ = run("inspect", "-env", "nonexistent", changed); code != ExitError {
+		t.Error("unknown environments should fail. Got: ", code)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	code, stdout, stderr := run("diff", fixture, changedSnapshot(t))
+	if code != ExitSuccess {
+		t.Error("unexpected exit code: ", code, stderr)
+	}
+
+	expected := strings.Join([]string{
+		"  environment (default)",
+		"    + flag new_flag (change number 1700000000000)",
+		"    ~ segment gold_users (+1 -0 keys)",
+		"+ environment staging",
+		"    + flag staging_flag (change number 1)",
+		"",
+	}, "\n")
+	if stdout != expected {
+		t.Errorf("unexpected diff. Expected:\n%s\nGot:\n%s", expected, stdout)
+	}
+
+	if code, stdout, _ = run("diff", fixture, fixture); code != ExitSuccess || stdout != "  environment (default)\n" {
+		t.Error("identical snapshots should have no differences. Got: ", stdout)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "flags.json")
+	if code, _, stderr := run("extract", "-output", output, fixture); code != ExitSuccess {
+		t.Error("unexpected exit code: ", code, stderr)
+	}
+
+	raw, err := os.ReadFile(output)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var payload dtos.SplitChangesDTO
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Error(err)
+	}
+
+	if len(payload.Splits) != 1 || payload.Splits[0].Name != "enable_paywall" || payload.Till != 1629225616727 {
+		t.Error("unexpected payload: ", payload)
+	}
+
+	changed := changedSnapshot(t)
+	code, stdout, _ := run("extract", "-env", "staging", changed)
+	if code != ExitSuccess || !strings.Contains(stdout, "staging_flag") || strings.Contains(stdout, "new_flag") {
+		t.Error("only the flags of the selected environment should be extracted. Got: ", stdout)
+	}
+}
+
+func TestInvalidUsage(t *testing.T) {
+	if code, _, _ := run(); code != ExitInvalidUse {
+		t.Error("a command is required. Got: ", code)
+	}
+
+	if code, _, _ := run("explode", fixture); code != ExitInvalidUse {
+		t.Error("unknown commands should fail. Got: ", code)
+	}
+
+	if code, _, _ := run("diff", fixture); code != ExitInvalidUse {
+		t.Error("diff requires two snapshots. Got: ", code)
+	}
+
+	if code, _, stderr := run("inspect", "nonexistent.snapshot"); code != ExitError || !strings.Contains(stderr, "cannot find snapshot file") {
+		t.Error("missing files should fail. Got: ", code, stderr)
+	}
+}
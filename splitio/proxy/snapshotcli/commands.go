@@ -0,0 +1,265 @@
+package snapshotcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+// inspect prints the metadata of a snapshot along with the feature flags & segments of every environment
+func inspect(out io.Writer, s *loaded, opts *options) error {
+	environments := s.environments(opts)
+	if opts.environment != "" && len(environments) == 0 {
+		return fmt.Errorf("environment '%s' not found in snapshot", opts.environment)
+	}
+
+	meta := s.snap.Meta()
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Snapshot:\t%s\n", s.path)
+	fmt.Fprintf(w, "Format:\tv%d, %s\n", s.snap.Format(), integrity(s.snap, opts))
+	fmt.Fprintf(w, "Version:\t%d\n", meta.Version)
+	fmt.Fprintf(w, "Created at:\t%s\n", orUnknown(meta.CreatedAt != 0, func() string { return time.UnixMilli(meta.CreatedAt).UTC().Format(time.RFC3339) }))
+	fmt.Fprintf(w, "Spec version:\t%s\n", orUnknown(meta.SpecVersion != "", func() string { return meta.SpecVersion }))
+	w.Flush()
+
+	for _, name := range environments {
+		contents := s.contents[name]
+		fmt.Fprintf(out, "\nEnvironment %s\n", displayName(name))
+		if envMeta := s.environmentMetadata(name); envMeta != nil {
+			fmt.Fprintf(out, "  SDK key hash: %d\n", envMeta.SDKKeyHash)
+			fmt.Fprintf(out, "  Flag sets filter: %s\n", orNone(envMeta.FlagSets))
+		}
+
+		fmt.Fprintf(out, "\n  Feature flags: %d active, %d total, change number %d\n", contents.ActiveFlags(), len(contents.Flags), contents.FlagsChangeNumber())
+		w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  NAME\tSTATUS\tCHANGE NUMBER\tKILLED\tDEFAULT TREATMENT\tSETS")
+		for _, flag := range contents.Flags {
+			fmt.Fprintf(w, "  %s\t%s\t%d\t%t\t%s\t%s\n", flag.Name, flag.Status, flag.ChangeNumber, flag.Killed, flag.DefaultTreatment, orNone(flag.Sets))
+		}
+		w.Flush()
+
+		fmt.Fprintf(out, "\n  Segments: %d, change number %d\n", len(contents.Segments), contents.SegmentsChangeNumber())
+		w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  NAME\tKEYS\tCHANGE NUMBER")
+		for idx := range contents.Segments {
+			segment := &contents.Segments[idx]
+			keys := segment.ActiveKeys()
+			fmt.Fprintf(w, "  %s\t%d\t%d\n", segment.Name, len(keys), segment.ChangeNumber())
+			if opts.keys {
+				for _, key := range keys {
+					fmt.Fprintf(w, "    %s\t\t\n", key)
+				}
+			}
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// diff prints the feature flags & segments added, removed or changed in every environment between two snapshots
+func diff(out io.Writer, old *loaded, new *loaded, opts *options) error {
+	names := make(map[string]struct{})
+	for _, name := range old.environments(opts) {
+		names[name] = struct{}{}
+	}
+	for _, name := range new.environments(opts) {
+		names[name] = struct{}{}
+	}
+
+	if opts.environment != "" && len(names) == 0 {
+		return fmt.Errorf("environment '%s' not found in either snapshot", opts.environment)
+	}
+
+	empty := &persistent.Contents{}
+	for _, name := range sortedKeys(names) {
+		before, after := old.contents[name], new.contents[name]
+		switch {
+		case before == nil:
+			fmt.Fprintf(out, "+ environment %s\n", displayName(name))
+			before = empty
+		case after == nil:
+			fmt.Fprintf(out, "- environment %s\n", displayName(name))
+			after = empty
+		default:
+			fmt.Fprintf(out, "  environment %s\n", displayName(name))
+		}
+
+		diffFlags(out, before.Flags, after.Flags)
+		diffSegments(out, before.Segments, after.Segments)
+	}
+	return nil
+}
+
+func diffFlags(out io.Writer, before []dtos.SplitDTO, after []dtos.SplitDTO) {
+	byName := func(flags []dtos.SplitDTO) map[string]*dtos.SplitDTO {
+		toRet := make(map[string]*dtos.SplitDTO, len(flags))
+		for idx := range flags {
+			toRet[flags[idx].Name] = &flags[idx]
+		}
+		return toRet
+	}
+
+	old, new := byName(before), byName(after)
+	for _, name := range unionOfKeys(old, new) {
+		o, n := old[name], new[name]
+		switch {
+		case o == nil:
+			fmt.Fprintf(out, "    + flag %s (change number %d)\n", name, n.ChangeNumber)
+		case n == nil:
+			fmt.Fprintf(out, "    - flag %s (change number %d)\n", name, o.ChangeNumber)
+		case o.ChangeNumber != n.ChangeNumber || !reflect.DeepEqual(o, n):
+			fmt.Fprintf(out, "    ~ flag %s (change number %d -> %d)\n", name, o.ChangeNumber, n.ChangeNumber)
+		}
+	}
+}
+
+func diffSegments(out io.Writer, before []persistent.SegmentChangesItem, after []persistent.SegmentChangesItem) {
+	byName := func(segments []persistent.SegmentChangesItem) map[string]map[string]struct{} {
+		toRet := make(map[string]map[string]struct{}, len(segments))
+		for idx := range segments {
+			keys := make(map[string]struct{})
+			for _, key := range segments[idx].ActiveKeys() {
+				keys[key] = struct{}{}
+			}
+			toRet[segments[idx].Name] = keys
+		}
+		return toRet
+	}
+
+	old, new := byName(before), byName(after)
+	for _, name := range unionOfKeys(old, new) {
+		o, inOld := old[name]
+		n, inNew := new[name]
+		switch {
+		case !inOld:
+			fmt.Fprintf(out, "    + segment %s (%d keys)\n", name, len(n))
+		case !inNew:
+			fmt.Fprintf(out, "    - segment %s (%d keys)\n", name, len(o))
+		default:
+			var added, removed int
+			for key := range n {
+				if _, ok := o[key]; !ok {
+					added++
+				}
+			}
+			for key := range o {
+				if _, ok := n[key]; !ok {
+					removed++
+				}
+			}
+			if added > 0 || removed > 0 {
+				fmt.Fprintf(out, "    ~ segment %s (+%d -%d keys)\n", name, added, removed)
+			}
+		}
+	}
+}
+
+// extract writes the active feature flags of an environment as a splitChanges payload
+func extract(out io.Writer, s *loaded, opts *options) error {
+	name, err := s.singleEnvironment(opts)
+	if err != nil {
+		return err
+	}
+
+	contents := s.contents[name]
+	payload := dtos.SplitChangesDTO{Splits: make([]dtos.SplitDTO, 0, len(contents.Flags))}
+	for _, flag := range contents.Flags {
+		if flag.Status == "ACTIVE" {
+			payload.Splits = append(payload.Splits, flag)
+		}
+	}
+	payload.Since = contents.FlagsChangeNumber()
+	payload.Till = payload.Since
+
+	serialized, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing feature flags: %w", err)
+	}
+	serialized = append(serialized, '\n')
+
+	if opts.output == "" {
+		_, err = out.Write(serialized)
+		return err
+	}
+
+	if err := os.WriteFile(opts.output, serialized, 0644); err != nil {
+		return fmt.Errorf("error writing feature flags to %s: %w", opts.output, err)
+	}
+	return nil
+}
+
+// singleEnvironment returns the environment selected with `-env`, or the only/default one if none was
+func (l *loaded) singleEnvironment(opts *options) (string, error) {
+	environments := l.environments(opts)
+	switch {
+	case len(environments) == 1:
+		return environments[0], nil
+	case opts.environment != "":
+		return "", fmt.Errorf("environment '%s' not found in snapshot", opts.environment)
+	case len(environments) == 0:
+		return "", fmt.Errorf("snapshot %s has no data", l.path)
+	}
+
+	if _, ok := l.contents[""]; ok {
+		return "", nil
+	}
+	return "", fmt.Errorf("%w: snapshot has several environments, one must be selected with -env", errInvalidUse)
+}
+
+func integrity(snap *snapshot.Snapshot, opts *options) string {
+	switch {
+	case snap.Format() == snapshot.FormatV1:
+		return "no checksum"
+	case snap.Signed() && opts.verificationKeyFN != "":
+		return "checksum verified, signature verified"
+	case snap.Signed():
+		return "checksum verified, signed (signature not verified)"
+	default:
+		return "checksum verified, unsigned"
+	}
+}
+
+func orUnknown(known bool, value func() string) string {
+	if !known {
+		return "unknown"
+	}
+	return value()
+}
+
+func orNone(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	toRet := make([]string, 0, len(m))
+	for key := range m {
+		toRet = append(toRet, key)
+	}
+	sort.Strings(toRet)
+	return toRet
+}
+
+func unionOfKeys[T any](a map[string]T, b map[string]T) []string {
+	union := make(map[string]struct{}, len(a)+len(b))
+	for key := range a {
+		union[key] = struct{}{}
+	}
+	for key := range b {
+		union[key] = struct{}{}
+	}
+	return sortedKeys(union)
+}
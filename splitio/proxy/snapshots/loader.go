@@ -0,0 +1,234 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+// defaultEnvironment is how the environment with no name is referred to in results & audit log entries
+const defaultEnvironment = "(default)"
+
+var (
+	// ErrInvalidSnapshot is returned when the snapshot to load cannot be decoded or verified
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+	// ErrNoMatchingEnvironment is returned when the snapshot has no data for any of the environments served
+	ErrNoMatchingEnvironment = errors.New("snapshot has no data for the environments served")
+
+	// ErrStaleSnapshot is returned when the snapshot holds data older than the one currently served
+	ErrStaleSnapshot = errors.New("snapshot is older than the data currently served")
+
+	// ErrSDKKeyMismatch is returned when an environment in the snapshot was synchronized with a different sdk key
+	ErrSDKKeyMismatch = errors.New("snapshot was taken with a different sdk key")
+)
+
+// Loader defines the interface of a component replacing the data served by a running proxy with the one in a snapshot
+type Loader interface {
+	Load(encoded []byte, force bool, actor string) (*LoadResult, error)
+}
+
+// SplitRestorer is the interface of a feature flag storage whose contents can be replaced
+type SplitRestorer interface {
+	Restore(all []dtos.SplitDTO) (int64, error)
+}
+
+// SegmentRestorer is the interface of a segment storage whose contents can be replaced
+type SegmentRestorer interface {
+	Restore(all []persistent.SegmentChangesItem) error
+}
+
+// CacheEvicter is the interface of the http cache used to serve sdk requests
+type CacheEvicter interface {
+	EvictAll()
+}
+
+// Target is an environment served by the proxy whose data can be replaced
+type Target struct {
+	Name       string // empty for the default environment
+	SDKKeyHash uint32
+	DB         persistent.DBWrapper
+	Splits     SplitRestorer
+	Segments   SegmentRestorer
+	Cache      CacheEvicter
+}
+
+// EnvironmentResult describes the data loaded into an environment
+type EnvironmentResult struct {
+	Name                 string `json:"name"`
+	Flags                int    `json:"flags"`
+	Segments             int    `json:"segments"`
+	PreviousChangeNumber int64  `json:"previousChangeNumber"`
+	ChangeNumber         int64  `json:"changeNumber"`
+}
+
+// LoadResult describes the outcome of loading a snapshot
+type LoadResult struct {
+	Environments []EnvironmentResult `json:"environments"`
+	Skipped      []string            `json:"skipped,omitempty"` // environments served that have no data in the snapshot
+}
+
+// LoaderImpl implements the Loader interface
+type LoaderImpl struct {
+	targets  []Target
+	key      ed25519.PublicKey
+	auditLog audit.Log
+	logger   logging.LoggerInterface
+	mutex    sync.Mutex
+}
+
+// NewLoader constructs a new snapshot loader for the supplied environments.
+// If `key` is not nil, only snapshots signed with the matching private key are accepted
+func NewLoader(targets []Target, key ed25519.PublicKey, auditLog audit.Log, logger logging.LoggerInterface) *LoaderImpl {
+	return &LoaderImpl{targets: targets, key: key, auditLog: auditLog, logger: logger}
+}
+
+// Load replaces the feature flags & segments of every environment in the snapshot, evicting their http caches.
+// Unless `force` is set, snapshots with data older than the one currently served (or synchronized with a different
+// sdk key) are rejected. All environments are validated before any data is replaced
+func (l *LoaderImpl) Load(encoded []byte, force bool, actor string) (*LoadResult, error) {
+	snap, err := snapshot.DecodeVerified(encoded, l.key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err.Error())
+	}
+
+	meta := snap.Meta()
+	if meta.Storage != snapshot.StorageBoltDB {
+		return nil, fmt.Errorf("%w: unsupported storage type %d", ErrInvalidSnapshot, meta.Storage)
+	}
+
+	contents, err := ReadSnapshotContents(snap, l.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	result := &LoadResult{Environments: make([]EnvironmentResult, 0, len(l.targets))}
+	toLoad := make([]*Target, 0, len(l.targets))
+	current := make([]*persistent.Contents, 0, len(l.targets))
+	for idx := range l.targets {
+		target := &l.targets[idx]
+		incoming, ok := contents[target.Name]
+		if !ok {
+			result.Skipped = append(result.Skipped, displayName(target.Name))
+			continue
+		}
+
+		served, err := persistent.ReadContents(target.DB, l.logger)
+		if err != nil {
+			return nil, fmt.Errorf("error reading data of environment %s: %w", displayName(target.Name), err)
+		}
+
+		if !force {
+			if err := checkLoadable(target, &meta, served, incoming); err != nil {
+				return nil, err
+			}
+		}
+		toLoad = append(toLoad, target)
+		current = append(current, served)
+	}
+
+	if len(toLoad) == 0 {
+		return nil, ErrNoMatchingEnvironment
+	}
+
+	for idx, target := range toLoad {
+		incoming := contents[target.Name]
+		cn, err := target.Splits.Restore(incoming.Flags)
+		if err != nil {
+			return nil, fmt.Errorf("error loading feature flags of environment %s: %w", displayName(target.Name), err)
+		}
+		if err := target.Segments.Restore(incoming.Segments); err != nil {
+			return nil, fmt.Errorf("error loading segments of environment %s: %w", displayName(target.Name), err)
+		}
+		target.Cache.EvictAll()
+
+		envResult := EnvironmentResult{
+			Name:                 displayName(target.Name),
+			Flags:                incoming.ActiveFlags(),
+			Segments:             len(incoming.Segments),
+			PreviousChangeNumber: current[idx].FlagsChangeNumber(),
+			ChangeNumber:         cn,
+		}
+		result.Environments = append(result.Environments, envResult)
+		l.auditLog.Record(actor, "snapshot.load", envResult.Name, map[string]string{
+			"changeNumber":         strconv.FormatInt(envResult.ChangeNumber, 10),
+			"previousChangeNumber": strconv.FormatInt(envResult.PreviousChangeNumber, 10),
+			"forced":               strconv.FormatBool(force),
+		})
+	}
+	return result, nil
+}
+
+// checkLoadable makes sure the data of an environment in the snapshot is not older than the one currently served
+// & that it was synchronized with the same sdk key (when known)
+func checkLoadable(target *Target, meta *snapshot.Metadata, served *persistent.Contents, incoming *persistent.Contents) error {
+	for idx := range meta.Environments {
+		envMeta := &meta.Environments[idx]
+		if envMeta.Name == target.Name && envMeta.SDKKeyHash != 0 && envMeta.SDKKeyHash != target.SDKKeyHash {
+			return fmt.Errorf("%w: environment %s", ErrSDKKeyMismatch, displayName(target.Name))
+		}
+	}
+
+	if servedCN, incomingCN := served.FlagsChangeNumber(), incoming.FlagsChangeNumber(); servedCN > incomingCN {
+		return fmt.Errorf("%w: feature flags of environment %s are at change number %d, whereas the snapshot's are at %d",
+			ErrStaleSnapshot, displayName(target.Name), servedCN, incomingCN)
+	}
+
+	servedSegments := make(map[string]int64, len(served.Segments))
+	for idx := range served.Segments {
+		servedSegments[served.Segments[idx].Name] = served.Segments[idx].ChangeNumber()
+	}
+	for idx := range incoming.Segments {
+		segment := &incoming.Segments[idx]
+		if servedCN, ok := servedSegments[segment.Name]; ok && servedCN > segment.ChangeNumber() {
+			return fmt.Errorf("%w: segment %s of environment %s is at change number %d, whereas the snapshot's is at %d",
+				ErrStaleSnapshot, segment.Name, displayName(target.Name), servedCN, segment.ChangeNumber())
+		}
+	}
+	return nil
+}
+
+// ReadSnapshotContents reads the data of every environment in a snapshot with a boltdb payload, keyed by namespace
+func ReadSnapshotContents(snap *snapshot.Snapshot, logger logging.LoggerInterface) (map[string]*persistent.Contents, error) {
+	dbPath, err := snap.WriteDataToTmpFile()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err.Error())
+	}
+	defer os.Remove(dbPath)
+
+	db, err := persistent.NewBoltWrapper(dbPath, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err.Error())
+	}
+	defer db.Close()
+
+	contents, err := persistent.ReadAllContents(db, logger)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err.Error())
+	}
+	return contents, nil
+}
+
+func displayName(environment string) string {
+	if environment == "" {
+		return defaultEnvironment
+	}
+	return environment
+}
+
+var _ Loader = (*LoaderImpl)(nil)
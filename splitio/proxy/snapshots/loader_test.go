@@ -0,0 +1,175 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/audit"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+type cacheMock struct {
+	evictions int
+}
+
+func (c *cacheMock) EvictAll() { c.evictions++ }
+
+// env sets up the storages of an environment on top of a (possibly namespaced) db
+func env(t *testing.T, db persistent.DBWrapper, name string, sdkKeyHash uint32, flagsCN int64, segmentCN int64) (*Target, *cacheMock) {
+	t.Helper()
+	logger := logging.NewLogger(nil)
+	splits := storage.NewProxySplitStorage(db, logger, flagsets.NewFlagSetFilter(nil), false)
+	splits.Update([]dtos.SplitDTO{{Name: "f_" + name, ChangeNumber: flagsCN, Status: "ACTIVE", TrafficTypeName: "user"}}, nil, flagsCN)
+	segments := storage.NewProxySegmentStorage(db, logger, false, 0)
+	if err := segments.Update("s1", set.NewSet("k_"+name), set.NewSet(), segmentCN); err != nil {
+		t.Fatal("error populating segments: ", err)
+	}
+
+	cache := &cacheMock{}
+	return &Target{Name: name, SDKKeyHash: sdkKeyHash, DB: db, Splits: splits, Segments: segments, Cache: cache}, cache
+}
+
+// encodedSnapshot builds a snapshot with a default & a `staging` environment
+func encodedSnapshot(t *testing.T, key ed25519.PrivateKey, flagsCN int64, segmentCN int64) []byte {
+	t.Helper()
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	if err != nil {
+		t.Fatal("error creating db: ", err)
+	}
+	defer db.Close()
+
+	env(t, db, "", 0, flagsCN, segmentCN)
+	env(t, persistent.NewNamespacedDB(db, "staging"), "staging", 0, flagsCN, segmentCN)
+	describe := func() (snapshot.Metadata, error) {
+		return snapshot.Metadata{Environments: []snapshot.EnvironmentMetadata{{Name: "", SDKKeyHash: 1}, {Name: "staging", SDKKeyHash: 2}}}, nil
+	}
+
+	encoded, _, err := snapshot.NewBuilder(db, key, describe).Build()
+	if err != nil {
+		t.Fatal("error building snapshot: ", err)
+	}
+	return encoded
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	db, _ := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	defer db.Close()
+
+	primary, cache := env(t, db, "", 1, 10, 10)
+	qa, qaCache := env(t, persistent.NewNamespacedDB(db, "qa"), "qa", 3, 10, 10)
+	auditLog, _ := audit.NewLog(logger, 10, "")
+	loader := NewLoader([]Target{*primary, *qa}, nil, auditLog, logger)
+
+	result, err := loader.Load(encodedSnapshot(t, nil, 20, 20), false, "someone")
+	if err != nil {
+		t.Fatal("no error should be returned. Got: ", err)
+	}
+
+	expected := EnvironmentResult{Name: defaultEnvironment, Flags: 1, Segments: 1, PreviousChangeNumber: 10, ChangeNumber: 20}
+	if len(result.Environments) != 1 || result.Environments[0] != expected {
+		t.Error("only the default environment should be loaded. Got: ", result.Environments)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "qa" {
+		t.Error("qa should be skipped. Got: ", result.Skipped)
+	}
+
+	splits := primary.Splits.(*storage.ProxySplitStorageImpl)
+	if cn, _ := splits.ChangeNumber(); cn != 20 || splits.Split("f_") == nil {
+		t.Error("feature flags should have been replaced. Got CN: ", cn)
+	}
+	segments := primary.Segments.(*storage.ProxySegmentStorageImpl)
+	if cn, _ := segments.ChangeNumber("s1"); cn != 20 {
+		t.Error("segments should have been replaced. Got CN: ", cn)
+	}
+	if cache.evictions != 1 || qaCache.evictions != 0 {
+		t.Error("only the cache of the loaded environment should be evicted. Got: ", cache.evictions, qaCache.evictions)
+	}
+	if cn, _ := qa.Splits.(*storage.ProxySplitStorageImpl).ChangeNumber(); cn != 10 {
+		t.Error("qa should be left untouched. Got CN: ", cn)
+	}
+
+	entries := auditLog.Entries()
+	if len(entries) != 1 || entries[0].Action != "snapshot.load" || entries[0].Actor != "someone" || entries[0].Details["changeNumber"] != "20" {
+		t.Error("the load should be audited. Got: ", entries)
+	}
+}
+
+func TestLoadSnapshotGuards(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	db, _ := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	defer db.Close()
+
+	primary, _ := env(t, db, "", 1, 10, 10)
+	staging, _ := env(t, persistent.NewNamespacedDB(db, "staging"), "staging", 2, 10, 10)
+	auditLog, _ := audit.NewLog(logger, 10, "")
+	loader := NewLoader([]Target{*primary, *staging}, nil, auditLog, logger)
+
+	if _, err := loader.Load(encodedSnapshot(t, nil, 5, 20), false, "someone"); !errors.Is(err, ErrStaleSnapshot) {
+		t.Error("snapshots with older feature flags should be rejected. Got: ", err)
+	}
+	if _, err := loader.Load(encodedSnapshot(t, nil, 20, 5), false, "someone"); !errors.Is(err, ErrStaleSnapshot) {
+		t.Error("snapshots with older segments should be rejected. Got: ", err)
+	}
+	if cn, _ := primary.Splits.(*storage.ProxySplitStorageImpl).ChangeNumber(); cn != 10 || len(auditLog.Entries()) != 0 {
+		t.Error("nothing should be loaded when a snapshot is rejected. Got CN: ", cn)
+	}
+
+	// snapshots are always loaded when forced
+	result, err := loader.Load(encodedSnapshot(t, nil, 5, 5), true, "someone")
+	if err != nil || len(result.Environments) != 2 {
+		t.Error("forced loads should succeed. Got: ", result, err)
+	}
+	if cn, _ := primary.Splits.(*storage.ProxySplitStorageImpl).ChangeNumber(); cn != 5 {
+		t.Error("older data should be loaded when forced. Got CN: ", cn)
+	}
+
+	staging.SDKKeyHash = 3
+	loader = NewLoader([]Target{*primary, *staging}, nil, auditLog, logger)
+	if _, err := loader.Load(encodedSnapshot(t, nil, 20, 20), false, "someone"); !errors.Is(err, ErrSDKKeyMismatch) {
+		t.Error("snapshots taken with a different sdk key should be rejected. Got: ", err)
+	}
+	if _, err := loader.Load(encodedSnapshot(t, nil, 20, 20), true, "someone"); err != nil {
+		t.Error("snapshots taken with a different sdk key should be loaded when forced. Got: ", err)
+	}
+
+	other, _ := env(t, persistent.NewNamespacedDB(db, "other"), "other", 0, 1, 1)
+	loader = NewLoader([]Target{*other}, nil, auditLog, logger)
+	if _, err := loader.Load(encodedSnapshot(t, nil, 20, 20), false, "someone"); !errors.Is(err, ErrNoMatchingEnvironment) {
+		t.Error("snapshots without any of the environments served should be rejected. Got: ", err)
+	}
+}
+
+func TestLoadSnapshotVerification(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	db, _ := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	defer db.Close()
+
+	public, private, _ := ed25519.GenerateKey(nil)
+	primary, _ := env(t, db, "", 1, 10, 10)
+	auditLog, _ := audit.NewLog(logger, 10, "")
+	loader := NewLoader([]Target{*primary}, public, auditLog, logger)
+
+	if _, err := loader.Load([]byte("garbage"), true, "someone"); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Error("garbage should be rejected. Got: ", err)
+	}
+	if _, err := loader.Load(encodedSnapshot(t, nil, 20, 20), true, "someone"); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Error("unsigned snapshots should be rejected. Got: ", err)
+	}
+
+	encoded := encodedSnapshot(t, private, 20, 20)
+	if _, err := loader.Load(encoded[:len(encoded)-3], true, "someone"); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Error("truncated snapshots should be rejected. Got: ", err)
+	}
+	if _, err := loader.Load(encoded, false, "someone"); err != nil {
+		t.Error("signed snapshots should be loaded. Got: ", err)
+	}
+}
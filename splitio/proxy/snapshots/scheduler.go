@@ -0,0 +1,97 @@
+package snapshots
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// how often the scheduler checks whether a snapshot is due
+const checkPeriodSecs = 1
+
+// ChangeCounter counts feature flag & segment updates, so that snapshots can be saved after a number of them
+type ChangeCounter struct {
+	count int64
+}
+
+// Add records `n` updates
+func (c *ChangeCounter) Add(n int) {
+	atomic.AddInt64(&c.count, int64(n))
+}
+
+// Count returns the number of updates recorded since the last snapshot
+func (c *ChangeCounter) Count() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+// Scheduler saves a snapshot every `period`, and whenever `afterChanges` updates have been processed since the last one
+type Scheduler struct {
+	writer       *Writer
+	changes      *ChangeCounter
+	period       time.Duration
+	afterChanges int64
+	lastWrite    time.Time
+	clock        func() time.Time
+	logger       logging.LoggerInterface
+	task         *asynctask.AsyncTask
+}
+
+// NewScheduler constructs a new snapshot scheduler. A zero `period` or `afterChanges` disables the respective trigger
+func NewScheduler(
+	writer *Writer,
+	changes *ChangeCounter,
+	period time.Duration,
+	afterChanges int64,
+	logger logging.LoggerInterface,
+) *Scheduler {
+	scheduler := &Scheduler{
+		writer:       writer,
+		changes:      changes,
+		period:       period,
+		afterChanges: afterChanges,
+		clock:        time.Now,
+		logger:       logger,
+	}
+
+	scheduler.task = asynctask.NewAsyncTask("snapshot-scheduler", func(logging.LoggerInterface) error {
+		scheduler.tick()
+		return nil
+	}, checkPeriodSecs, func(logging.LoggerInterface) error {
+		scheduler.lastWrite = scheduler.clock()
+		return nil
+	}, nil, logger)
+	return scheduler
+}
+
+// Start begins saving snapshots. The first periodic one is saved a full period after starting
+func (s *Scheduler) Start() {
+	s.task.Start()
+}
+
+// Stop stops saving snapshots
+func (s *Scheduler) Stop() {
+	s.task.Stop(false)
+}
+
+func (s *Scheduler) tick() {
+	now := s.clock()
+	changes := s.changes.Count()
+	periodElapsed := s.period > 0 && now.Sub(s.lastWrite) >= s.period
+	enoughChanges := s.afterChanges > 0 && changes >= s.afterChanges
+	if !periodElapsed && !enoughChanges {
+		return
+	}
+
+	// failures are not retried until the next trigger, so that a persistent error doesn't fill the logs
+	s.lastWrite = now
+	s.changes.Add(-int(changes))
+	path, err := s.writer.Write()
+	if err != nil {
+		s.logger.Error("error saving scheduled snapshot: ", err)
+		return
+	}
+	s.logger.Info(fmt.Sprintf("Snapshot saved to %s (%d updates since the previous one)", path, changes))
+}
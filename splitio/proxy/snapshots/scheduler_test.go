@@ -0,0 +1,67 @@
+package snapshots
+
+import (
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+func TestSchedulerTriggers(t *testing.T) {
+	dir := t.TempDir()
+	logger := logging.NewLogger(nil)
+	now := time.Now()
+	builder := &builderMock{createdAt: now.UnixMilli()}
+	writer, _ := NewWriter(dir, builder, 0, 0, logger)
+
+	changes := &ChangeCounter{}
+	scheduler := NewScheduler(writer, changes, time.Hour, 10, logger)
+	scheduler.clock = func() time.Time { return now }
+	scheduler.lastWrite = now
+
+	count := func() int {
+		entries, _ := List(dir)
+		return len(entries)
+	}
+
+	changes.Add(9)
+	scheduler.tick()
+	if count() != 0 {
+		t.Error("no snapshot should be saved before the period elapses or enough changes are made")
+	}
+
+	changes.Add(1)
+	builder.createdAt++
+	scheduler.tick()
+	if count() != 1 || changes.Count() != 0 {
+		t.Error("a snapshot should be saved after enough changes. Got: ", count(), changes.Count())
+	}
+
+	now = now.Add(time.Hour)
+	builder.createdAt++
+	scheduler.tick()
+	if count() != 2 {
+		t.Error("a snapshot should be saved once the period elapses. Got: ", count())
+	}
+
+	builder.createdAt++
+	scheduler.tick()
+	if count() != 2 {
+		t.Error("the period should restart after a snapshot is saved. Got: ", count())
+	}
+}
+
+func TestSchedulerDisabledTriggers(t *testing.T) {
+	dir := t.TempDir()
+	logger := logging.NewLogger(nil)
+	writer, _ := NewWriter(dir, &builderMock{createdAt: time.Now().UnixMilli()}, 0, 0, logger)
+
+	changes := &ChangeCounter{}
+	scheduler := NewScheduler(writer, changes, 0, 0, logger)
+	scheduler.clock = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	changes.Add(1000)
+	scheduler.tick()
+	if entries, _ := List(dir); len(entries) != 0 {
+		t.Error("no snapshot should be saved with both triggers disabled. Got: ", entries)
+	}
+}
@@ -0,0 +1,86 @@
+package snapshots
+
+import (
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/segment"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
+)
+
+// CountingSplitUpdater wraps a feature flag updater and counts the flags updated
+type CountingSplitUpdater struct {
+	wrapped split.Updater
+	changes *ChangeCounter
+}
+
+// NewCountingSplitUpdater constructs a new feature flag updater that records every update in `changes`
+func NewCountingSplitUpdater(wrapped split.Updater, changes *ChangeCounter) *CountingSplitUpdater {
+	return &CountingSplitUpdater{wrapped: wrapped, changes: changes}
+}
+
+// SynchronizeSplits forwards the call to the wrapped updater & counts the flags updated
+func (c *CountingSplitUpdater) SynchronizeSplits(till *int64) (*split.UpdateResult, error) {
+	result, err := c.wrapped.SynchronizeSplits(till)
+	if result != nil {
+		c.changes.Add(len(result.UpdatedSplits))
+	}
+	return result, err
+}
+
+// SynchronizeFeatureFlags forwards the call to the wrapped updater & counts the flags updated
+func (c *CountingSplitUpdater) SynchronizeFeatureFlags(ffChange *dtos.SplitChangeUpdate) (*split.UpdateResult, error) {
+	result, err := c.wrapped.SynchronizeFeatureFlags(ffChange)
+	if result != nil {
+		c.changes.Add(len(result.UpdatedSplits))
+	}
+	return result, err
+}
+
+// LocalKill forwards the call to the wrapped updater & counts the kill as an update
+func (c *CountingSplitUpdater) LocalKill(splitName string, defaultTreatment string, changeNumber int64) {
+	c.wrapped.LocalKill(splitName, defaultTreatment, changeNumber)
+	c.changes.Add(1)
+}
+
+// CountingSegmentUpdater wraps a segment updater and counts the segments updated
+type CountingSegmentUpdater struct {
+	wrapped segment.Updater
+	changes *ChangeCounter
+}
+
+// NewCountingSegmentUpdater constructs a new segment updater that records every update in `changes`
+func NewCountingSegmentUpdater(wrapped segment.Updater, changes *ChangeCounter) *CountingSegmentUpdater {
+	return &CountingSegmentUpdater{wrapped: wrapped, changes: changes}
+}
+
+// SynchronizeSegment forwards the call to the wrapped updater & counts the segment if it was updated
+func (c *CountingSegmentUpdater) SynchronizeSegment(name string, till *int64) (*segment.UpdateResult, error) {
+	result, err := c.wrapped.SynchronizeSegment(name, till)
+	if result != nil && len(result.UpdatedKeys) > 0 {
+		c.changes.Add(1)
+	}
+	return result, err
+}
+
+// SynchronizeSegments forwards the call to the wrapped updater & counts the segments updated
+func (c *CountingSegmentUpdater) SynchronizeSegments() (map[string]segment.UpdateResult, error) {
+	results, err := c.wrapped.SynchronizeSegments()
+	for name := range results {
+		if len(results[name].UpdatedKeys) > 0 {
+			c.changes.Add(1)
+		}
+	}
+	return results, err
+}
+
+// SegmentNames forwards the call to the wrapped updater
+func (c *CountingSegmentUpdater) SegmentNames() []interface{} {
+	return c.wrapped.SegmentNames()
+}
+
+// IsSegmentCached forwards the call to the wrapped updater
+func (c *CountingSegmentUpdater) IsSegmentCached(segmentName string) bool {
+	return c.wrapped.IsSegmentCached(segmentName)
+}
+
+var _ split.Updater = (*CountingSplitUpdater)(nil)
+var _ segment.Updater = (*CountingSegmentUpdater)(nil)
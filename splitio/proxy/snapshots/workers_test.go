@@ -0,0 +1,101 @@
+package snapshots
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/segment"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCountingSplitUpdater(t *testing.T) {
+	changes := &ChangeCounter{}
+	wrapped := &splitUpdaterMock{}
+	wrapped.On("SynchronizeSplits", (*int64)(nil)).Return(&split.UpdateResult{UpdatedSplits: []string{"f1", "f2"}}, nil).Once()
+	wrapped.On("SynchronizeSplits", (*int64)(nil)).Return((*split.UpdateResult)(nil), errors.New("something")).Once()
+	wrapped.On("SynchronizeFeatureFlags", mock.Anything).Return(&split.UpdateResult{UpdatedSplits: []string{"f3"}}, nil).Once()
+	wrapped.On("LocalKill", "f1", "off", int64(123)).Once()
+
+	updater := NewCountingSplitUpdater(wrapped, changes)
+	res, err := updater.SynchronizeSplits(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"f1", "f2"}, res.UpdatedSplits)
+	assert.Equal(t, int64(2), changes.Count())
+
+	_, err = updater.SynchronizeSplits(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(2), changes.Count())
+
+	updater.SynchronizeFeatureFlags(&dtos.SplitChangeUpdate{})
+	assert.Equal(t, int64(3), changes.Count())
+
+	updater.LocalKill("f1", "off", 123)
+	assert.Equal(t, int64(4), changes.Count())
+	wrapped.AssertExpectations(t)
+}
+
+func TestCountingSegmentUpdater(t *testing.T) {
+	changes := &ChangeCounter{}
+	wrapped := &segmentUpdaterMock{}
+	wrapped.On("SynchronizeSegment", "s1", (*int64)(nil)).Return(&segment.UpdateResult{UpdatedKeys: []string{"k1"}}, nil).Once()
+	wrapped.On("SynchronizeSegment", "s2", (*int64)(nil)).Return(&segment.UpdateResult{}, nil).Once()
+	wrapped.On("SynchronizeSegments").Return(map[string]segment.UpdateResult{
+		"s1": {UpdatedKeys: []string{"k2", "k3"}},
+		"s2": {},
+		"s3": {UpdatedKeys: []string{"k4"}},
+	}, nil).Once()
+
+	updater := NewCountingSegmentUpdater(wrapped, changes)
+	updater.SynchronizeSegment("s1", nil)
+	assert.Equal(t, int64(1), changes.Count())
+
+	updater.SynchronizeSegment("s2", nil)
+	assert.Equal(t, int64(1), changes.Count())
+
+	res, err := updater.SynchronizeSegments()
+	assert.Nil(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, int64(3), changes.Count())
+	wrapped.AssertExpectations(t)
+}
+
+type splitUpdaterMock struct {
+	mock.Mock
+}
+
+func (s *splitUpdaterMock) SynchronizeSplits(till *int64) (*split.UpdateResult, error) {
+	args := s.Called(till)
+	return args.Get(0).(*split.UpdateResult), args.Error(1)
+}
+
+func (s *splitUpdaterMock) SynchronizeFeatureFlags(ffChange *dtos.SplitChangeUpdate) (*split.UpdateResult, error) {
+	args := s.Called(ffChange)
+	return args.Get(0).(*split.UpdateResult), args.Error(1)
+}
+
+func (s *splitUpdaterMock) LocalKill(splitName string, defaultTreatment string, changeNumber int64) {
+	s.Called(splitName, defaultTreatment, changeNumber)
+}
+
+type segmentUpdaterMock struct {
+	mock.Mock
+}
+
+func (s *segmentUpdaterMock) IsSegmentCached(segmentName string) bool { panic("unimplemented") }
+func (s *segmentUpdaterMock) SegmentNames() []interface{}             { panic("unimplemented") }
+
+func (s *segmentUpdaterMock) SynchronizeSegment(name string, till *int64) (*segment.UpdateResult, error) {
+	args := s.Called(name, till)
+	return args.Get(0).(*segment.UpdateResult), args.Error(1)
+}
+
+func (s *segmentUpdaterMock) SynchronizeSegments() (map[string]segment.UpdateResult, error) {
+	args := s.Called()
+	return args.Get(0).(map[string]segment.UpdateResult), args.Error(1)
+}
+
+var _ split.Updater = (*splitUpdaterMock)(nil)
+var _ segment.Updater = (*segmentUpdaterMock)(nil)
@@ -0,0 +1,185 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+)
+
+// LatestLink is the name of the symlink pointing to the newest snapshot in the directory
+const LatestLink = "latest"
+
+// ErrNoSnapshot is returned when no valid snapshot is found in the directory
+var ErrNoSnapshot = errors.New("no valid snapshot found")
+
+// only files following the naming used by `snapshot.FileName` are considered (and rotated)
+var fileNameRegex = regexp.MustCompile(`^split\.proxy\.(\d+)\.snapshot$`)
+
+// Builder is the interface of the component generating the encoded snapshots
+type Builder interface {
+	Build() ([]byte, *snapshot.Metadata, error)
+}
+
+// Entry is a snapshot file found in the directory
+type Entry struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// Writer saves snapshots to a directory, deleting the ones that exceed the configured count or age
+type Writer struct {
+	dir      string
+	builder  Builder
+	maxCount int
+	maxAge   time.Duration
+	clock    func() time.Time
+	logger   logging.LoggerInterface
+	mutex    sync.Mutex
+}
+
+// NewWriter constructs a new snapshot writer. A zero `maxCount` or `maxAge` means no limit
+func NewWriter(dir string, builder Builder, maxCount int, maxAge time.Duration, logger logging.LoggerInterface) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating snapshots dir: %w", err)
+	}
+	return &Writer{dir: dir, builder: builder, maxCount: maxCount, maxAge: maxAge, clock: time.Now, logger: logger}, nil
+}
+
+// Write saves a new snapshot & points the `latest` symlink to it, returning its path.
+// Snapshots are written to a temporary file which is then renamed, so that they're never left half-written
+func (w *Writer) Write() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	encoded, meta, err := w.builder.Build()
+	if err != nil {
+		return "", err
+	}
+
+	name := snapshot.FileName(meta.CreatedAt)
+	path := filepath.Join(w.dir, name)
+	if err := writeAtomically(path, encoded); err != nil {
+		return "", err
+	}
+
+	// symlinks are replaced by renaming as well, so that `latest` always points to a complete snapshot
+	tmpLink := filepath.Join(w.dir, LatestLink+".tmp")
+	os.Remove(tmpLink)
+	if err := os.Symlink(name, tmpLink); err != nil {
+		return path, fmt.Errorf("error creating latest snapshot symlink: %w", err)
+	}
+	if err := os.Rename(tmpLink, filepath.Join(w.dir, LatestLink)); err != nil {
+		return path, fmt.Errorf("error replacing latest snapshot symlink: %w", err)
+	}
+
+	w.rotate()
+	return path, nil
+}
+
+// rotate deletes the snapshots exceeding the max count or age. The newest one is always kept
+func (w *Writer) rotate() {
+	entries, err := List(w.dir)
+	if err != nil {
+		w.logger.Error("error listing snapshots to rotate: ", err)
+		return
+	}
+
+	now := w.clock()
+	for idx := 1; idx < len(entries); idx++ {
+		tooMany := w.maxCount > 0 && idx >= w.maxCount
+		tooOld := w.maxAge > 0 && now.Sub(entries[idx].CreatedAt) > w.maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := os.Remove(entries[idx].Path); err != nil {
+			w.logger.Error(fmt.Sprintf("error deleting snapshot %s: %s", entries[idx].Path, err))
+		}
+	}
+}
+
+// List returns the snapshots in a directory, newest first
+func List(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshots dir: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for _, file := range files {
+		match := fileNameRegex.FindStringSubmatch(file.Name())
+		if match == nil || !file.Type().IsRegular() {
+			continue
+		}
+
+		createdAt, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Path: filepath.Join(dir, file.Name()), CreatedAt: time.UnixMilli(createdAt)})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+// LatestGood returns the path of the newest snapshot in a directory that can be decoded (& verified, if a key is
+// supplied), skipping corrupted ones
+func LatestGood(dir string, key ed25519.PublicKey, logger logging.LoggerInterface) (string, error) {
+	entries, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		snap, err := snapshot.DecodeFromFileVerified(entry.Path, key)
+		if err == nil {
+			// legacy snapshots have no checksum, so their data is checked as well
+			_, err = snap.Data()
+		}
+
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Skipping invalid snapshot %s: %s", entry.Path, err))
+			continue
+		}
+		return entry.Path, nil
+	}
+	return "", ErrNoSnapshot
+}
+
+func writeAtomically(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("error creating snapshot file: %w", err)
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error replacing snapshot file: %w", err)
+	}
+	return nil
+}
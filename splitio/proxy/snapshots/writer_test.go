@@ -0,0 +1,135 @@
+package snapshots
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+)
+
+type builderMock struct {
+	createdAt int64
+	key       ed25519.PrivateKey
+	err       error
+}
+
+func (b *builderMock) Build() ([]byte, *snapshot.Metadata, error) {
+	if b.err != nil {
+		return nil, nil, b.err
+	}
+
+	meta := snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, CreatedAt: b.createdAt}
+	snap, _ := snapshot.New(meta, []byte("some data"))
+	encoded, err := snap.EncodeSigned(b.key)
+	return encoded, &meta, err
+}
+
+func TestWriteAndRotate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	builder := &builderMock{}
+	writer, err := NewWriter(filepath.Join(dir, "nested"), builder, 3, time.Hour, logging.NewLogger(nil))
+	if err != nil {
+		t.Fatal("writer should be created along with its dir. Got: ", err)
+	}
+	writer.clock = func() time.Time { return now }
+
+	// an old snapshot left by a previous run, which should be deleted on the next write
+	builder.createdAt = now.Add(-2 * time.Hour).UnixMilli()
+	if _, err := writer.Write(); err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+
+	var paths []string
+	for idx := 4; idx >= 0; idx-- {
+		builder.createdAt = now.Add(-time.Duration(idx) * time.Minute).UnixMilli()
+		path, err := writer.Write()
+		if err != nil {
+			t.Error("no error should be returned. Got: ", err)
+		}
+		paths = append(paths, path)
+	}
+
+	entries, err := List(writer.dir)
+	if err != nil {
+		t.Error("no error should be returned. Got: ", err)
+	}
+	if len(entries) != 3 {
+		t.Fatal("only the 3 newest snapshots should be kept. Got: ", entries)
+	}
+	for idx, entry := range entries {
+		if entry.Path != paths[len(paths)-1-idx] {
+			t.Error("unexpected snapshot kept: ", entry.Path)
+		}
+	}
+
+	latest, err := os.Readlink(filepath.Join(writer.dir, LatestLink))
+	if err != nil || latest != filepath.Base(paths[len(paths)-1]) {
+		t.Error("latest should point to the newest snapshot. Got: ", latest, err)
+	}
+
+	if _, err := os.Stat(paths[len(paths)-1] + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Error("no temporary files should be left behind")
+	}
+
+	builder.err = errors.New("something")
+	if _, err := writer.Write(); err == nil {
+		t.Error("build errors should be propagated")
+	}
+}
+
+func TestRotationKeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	builder := &builderMock{createdAt: time.Now().Add(-48 * time.Hour).UnixMilli()}
+	writer, _ := NewWriter(dir, builder, 0, time.Hour, logging.NewLogger(nil))
+	writer.Write()
+	writer.Write()
+
+	if entries, _ := List(dir); len(entries) != 1 {
+		t.Error("the newest snapshot should never be deleted. Got: ", entries)
+	}
+}
+
+func TestLatestGood(t *testing.T) {
+	dir := t.TempDir()
+	logger := logging.NewLogger(nil)
+	public, private, _ := ed25519.GenerateKey(nil)
+
+	if _, err := LatestGood(dir, nil, logger); !errors.Is(err, ErrNoSnapshot) {
+		t.Error("an empty dir should have no snapshot. Got: ", err)
+	}
+
+	now := time.Now()
+	builder := &builderMock{createdAt: now.Add(-2 * time.Minute).UnixMilli(), key: private}
+	writer, _ := NewWriter(dir, builder, 0, 0, logger)
+	signed, _ := writer.Write()
+
+	builder.createdAt, builder.key = now.Add(-time.Minute).UnixMilli(), nil
+	unsigned, _ := writer.Write()
+
+	builder.createdAt = now.UnixMilli()
+	corrupted, _ := writer.Write()
+	raw, _ := os.ReadFile(corrupted)
+	os.WriteFile(corrupted, raw[:len(raw)-3], 0644)
+
+	// unrelated files are ignored
+	os.WriteFile(filepath.Join(dir, "split.proxy.notanumber.snapshot"), []byte("garbage"), 0644)
+
+	if path, err := LatestGood(dir, nil, logger); err != nil || path != unsigned {
+		t.Error("corrupted snapshots should be skipped. Got: ", path, err)
+	}
+
+	if path, err := LatestGood(dir, public, logger); err != nil || path != signed {
+		t.Error("unsigned snapshots should be skipped when a key is supplied. Got: ", path, err)
+	}
+
+	if _, err := LatestGood(filepath.Join(dir, "missing"), nil, logger); err == nil {
+		t.Error("a missing dir should be an error")
+	}
+}
@@ -14,6 +14,16 @@ func (p *ProxySplitStorageMock) ChangesSince(since int64, sets []string) (*dtos.
 	return args.Get(0).(*dtos.SplitChangesDTO), args.Error(1)
 }
 
+func (p *ProxySplitStorageMock) ChangeNumber() (int64, error) {
+	args := p.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (p *ProxySplitStorageMock) ApplyOverrides(changes *dtos.SplitChangesDTO, sets []string) *dtos.SplitChangesDTO {
+	args := p.Called(changes, sets)
+	return args.Get(0).(*dtos.SplitChangesDTO)
+}
+
 func (p *ProxySplitStorageMock) RegisterOlderCn(payload *dtos.SplitChangesDTO) {
 	p.Called(payload)
 }
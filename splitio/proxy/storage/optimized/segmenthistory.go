@@ -0,0 +1,268 @@
+package optimized
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+)
+
+// ErrSegmentNotCached is returned when querying the history of a segment that has never been updated
+var ErrSegmentNotCached = errors.New("segment not cached")
+
+// SegmentChangesHistory defines the interface for a change-number-aware segment history
+type SegmentChangesHistory interface {
+	ChangesSince(name string, since int64) (*SegmentChangesView, error)
+	Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet, changeNumber int64)
+	Restore(name string, keys []SegmentKeyView, horizon int64)
+	Prune(name string) (int64, bool)
+}
+
+// SegmentKeyView represents the latest known state of a key in a segment
+type SegmentKeyView struct {
+	Name         string
+	Removed      bool
+	ChangeNumber int64
+}
+
+// SegmentChangesView is the result of querying the history of a segment
+type SegmentChangesView struct {
+	Added   []string
+	Removed []string
+	Till    int64
+}
+
+// SegmentChangesHistoryImpl keeps, for every segment, its keys sorted by the change number in which they were last
+// updated, so that diffs can be computed without traversing the whole segment.
+// Removed keys are only kept for `retention` time. Requests with a `since` older than the resulting horizon are
+// answered with the current keys only. SDKs apply segmentChanges as a diff, so the ones still holding keys removed
+// before the horizon keep them for good. That's why removed keys are kept forever unless a retention is configured.
+type SegmentChangesHistoryImpl struct {
+	segments  map[string]*segmentHistory
+	retention time.Duration
+	mutex     sync.RWMutex
+}
+
+// NewSegmentChangesHistory constructs a new segment history. A retention <= 0 means removed keys are kept forever
+func NewSegmentChangesHistory(retention time.Duration) *SegmentChangesHistoryImpl {
+	return &SegmentChangesHistoryImpl{
+		segments:  make(map[string]*segmentHistory),
+		retention: retention,
+	}
+}
+
+// ChangesSince returns the keys added & removed after `since`
+func (h *SegmentChangesHistoryImpl) ChangesSince(name string, since int64) (*SegmentChangesView, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	segment, ok := h.segments[name]
+	if !ok {
+		return nil, ErrSegmentNotCached
+	}
+
+	if since < segment.horizon {
+		// we no longer know which keys were removed since then. return the current state of the segment
+		since = -1
+	}
+
+	view := &SegmentChangesView{Added: make([]string, 0), Removed: make([]string, 0), Till: segment.till}
+	for _, key := range segment.findNewerThan(since) {
+		switch {
+		case key.superseded:
+		case key.removed && since < 0: // removed keys should not be returned on initialization payloads
+		case key.removed:
+			view.Removed = append(view.Removed, key.name)
+		default:
+			view.Added = append(view.Added, key.name)
+		}
+	}
+	return view, nil
+}
+
+// Update registers the keys added & removed in a certain change number
+func (h *SegmentChangesHistoryImpl) Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet, changeNumber int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	segment := h.getOrCreate(name)
+	for _, key := range toRemove.List() {
+		if strKey, ok := key.(string); ok {
+			segment.update(strKey, true, changeNumber)
+		}
+	}
+	for _, key := range toAdd.List() {
+		if strKey, ok := key.(string); ok {
+			segment.update(strKey, false, changeNumber)
+		}
+	}
+	segment.sortIfNeeded()
+	if changeNumber > segment.till {
+		segment.till = changeNumber
+	}
+	h.checkpoint(segment, changeNumber)
+}
+
+// Restore populates the history of a segment from a previously persisted state
+func (h *SegmentChangesHistoryImpl) Restore(name string, keys []SegmentKeyView, horizon int64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	segment := h.getOrCreate(name)
+	for idx := range keys {
+		segment.update(keys[idx].Name, keys[idx].Removed, keys[idx].ChangeNumber)
+		if keys[idx].ChangeNumber > segment.till {
+			segment.till = keys[idx].ChangeNumber
+		}
+	}
+	segment.sortIfNeeded()
+	if horizon > segment.horizon {
+		segment.horizon = horizon
+	}
+
+	// we don't know when these changes were processed, so the retention period starts now
+	h.checkpoint(segment, segment.till)
+}
+
+// Prune discards removed keys that have been kept for longer than the retention period.
+// If the horizon of the segment is moved, the new value is returned along with `true`.
+func (h *SegmentChangesHistoryImpl) Prune(name string) (int64, bool) {
+	return h.prune(name, time.Now())
+}
+
+// public interface ends here
+
+func (h *SegmentChangesHistoryImpl) prune(name string, now time.Time) (int64, bool) {
+	if h.retention <= 0 {
+		return 0, false
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	segment, ok := h.segments[name]
+	if !ok {
+		return 0, false
+	}
+
+	cutoff := now.Add(-h.retention)
+	idx := sort.Search(len(segment.checkpoints), func(i int) bool { return segment.checkpoints[i].at.After(cutoff) })
+	if idx == 0 {
+		return 0, false
+	}
+
+	horizon := segment.checkpoints[idx-1].changeNumber
+	segment.checkpoints = append([]checkpoint(nil), segment.checkpoints[idx:]...)
+	if horizon <= segment.horizon {
+		return 0, false
+	}
+
+	for _, key := range segment.findNewerThan(segment.horizon) {
+		if key.changeNumber > horizon {
+			break
+		}
+		if key.removed && !key.superseded {
+			segment.supersede(key.name)
+		}
+	}
+	segment.horizon = horizon
+	segment.compactIfNeeded()
+	return horizon, true
+}
+
+// checkpoint records when a change number was processed. Checkpoints are only used to move the horizon when pruning,
+// so nothing is recorded if removed keys are kept forever. must be called with the lock held
+func (h *SegmentChangesHistoryImpl) checkpoint(segment *segmentHistory, changeNumber int64) {
+	if h.retention <= 0 {
+		return
+	}
+	segment.checkpoints = append(segment.checkpoints, checkpoint{changeNumber: changeNumber, at: time.Now()})
+}
+
+func (h *SegmentChangesHistoryImpl) getOrCreate(name string) *segmentHistory {
+	segment, ok := h.segments[name]
+	if !ok {
+		segment = &segmentHistory{positions: make(map[string]int), horizon: -1, till: -1}
+		h.segments[name] = segment
+	}
+	return segment
+}
+
+type keyChange struct {
+	name         string
+	removed      bool
+	changeNumber int64
+	superseded   bool // a newer change exists for this key, or it has been pruned
+}
+
+type checkpoint struct {
+	changeNumber int64
+	at           time.Time
+}
+
+type segmentHistory struct {
+	keys        []keyChange // sorted by change number
+	positions   map[string]int
+	stale       int
+	unsorted    bool
+	horizon     int64
+	till        int64
+	checkpoints []checkpoint
+}
+
+func (s *segmentHistory) update(name string, removed bool, changeNumber int64) {
+	s.supersede(name)
+	if len(s.keys) > 0 && changeNumber < s.keys[len(s.keys)-1].changeNumber {
+		s.unsorted = true // should only happen when processing out-of-order updates
+	}
+	s.positions[name] = len(s.keys)
+	s.keys = append(s.keys, keyChange{name: name, removed: removed, changeNumber: changeNumber})
+}
+
+func (s *segmentHistory) supersede(name string) {
+	if idx, ok := s.positions[name]; ok {
+		s.keys[idx].superseded = true
+		delete(s.positions, name)
+		s.stale++
+	}
+}
+
+func (s *segmentHistory) sortIfNeeded() {
+	if s.unsorted {
+		sort.SliceStable(s.keys, func(i, j int) bool { return s.keys[i].changeNumber < s.keys[j].changeNumber })
+		s.unsorted = false
+		s.rebuildPositions()
+	}
+	s.compactIfNeeded()
+}
+
+func (s *segmentHistory) compactIfNeeded() {
+	if s.stale == 0 || s.stale < len(s.keys)/2 {
+		return
+	}
+
+	live := make([]keyChange, 0, len(s.keys)-s.stale)
+	for idx := range s.keys {
+		if !s.keys[idx].superseded {
+			live = append(live, s.keys[idx])
+		}
+	}
+	s.keys = live
+	s.stale = 0
+	s.rebuildPositions()
+}
+
+func (s *segmentHistory) rebuildPositions() {
+	s.positions = make(map[string]int, len(s.keys))
+	for idx := range s.keys {
+		if !s.keys[idx].superseded {
+			s.positions[s.keys[idx].name] = idx
+		}
+	}
+}
+
+func (s *segmentHistory) findNewerThan(since int64) []keyChange {
+	// precondition: s.keys is sorted by CN
+	start := sort.Search(len(s.keys), func(i int) bool { return s.keys[i].changeNumber > since })
+	return s.keys[start:]
+}
+
+var _ SegmentChangesHistory = (*SegmentChangesHistoryImpl)(nil)
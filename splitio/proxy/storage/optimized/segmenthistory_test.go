@@ -0,0 +1,126 @@
+package optimized
+
+import (
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentHistoryDiffs(t *testing.T) {
+	history := NewSegmentChangesHistory(0)
+
+	_, err := history.ChangesSince("s1", -1)
+	assert.ErrorIs(t, err, ErrSegmentNotCached)
+
+	history.Update("s1", set.NewSet("k1", "k2", "k3"), set.NewSet(), 1)
+	history.Update("s1", set.NewSet("k4"), set.NewSet("k1"), 2)
+	history.Update("s1", set.NewSet("k1"), set.NewSet("k2"), 3)
+	history.Update("s1", set.NewSet(), set.NewSet(), 4) // no changes, only the CN is bumped
+	assert.Empty(t, history.segments["s1"].checkpoints) // removed keys are kept forever, nothing to prune
+
+	view, err := history.ChangesSince("s1", -1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k3", "k4"}, view.Added)
+	assert.ElementsMatch(t, []string{}, view.Removed)
+	assert.Equal(t, int64(4), view.Till)
+
+	view, err = history.ChangesSince("s1", 1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k4"}, view.Added)
+	assert.ElementsMatch(t, []string{"k2"}, view.Removed)
+
+	view, err = history.ChangesSince("s1", 2)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1"}, view.Added)
+	assert.ElementsMatch(t, []string{"k2"}, view.Removed)
+
+	view, err = history.ChangesSince("s1", 4)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{}, view.Added)
+	assert.ElementsMatch(t, []string{}, view.Removed)
+	assert.Equal(t, int64(4), view.Till)
+
+	// retention disabled, nothing should be pruned
+	_, pruned := history.Prune("s1")
+	assert.False(t, pruned)
+}
+
+func TestSegmentHistoryOutOfOrderAndCompaction(t *testing.T) {
+	history := NewSegmentChangesHistory(0)
+	for cn := int64(1); cn <= 100; cn++ {
+		history.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), cn) // same keys updated over and over
+	}
+
+	segment := history.segments["s1"]
+	assert.LessOrEqual(t, len(segment.keys), 4) // superseded entries were compacted
+	assert.Len(t, segment.positions, 2)
+
+	history.Update("s1", set.NewSet("k3"), set.NewSet(), 50)
+	view, err := history.ChangesSince("s1", 49)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k2", "k3"}, view.Added)
+	assert.Equal(t, int64(100), view.Till)
+
+	view, err = history.ChangesSince("s1", 99)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k2"}, view.Added)
+}
+
+func TestSegmentHistoryPruning(t *testing.T) {
+	history := NewSegmentChangesHistory(time.Hour)
+	history.Update("s1", set.NewSet("k1", "k2", "k3"), set.NewSet(), 1)
+	history.Update("s1", set.NewSet(), set.NewSet("k1"), 2)
+	history.Update("s1", set.NewSet(), set.NewSet("k2"), 3)
+
+	_, pruned := history.prune("s1", time.Now())
+	assert.False(t, pruned)
+	_, pruned = history.prune("s2", time.Now())
+	assert.False(t, pruned)
+
+	// pretend the first 2 updates were processed over an hour ago
+	segment := history.segments["s1"]
+	segment.checkpoints[0].at = time.Now().Add(-3 * time.Hour)
+	segment.checkpoints[1].at = time.Now().Add(-2 * time.Hour)
+	horizon, pruned := history.prune("s1", time.Now())
+	assert.True(t, pruned)
+	assert.Equal(t, int64(2), horizon)
+	assert.Len(t, segment.checkpoints, 1)
+
+	view, err := history.ChangesSince("s1", 1) // older than horizon, full snapshot
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k3"}, view.Added)
+	assert.ElementsMatch(t, []string{}, view.Removed)
+
+	view, err = history.ChangesSince("s1", 2)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{}, view.Added)
+	assert.ElementsMatch(t, []string{"k2"}, view.Removed)
+
+	// a key removed before the horizon that's added back should be reported normally
+	history.Update("s1", set.NewSet("k1"), set.NewSet(), 4)
+	view, err = history.ChangesSince("s1", 3)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1"}, view.Added)
+}
+
+func TestSegmentHistoryRestore(t *testing.T) {
+	history := NewSegmentChangesHistory(time.Hour)
+	history.Restore("s1", []SegmentKeyView{
+		{Name: "k3", ChangeNumber: 3},
+		{Name: "k1", ChangeNumber: 1},
+		{Name: "k2", ChangeNumber: 2, Removed: true},
+	}, 1)
+
+	view, err := history.ChangesSince("s1", 0) // older than the restored horizon
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k3"}, view.Added)
+	assert.ElementsMatch(t, []string{}, view.Removed)
+	assert.Equal(t, int64(3), view.Till)
+
+	view, err = history.ChangesSince("s1", 1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k3"}, view.Added)
+	assert.ElementsMatch(t, []string{"k2"}, view.Removed)
+}
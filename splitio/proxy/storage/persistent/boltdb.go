@@ -60,6 +60,11 @@ func (b *BoltDBWrapper) Unlock() {
 	b.mutex.Unlock()
 }
 
+// Close releases all resources associated with the db, including the file lock
+func (b *BoltDBWrapper) Close() error {
+	return b.wrapped.Close()
+}
+
 // GetRawSnapshot dumps all the contents of the db into a raw byte buffer
 func (b *BoltDBWrapper) GetRawSnapshot() ([]byte, error) {
 	var buffer bytes.Buffer
@@ -75,6 +80,31 @@ func (b *BoltDBWrapper) GetRawSnapshot() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// NamespacedDB wraps a DBWrapper so that collections built on top of it use buckets prefixed with a namespace.
+// This allows several environments to share the same db file without stepping on each other's data
+type NamespacedDB struct {
+	DBWrapper
+	namespace string
+}
+
+// NewNamespacedDB constructs a new namespaced view of the supplied db
+func NewNamespacedDB(db DBWrapper, namespace string) *NamespacedDB {
+	return &NamespacedDB{DBWrapper: db, namespace: namespace}
+}
+
+// BucketName returns the namespaced name of a bucket
+func (n *NamespacedDB) BucketName(name string) string {
+	return n.namespace + "_" + name
+}
+
+// bucketName returns the name of the bucket to use for a collection, taking the db namespace into account (if any)
+func bucketName(db DBWrapper, name string) string {
+	if namespaced, ok := db.(*NamespacedDB); ok {
+		return namespaced.BucketName(name)
+	}
+	return name
+}
+
 // CollectionItem is the item into a collection
 type CollectionItem interface {
 	SetID(id uint64)
@@ -90,6 +120,7 @@ type CollectionWrapper interface {
 	Fetch(id uint64) ([]byte, error)
 	FetchBy(key []byte) ([]byte, error)
 	FetchAll() ([][]byte, error)
+	ReplaceAll(items map[string]interface{}) error
 	Logger() logging.LoggerInterface
 }
 
@@ -312,6 +343,35 @@ func (c *BoltDBCollectionWrapper) FetchAll() ([][]byte, error) {
 	return toReturn, err
 }
 
+// ReplaceAll deletes all the items in the collection & saves the supplied ones (keyed by name) in a single transaction
+func (c *BoltDBCollectionWrapper) ReplaceAll(items map[string]interface{}) error {
+	c.db.Lock()
+	defer c.db.Unlock()
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(c.name)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+
+		bucket, err := tx.CreateBucket([]byte(c.name))
+		if err != nil {
+			return err
+		}
+
+		for key, item := range items {
+			var encodeBuffer bytes.Buffer
+			if err := gob.NewEncoder(&encodeBuffer).Encode(item); err != nil {
+				return fmt.Errorf("error encoding item '%s': %w", key, err)
+			}
+
+			if err := bucket.Put([]byte(key), encodeBuffer.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Logger returns a reference to a logger
 func (c *BoltDBCollectionWrapper) Logger() logging.LoggerInterface {
 	return c.logger
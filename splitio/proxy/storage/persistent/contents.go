@@ -0,0 +1,139 @@
+package persistent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Contents holds all the feature flags & segments stored in a db for an environment
+type Contents struct {
+	Flags    []dtos.SplitDTO
+	Segments []SegmentChangesItem
+}
+
+// ReadContents reads the feature flags & segments stored in a db (or a namespaced view of it), sorted by name.
+// Missing collections are not an error, since they're only created once data is first stored
+func ReadContents(db DBWrapper, logger logging.LoggerInterface) (*Contents, error) {
+	flags, err := NewSplitChangesCollection(db, logger).FetchAll()
+	if err != nil && !errors.Is(err, ErrorBucketNotFound) {
+		return nil, err
+	}
+
+	segments, err := NewSegmentChangesCollection(db, logger).FetchAll()
+	if err != nil && !errors.Is(err, ErrorBucketNotFound) {
+		return nil, err
+	}
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Name < segments[j].Name })
+	return &Contents{Flags: flags, Segments: segments}, nil
+}
+
+// ReadAllContents reads the feature flags & segments of every environment with data stored in the db, keyed by namespace
+func ReadAllContents(db *BoltDBWrapper, logger logging.LoggerInterface) (map[string]*Contents, error) {
+	namespaces, err := db.Namespaces()
+	if err != nil {
+		return nil, fmt.Errorf("error listing environments: %w", err)
+	}
+
+	toRet := make(map[string]*Contents, len(namespaces))
+	for _, namespace := range namespaces {
+		var envDB DBWrapper = db
+		if namespace != "" {
+			envDB = NewNamespacedDB(db, namespace)
+		}
+
+		if toRet[namespace], err = ReadContents(envDB, logger); err != nil {
+			return nil, err
+		}
+	}
+	return toRet, nil
+}
+
+// ActiveFlags returns the number of flags that haven't been archived
+func (c *Contents) ActiveFlags() int {
+	var count int
+	for idx := range c.Flags {
+		if c.Flags[idx].Status == "ACTIVE" {
+			count++
+		}
+	}
+	return count
+}
+
+// FlagsChangeNumber returns the highest change number among the stored flags (-1 if there are none)
+func (c *Contents) FlagsChangeNumber() int64 {
+	var cn int64 = -1
+	for idx := range c.Flags {
+		if c.Flags[idx].ChangeNumber > cn {
+			cn = c.Flags[idx].ChangeNumber
+		}
+	}
+	return cn
+}
+
+// SegmentsChangeNumber returns the highest change number among the stored segment keys (-1 if there are none)
+func (c *Contents) SegmentsChangeNumber() int64 {
+	var cn int64 = -1
+	for idx := range c.Segments {
+		if segmentCN := c.Segments[idx].ChangeNumber(); segmentCN > cn {
+			cn = segmentCN
+		}
+	}
+	return cn
+}
+
+// ChangeNumber returns the highest change number among the keys of the segment (-1 if it has none)
+func (s *SegmentChangesItem) ChangeNumber() int64 {
+	var cn int64 = -1
+	for _, key := range s.Keys {
+		if key.ChangeNumber > cn {
+			cn = key.ChangeNumber
+		}
+	}
+	return cn
+}
+
+// ActiveKeys returns the keys currently in the segment, sorted
+func (s *SegmentChangesItem) ActiveKeys() []string {
+	keys := make([]string, 0, len(s.Keys))
+	for name, key := range s.Keys {
+		if !key.Removed {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Namespaces returns the namespaces of the environments with data stored in the db, sorted.
+// The default environment, whose buckets are not namespaced, is returned as an empty string
+func (b *BoltDBWrapper) Namespaces() ([]string, error) {
+	found := make(map[string]struct{})
+	err := b.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			for _, collection := range []string{splitChangesCollectionName, segmentChangesCollectionName} {
+				if namespace, ok := strings.CutSuffix(string(name), collection); ok {
+					found[strings.TrimSuffix(namespace, "_")] = struct{}{}
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	toRet := make([]string, 0, len(found))
+	for namespace := range found {
+		toRet = append(toRet, namespace)
+	}
+	sort.Strings(toRet)
+	return toRet, nil
+}
@@ -0,0 +1,59 @@
+package persistent
+
+import (
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"golang.org/x/exp/slices"
+)
+
+func TestReadContents(t *testing.T) {
+	dbw, err := NewBoltWrapper(BoltInMemoryMode, nil)
+	if err != nil {
+		t.Error("error creating bolt wrapper: ", err)
+		return
+	}
+
+	logger := logging.NewLogger(nil)
+	staging := NewNamespacedDB(dbw, "staging")
+
+	// nothing stored yet
+	contents, err := ReadContents(staging, logger)
+	if err != nil || len(contents.Flags) != 0 || contents.FlagsChangeNumber() != -1 || contents.SegmentsChangeNumber() != -1 {
+		t.Error("an empty db should have no contents. Got: ", contents, err)
+	}
+
+	NewSplitChangesCollection(dbw, logger).Update([]dtos.SplitDTO{
+		{Name: "f2", ChangeNumber: 3, Status: "ACTIVE"},
+		{Name: "f1", ChangeNumber: 5, Status: "ARCHIVED"},
+	}, nil, 5)
+	segments := NewSegmentChangesCollection(dbw, logger)
+	segments.Update("s1", set.NewSet("k1", "k2", "k3"), set.NewSet(), 7)
+	segments.Update("s1", set.NewSet(), set.NewSet("k2"), 8)
+	NewSegmentChangesCollection(staging, logger).Update("s2", set.NewSet("k1"), set.NewSet(), 1)
+
+	contents, err = ReadContents(dbw, logger)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(contents.Flags) != 2 || contents.Flags[0].Name != "f1" || contents.ActiveFlags() != 1 || contents.FlagsChangeNumber() != 5 {
+		t.Error("unexpected flags: ", contents.Flags)
+	}
+
+	if len(contents.Segments) != 1 || contents.SegmentsChangeNumber() != 8 {
+		t.Error("unexpected segments: ", contents.Segments)
+	}
+
+	if keys := contents.Segments[0].ActiveKeys(); !slices.Equal(keys, []string{"k1", "k3"}) {
+		t.Error("unexpected keys: ", keys)
+	}
+
+	namespaces, err := dbw.Namespaces()
+	if err != nil || !slices.Equal(namespaces, []string{"", "staging"}) {
+		t.Error("unexpected namespaces: ", namespaces, err)
+	}
+}
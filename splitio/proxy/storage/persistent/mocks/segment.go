@@ -26,3 +26,11 @@ func (s *SegmentChangesCollectionMock) ChangeNumber(segment string) int64 {
 func (s *SegmentChangesCollectionMock) SetChangeNumber(segment string, cn int64) {
 	s.Called(segment, cn)
 }
+
+func (s *SegmentChangesCollectionMock) PruneRemoved(name string, horizon int64) error {
+	return s.Called(name, horizon).Error(0)
+}
+
+func (s *SegmentChangesCollectionMock) Replace(all []persistent.SegmentChangesItem) error {
+	return s.Called(all).Error(0)
+}
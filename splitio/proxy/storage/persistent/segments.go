@@ -21,8 +21,9 @@ type SegmentKey struct {
 
 // SegmentChangesItem represents an SplitChanges service response
 type SegmentChangesItem struct {
-	Name string
-	Keys map[string]SegmentKey
+	Name    string
+	Keys    map[string]SegmentKey
+	Horizon int64 // removed keys up to this change number have been discarded
 }
 
 type SegmentChangesCollection interface {
@@ -30,6 +31,8 @@ type SegmentChangesCollection interface {
 	Fetch(name string) (*SegmentChangesItem, error)
 	ChangeNumber(segment string) int64
 	SetChangeNumber(segment string, cn int64)
+	PruneRemoved(name string, horizon int64) error
+	Replace(all []SegmentChangesItem) error
 }
 
 // SegmentChangesCollectionImpl represents a collection of SplitChangesItem
@@ -43,7 +46,7 @@ type SegmentChangesCollectionImpl struct {
 // NewSegmentChangesCollection returns an instance of SegmentChangesCollection
 func NewSegmentChangesCollection(db DBWrapper, logger logging.LoggerInterface) *SegmentChangesCollectionImpl {
 	return &SegmentChangesCollectionImpl{
-		collection:   &BoltDBCollectionWrapper{db: db, name: segmentChangesCollectionName, logger: logger},
+		collection:   &BoltDBCollectionWrapper{db: db, name: bucketName(db, segmentChangesCollectionName), logger: logger},
 		segmentsTill: make(map[string]int64, 0),
 		logger:       logger,
 	}
@@ -100,6 +103,50 @@ func (c *SegmentChangesCollectionImpl) Update(name string, toAdd *set.ThreadUnsa
 	return nil
 }
 
+// PruneRemoved discards removed keys with a change number lower or equal than the supplied horizon
+func (c *SegmentChangesCollectionImpl) PruneRemoved(name string, horizon int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	segmentItem, err := c.fetch(name)
+	if err != nil {
+		return fmt.Errorf("error fetching segment changes from bolt: %w", err)
+	}
+
+	for key, item := range segmentItem.Keys {
+		if item.Removed && item.ChangeNumber <= horizon {
+			delete(segmentItem.Keys, key)
+		}
+	}
+	if horizon > segmentItem.Horizon {
+		segmentItem.Horizon = horizon
+	}
+
+	if err := c.collection.SaveAs([]byte(name), segmentItem); err != nil {
+		return fmt.Errorf("error saving segment changes to bolt: %w", err)
+	}
+	return nil
+}
+
+// Replace discards all the stored segments & stores the supplied ones instead.
+// Change numbers of the previous segments are forgotten, and the ones of the new segments set to their latest key update
+func (c *SegmentChangesCollectionImpl) Replace(all []SegmentChangesItem) error {
+	items := make(map[string]interface{}, len(all))
+	segmentsTill := make(map[string]int64, len(all))
+	for idx := range all {
+		items[all[idx].Name] = all[idx]
+		segmentsTill[all[idx].Name] = all[idx].ChangeNumber()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.collection.ReplaceAll(items); err != nil {
+		return fmt.Errorf("error replacing segment changes in bolt: %w", err)
+	}
+	c.segmentsTill = segmentsTill
+	return nil
+}
+
 // Fetch return a SegmentChangesItem
 func (c *SegmentChangesCollectionImpl) Fetch(name string) (*SegmentChangesItem, error) {
 	c.mutex.RLock()
@@ -56,3 +56,36 @@ func TestSegmentPersistentStorage(t *testing.T) {
 		t.Error("k1 should be removed")
 	}
 }
+
+func TestSegmentPersistentStorageReplace(t *testing.T) {
+	dbw, err := NewBoltWrapper(BoltInMemoryMode, nil)
+	if err != nil {
+		t.Error("error creating bolt wrapper: ", err)
+	}
+
+	logger := logging.NewLogger(nil)
+	segmentC := NewSegmentChangesCollection(dbw, logger)
+	segmentC.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 10)
+
+	err = segmentC.Replace([]SegmentChangesItem{{
+		Name:    "s2",
+		Keys:    map[string]SegmentKey{"k3": {Name: "k3", ChangeNumber: 3}, "k4": {Name: "k4", ChangeNumber: 4, Removed: true}},
+		Horizon: 2,
+	}})
+	if err != nil {
+		t.Error("Replace should not return an error. Got: ", err)
+	}
+
+	if forS1, err := segmentC.Fetch("s1"); forS1 != nil || segmentC.ChangeNumber("s1") != -1 {
+		t.Error("s1 should no longer exist.", forS1, err)
+	}
+
+	forS2, err := segmentC.Fetch("s2")
+	if err != nil || len(forS2.Keys) != 2 || !forS2.Keys["k4"].Removed || forS2.Horizon != 2 {
+		t.Error("s2 should be stored as supplied. Got: ", forS2, err)
+	}
+
+	if segmentC.ChangeNumber("s2") != 4 {
+		t.Error("CN of s2 should be the one of its latest key update. Got: ", segmentC.ChangeNumber("s2"))
+	}
+}
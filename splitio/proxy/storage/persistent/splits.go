@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"fmt"
 	"sync"
 
 	"github.com/splitio/go-split-commons/v6/dtos"
@@ -47,7 +48,7 @@ type SplitChangesCollection struct {
 // NewSplitChangesCollection returns an instance of SplitChangesCollection
 func NewSplitChangesCollection(db DBWrapper, logger logging.LoggerInterface) *SplitChangesCollection {
 	return &SplitChangesCollection{
-		collection:   &BoltDBCollectionWrapper{db: db, name: splitChangesCollectionName, logger: logger},
+		collection:   &BoltDBCollectionWrapper{db: db, name: bucketName(db, splitChangesCollectionName), logger: logger},
 		changeNumber: 0,
 	}
 }
@@ -89,6 +90,36 @@ func (c *SplitChangesCollection) Update(toAdd []dtos.SplitDTO, toRemove []dtos.S
 	c.changeNumber = cn
 }
 
+// Replace discards all the stored feature flags & stores the supplied ones instead.
+// The change number is set to the highest one among them (-1 if there are none)
+func (c *SplitChangesCollection) Replace(all []dtos.SplitDTO) error {
+	var cn int64 = -1
+	items := make(map[string]interface{}, len(all))
+	for idx := range all {
+		asJSON, err := json.Marshal(all[idx])
+		if err != nil {
+			return fmt.Errorf("error serializing feature flag '%s': %w", all[idx].Name, err)
+		}
+		items[all[idx].Name] = SplitChangesItem{
+			ChangeNumber: all[idx].ChangeNumber,
+			Name:         all[idx].Name,
+			Status:       all[idx].Status,
+			JSON:         string(asJSON),
+		}
+		if all[idx].ChangeNumber > cn {
+			cn = all[idx].ChangeNumber
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.collection.ReplaceAll(items); err != nil {
+		return fmt.Errorf("error replacing feature flags in bolt: %w", err)
+	}
+	c.changeNumber = cn
+	return nil
+}
+
 // FetchAll return a SplitChangesItem
 func (c *SplitChangesCollection) FetchAll() ([]dtos.SplitDTO, error) {
 	c.mutex.RLock()
@@ -58,3 +58,62 @@ func TestSplitPersistentStorage(t *testing.T) {
 		t.Error("CN should be 2.")
 	}
 }
+
+func TestSplitPersistentStorageNamespaces(t *testing.T) {
+	dbw, err := NewBoltWrapper(BoltInMemoryMode, nil)
+	if err != nil {
+		t.Error("error creating bolt wrapper: ", err)
+	}
+
+	logger := logging.NewLogger(nil)
+	defaultC := NewSplitChangesCollection(dbw, logger)
+	stagingC := NewSplitChangesCollection(NewNamespacedDB(dbw, "staging"), logger)
+
+	defaultC.Update([]dtos.SplitDTO{{Name: "s1", ChangeNumber: 1, Status: "ACTIVE"}}, nil, 1)
+	stagingC.Update([]dtos.SplitDTO{{Name: "s2", ChangeNumber: 2, Status: "ACTIVE"}, {Name: "s3", ChangeNumber: 2, Status: "ACTIVE"}}, nil, 2)
+
+	all, err := defaultC.FetchAll()
+	if err != nil || len(all) != 1 || all[0].Name != "s1" {
+		t.Error("default collection should only contain s1. Got: ", all, err)
+	}
+
+	all, err = NewSplitChangesCollection(NewNamespacedDB(dbw, "staging"), logger).FetchAll()
+	if err != nil || len(all) != 2 || all[0].Name != "s2" || all[1].Name != "s3" {
+		t.Error("staging collection should contain s2 & s3. Got: ", all, err)
+	}
+}
+
+func TestSplitPersistentStorageReplace(t *testing.T) {
+	dbw, err := NewBoltWrapper(BoltInMemoryMode, nil)
+	if err != nil {
+		t.Error("error creating bolt wrapper: ", err)
+	}
+
+	logger := logging.NewLogger(nil)
+	splitC := NewSplitChangesCollection(dbw, logger)
+	splitC.Update([]dtos.SplitDTO{{Name: "s1", ChangeNumber: 5, Status: "ACTIVE"}}, nil, 5)
+
+	err = splitC.Replace([]dtos.SplitDTO{
+		{Name: "s2", ChangeNumber: 2, Status: "ACTIVE"},
+		{Name: "s3", ChangeNumber: 3, Status: "ARCHIVED"},
+	})
+	if err != nil {
+		t.Error("Replace should not return an error. Got: ", err)
+	}
+
+	all, err := splitC.FetchAll()
+	if err != nil || len(all) != 2 || all[0].Name != "s2" || all[1].Name != "s3" || all[1].Status != "ARCHIVED" {
+		t.Error("only the new feature flags should be stored. Got: ", all, err)
+	}
+
+	if splitC.ChangeNumber() != 3 {
+		t.Error("CN should be the highest one among the new flags. Got: ", splitC.ChangeNumber())
+	}
+
+	if err := splitC.Replace(nil); err != nil {
+		t.Error("Replace should not return an error. Got: ", err)
+	}
+	if all, _ := splitC.FetchAll(); len(all) != 0 || splitC.ChangeNumber() != -1 {
+		t.Error("collection should be empty. Got: ", all, splitC.ChangeNumber())
+	}
+}
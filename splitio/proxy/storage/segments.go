@@ -3,6 +3,9 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/splitio/go-split-commons/v6/dtos"
 	"github.com/splitio/go-split-commons/v6/storage"
@@ -27,81 +30,88 @@ type ProxySegmentStorage interface {
 
 // ProxySegmentStorageImpl implements the ProxySegmentStorage interface
 type ProxySegmentStorageImpl struct {
-	logger         logging.LoggerInterface
+	logger           logging.LoggerInterface
+	view             atomic.Pointer[segmentView]
+	db               persistent.SegmentChangesCollection
+	historyRetention time.Duration
+	mtx              sync.RWMutex // held for reading by updates (which can run concurrently) & for writing by restores
+}
+
+// segmentView bundles the in-memory structures used to serve segments, so that they can be replaced as a whole
+type segmentView struct {
 	nameCountCache *observability.ActiveSegmentTracker
-	db             persistent.SegmentChangesCollection
 	mysegments     optimized.MySegmentsCache
+	history        optimized.SegmentChangesHistory
 }
 
-// NewProxySegmentStorage for proxy
-func NewProxySegmentStorage(db persistent.DBWrapper, logger logging.LoggerInterface, restoreFromBackup bool) *ProxySegmentStorageImpl {
-	cache := optimized.NewMySegmentsCache()
+// NewProxySegmentStorage for proxy.
+// Removed keys are reported to SDKs for `historyRetention` time, after which they're discarded (<= 0 means forever)
+func NewProxySegmentStorage(
+	db persistent.DBWrapper,
+	logger logging.LoggerInterface,
+	restoreFromBackup bool,
+	historyRetention time.Duration,
+) *ProxySegmentStorageImpl {
 	disk := persistent.NewSegmentChangesCollection(db, logger)
-	nameCountCache := observability.NewActiveSegmentTracker(100) // just a guess, we don't know the size yet
+	view := newSegmentView(historyRetention)
 	if restoreFromBackup {
-		populateCachesFromDisk(cache, nameCountCache, disk, logger)
+		all, err := disk.FetchAll()
+		if err != nil {
+			logger.Error("error popoulating segment cache from disk. Cache will be empty!: ", err)
+		}
+		populateCaches(view, all, disk)
 	}
-	return &ProxySegmentStorageImpl{
-		db:             disk,
-		mysegments:     cache,
-		logger:         logger,
-		nameCountCache: nameCountCache,
+	toRet := &ProxySegmentStorageImpl{
+		db:               disk,
+		logger:           logger,
+		historyRetention: historyRetention,
 	}
+	toRet.view.Store(view)
+	return toRet
 }
 
-// ChangesSince returns the `segmentChanges` like payload to from a certain CN to the last snapshot
-// This method has one drawback. ALL the historically removed keys are always returned as part of the `removed` array,
-// regardless whether the `since` parameter is old enough to require such removal or not.
-// We should eventually see if it's worth taking an approach similar to the one in feature flags or not
+// Restore replaces all the segments with the supplied ones (both on disk & in memory), as if they had been
+// restored upon startup
+func (s *ProxySegmentStorageImpl) Restore(all []persistent.SegmentChangesItem) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if err := s.db.Replace(all); err != nil {
+		return err
+	}
+
+	view := newSegmentView(s.historyRetention)
+	populateCaches(view, all, s.db)
+	s.view.Store(view)
+	return nil
+}
+
+// ChangesSince returns the `segmentChanges` like payload to from a certain CN to the last snapshot.
+// If `since` is older than the retention horizon for removed keys, the current state of the segment is returned
 func (s *ProxySegmentStorageImpl) ChangesSince(name string, since int64) (*dtos.SegmentChangesDTO, error) {
-	item, err := s.db.Fetch(name)
+	view, err := s.view.Load().history.ChangesSince(name, since)
 	if err != nil {
-		if errors.Is(err, persistent.ErrorBucketNotFound) || errors.Is(err, persistent.ErrorKeyNotFound) {
+		if errors.Is(err, optimized.ErrSegmentNotCached) {
 			return nil, ErrSegmentNotFound
 		}
 		return nil, fmt.Errorf("unexpected error when fetching segment '%s': %w", name, err)
 	}
 
-	added := make([]string, 0)
-	removed := make([]string, 0)
-	till := since
-
-	// Horrible loop borrowed from sdk-api
-	for _, skey := range item.Keys {
-
-		if skey.ChangeNumber <= since { // if the key was updated in a previous/current CN, we don't need to return it
-			continue
-		}
-
-		if skey.Removed && since < 0 {
-			// removed keys should not be returned on initialization payloads
-			continue
-		}
-
-		// Add the key to the corresponding list
-		if skey.Removed {
-			removed = append(removed, skey.Name)
-		} else {
-			added = append(added, skey.Name)
-		}
-
-		// Update the till to be returned if necessary
-		if skey.ChangeNumber > till {
-			till = skey.ChangeNumber
-		}
+	till := view.Till
+	if till < since {
+		till = since
 	}
 
-	return &dtos.SegmentChangesDTO{Name: name, Since: since, Till: till, Added: added, Removed: removed}, nil
+	return &dtos.SegmentChangesDTO{Name: name, Since: since, Till: till, Added: view.Added, Removed: view.Removed}, nil
 }
 
 // SegmentsFor returns the list of segments a key belongs to
 func (s *ProxySegmentStorageImpl) SegmentsFor(key string) ([]string, error) {
-	return s.mysegments.SegmentsForUser(key), nil
+	return s.view.Load().mysegments.SegmentsForUser(key), nil
 }
 
 // SegmentKeysCount returns 0
 func (s *ProxySegmentStorageImpl) SegmentKeysCount() int64 {
-	return int64(s.mysegments.KeyCount())
+	return int64(s.view.Load().mysegments.KeyCount())
 }
 
 // ChangeNumber storage
@@ -134,17 +144,32 @@ func (s *ProxySegmentStorageImpl) Keys(segmentName string) *set.ThreadUnsafeSet
 	return toReturn
 }
 
-// SegmentContainsKey method
+// SegmentContainsKey returns whether a key belongs to a segment. Used when evaluating flags in the proxy
 func (s *ProxySegmentStorageImpl) SegmentContainsKey(segmentName string, key string) (bool, error) {
+	for _, segment := range s.view.Load().mysegments.SegmentsForUser(key) {
+		if segment == segmentName {
+			return true, nil
+		}
+	}
 	return false, nil
 }
 
 // Update method
 func (s *ProxySegmentStorageImpl) Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet, changeNumber int64) error {
-	errCache := s.mysegments.Update(name, toAdd, toRemove)
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	view := s.view.Load()
+	errCache := view.mysegments.Update(name, toAdd, toRemove)
 	errDB := s.db.Update(name, toAdd, toRemove, changeNumber)
+	view.history.Update(name, toAdd, toRemove, changeNumber)
+	if horizon, pruned := view.history.Prune(name); pruned {
+		if err := s.db.PruneRemoved(name, horizon); err != nil {
+			s.logger.Error(fmt.Sprintf("error discarding removed keys for segment '%s' from disk: %s", name, err.Error()))
+		}
+	}
+
 	if errCache == nil && errDB == nil {
-		s.nameCountCache.Update(name, toAdd.Size(), toRemove.Size())
+		view.nameCountCache.Update(name, toAdd.Size(), toRemove.Size())
 		return nil
 	}
 
@@ -175,32 +200,39 @@ func (s *ProxySegmentStorageImpl) CountRemovedKeys(segmentName string) int {
 
 // NamesAndCount returns a map of segment names to key count
 func (s *ProxySegmentStorageImpl) NamesAndCount() map[string]int {
-	return s.nameCountCache.NamesAndCount()
+	return s.view.Load().nameCountCache.NamesAndCount()
 }
 
-func populateCachesFromDisk(
-	dst optimized.MySegmentsCache,
-	names *observability.ActiveSegmentTracker,
-	src *persistent.SegmentChangesCollectionImpl,
-	logger logging.LoggerInterface,
-) {
-	all, err := src.FetchAll()
-	if err != nil {
-		logger.Error("error popoulating segment cache from disk. Cache will be empty!: ", err)
-		return
+func newSegmentView(historyRetention time.Duration) *segmentView {
+	return &segmentView{
+		mysegments:     optimized.NewMySegmentsCache(),
+		history:        optimized.NewSegmentChangesHistory(historyRetention),
+		nameCountCache: observability.NewActiveSegmentTracker(100), // just a guess, we don't know the size yet
 	}
+}
 
+func populateCaches(dst *segmentView, all []persistent.SegmentChangesItem, src persistent.SegmentChangesCollection) {
 	for idx := range all {
 		s := set.NewSet()
 		count := 0
+		cn := int64(-1)
+		keys := make([]optimized.SegmentKeyView, 0, len(all[idx].Keys))
 		for _, k := range all[idx].Keys {
 			if !k.Removed {
 				s.Add(k.Name)
 				count++
 			}
+			if k.ChangeNumber > cn {
+				cn = k.ChangeNumber
+			}
+			keys = append(keys, optimized.SegmentKeyView{Name: k.Name, Removed: k.Removed, ChangeNumber: k.ChangeNumber})
 		}
-		dst.Update(all[idx].Name, s, set.NewSet())
-		names.Update(all[idx].Name, count, 0)
+		dst.mysegments.Update(all[idx].Name, s, set.NewSet())
+		dst.history.Restore(all[idx].Name, keys, all[idx].Horizon)
+		dst.nameCountCache.Update(all[idx].Name, count, 0)
+
+		// resume syncing from the latest change we know of, instead of fetching the whole segment again
+		src.SetChangeNumber(all[idx].Name, cn)
 	}
 }
 
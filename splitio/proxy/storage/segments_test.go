@@ -1,8 +1,13 @@
 package storage
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
@@ -12,25 +17,25 @@ import (
 
 func TestSegmentStorage(t *testing.T) {
 
-	psm := &mocks.SegmentChangesCollectionMock{}
-	psm.On("Fetch", "some").Return(&persistent.SegmentChangesItem{
-		Name: "some",
-		Keys: map[string]persistent.SegmentKey{
-			"k1": {Name: "k1", ChangeNumber: 1, Removed: false},
-			"k2": {Name: "k2", ChangeNumber: 1, Removed: true},
-			"k3": {Name: "k3", ChangeNumber: 2, Removed: false},
-			"k4": {Name: "k4", ChangeNumber: 2, Removed: true},
-			"k5": {Name: "k5", ChangeNumber: 3, Removed: false},
-			"k6": {Name: "k6", ChangeNumber: 3, Removed: true},
-			"k7": {Name: "k7", ChangeNumber: 4, Removed: false},
-		},
-	}, nil)
+	history := optimized.NewSegmentChangesHistory(0)
+	history.Restore("some", []optimized.SegmentKeyView{
+		{Name: "k1", ChangeNumber: 1, Removed: false},
+		{Name: "k2", ChangeNumber: 1, Removed: true},
+		{Name: "k3", ChangeNumber: 2, Removed: false},
+		{Name: "k4", ChangeNumber: 2, Removed: true},
+		{Name: "k5", ChangeNumber: 3, Removed: false},
+		{Name: "k6", ChangeNumber: 3, Removed: true},
+		{Name: "k7", ChangeNumber: 4, Removed: false},
+	}, 0)
 
 	ss := ProxySegmentStorageImpl{
-		logger:     logging.NewLogger(nil),
-		db:         psm,
-		mysegments: optimized.NewMySegmentsCache(),
+		logger: logging.NewLogger(nil),
+		db:     &mocks.SegmentChangesCollectionMock{},
 	}
+	ss.view.Store(&segmentView{mysegments: optimized.NewMySegmentsCache(), history: history})
+
+	_, err := ss.ChangesSince("other", -1)
+	assert.ErrorIs(t, err, ErrSegmentNotFound)
 
 	changes, err := ss.ChangesSince("some", -1)
 	assert.Nil(t, err)
@@ -72,4 +77,136 @@ func TestSegmentStorage(t *testing.T) {
 	assert.Equal(t, int64(4), changes.Since)
 	assert.Equal(t, int64(4), changes.Till)
 
+	ss.view.Load().mysegments.Update("some", set.NewSet("k1", "k3"), set.NewSet())
+	contained, _ := ss.SegmentContainsKey("some", "k1")
+	assert.True(t, contained)
+	contained, _ = ss.SegmentContainsKey("some", "k2")
+	assert.False(t, contained)
+	contained, _ = ss.SegmentContainsKey("other", "k1")
+	assert.False(t, contained)
+}
+
+func TestSegmentStorageHistoryRetention(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	logger := logging.NewLogger(nil)
+	ss := NewProxySegmentStorage(dbw, logger, false, 50*time.Millisecond)
+	assert.Nil(t, ss.Update("some", set.NewSet("k1", "k2", "k3"), set.NewSet(), 1))
+	assert.Nil(t, ss.Update("some", set.NewSet(), set.NewSet("k1"), 2))
+
+	changes, err := ss.ChangesSince("some", 1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{}, changes.Added)
+	assert.ElementsMatch(t, []string{"k1"}, changes.Removed)
+	assert.Equal(t, int64(2), changes.Till)
+	assert.Equal(t, 1, ss.CountRemovedKeys("some"))
+
+	// once the retention period is over, the next update moves the horizon & discards removed keys
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, ss.Update("some", set.NewSet("k4"), set.NewSet("k2"), 3))
+	assert.Equal(t, 1, ss.CountRemovedKeys("some")) // only k2 left
+
+	// requests older than the horizon get a full snapshot
+	changes, err = ss.ChangesSince("some", 1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k3", "k4"}, changes.Added)
+	assert.ElementsMatch(t, []string{}, changes.Removed)
+	assert.Equal(t, int64(1), changes.Since)
+	assert.Equal(t, int64(3), changes.Till)
+
+	// requests newer than the horizon still get an accurate diff
+	changes, err = ss.ChangesSince("some", 2)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k4"}, changes.Added)
+	assert.ElementsMatch(t, []string{"k2"}, changes.Removed)
+
+	// the horizon survives restarts
+	restored := NewProxySegmentStorage(dbw, logger, true, 50*time.Millisecond)
+	changes, err = restored.ChangesSince("some", 1)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k3", "k4"}, changes.Added)
+	assert.ElementsMatch(t, []string{}, changes.Removed)
+	changes, err = restored.ChangesSince("some", 2)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"k4"}, changes.Added)
+	assert.ElementsMatch(t, []string{"k2"}, changes.Removed)
+}
+
+func TestWarmRestartFromPersistentFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.db")
+	logger := logging.NewLogger(nil)
+
+	dbw, err := persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+	splits := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), false)
+	splits.Update([]dtos.SplitDTO{{Name: "f1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "tt"}}, nil, 10)
+	segments := NewProxySegmentStorage(dbw, logger, false, 0)
+	assert.Nil(t, segments.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 5))
+	assert.Nil(t, segments.Update("s1", set.NewSet(), set.NewSet("k2"), 6))
+	assert.Nil(t, dbw.Close())
+
+	dbw, err = persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+	defer dbw.Close()
+
+	splits = NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true)
+	cn, _ := splits.ChangeNumber()
+	assert.Equal(t, int64(10), cn)
+	splitChanges, err := splits.ChangesSince(-1, nil)
+	assert.Nil(t, err)
+	assert.Len(t, splitChanges.Splits, 1)
+	assert.Equal(t, "f1", splitChanges.Splits[0].Name)
+
+	segments = NewProxySegmentStorage(dbw, logger, true, 0)
+	cn, _ = segments.ChangeNumber("s1")
+	assert.Equal(t, int64(6), cn)
+	mySegments, _ := segments.SegmentsFor("k1")
+	assert.Equal(t, []string{"s1"}, mySegments)
+	segmentChanges, err := segments.ChangesSince("s1", 5)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{}, segmentChanges.Added)
+	assert.ElementsMatch(t, []string{"k2"}, segmentChanges.Removed)
+}
+
+func TestSegmentStorageRestore(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	logger := logging.NewLogger(nil)
+	ss := NewProxySegmentStorage(dbw, logger, false, 0)
+	assert.Nil(t, ss.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 10))
+
+	err = ss.Restore([]persistent.SegmentChangesItem{{
+		Name: "s2",
+		Keys: map[string]persistent.SegmentKey{
+			"k1": {Name: "k1", ChangeNumber: 3},
+			"k3": {Name: "k3", ChangeNumber: 4, Removed: true},
+		},
+	}})
+	assert.Nil(t, err)
+
+	_, err = ss.ChangesSince("s1", -1)
+	assert.ErrorIs(t, err, ErrSegmentNotFound)
+	cn, _ := ss.ChangeNumber("s1")
+	assert.Equal(t, int64(-1), cn)
+
+	changes, err := ss.ChangesSince("s2", 3)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{}, changes.Added)
+	assert.ElementsMatch(t, []string{"k3"}, changes.Removed)
+	assert.Equal(t, int64(4), changes.Till)
+	cn, _ = ss.ChangeNumber("s2")
+	assert.Equal(t, int64(4), cn)
+
+	mySegments, _ := ss.SegmentsFor("k1")
+	assert.Equal(t, []string{"s2"}, mySegments)
+	mySegments, _ = ss.SegmentsFor("k2")
+	assert.Empty(t, mySegments)
+	assert.Equal(t, map[string]int{"s2": 1}, ss.NamesAndCount())
+
+	assert.Nil(t, ss.Update("s2", set.NewSet("k4"), set.NewSet(), 5))
+	mySegments, _ = ss.SegmentsFor("k4")
+	assert.Equal(t, []string{"s2"}, mySegments)
+	assert.Equal(t, map[string]int{"s2": 2}, ss.NamesAndCount())
 }
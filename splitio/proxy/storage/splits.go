@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/splitio/go-split-commons/v6/dtos"
 	"github.com/splitio/go-split-commons/v6/flagsets"
@@ -11,8 +12,10 @@ import (
 	"github.com/splitio/go-split-commons/v6/storage/inmemory/mutexmap"
 	"github.com/splitio/go-toolkit/v5/datastructures/set"
 	"github.com/splitio/go-toolkit/v5/logging"
+	"golang.org/x/exp/slices"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/observability"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 )
@@ -28,19 +31,32 @@ var ErrSinceParamTooOld = errors.New("summary for requested change number not ca
 // for different requested `since` parameters
 type ProxySplitStorage interface {
 	ChangesSince(since int64, flagSets []string) (*dtos.SplitChangesDTO, error)
+	ChangeNumber() (int64, error)
+	ApplyOverrides(changes *dtos.SplitChangesDTO, flagSets []string) *dtos.SplitChangesDTO
+}
+
+// FlagOverrides defines the interface of a component providing local overrides to be merged into splitChanges payloads
+type FlagOverrides interface {
+	State() overrides.State
 }
 
 // ProxySplitStorageImpl implements the ProxySplitStorage interface and the SplitProducer interface
 type ProxySplitStorageImpl struct {
-	snapshot      mutexmap.MMSplitStorage
+	view          atomic.Pointer[splitView]
 	db            *persistent.SplitChangesCollection
 	flagSets      flagsets.FlagSetFilter
-	historic      optimized.HistoricChanges
 	logger        logging.LoggerInterface
 	oldestKnownCN int64
+	overrides     FlagOverrides
 	mtx           sync.Mutex
 }
 
+// splitView bundles the in-memory structures used to serve feature flags, so that they can be replaced as a whole
+type splitView struct {
+	snapshot *mutexmap.MMSplitStorage
+	historic optimized.HistoricChanges
+}
+
 // NewProxySplitStorage instantiates a new proxy storage that wraps an in-memory snapshot of the last known,
 // flag configuration, a changes summaries containing recipes to update SDKs with different CNs, and a persistent storage
 // for snapshot purposes
@@ -53,34 +69,35 @@ func NewProxySplitStorage(db persistent.DBWrapper, logger logging.LoggerInterfac
 	if restoreBackup {
 		initialCN = snapshotFromDisk(snapshot, historic, disk, logger)
 	}
-	return &ProxySplitStorageImpl{
-		snapshot:      *snapshot,
+	toRet := &ProxySplitStorageImpl{
 		db:            disk,
 		flagSets:      flagSets,
-		historic:      historic,
 		logger:        logger,
 		oldestKnownCN: initialCN,
 	}
+	toRet.view.Store(&splitView{snapshot: snapshot, historic: historic})
+	return toRet
 }
 
 // ChangesSince builds a SplitChanges payload to from `since` to the latest known CN
 func (p *ProxySplitStorageImpl) ChangesSince(since int64, flagSets []string) (*dtos.SplitChangesDTO, error) {
+	view := p.view.Load()
 
 	// No flagsets and fetching from -1, return the current snapshot
 	if since == -1 && len(flagSets) == 0 {
-		cn, err := p.snapshot.ChangeNumber()
+		cn, err := view.snapshot.ChangeNumber()
 		if err != nil {
 			return nil, fmt.Errorf("error fetching changeNumber from snapshot: %w", err)
 		}
-		all := p.snapshot.All()
-		return &dtos.SplitChangesDTO{Since: since, Till: cn, Splits: all}, nil
+		all := view.snapshot.All()
+		return p.withOverrides(view, &dtos.SplitChangesDTO{Since: since, Till: cn, Splits: all}, flagSets), nil
 	}
 
 	if p.sinceIsTooOld(since) {
 		return nil, ErrSinceParamTooOld
 	}
 
-	views := p.historic.GetUpdatedSince(since, flagSets)
+	views := view.historic.GetUpdatedSince(since, flagSets)
 	namesToFetch := make([]string, 0, len(views))
 	all := make([]dtos.SplitDTO, 0, len(views))
 	var till int64 = since
@@ -95,7 +112,7 @@ func (p *ProxySplitStorageImpl) ChangesSince(since int64, flagSets []string) (*d
 		}
 	}
 
-	for name, split := range p.snapshot.FetchMany(namesToFetch) {
+	for name, split := range view.snapshot.FetchMany(namesToFetch) {
 		if split == nil {
 			p.logger.Warning(fmt.Sprintf(
 				"possible inconsistency between historic & snapshot storages. Feature `%s` is missing in the latter",
@@ -106,12 +123,25 @@ func (p *ProxySplitStorageImpl) ChangesSince(since int64, flagSets []string) (*d
 		all = append(all, *split)
 	}
 
-	return &dtos.SplitChangesDTO{Since: since, Till: till, Splits: all}, nil
+	return p.withOverrides(view, &dtos.SplitChangesDTO{Since: since, Till: till, Splits: all}, flagSets), nil
+}
+
+// ApplyOverrides patches a splitChanges payload not built by this storage (ie: fetched from Split servers for a `since`
+// older than the ones known) with the local overrides. The supplied payload, which may be shared, is left untouched
+func (p *ProxySplitStorageImpl) ApplyOverrides(changes *dtos.SplitChangesDTO, flagSets []string) *dtos.SplitChangesDTO {
+	patched := *changes
+	patched.Splits = append([]dtos.SplitDTO(nil), changes.Splits...)
+	return p.withOverrides(p.view.Load(), &patched, flagSets)
+}
+
+// SetOverrides sets the local overrides to be merged into splitChanges payloads. Must be called before serving requests
+func (p *ProxySplitStorageImpl) SetOverrides(flagOverrides FlagOverrides) {
+	p.overrides = flagOverrides
 }
 
 // KillLocally marks a feature flag as killed in the current storage
 func (p *ProxySplitStorageImpl) KillLocally(splitName string, defaultTreatment string, changeNumber int64) {
-	p.snapshot.KillLocally(splitName, defaultTreatment, changeNumber)
+	p.view.Load().snapshot.KillLocally(splitName, defaultTreatment, changeNumber)
 }
 
 // Update the storage atomically
@@ -124,47 +154,70 @@ func (p *ProxySplitStorageImpl) Update(toAdd []dtos.SplitDTO, toRemove []dtos.Sp
 	}
 
 	p.mtx.Lock()
-	p.snapshot.Update(toAdd, toRemove, changeNumber)
-	p.historic.Update(toAdd, toRemove, changeNumber)
+	view := p.view.Load()
+	view.snapshot.Update(toAdd, toRemove, changeNumber)
+	view.historic.Update(toAdd, toRemove, changeNumber)
 	p.db.Update(toAdd, toRemove, changeNumber)
 	p.mtx.Unlock()
 }
 
+// Restore replaces all the feature flags with the supplied ones (both on disk & in memory), as if they had been
+// restored upon startup. Requests with a `since` older than the resulting change number, which is returned,
+// are answered with ErrSinceParamTooOld from then on
+func (p *ProxySplitStorageImpl) Restore(all []dtos.SplitDTO) (int64, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if err := p.db.Replace(all); err != nil {
+		return 0, err
+	}
+
+	snapshot := mutexmap.NewMMSplitStorage(p.flagSets)
+	historic := optimized.NewHistoricSplitChanges(1000)
+	cn := snapshotFromDisk(snapshot, historic, p.db, p.logger)
+	p.view.Store(&splitView{snapshot: snapshot, historic: historic})
+	p.oldestKnownCN = cn
+	return cn, nil
+}
+
 // ChangeNumber returns the current change number
 func (p *ProxySplitStorageImpl) ChangeNumber() (int64, error) {
-	return p.snapshot.ChangeNumber()
+	return p.view.Load().snapshot.ChangeNumber()
 }
 
 // SetChangeNumber updates the change number
 func (p *ProxySplitStorageImpl) SetChangeNumber(cn int64) error {
-	return p.snapshot.SetChangeNumber(cn)
+	return p.view.Load().snapshot.SetChangeNumber(cn)
 }
 
 // Remove deletes a split by name
 func (p *ProxySplitStorageImpl) Remove(name string) {
-	p.snapshot.Remove(name)
+	p.view.Load().snapshot.Remove(name)
 }
 
 // All call is forwarded to the snapshot
-func (p *ProxySplitStorageImpl) All() []dtos.SplitDTO { return p.snapshot.All() }
+func (p *ProxySplitStorageImpl) All() []dtos.SplitDTO { return p.view.Load().snapshot.All() }
 
 // FetchMany call is forwarded to the snapshot
 func (p *ProxySplitStorageImpl) FetchMany(names []string) map[string]*dtos.SplitDTO {
-	return p.snapshot.FetchMany(names)
+	return p.view.Load().snapshot.FetchMany(names)
 }
 
 // SegmentNames call is forwarded to the snapshot
-func (p *ProxySplitStorageImpl) SegmentNames() *set.ThreadUnsafeSet { return p.snapshot.SegmentNames() }
+func (p *ProxySplitStorageImpl) SegmentNames() *set.ThreadUnsafeSet {
+	return p.view.Load().snapshot.SegmentNames()
+}
 
 // Split call is forwarded to the snapshot
-func (p *ProxySplitStorageImpl) Split(name string) *dtos.SplitDTO { return p.snapshot.Split(name) }
+func (p *ProxySplitStorageImpl) Split(name string) *dtos.SplitDTO {
+	return p.view.Load().snapshot.Split(name)
+}
 
 // SplitNames call is forwarded to the snapshot
-func (p *ProxySplitStorageImpl) SplitNames() []string { return p.snapshot.SplitNames() }
+func (p *ProxySplitStorageImpl) SplitNames() []string { return p.view.Load().snapshot.SplitNames() }
 
 // TrafficTypeExists call is forwarded to the snapshot
 func (p *ProxySplitStorageImpl) TrafficTypeExists(tt string) bool {
-	return p.snapshot.TrafficTypeExists(tt)
+	return p.view.Load().snapshot.TrafficTypeExists(tt)
 }
 
 // Count returns the number of cached feature flags
@@ -174,12 +227,12 @@ func (p *ProxySplitStorageImpl) Count() int {
 
 // GetNamesByFlagSets implements storage.SplitStorage
 func (p *ProxySplitStorageImpl) GetNamesByFlagSets(sets []string) map[string][]string {
-	return p.snapshot.GetNamesByFlagSets(sets)
+	return p.view.Load().snapshot.GetNamesByFlagSets(sets)
 }
 
 // GetAllFlagSetNames implements storage.SplitStorage
 func (p *ProxySplitStorageImpl) GetAllFlagSetNames() []string {
-	return p.snapshot.GetAllFlagSetNames()
+	return p.view.Load().snapshot.GetAllFlagSetNames()
 }
 
 func (p *ProxySplitStorageImpl) setStartingPoint(cn int64) {
@@ -232,6 +285,130 @@ func snapshotFromDisk(
 	return cn
 }
 
+// withOverrides patches a splitChanges payload with the local overrides. Overridden flags are replaced, and flags whose
+// override was set or lifted after `since` are added even if they haven't changed upstream, so that SDKs get the
+// new definition. The `till` is bumped to the synthesized change numbers of such changes
+func (p *ProxySplitStorageImpl) withOverrides(view *splitView, changes *dtos.SplitChangesDTO, flagSets []string) *dtos.SplitChangesDTO {
+	if p.overrides == nil {
+		return changes
+	}
+
+	state := p.overrides.State()
+	if len(state.Active) == 0 && len(state.Lifted) == 0 {
+		return changes
+	}
+
+	included := make(map[string]struct{}, len(changes.Splits))
+	for idx := range changes.Splits {
+		split := &changes.Splits[idx]
+		included[split.Name] = struct{}{}
+		if override, ok := state.Active[split.Name]; ok && split.Status != "ARCHIVED" {
+			*split = override.Apply(split)
+		} else if cn, ok := state.Lifted[split.Name]; ok && cn > split.ChangeNumber {
+			split.ChangeNumber = cn
+		}
+	}
+
+	var missing []string
+	for name, override := range state.Active {
+		if _, ok := included[name]; !ok && override.ChangeNumber > changes.Since {
+			missing = append(missing, name)
+		}
+	}
+	for name, cn := range state.Lifted {
+		if _, ok := included[name]; !ok && cn > changes.Since {
+			missing = append(missing, name)
+		}
+	}
+
+	for name, split := range view.snapshot.FetchMany(missing) {
+		if split == nil || !matchesFlagSets(split, flagSets) {
+			continue
+		}
+		if override, ok := state.Active[name]; ok {
+			changes.Splits = append(changes.Splits, override.Apply(split))
+		} else {
+			changes.Splits = append(changes.Splits, *split)
+			changes.Splits[len(changes.Splits)-1].ChangeNumber = state.Lifted[name]
+		}
+	}
+
+	for idx := range changes.Splits {
+		if cn := changes.Splits[idx].ChangeNumber; cn > changes.Till {
+			changes.Till = cn
+		}
+	}
+	return changes
+}
+
+// Overridden returns a read-only view of the storage with the local overrides & kills applied, so that flags evaluated
+// by the proxy itself match what SDKs get
+func (p *ProxySplitStorageImpl) Overridden() storage.SplitStorageConsumer {
+	return &overriddenSplitStorage{ProxySplitStorageImpl: p}
+}
+
+// overriddenSplitStorage patches the feature flags fetched from the snapshot with the active overrides
+type overriddenSplitStorage struct {
+	*ProxySplitStorageImpl
+}
+
+// All returns every cached feature flag, with the active overrides applied
+func (o *overriddenSplitStorage) All() []dtos.SplitDTO {
+	all := o.ProxySplitStorageImpl.All()
+	active := o.activeOverrides()
+	for idx := range all {
+		if override, ok := active[all[idx].Name]; ok {
+			all[idx] = override.Apply(&all[idx])
+		}
+	}
+	return all
+}
+
+// FetchMany returns the requested feature flags, with the active overrides applied
+func (o *overriddenSplitStorage) FetchMany(names []string) map[string]*dtos.SplitDTO {
+	splits := o.ProxySplitStorageImpl.FetchMany(names)
+	active := o.activeOverrides()
+	for name, split := range splits {
+		if override, ok := active[name]; ok && split != nil {
+			overridden := override.Apply(split)
+			splits[name] = &overridden
+		}
+	}
+	return splits
+}
+
+// Split returns a feature flag, with its active override applied
+func (o *overriddenSplitStorage) Split(name string) *dtos.SplitDTO {
+	split := o.ProxySplitStorageImpl.Split(name)
+	if split == nil {
+		return nil
+	}
+	if override, ok := o.activeOverrides()[name]; ok {
+		overridden := override.Apply(split)
+		return &overridden
+	}
+	return split
+}
+
+func (o *overriddenSplitStorage) activeOverrides() map[string]overrides.Override {
+	if o.overrides == nil {
+		return nil
+	}
+	return o.overrides.State().Active
+}
+
+func matchesFlagSets(split *dtos.SplitDTO, flagSets []string) bool {
+	if len(flagSets) == 0 {
+		return true
+	}
+	for _, set := range split.Sets {
+		if slices.Contains(flagSets, set) {
+			return true
+		}
+	}
+	return false
+}
+
 func archivedDTOForView(view *optimized.FeatureView) dtos.SplitDTO {
 	return dtos.SplitDTO{
 		ChangeNumber:          view.LastUpdated,
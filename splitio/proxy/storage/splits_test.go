@@ -3,6 +3,7 @@ package storage
 import (
 	"testing"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/overrides"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized/mocks"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
@@ -43,8 +44,8 @@ func TestSplitStorage(t *testing.T) {
 		[]optimized.FeatureView{
 			{Name: "f1", Active: true, LastUpdated: 1, FlagSets: []optimized.FlagSetView{}, TrafficTypeName: "ttt"},
 			{Name: "f2", Active: true, LastUpdated: 2, FlagSets: []optimized.FlagSetView{}, TrafficTypeName: "ttt"},
-		}, pss.historic.GetUpdatedSince(-1, nil))
-	pss.historic = &historicMock
+		}, pss.view.Load().historic.GetUpdatedSince(-1, nil))
+	pss.view.Load().historic = &historicMock
 	// ----
 
 	changes, err := pss.ChangesSince(-1, nil)
@@ -247,3 +248,127 @@ func TestGetAllFlagSetNames(t *testing.T) {
 		t.Errorf("setNames len should be 4. Actual %v", len(setNames))
 	}
 }
+
+type staticOverrides overrides.State
+
+func (s staticOverrides) State() overrides.State { return overrides.State(s) }
+
+func TestSplitStorageWithOverrides(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	pss := NewProxySplitStorage(dbw, logging.NewLogger(nil), flagsets.NewFlagSetFilter(nil), false)
+	pss.Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 1, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "on", Sets: []string{"s1"}},
+		{Name: "f2", ChangeNumber: 2, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "on", Sets: []string{"s2"}},
+	}, nil, 2)
+
+	pss.SetOverrides(staticOverrides{
+		Active: map[string]overrides.Override{"f1": {Split: "f1", Treatment: "off", ChangeNumber: 10}},
+		Lifted: map[string]int64{"f2": 12},
+	})
+
+	byName := func(changes *dtos.SplitChangesDTO) map[string]dtos.SplitDTO {
+		toRet := make(map[string]dtos.SplitDTO)
+		for _, split := range changes.Splits {
+			toRet[split.Name] = split
+		}
+		return toRet
+	}
+
+	// full payload: the overridden flag is replaced & the lifted one gets the synthesized change number
+	changes, err := pss.ChangesSince(-1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), changes.Till)
+	splits := byName(changes)
+	assert.Len(t, splits, 2)
+	assert.Equal(t, int64(10), splits["f1"].ChangeNumber)
+	assert.Equal(t, "off", splits["f1"].DefaultTreatment)
+	assert.Len(t, splits["f1"].Conditions, 1)
+	assert.Equal(t, overrides.OverrideLabel, splits["f1"].Conditions[0].Label)
+	assert.Equal(t, []dtos.PartitionDTO{{Treatment: "off", Size: 100}}, splits["f1"].Conditions[0].Partitions)
+	assert.Equal(t, int64(12), splits["f2"].ChangeNumber)
+	assert.Equal(t, "on", splits["f2"].DefaultTreatment)
+
+	// the snapshot itself is untouched
+	assert.Equal(t, "on", pss.Split("f1").DefaultTreatment)
+
+	// the overridden view used for evaluations applies the active overrides only
+	overridden := pss.Overridden()
+	assert.Equal(t, "off", overridden.Split("f1").DefaultTreatment)
+	assert.Equal(t, "on", overridden.Split("f2").DefaultTreatment)
+	assert.Nil(t, overridden.Split("f3"))
+	fetched := overridden.FetchMany([]string{"f1", "f2"})
+	assert.Equal(t, overrides.OverrideLabel, fetched["f1"].Conditions[0].Label)
+	assert.Equal(t, "on", fetched["f2"].DefaultTreatment)
+	assert.Equal(t, "on", pss.Split("f1").DefaultTreatment)
+
+	// flags are included when their override changed after `since`, even if they haven't changed upstream
+	changes, err = pss.ChangesSince(9, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), changes.Till)
+	assert.Len(t, byName(changes), 2)
+
+	changes, err = pss.ChangesSince(11, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), changes.Till)
+	assert.Len(t, changes.Splits, 1)
+	assert.Equal(t, "f2", changes.Splits[0].Name)
+
+	changes, err = pss.ChangesSince(11, []string{"s1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), changes.Till)
+	assert.Empty(t, changes.Splits)
+
+	changes, err = pss.ChangesSince(12, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), changes.Till)
+	assert.Empty(t, changes.Splits)
+}
+
+func TestSplitStorageRestore(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	logger := logging.NewLogger(nil)
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), false)
+	pss.Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "ttt"},
+		{Name: "f2", ChangeNumber: 20, Status: "ACTIVE", TrafficTypeName: "ttt"},
+	}, nil, 20)
+
+	restored := []dtos.SplitDTO{
+		{Name: "f2", ChangeNumber: 5, Status: "ACTIVE", TrafficTypeName: "ttt"},
+		{Name: "f3", ChangeNumber: 6, Status: "ACTIVE", TrafficTypeName: "ttt"},
+		{Name: "f4", ChangeNumber: 7, Status: "ARCHIVED", TrafficTypeName: "ttt"},
+	}
+	cn, err := pss.Restore(restored)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), cn)
+
+	current, _ := pss.ChangeNumber()
+	assert.Equal(t, int64(7), current)
+	assert.Nil(t, pss.Split("f1"))
+	assert.ElementsMatch(t, []string{"f2", "f3"}, pss.SplitNames())
+
+	changes, err := pss.ChangesSince(-1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), changes.Till)
+	assert.ElementsMatch(t, restored[:2], changes.Splits)
+
+	// as when restoring upon startup, history prior to the restored data is unknown
+	_, err = pss.ChangesSince(5, nil)
+	assert.ErrorIs(t, err, ErrSinceParamTooOld)
+
+	// the new data is persisted
+	onDisk, err := persistent.NewSplitChangesCollection(dbw, logger).FetchAll()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, restored, onDisk)
+
+	// updates are applied on top of the restored data
+	pss.Update([]dtos.SplitDTO{{Name: "f5", ChangeNumber: 8, Status: "ACTIVE", TrafficTypeName: "ttt"}}, nil, 8)
+	changes, err = pss.ChangesSince(7, nil)
+	assert.Nil(t, err)
+	assert.Len(t, changes.Splits, 1)
+	assert.Equal(t, "f5", changes.Splits[0].Name)
+}
@@ -32,6 +32,8 @@ const (
 	TelemetryKeysClientSideEndpoint
 	TelemetryKeysClientSideBeaconEndpoint
 	TelemetryKeysServerSideEndpoint
+	EvaluateEndpoint    // not tracked in local telemetry
+	EvaluateAllEndpoint // not tracked in local telemetry
 )
 
 type statusCodeMap struct {
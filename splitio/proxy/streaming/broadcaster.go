@@ -0,0 +1,187 @@
+package streaming
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+const occupancyEventName = "[meta]occupancy"
+
+// Message is a notification ready to be written to an SSE connection. The wire format mimics the one used by Split's
+// streaming service, so that SDKs can parse it without any modification
+type Message struct {
+	ID        string `json:"id"`
+	ClientID  string `json:"clientId"`
+	Timestamp int64  `json:"timestamp"`
+	Encoding  string `json:"encoding"`
+	Channel   string `json:"channel"`
+	Data      string `json:"data"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Encode serializes the message as an SSE event
+func (m *Message) Encode() ([]byte, error) {
+	serialized, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing message: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("id: ")
+	buf.WriteString(m.ID)
+	buf.WriteString("\nevent: message\ndata: ")
+	buf.Write(serialized)
+	buf.WriteString("\n\n")
+	return buf.Bytes(), nil
+}
+
+// NewOccupancyMessage builds a message notifying that a control channel has an active publisher
+func NewOccupancyMessage(channel string) *Message {
+	return &Message{
+		ID:        strconv.FormatInt(time.Now().UnixNano(), 10),
+		ClientID:  "split-proxy",
+		Timestamp: time.Now().UnixMilli(),
+		Encoding:  "json",
+		Channel:   OccupancyPrefix + channel,
+		Data:      `{"metrics":{"publishers":1}}`,
+		Name:      occupancyEventName,
+	}
+}
+
+// Publisher defines the interface for a component that can forward notifications to subscribed SDKs
+type Publisher interface {
+	Publish(channel string, payload interface{})
+}
+
+// Broadcaster defines the interface for a component that fans out notifications to all interested subscribers
+type Broadcaster interface {
+	Publisher
+	Subscribe(channels []string) *Subscriber
+	Unsubscribe(subscriber *Subscriber)
+	SubscriberCount() int
+	Close()
+}
+
+// Subscriber represents an SDK connected to the SSE endpoint
+type Subscriber struct {
+	channels map[string]struct{}
+	messages chan *Message
+	done     <-chan struct{}
+	dropped  int64
+}
+
+// Messages returns the channel from which incoming notifications should be read
+func (s *Subscriber) Messages() <-chan *Message {
+	return s.messages
+}
+
+// Done returns a channel that's closed when the broadcaster is shut down & the subscriber should disconnect
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped returns the number of messages that were discarded because the subscriber was not consuming them fast enough
+func (s *Subscriber) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// BroadcasterImpl is an in-memory implementation of the Broadcaster interface
+type BroadcasterImpl struct {
+	logger      logging.LoggerInterface
+	bufferSize  int
+	subscribers map[*Subscriber]struct{}
+	sequence    int64
+	done        chan struct{}
+	closeOnce   sync.Once
+	mutex       sync.RWMutex
+}
+
+// NewBroadcaster constructs a new broadcaster. Each subscriber will be able to buffer up to `bufferSize` messages
+func NewBroadcaster(logger logging.LoggerInterface, bufferSize int) *BroadcasterImpl {
+	return &BroadcasterImpl{
+		logger:      logger,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscriber]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber interested in the supplied channels
+func (b *BroadcasterImpl) Subscribe(channels []string) *Subscriber {
+	subscriber := &Subscriber{
+		channels: make(map[string]struct{}, len(channels)),
+		messages: make(chan *Message, b.bufferSize),
+		done:     b.done,
+	}
+	for _, channel := range channels {
+		subscriber.channels[channel] = struct{}{}
+	}
+
+	b.mutex.Lock()
+	b.subscribers[subscriber] = struct{}{}
+	b.mutex.Unlock()
+	return subscriber
+}
+
+// Unsubscribe removes a subscriber. No more messages will be delivered to it
+func (b *BroadcasterImpl) Unsubscribe(subscriber *Subscriber) {
+	b.mutex.Lock()
+	delete(b.subscribers, subscriber)
+	b.mutex.Unlock()
+}
+
+// SubscriberCount returns the number of connected subscribers
+func (b *BroadcasterImpl) SubscriberCount() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return len(b.subscribers)
+}
+
+// Close signals every subscriber (current & future ones) to disconnect, so that long-lived streaming connections
+// don't hold a graceful shutdown of the http server
+func (b *BroadcasterImpl) Close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+// Publish serializes the payload and forwards it to every subscriber listening on the supplied channel.
+// Publishing never blocks: if a subscriber's buffer is full, the message is dropped for that subscriber
+func (b *BroadcasterImpl) Publish(channel string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		b.logger.Error(fmt.Sprintf("error serializing notification for channel %s: %s", channel, err))
+		return
+	}
+
+	message := &Message{
+		ID:        strconv.FormatInt(atomic.AddInt64(&b.sequence, 1), 10),
+		ClientID:  "split-proxy",
+		Timestamp: time.Now().UnixMilli(),
+		Encoding:  "json",
+		Channel:   channel,
+		Data:      string(data),
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for subscriber := range b.subscribers {
+		if _, ok := subscriber.channels[channel]; !ok {
+			continue
+		}
+
+		select {
+		case subscriber.messages <- message:
+		default:
+			atomic.AddInt64(&subscriber.dropped, 1)
+			b.logger.Warning(fmt.Sprintf("dropping notification for channel %s: subscriber buffer is full", channel))
+		}
+	}
+}
+
+var _ Broadcaster = (*BroadcasterImpl)(nil)
@@ -0,0 +1,83 @@
+package streaming
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcasterDeliversToInterestedSubscribers(t *testing.T) {
+	broadcaster := NewBroadcaster(logging.NewLogger(nil), 10)
+	s1 := broadcaster.Subscribe([]string{"ch1", "ch2"})
+	s2 := broadcaster.Subscribe([]string{"ch2"})
+	assert.Equal(t, 2, broadcaster.SubscriberCount())
+
+	broadcaster.Publish("ch1", map[string]interface{}{"type": "SPLIT_UPDATE"})
+	broadcaster.Publish("ch2", map[string]interface{}{"type": "SEGMENT_UPDATE"})
+
+	assert.Len(t, s1.Messages(), 2)
+	assert.Len(t, s2.Messages(), 1)
+
+	msg := <-s1.Messages()
+	assert.Equal(t, "ch1", msg.Channel)
+	assert.Equal(t, `{"type":"SPLIT_UPDATE"}`, msg.Data)
+	msg = <-s2.Messages()
+	assert.Equal(t, "ch2", msg.Channel)
+	assert.Equal(t, `{"type":"SEGMENT_UPDATE"}`, msg.Data)
+
+	broadcaster.Unsubscribe(s1)
+	assert.Equal(t, 1, broadcaster.SubscriberCount())
+	broadcaster.Publish("ch2", map[string]interface{}{"type": "SEGMENT_UPDATE"})
+	assert.Len(t, s1.Messages(), 1) // only the one that was already there
+	assert.Len(t, s2.Messages(), 1)
+}
+
+func TestBroadcasterDropsWhenBufferIsFull(t *testing.T) {
+	broadcaster := NewBroadcaster(logging.NewLogger(nil), 1)
+	s := broadcaster.Subscribe([]string{"ch1"})
+
+	broadcaster.Publish("ch1", 1)
+	broadcaster.Publish("ch1", 2)
+	broadcaster.Publish("ch1", 3)
+	assert.Len(t, s.Messages(), 1)
+	assert.Equal(t, int64(2), s.Dropped())
+}
+
+func TestBroadcasterClose(t *testing.T) {
+	broadcaster := NewBroadcaster(logging.NewLogger(nil), 1)
+	before := broadcaster.Subscribe([]string{"ch1"})
+	broadcaster.Close()
+	broadcaster.Close() // closing twice is harmless
+	after := broadcaster.Subscribe([]string{"ch1"})
+
+	for _, s := range []*Subscriber{before, after} {
+		select {
+		case <-s.Done():
+		default:
+			t.Error("subscribers should be told to disconnect once the broadcaster is closed")
+		}
+	}
+}
+
+func TestMessageEncoding(t *testing.T) {
+	msg := NewOccupancyMessage("control_pri")
+	encoded, err := msg.Encode()
+	assert.Nil(t, err)
+
+	lines := strings.Split(string(encoded), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "id: "+msg.ID, lines[0])
+	assert.Equal(t, "event: message", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "data: "))
+	assert.Equal(t, "", lines[3])
+	assert.Equal(t, "", lines[4])
+
+	var parsed map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &parsed))
+	assert.Equal(t, OccupancyPrefix+"control_pri", parsed["channel"])
+	assert.Equal(t, "[meta]occupancy", parsed["name"])
+	assert.Equal(t, `{"metrics":{"publishers":1}}`, parsed["data"])
+}
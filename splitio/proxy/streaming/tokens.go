@@ -0,0 +1,177 @@
+package streaming
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/hasher"
+)
+
+const (
+	controlPrimaryChannel   = "control_pri"
+	controlSecondaryChannel = "control_sec"
+	splitsChannelSuffix     = "_splits"
+	segmentsChannelSuffix   = "_segments"
+	mySegmentsChannelSuffix = "_mySegments"
+
+	capabilitySubscribe = "subscribe"
+	capabilityMetadata  = "channel-metadata:publishers"
+
+	// OccupancyPrefix is prepended by SDKs to control channels in order to receive occupancy notifications
+	OccupancyPrefix = "[?occupancy=metrics.publishers]"
+)
+
+// ErrInvalidToken is returned when a token cannot be parsed or it's signature doesn't match
+var ErrInvalidToken = errors.New("invalid token")
+
+// ErrExpiredToken is returned when a token was properly signed by this proxy but it's already expired
+var ErrExpiredToken = errors.New("token expired")
+
+// TokenIssuer mints and validates the jwt tokens handed to SDKs in /auth responses
+type TokenIssuer interface {
+	Issue(userKeys []string) (string, error)
+	Validate(token string) (*TokenClaims, error)
+	TTL() time.Duration
+}
+
+// TokenClaims is the payload of a token issued by the proxy. The capability field follows the same format as
+// tokens issued by Split so that SDKs can extract the channel list without any changes
+type TokenClaims struct {
+	Capability string `json:"x-ably-capability"`
+	ClientID   string `json:"x-ably-clientId"`
+	Exp        int64  `json:"exp"`
+	Iat        int64  `json:"iat"`
+}
+
+// Channels returns the list of channels this token grants access to
+func (t *TokenClaims) Channels() ([]string, error) {
+	var parsed map[string][]string
+	if err := json.Unmarshal([]byte(t.Capability), &parsed); err != nil {
+		return nil, fmt.Errorf("error parsing token capabilities: %w", err)
+	}
+
+	channels := make([]string, 0, len(parsed))
+	for name := range parsed {
+		channels = append(channels, name)
+	}
+	return channels, nil
+}
+
+// HMACTokenIssuer issues HS256-signed tokens using a proxy-local secret
+type HMACTokenIssuer struct {
+	secret    []byte
+	ttl       time.Duration
+	namespace string
+}
+
+// NewHMACTokenIssuer constructs a new token issuer. Namespace is used to prefix all channel names
+func NewHMACTokenIssuer(secret []byte, ttl time.Duration, namespace string) *HMACTokenIssuer {
+	return &HMACTokenIssuer{secret: secret, ttl: ttl, namespace: namespace}
+}
+
+// Issue builds a signed token with access to feature flag & segment channels, plus one mySegments channel per user key
+func (i *HMACTokenIssuer) Issue(userKeys []string) (string, error) {
+	capabilities := map[string][]string{
+		controlPrimaryChannel:        {capabilitySubscribe, capabilityMetadata},
+		controlSecondaryChannel:      {capabilitySubscribe, capabilityMetadata},
+		SplitsChannel(i.namespace):   {capabilitySubscribe},
+		SegmentsChannel(i.namespace): {capabilitySubscribe},
+	}
+	for _, key := range userKeys {
+		capabilities[MySegmentsChannel(i.namespace, key)] = []string{capabilitySubscribe}
+	}
+
+	serializedCapabilities, err := json.Marshal(capabilities)
+	if err != nil {
+		return "", fmt.Errorf("error serializing token capabilities: %w", err)
+	}
+
+	now := time.Now()
+	claims, err := json.Marshal(TokenClaims{
+		Capability: string(serializedCapabilities),
+		ClientID:   "split-proxy",
+		Iat:        now.Unix(),
+		Exp:        now.Add(i.ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error serializing token claims: %w", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + i.sign(unsigned), nil
+}
+
+// Validate checks the token signature & expiration, and returns the parsed claims
+func (i *HMACTokenIssuer) Validate(token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(i.sign(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims TokenClaims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() > claims.Exp {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+// TTL returns the lifetime of the tokens issued
+func (i *HMACTokenIssuer) TTL() time.Duration {
+	return i.ttl
+}
+
+func (i *HMACTokenIssuer) sign(data string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// MakeNamespace builds a channel namespace from the upstream sdk key, so that channel names remain stable across restarts
+func MakeNamespace(apikey string) string {
+	return hashToBase64(apikey)
+}
+
+// SplitsChannel returns the name of the channel used to notify feature flag changes
+func SplitsChannel(namespace string) string {
+	return namespace + splitsChannelSuffix
+}
+
+// SegmentsChannel returns the name of the channel used to notify segment changes
+func SegmentsChannel(namespace string) string {
+	return namespace + segmentsChannelSuffix
+}
+
+// MySegmentsChannel returns the name of the channel used to notify membership changes for a specific key.
+// The key hash is computed the same way client-side SDKs do, so that they can match the channel to the key.
+func MySegmentsChannel(namespace string, key string) string {
+	return namespace + "_" + hashToBase64(key) + mySegmentsChannelSuffix
+}
+
+func hashToBase64(s string) string {
+	hash := hasher.NewMurmur332Hasher(0).Hash([]byte(s))
+	return base64.StdEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(hash), 10)))
+}
+
+var _ TokenIssuer = (*HMACTokenIssuer)(nil)
@@ -0,0 +1,73 @@
+package streaming
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndValidate(t *testing.T) {
+	issuer := NewHMACTokenIssuer([]byte("secret"), time.Hour, "ns")
+	token, err := issuer.Issue([]string{"key1", "key2"})
+	assert.Nil(t, err)
+
+	claims, err := issuer.Validate(token)
+	assert.Nil(t, err)
+	assert.Equal(t, claims.Iat+3600, claims.Exp)
+
+	channels, err := claims.Channels()
+	assert.Nil(t, err)
+	sort.Strings(channels)
+	expected := []string{
+		"control_pri",
+		"control_sec",
+		SegmentsChannel("ns"),
+		SplitsChannel("ns"),
+		MySegmentsChannel("ns", "key1"),
+		MySegmentsChannel("ns", "key2"),
+	}
+	sort.Strings(expected)
+	assert.Equal(t, expected, channels)
+
+	var capabilities map[string][]string
+	assert.Nil(t, json.Unmarshal([]byte(claims.Capability), &capabilities))
+	assert.Equal(t, []string{"subscribe", "channel-metadata:publishers"}, capabilities["control_pri"])
+	assert.Equal(t, []string{"subscribe"}, capabilities[SplitsChannel("ns")])
+}
+
+func TestValidateRejectsTamperedTokens(t *testing.T) {
+	issuer := NewHMACTokenIssuer([]byte("secret"), time.Hour, "ns")
+	token, err := issuer.Issue(nil)
+	assert.Nil(t, err)
+
+	other := NewHMACTokenIssuer([]byte("another secret"), time.Hour, "ns")
+	_, err = other.Validate(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = issuer.Validate("not.a.token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = issuer.Validate("")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestValidateRejectsExpiredTokens(t *testing.T) {
+	issuer := NewHMACTokenIssuer([]byte("secret"), -time.Minute, "ns")
+	token, err := issuer.Issue(nil)
+	assert.Nil(t, err)
+
+	_, err = issuer.Validate(token)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestChannelNames(t *testing.T) {
+	ns := MakeNamespace("someApikey")
+	assert.Equal(t, ns, MakeNamespace("someApikey"))
+	assert.NotEqual(t, ns, MakeNamespace("anotherApikey"))
+	assert.Equal(t, ns+"_splits", SplitsChannel(ns))
+	assert.Equal(t, ns+"_segments", SegmentsChannel(ns))
+	assert.NotEqual(t, MySegmentsChannel(ns, "key1"), MySegmentsChannel(ns, "key2"))
+}
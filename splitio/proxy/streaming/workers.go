@@ -0,0 +1,186 @@
+package streaming
+
+import (
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/storage"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/segment"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
+)
+
+const updateTypeMySegments = "MY_SEGMENTS_UPDATE"
+
+type splitUpdateNotification struct {
+	Type         string `json:"type"`
+	ChangeNumber int64  `json:"changeNumber"`
+}
+
+type splitKillNotification struct {
+	Type             string `json:"type"`
+	ChangeNumber     int64  `json:"changeNumber"`
+	SplitName        string `json:"splitName"`
+	DefaultTreatment string `json:"defaultTreatment"`
+}
+
+type segmentUpdateNotification struct {
+	Type         string `json:"type"`
+	ChangeNumber int64  `json:"changeNumber"`
+	SegmentName  string `json:"segmentName"`
+}
+
+type mySegmentsUpdateNotification struct {
+	Type            string `json:"type"`
+	ChangeNumber    int64  `json:"changeNumber"`
+	IncludesPayload bool   `json:"includesPayload"`
+}
+
+// NotifyingSplitUpdater wraps a feature flag updater and notifies connected SDKs when a change is processed
+type NotifyingSplitUpdater struct {
+	wrapped      split.Updater
+	splitStorage storage.SplitStorageConsumer
+	publisher    Publisher
+	namespace    string
+}
+
+// NewNotifyingSplitUpdater constructs a new feature flag updater that publishes changes to connected SDKs
+func NewNotifyingSplitUpdater(
+	wrapped split.Updater,
+	splitStorage storage.SplitStorageConsumer,
+	publisher Publisher,
+	namespace string,
+) *NotifyingSplitUpdater {
+	return &NotifyingSplitUpdater{
+		wrapped:      wrapped,
+		splitStorage: splitStorage,
+		publisher:    publisher,
+		namespace:    namespace,
+	}
+}
+
+// SynchronizeSplits forwards the call to the wrapped updater and notifies SDKs if the change number was bumped
+func (n *NotifyingSplitUpdater) SynchronizeSplits(till *int64) (*split.UpdateResult, error) {
+	previous, _ := n.splitStorage.ChangeNumber()
+	result, err := n.wrapped.SynchronizeSplits(till)
+	n.notifyIfUpdated(previous)
+	return result, err
+}
+
+// SynchronizeFeatureFlags forwards the call to the wrapped updater and notifies SDKs if the change number was bumped
+func (n *NotifyingSplitUpdater) SynchronizeFeatureFlags(ffChange *dtos.SplitChangeUpdate) (*split.UpdateResult, error) {
+	previous, _ := n.splitStorage.ChangeNumber()
+	result, err := n.wrapped.SynchronizeFeatureFlags(ffChange)
+	n.notifyIfUpdated(previous)
+	return result, err
+}
+
+// LocalKill forwards the call to the wrapped updater and notifies SDKs of the kill
+func (n *NotifyingSplitUpdater) LocalKill(splitName string, defaultTreatment string, changeNumber int64) {
+	n.wrapped.LocalKill(splitName, defaultTreatment, changeNumber)
+	n.publisher.Publish(SplitsChannel(n.namespace), splitKillNotification{
+		Type:             dtos.UpdateTypeSplitKill,
+		ChangeNumber:     changeNumber,
+		SplitName:        splitName,
+		DefaultTreatment: defaultTreatment,
+	})
+}
+
+func (n *NotifyingSplitUpdater) notifyIfUpdated(previous int64) {
+	if current, _ := n.splitStorage.ChangeNumber(); current > previous {
+		NotifySplitChange(n.publisher, n.namespace, current)
+	}
+}
+
+// NotifySplitChange tells connected SDKs to fetch feature flags up to the supplied change number.
+// Used as well for changes not coming from Split servers, such as local overrides
+func NotifySplitChange(publisher Publisher, namespace string, changeNumber int64) {
+	publisher.Publish(SplitsChannel(namespace), splitUpdateNotification{
+		Type:         dtos.UpdateTypeSplitChange,
+		ChangeNumber: changeNumber,
+	})
+}
+
+// NotifyingSegmentUpdater wraps a segment updater and notifies connected SDKs when a change is processed
+type NotifyingSegmentUpdater struct {
+	wrapped        segment.Updater
+	splitStorage   storage.SplitStorageConsumer
+	segmentStorage storage.SegmentStorageConsumer
+	publisher      Publisher
+	namespace      string
+}
+
+// NewNotifyingSegmentUpdater constructs a new segment updater that publishes changes to connected SDKs
+func NewNotifyingSegmentUpdater(
+	wrapped segment.Updater,
+	splitStorage storage.SplitStorageConsumer,
+	segmentStorage storage.SegmentStorageConsumer,
+	publisher Publisher,
+	namespace string,
+) *NotifyingSegmentUpdater {
+	return &NotifyingSegmentUpdater{
+		wrapped:        wrapped,
+		splitStorage:   splitStorage,
+		segmentStorage: segmentStorage,
+		publisher:      publisher,
+		namespace:      namespace,
+	}
+}
+
+// SynchronizeSegment forwards the call to the wrapped updater and notifies SDKs if the segment was updated
+func (n *NotifyingSegmentUpdater) SynchronizeSegment(name string, till *int64) (*segment.UpdateResult, error) {
+	previous, _ := n.segmentStorage.ChangeNumber(name)
+	result, err := n.wrapped.SynchronizeSegment(name, till)
+	if result != nil {
+		n.notifyIfUpdated(name, previous, result)
+	}
+	return result, err
+}
+
+// SynchronizeSegments forwards the call to the wrapped updater and notifies SDKs of every segment that was updated
+func (n *NotifyingSegmentUpdater) SynchronizeSegments() (map[string]segment.UpdateResult, error) {
+	names := n.splitStorage.SegmentNames()
+	previousCNs := make(map[string]int64, names.Size())
+	for _, name := range names.List() {
+		if strName, ok := name.(string); ok {
+			previousCNs[strName], _ = n.segmentStorage.ChangeNumber(strName)
+		}
+	}
+
+	results, err := n.wrapped.SynchronizeSegments()
+	for name := range results {
+		result := results[name]
+		n.notifyIfUpdated(name, previousCNs[name], &result)
+	}
+	return results, err
+}
+
+// SegmentNames forwards the call to the wrapped updater
+func (n *NotifyingSegmentUpdater) SegmentNames() []interface{} {
+	return n.wrapped.SegmentNames()
+}
+
+// IsSegmentCached forwards the call to the wrapped updater
+func (n *NotifyingSegmentUpdater) IsSegmentCached(segmentName string) bool {
+	return n.wrapped.IsSegmentCached(segmentName)
+}
+
+func (n *NotifyingSegmentUpdater) notifyIfUpdated(name string, previous int64, result *segment.UpdateResult) {
+	if result.NewChangeNumber <= previous {
+		return
+	}
+
+	n.publisher.Publish(SegmentsChannel(n.namespace), segmentUpdateNotification{
+		Type:         dtos.UpdateTypeSegmentChange,
+		ChangeNumber: result.NewChangeNumber,
+		SegmentName:  name,
+	})
+
+	// client-side sdks are notified individually, and will re-fetch their memberships upon receiving the notification
+	for _, key := range result.UpdatedKeys {
+		n.publisher.Publish(MySegmentsChannel(n.namespace, key), mySegmentsUpdateNotification{
+			Type:         updateTypeMySegments,
+			ChangeNumber: result.NewChangeNumber,
+		})
+	}
+}
+
+var _ split.Updater = (*NotifyingSplitUpdater)(nil)
+var _ segment.Updater = (*NotifyingSegmentUpdater)(nil)
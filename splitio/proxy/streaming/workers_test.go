@@ -0,0 +1,128 @@
+package streaming
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-split-commons/v6/flagsets"
+	"github.com/splitio/go-split-commons/v6/storage/inmemory/mutexmap"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/segment"
+	"github.com/splitio/go-split-commons/v6/synchronizer/worker/split"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotifyingSplitUpdater(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage(flagsets.NewFlagSetFilter(nil))
+	var wrapped splitUpdaterMock
+	wrapped.On("SynchronizeSplits", (*int64)(nil)).
+		Run(func(mock.Arguments) { splitStorage.Update([]dtos.SplitDTO{{Name: "s1"}}, nil, 10) }).
+		Return(&split.UpdateResult{}, nil).
+		Once()
+	wrapped.On("SynchronizeSplits", (*int64)(nil)).Return(&split.UpdateResult{}, nil).Once() // no changes
+	wrapped.On("LocalKill", "s1", "off", int64(11)).Once()
+
+	var publisher publisherMock
+	updater := NewNotifyingSplitUpdater(&wrapped, splitStorage, &publisher, "ns")
+
+	_, err := updater.SynchronizeSplits(nil)
+	assert.Nil(t, err)
+	_, err = updater.SynchronizeSplits(nil)
+	assert.Nil(t, err)
+	updater.LocalKill("s1", "off", 11)
+
+	assert.Equal(t, []published{
+		{channel: "ns_splits", payload: `{"type":"SPLIT_UPDATE","changeNumber":10}`},
+		{channel: "ns_splits", payload: `{"type":"SPLIT_KILL","changeNumber":11,"splitName":"s1","defaultTreatment":"off"}`},
+	}, publisher.published)
+	wrapped.AssertExpectations(t)
+}
+
+func TestNotifyingSegmentUpdater(t *testing.T) {
+	splitStorage := mutexmap.NewMMSplitStorage(flagsets.NewFlagSetFilter(nil))
+	splitStorage.Update([]dtos.SplitDTO{{
+		Name: "s1",
+		Conditions: []dtos.ConditionDTO{{MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{
+			MatcherType:        "IN_SEGMENT",
+			UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "seg1"},
+		}}}}},
+	}}, nil, 1)
+	segmentStorage := mutexmap.NewMMSegmentStorage()
+	segmentStorage.Update("seg1", set.NewSet("k0"), set.NewSet(), 5)
+
+	var wrapped segmentUpdaterMock
+	wrapped.On("SynchronizeSegments").Return(map[string]segment.UpdateResult{
+		"seg1": {UpdatedKeys: []string{"k1", "k2"}, NewChangeNumber: 6},
+	}, nil).Once()
+	wrapped.On("SynchronizeSegment", "seg1", (*int64)(nil)).Return(&segment.UpdateResult{NewChangeNumber: 5}, nil).Once()
+
+	var publisher publisherMock
+	updater := NewNotifyingSegmentUpdater(&wrapped, splitStorage, segmentStorage, &publisher, "ns")
+
+	_, err := updater.SynchronizeSegments()
+	assert.Nil(t, err)
+	_, err = updater.SynchronizeSegment("seg1", nil) // same change number, no notification expected
+	assert.Nil(t, err)
+
+	assert.Equal(t, []published{
+		{channel: "ns_segments", payload: `{"type":"SEGMENT_UPDATE","changeNumber":6,"segmentName":"seg1"}`},
+		{channel: MySegmentsChannel("ns", "k1"), payload: `{"type":"MY_SEGMENTS_UPDATE","changeNumber":6,"includesPayload":false}`},
+		{channel: MySegmentsChannel("ns", "k2"), payload: `{"type":"MY_SEGMENTS_UPDATE","changeNumber":6,"includesPayload":false}`},
+	}, publisher.published)
+	wrapped.AssertExpectations(t)
+}
+
+type published struct {
+	channel string
+	payload string
+}
+
+type publisherMock struct {
+	published []published
+}
+
+func (p *publisherMock) Publish(channel string, payload interface{}) {
+	serialized, _ := json.Marshal(payload)
+	p.published = append(p.published, published{channel: channel, payload: string(serialized)})
+}
+
+type splitUpdaterMock struct {
+	mock.Mock
+}
+
+func (s *splitUpdaterMock) SynchronizeSplits(till *int64) (*split.UpdateResult, error) {
+	args := s.Called(till)
+	return args.Get(0).(*split.UpdateResult), args.Error(1)
+}
+
+func (s *splitUpdaterMock) SynchronizeFeatureFlags(ffChange *dtos.SplitChangeUpdate) (*split.UpdateResult, error) {
+	args := s.Called(ffChange)
+	return args.Get(0).(*split.UpdateResult), args.Error(1)
+}
+
+func (s *splitUpdaterMock) LocalKill(splitName string, defaultTreatment string, changeNumber int64) {
+	s.Called(splitName, defaultTreatment, changeNumber)
+}
+
+type segmentUpdaterMock struct {
+	mock.Mock
+}
+
+func (s *segmentUpdaterMock) IsSegmentCached(segmentName string) bool { panic("unimplemented") }
+func (s *segmentUpdaterMock) SegmentNames() []interface{}             { panic("unimplemented") }
+
+func (s *segmentUpdaterMock) SynchronizeSegment(name string, till *int64) (*segment.UpdateResult, error) {
+	args := s.Called(name, till)
+	return args.Get(0).(*segment.UpdateResult), args.Error(1)
+}
+
+func (s *segmentUpdaterMock) SynchronizeSegments() (map[string]segment.UpdateResult, error) {
+	args := s.Called()
+	return args.Get(0).(map[string]segment.UpdateResult), args.Error(1)
+}
+
+var _ split.Updater = (*splitUpdaterMock)(nil)
+var _ segment.Updater = (*segmentUpdaterMock)(nil)
+var _ Publisher = (*publisherMock)(nil)
@@ -0,0 +1,325 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Names used to identify each kind of data posted by SDKs & forwarded to Split servers
+const (
+	KindImpressions             = "impressions"
+	KindImpressionCounts        = "impressionCounts"
+	KindEvents                  = "events"
+	KindTelemetryConfig         = "telemetryConfig"
+	KindTelemetryUsage          = "telemetryUsage"
+	KindTelemetryKeysClientSide = "telemetryKeysClientSide"
+	KindTelemetryKeysServerSide = "telemetryKeysServerSide"
+)
+
+// DeadLetter is a payload that could not be posted to Split servers
+type DeadLetter struct {
+	ID         uint64
+	Kind       string
+	Item       interface{}
+	Attempts   int
+	LastError  string
+	StatusCode int
+	FailedAt   time.Time
+}
+
+// Payload returns the sdk metadata & raw body of the post that failed
+func (d *DeadLetter) Payload() (dtos.Metadata, []byte, bool) {
+	switch item := d.Item.(type) {
+	case *internal.RawImpressions:
+		return item.Metadata, item.Payload, true
+	case *internal.RawData:
+		return item.Metadata, item.Payload, true
+	}
+	return dtos.Metadata{}, nil, false
+}
+
+// DeadLetterStore defines the interface for a component holding payloads that could not be posted
+type DeadLetterStore interface {
+	Add(kind string, item interface{}, attempts int, err error)
+	List() []DeadLetter
+	Get(id uint64) (*DeadLetter, bool)
+	Remove(filter func(*DeadLetter) bool) []DeadLetter
+	Dropped() int64
+}
+
+func newDeadLetter(kind string, item interface{}, attempts int, err error) DeadLetter {
+	letter := DeadLetter{Kind: kind, Item: item, Attempts: attempts, FailedAt: time.Now()}
+	if err != nil {
+		letter.LastError = err.Error()
+		letter.StatusCode = retry.StatusCodeOf(err)
+	}
+	return letter
+}
+
+// InMemoryDeadLetterStore keeps up to `maxItems` dead letters in memory. When full, the oldest ones are discarded
+type InMemoryDeadLetterStore struct {
+	items    []DeadLetter
+	maxItems int
+	nextID   uint64
+	dropped  int64
+	mutex    sync.RWMutex
+}
+
+// NewInMemoryDeadLetterStore constructs a new dead letter store
+func NewInMemoryDeadLetterStore(maxItems int) *InMemoryDeadLetterStore {
+	return &InMemoryDeadLetterStore{maxItems: maxItems}
+}
+
+// Add stores a payload that exhausted all of its attempts
+func (s *InMemoryDeadLetterStore) Add(kind string, item interface{}, attempts int, err error) {
+	letter := newDeadLetter(kind, item, attempts, err)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.maxItems <= 0 {
+		s.dropped++
+		return
+	}
+
+	s.nextID++
+	letter.ID = s.nextID
+	if len(s.items) >= s.maxItems {
+		s.dropped += int64(len(s.items) - s.maxItems + 1)
+		s.items = append(s.items[:0:0], s.items[len(s.items)-s.maxItems+1:]...)
+	}
+	s.items = append(s.items, letter)
+}
+
+// List returns all the dead letters, oldest first
+func (s *InMemoryDeadLetterStore) List() []DeadLetter {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]DeadLetter(nil), s.items...)
+}
+
+// Get returns a dead letter by id
+func (s *InMemoryDeadLetterStore) Get(id uint64) (*DeadLetter, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for idx := range s.items {
+		if s.items[idx].ID == id {
+			letter := s.items[idx]
+			return &letter, true
+		}
+	}
+	return nil, false
+}
+
+// Remove discards the dead letters matching the filter (all of them if nil) and returns them
+func (s *InMemoryDeadLetterStore) Remove(filter func(*DeadLetter) bool) []DeadLetter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var removed []DeadLetter
+	kept := s.items[:0]
+	for idx := range s.items {
+		if filter == nil || filter(&s.items[idx]) {
+			removed = append(removed, s.items[idx])
+		} else {
+			kept = append(kept, s.items[idx])
+		}
+	}
+	s.items = kept
+	return removed
+}
+
+// Dropped returns the amount of dead letters discarded due to the store being full
+func (s *InMemoryDeadLetterStore) Dropped() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.dropped
+}
+
+var _ DeadLetterStore = (*InMemoryDeadLetterStore)(nil)
+
+// BoltDeadLetterStore keeps up to `maxItems` dead letters on a boltdb bucket, so that they survive restarts.
+// Dead letters are keyed by their id, which is ever-increasing, so iterating the bucket yields the oldest ones first.
+// When full, the oldest ones are discarded
+type BoltDeadLetterStore struct {
+	db       persistent.DBWrapper
+	bucket   []byte
+	maxItems int
+	items    int
+	dropped  int64
+	mutex    sync.Mutex
+}
+
+// NewBoltDeadLetterStore constructs a dead letter store on the bucket `name` of the supplied db. Dead letters
+// already present in the bucket (from a previous run) are kept
+func NewBoltDeadLetterStore(db persistent.DBWrapper, name string, maxItems int) (*BoltDeadLetterStore, error) {
+	store := &BoltDeadLetterStore{db: db, bucket: []byte(name), maxItems: maxItems}
+	err := db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(store.bucket)
+		if err != nil {
+			return err
+		}
+		store.items = bucket.Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error setting up dead letters bucket '%s': %w", name, err)
+	}
+	return store, nil
+}
+
+// Add stores a payload that exhausted all of its attempts
+func (s *BoltDeadLetterStore) Add(kind string, item interface{}, attempts int, err error) {
+	letter := newDeadLetter(kind, item, attempts, err)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.maxItems <= 0 {
+		s.dropped++
+		return
+	}
+
+	var evicted int
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return persistent.ErrorBucketNotFound
+		}
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		letter.ID = id
+		var buffer bytes.Buffer
+		if err := gob.NewEncoder(&buffer).Encode(letter); err != nil {
+			return fmt.Errorf("error encoding item of type %T: %w", item, err)
+		}
+
+		cursor := bucket.Cursor()
+		for key, _ := cursor.First(); key != nil && s.items-evicted >= s.maxItems; key, _ = cursor.Next() {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			evicted++
+		}
+		return bucket.Put(deadLetterKey(id), buffer.Bytes())
+	})
+	if err != nil {
+		s.dropped++
+		return
+	}
+	s.items += 1 - evicted
+	s.dropped += int64(evicted)
+}
+
+// List returns all the dead letters, oldest first
+func (s *BoltDeadLetterStore) List() []DeadLetter {
+	var letters []DeadLetter
+	s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return persistent.ErrorBucketNotFound
+		}
+		return bucket.ForEach(func(_, raw []byte) error {
+			if letter, ok := decodeDeadLetter(raw); ok {
+				letters = append(letters, *letter)
+			}
+			return nil
+		})
+	})
+	return letters
+}
+
+// Get returns a dead letter by id
+func (s *BoltDeadLetterStore) Get(id uint64) (*DeadLetter, bool) {
+	var letter *DeadLetter
+	var ok bool
+	s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return persistent.ErrorBucketNotFound
+		}
+		if raw := bucket.Get(deadLetterKey(id)); raw != nil {
+			letter, ok = decodeDeadLetter(raw)
+		}
+		return nil
+	})
+	return letter, ok
+}
+
+// Remove discards the dead letters matching the filter (all of them if nil) and returns them
+func (s *BoltDeadLetterStore) Remove(filter func(*DeadLetter) bool) []DeadLetter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var removed []DeadLetter
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return persistent.ErrorBucketNotFound
+		}
+
+		removed = nil
+		cursor := bucket.Cursor()
+		for key, raw := cursor.First(); key != nil; {
+			letter, ok := decodeDeadLetter(raw)
+			if !ok || (filter != nil && !filter(letter)) {
+				key, raw = cursor.Next()
+				continue
+			}
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			removed = append(removed, *letter)
+			// after deleting, the cursor is left on the previous item, or unpositioned if it was the first one
+			key, raw = cursor.Seek(deadLetterKey(letter.ID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+	s.items -= len(removed)
+	return removed
+}
+
+// Dropped returns the amount of dead letters discarded due to the store being full (since the proxy started)
+func (s *BoltDeadLetterStore) Dropped() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.dropped
+}
+
+// Len returns the amount of dead letters stored
+func (s *BoltDeadLetterStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.items
+}
+
+func deadLetterKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+func decodeDeadLetter(raw []byte) (*DeadLetter, bool) {
+	var letter DeadLetter
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&letter); err != nil {
+		return nil, false
+	}
+	return &letter, true
+}
+
+var _ DeadLetterStore = (*BoltDeadLetterStore)(nil)
@@ -3,6 +3,8 @@ package tasks
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/splitio/go-split-commons/v6/tasks"
 	"github.com/splitio/go-toolkit/v5/asynctask"
@@ -20,16 +22,30 @@ import (
 // the cost of copying those structs everywhere).
 // The worker pool now defines the level of concurrency when posting data
 // The size of the incoming  & worker pool channels, define the amount of impression posts that can be kept in memory
+// Optionally, a spool can be supplied to hold whatever doesn't fit in those channels on disk. When flushing, the spool is
+// drained first (as long as there's room in the worker pool), and data that cannot be handed to the pool is spooled back.
 
 // ErrQueueFull is returned when attempting to add data to a full queue
 var ErrQueueFull = errors.New("queue is full, data not pushed")
 
+// how often to check whether workers have caught up while draining
+const drainPollInterval = 10 * time.Millisecond
+
 // DeferredRecordingTask defines the interface for a task that accepts POSTs and submits them asyncrhonously
 type DeferredRecordingTask interface {
 	Stage(rawData interface{}) error
+	Drain(deadline time.Time) DrainResult
 	tasks.Task
 }
 
+// DrainResult summarizes what happened to the data held by a task when draining it
+type DrainResult struct {
+	Pending         int  // items held in memory & on disk when draining started
+	Spooled         int  // items left in the on-disk spool
+	Dropped         int  // items lost because they couldn't be posted nor spooled before the deadline, including in-flight ones
+	WorkersTimedOut bool // whether some posts were still in progress when the deadline was reached
+}
+
 // WorkerFactory defines the signature of a function for instantiating workers
 type WorkerFactory = func() workerpool.Worker
 
@@ -42,10 +58,21 @@ type DeferredRecordingTaskImpl struct {
 	drainInProgress *gtSync.AtomicBool
 	pool            *workerpool.WorkerAdmin
 	queue           genericQueue
+	spool           Spool
+	retries         *retrier
+	inFlight        *int64
 	mutex           sync.Mutex
 }
 
-func newDeferredFlushTask(logger logging.LoggerInterface, wfactory WorkerFactory, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
+func newDeferredFlushTask(
+	logger logging.LoggerInterface,
+	wfactory WorkerFactory,
+	period int,
+	queueSize int,
+	threads int,
+	spool Spool,
+	retries *retrier,
+) *DeferredRecordingTaskImpl {
 	drainFlag := gtSync.NewAtomicBool(false)
 	queue := make(genericQueue, queueSize)
 	pool := workerpool.NewWorkerAdmin(queueSize, logger)
@@ -55,14 +82,34 @@ func newDeferredFlushTask(logger logging.LoggerInterface, wfactory WorkerFactory
 			return nil
 		}
 		defer drainFlag.Unset() // clear the flag after we're done
+		if spool != nil {
+			drainSpool(logger, spool, pool, queueSize)
+		}
 		for len(queue) > 0 {
-			pool.QueueMessage(<-queue)
+			item := <-queue
+			if !pool.QueueMessage(item) && spool != nil {
+				if err := spool.Push(item); err != nil {
+					logger.Error("error spooling data that doesn't fit in the worker pool: ", err)
+				}
+			}
 		}
 		return nil
 	}
 
+	if retries != nil {
+		// payloads whose backoff has expired go back to the pool, or to the spool if the pool is full
+		retries.requeue = func(retry *pendingRetry) bool {
+			return pool.QueueMessage(retry) || (spool != nil && spool.Push(retry.payload) == nil)
+		}
+	}
+
+	inFlight := new(int64)
 	for i := 0; i < threads; i++ {
-		pool.AddWorker(wfactory())
+		worker := wfactory()
+		if retries != nil {
+			worker = retries.wrap(worker)
+		}
+		pool.AddWorker(&trackingWorker{Worker: worker, inFlight: inFlight})
 	}
 
 	return &DeferredRecordingTaskImpl{
@@ -71,6 +118,9 @@ func newDeferredFlushTask(logger logging.LoggerInterface, wfactory WorkerFactory
 		drainInProgress: drainFlag,
 		pool:            pool,
 		queue:           queue,
+		spool:           spool,
+		retries:         retries,
+		inFlight:        inFlight,
 	}
 }
 
@@ -81,7 +131,15 @@ func (t *DeferredRecordingTaskImpl) Stage(data interface{}) error {
 	select {
 	case t.queue <- data:
 	default:
-		return ErrQueueFull
+		if t.spool == nil {
+			return ErrQueueFull
+		}
+		if err := t.spool.Push(data); err != nil {
+			t.logger.Error("error spooling data that doesn't fit in memory: ", err)
+			return ErrQueueFull
+		}
+		t.task.WakeUp()
+		return nil
 	}
 
 	if len(t.queue) == cap(t.queue) { // The queue has become full with this new element we added
@@ -90,6 +148,15 @@ func (t *DeferredRecordingTaskImpl) Stage(data interface{}) error {
 	return nil
 }
 
+// SpoolStats returns the state of the on-disk spool, or nil if the task has none
+func (t *DeferredRecordingTaskImpl) SpoolStats() *SpoolStats {
+	if t.spool == nil {
+		return nil
+	}
+	stats := t.spool.Stats()
+	return &stats
+}
+
 // Start starts the flushing task
 func (t *DeferredRecordingTaskImpl) Start() {
 	t.task.Start()
@@ -100,9 +167,115 @@ func (t *DeferredRecordingTaskImpl) Stop(blocking bool) error {
 	return t.task.Stop(blocking)
 }
 
+// Drain stops the periodic flush and hands everything queued (in memory or spooled) to the workers, waiting for them
+// to post it until the deadline is reached. Whatever cannot be posted in time is spooled if possible, or dropped
+func (t *DeferredRecordingTaskImpl) Drain(deadline time.Time) DrainResult {
+	t.task.Stop(false)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var result DrainResult
+	result.Pending = len(t.queue) + t.pool.QueueSize()
+	if t.spool != nil {
+		result.Pending += int(t.spool.Stats().Items)
+	}
+
+	capacity := cap(t.queue)
+	for time.Now().Before(deadline) {
+		if t.spool != nil {
+			drainSpool(t.logger, t.spool, t.pool, capacity)
+		}
+		for len(t.queue) > 0 && t.pool.QueueSize() < capacity {
+			if item := <-t.queue; !t.pool.QueueMessage(item) {
+				t.queue <- item // workers didn't keep up, we're holding the lock so there's room for it
+				break
+			}
+		}
+		if len(t.queue) == 0 && (t.spool == nil || t.spool.Stats().Items == 0) {
+			break
+		}
+		time.Sleep(drainPollInterval)
+	}
+
+	for t.pool.QueueSize() > 0 && time.Now().Before(deadline) {
+		time.Sleep(drainPollInterval)
+	}
+
+	// stopping the workers waits for in-flight posts to complete
+	stopped := make(chan struct{})
+	go func() {
+		t.pool.StopAll(true)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Until(deadline)):
+		result.WorkersTimedOut = true
+	}
+
+	// messages never picked up by a worker cannot be retrieved from the pool. Posts still running when the deadline
+	// is reached are accounted as dropped as well, since they'll be aborted when the process exits (and if they fail
+	// before that, they can no longer be retried nor spooled)
+	result.Dropped = t.pool.QueueSize()
+	if result.WorkersTimedOut {
+		result.Dropped += int(atomic.LoadInt64(t.inFlight))
+	}
+	leftovers := make([]interface{}, 0, len(t.queue))
+	for len(t.queue) > 0 {
+		leftovers = append(leftovers, <-t.queue)
+	}
+	if t.retries != nil {
+		leftovers = append(leftovers, t.retries.stop()...)
+	}
+	for _, item := range leftovers {
+		if t.spool == nil {
+			result.Dropped++
+			continue
+		}
+		if err := t.spool.Push(item); err != nil {
+			t.logger.Error("error spooling data upon shutdown: ", err)
+			result.Dropped++
+		}
+	}
+	if t.spool != nil {
+		result.Spooled = int(t.spool.Stats().Items)
+	}
+	return result
+}
+
 // IsRunning returns whether the task is running
 func (t *DeferredRecordingTaskImpl) IsRunning() bool {
 	return t.task.IsRunning()
 }
 
+// trackingWorker decorates a worker keeping count of the messages being processed
+type trackingWorker struct {
+	workerpool.Worker
+	inFlight *int64
+}
+
+// DoWork calls the wrapped worker, accounting for the message while it's being processed
+func (w *trackingWorker) DoWork(message interface{}) error {
+	atomic.AddInt64(w.inFlight, 1)
+	defer atomic.AddInt64(w.inFlight, -1)
+	return w.Worker.DoWork(message)
+}
+
+// drainSpool moves items from the spool to the worker pool, oldest first, while there's room for them.
+// Items are only removed from the spool once queued, so that if workers didn't keep up with the check above,
+// the oldest one is left in place & retried later
+func drainSpool(logger logging.LoggerInterface, spool Spool, pool *workerpool.WorkerAdmin, capacity int) {
+	for pool.QueueSize() < capacity {
+		queued, err := spool.PopInto(pool.QueueMessage)
+		if err != nil {
+			logger.Error("error reading data from spool: ", err)
+			return
+		}
+		if !queued {
+			return
+		}
+	}
+}
+
 var _ DeferredRecordingTask = (*DeferredRecordingTaskImpl)(nil)
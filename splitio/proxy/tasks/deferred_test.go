@@ -0,0 +1,87 @@
+package tasks
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+type blockingWorker struct {
+	release chan struct{}
+}
+
+func (w *blockingWorker) Name() string       { return "blocking-worker" }
+func (w *blockingWorker) OnError(e error)    {}
+func (w *blockingWorker) Cleanup() error     { return nil }
+func (w *blockingWorker) FailureTime() int64 { return 1 }
+func (w *blockingWorker) DoWork(m interface{}) error {
+	<-w.release
+	return nil
+}
+
+func TestDeferredTaskDrain(t *testing.T) {
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	spool, err := NewBoltSpool(db, "events", 0)
+	assert.Nil(t, err)
+
+	var received []interface{}
+	var mutex sync.Mutex
+	factory := func() workerpool.Worker { return &recordingWorker{received: &received, mutex: &mutex} }
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 3600, 2, 1, spool, nil)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, task.Stage(internal.NewRawEvents(dtos.Metadata{}, []byte(fmt.Sprintf("events%d", i)))))
+	}
+	assert.Equal(t, int64(3), task.SpoolStats().Items)
+
+	// everything held in memory & in the spool is posted
+	result := task.Drain(time.Now().Add(5 * time.Second))
+	assert.Equal(t, DrainResult{Pending: 5}, result)
+	mutex.Lock()
+	assert.Len(t, received, 5)
+	mutex.Unlock()
+	assert.Equal(t, int64(0), task.SpoolStats().Items)
+}
+
+func TestDeferredTaskDrainDeadline(t *testing.T) {
+	worker := &blockingWorker{release: make(chan struct{})}
+	defer close(worker.release)
+
+	task := newDeferredFlushTask(logging.NewLogger(nil), func() workerpool.Worker { return worker }, 3600, 3, 1, nil, nil)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, task.Stage(internal.NewRawEvents(dtos.Metadata{}, []byte(fmt.Sprintf("events%d", i)))))
+	}
+
+	// the worker gets stuck posting the first item, so the other 2 are never picked up
+	before := time.Now()
+	result := task.Drain(time.Now().Add(200 * time.Millisecond))
+	assert.Less(t, time.Since(before), 2*time.Second)
+	assert.Equal(t, DrainResult{Pending: 3, Dropped: 3, WorkersTimedOut: true}, result)
+}
+
+func TestDeferredTaskDrainSpoolsPendingRetries(t *testing.T) {
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	spool, err := NewBoltSpool(db, "events", 0)
+	assert.Nil(t, err)
+
+	policy := &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Hour, RetryableStatusCodes: []int{500}}
+	worker := &failingWorker{errs: []error{&dtos.HTTPError{Code: 500}}}
+	task := newDeferredFlushTask(logging.NewLogger(nil), func() workerpool.Worker { return worker }, 3600, 3, 1, spool, newRetrier(KindEvents, policy, nil, logging.NewLogger(nil)))
+	assert.Nil(t, task.Stage(internal.NewRawEvents(dtos.Metadata{}, []byte("events"))))
+
+	// the post fails & is waiting for its backoff when the deadline is reached, so it's spooled for the next run
+	result := task.Drain(time.Now().Add(200 * time.Millisecond))
+	assert.Equal(t, DrainResult{Pending: 1, Spooled: 1}, result)
+	assert.Equal(t, int64(1), spool.Stats().Items)
+}
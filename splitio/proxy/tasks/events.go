@@ -8,6 +8,7 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 )
 
@@ -38,7 +39,9 @@ func (w *EventWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/events/bulk", asEvents.Payload, asEvents.Metadata, nil)
+	if err := w.recorder.RecordRaw("/events/bulk", asEvents.Payload, asEvents.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting events to Split servers: %w", err)
+	}
 	return nil
 }
 
@@ -51,6 +54,23 @@ func newEventWorkerFactory(name string, recorder *api.HTTPEventsRecorder, logger
 }
 
 // NewEventsFlushTask creates a new impressions flushing task
-func NewEventsFlushTask(recorder *api.HTTPEventsRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
-	return newDeferredFlushTask(logger, newEventWorkerFactory("events-worker", recorder, logger), period, queueSize, threads)
+func NewEventsFlushTask(
+	recorder *api.HTTPEventsRecorder,
+	logger logging.LoggerInterface,
+	period int,
+	queueSize int,
+	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
+) *DeferredRecordingTaskImpl {
+	return newDeferredFlushTask(
+		logger,
+		newEventWorkerFactory("events-worker", recorder, logger),
+		period,
+		queueSize,
+		threads,
+		spool,
+		newRetrier(KindEvents, retryPolicy, deadLetters, logger),
+	)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 )
 
@@ -64,6 +65,9 @@ func NewImpressionCountFlushTask(
 	period int,
 	queueSize int,
 	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
 ) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(
 		logger,
@@ -71,5 +75,7 @@ func NewImpressionCountFlushTask(
 		period,
 		queueSize,
 		threads,
+		spool,
+		newRetrier(KindImpressionCounts, retryPolicy, deadLetters, logger),
 	)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 )
 
@@ -66,6 +67,9 @@ func NewImpressionsFlushTask(
 	period int,
 	queueSize int,
 	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
 ) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(
 		logger,
@@ -73,5 +77,7 @@ func NewImpressionsFlushTask(
 		period,
 		queueSize,
 		threads,
+		spool,
+		newRetrier(KindImpressions, retryPolicy, deadLetters, logger),
 	)
 }
@@ -1,7 +1,14 @@
 package mocks
 
+import (
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+)
+
 type MockDeferredRecordingTask struct {
 	StageCall     func(rawData interface{}) error
+	DrainCall     func(deadline time.Time) tasks.DrainResult
 	StartCall     func()
 	StopCall      func(blocking bool) error
 	IsRunningCall func() bool
@@ -11,6 +18,10 @@ func (t *MockDeferredRecordingTask) Stage(rawData interface{}) error {
 	return t.StageCall(rawData)
 }
 
+func (t *MockDeferredRecordingTask) Drain(deadline time.Time) tasks.DrainResult {
+	return t.DrainCall(deadline)
+}
+
 func (t *MockDeferredRecordingTask) Start() {
 	t.StartCall()
 }
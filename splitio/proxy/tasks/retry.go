@@ -0,0 +1,148 @@
+package tasks
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+)
+
+// pendingRetry wraps a payload that failed to be posted & is waiting for its backoff to expire
+type pendingRetry struct {
+	payload  interface{}
+	attempts int
+	lastErr  error
+}
+
+// retrier keeps track of the payloads waiting to be retried by the workers of a task. Instead of blocking a worker
+// while waiting for the backoff, a timer is set so that the payload is handed back to the task (through `requeue`)
+// when it expires, leaving the worker free to post other payloads in the meantime
+type retrier struct {
+	kind        string
+	policy      *retry.Policy
+	deadLetters DeadLetterStore
+	logger      logging.LoggerInterface
+	requeue     func(*pendingRetry) bool
+	after       func(time.Duration, func()) *time.Timer
+	pending     map[*pendingRetry]*time.Timer
+	stopped     bool
+	mutex       sync.Mutex
+}
+
+func newRetrier(kind string, policy *retry.Policy, deadLetters DeadLetterStore, logger logging.LoggerInterface) *retrier {
+	if policy == nil {
+		return nil
+	}
+	return &retrier{
+		kind:        kind,
+		policy:      policy,
+		deadLetters: deadLetters,
+		logger:      logger,
+		after:       time.AfterFunc,
+		pending:     make(map[*pendingRetry]*time.Timer),
+	}
+}
+
+// wrap decorates a worker so that failed posts are retried according to the policy
+func (r *retrier) wrap(worker workerpool.Worker) workerpool.Worker {
+	return &retryingWorker{Worker: worker, retries: r}
+}
+
+// schedule hands the payload back to the task once the backoff expires. If the retrier has already been stopped,
+// the payload is stored as a dead letter right away
+func (r *retrier) schedule(retry *pendingRetry, backoff time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stopped {
+		r.giveUp(retry)
+		return
+	}
+	r.pending[retry] = r.after(backoff, func() { r.fire(retry) })
+}
+
+func (r *retrier) fire(retry *pendingRetry) {
+	r.mutex.Lock()
+	if _, ok := r.pending[retry]; !ok { // stopped while the timer was firing
+		r.mutex.Unlock()
+		return
+	}
+	delete(r.pending, retry)
+	r.mutex.Unlock()
+
+	if !r.requeue(retry) {
+		r.logger.Error(fmt.Sprintf("[%s] cannot requeue payload for retrying, no room left", r.kind))
+		r.giveUp(retry)
+	}
+}
+
+// giveUp stores a payload that could not be posted as a dead letter
+func (r *retrier) giveUp(retry *pendingRetry) {
+	if r.deadLetters != nil {
+		r.deadLetters.Add(r.kind, retry.payload, retry.attempts, retry.lastErr)
+	}
+}
+
+// pendingCount returns the number of payloads waiting for their backoff to expire
+func (r *retrier) pendingCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.pending)
+}
+
+// stop cancels all pending retries and returns their payloads. Payloads failing after this call are stored as
+// dead letters without further retries
+func (r *retrier) stop() []interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stopped = true
+	payloads := make([]interface{}, 0, len(r.pending))
+	for retry, timer := range r.pending {
+		timer.Stop()
+		payloads = append(payloads, retry.payload)
+	}
+	r.pending = make(map[*pendingRetry]*time.Timer)
+	return payloads
+}
+
+// retryingWorker decorates a worker so that failed posts are retried according to a policy, and payloads
+// that cannot be posted after exhausting all attempts are stored as dead letters
+type retryingWorker struct {
+	workerpool.Worker
+	retries *retrier
+}
+
+// DoWork calls the wrapped worker and schedules a retry if it fails with a retryable error & attempts are not
+// exhausted yet
+func (w *retryingWorker) DoWork(message interface{}) error {
+	retry, ok := message.(*pendingRetry)
+	if !ok {
+		retry = &pendingRetry{payload: message}
+	}
+
+	retry.attempts++
+	err := w.Worker.DoWork(retry.payload)
+	if err == nil {
+		return nil
+	}
+
+	retry.lastErr = err
+	if retry.attempts >= w.retries.policy.MaxAttempts || !w.retries.policy.Retryable(err) {
+		w.retries.giveUp(retry)
+		return fmt.Errorf("giving up after %d attempts: %w", retry.attempts, err)
+	}
+
+	backoff := w.retries.policy.Backoff(retry.attempts)
+	w.retries.logger.Debug(fmt.Sprintf("[%s] attempt %d failed (%s). retrying in %s", w.Name(), retry.attempts, err.Error(), backoff))
+	w.retries.schedule(retry, backoff)
+	return nil
+}
+
+// OnError is called whenever theres an error in the worker function
+func (w *retryingWorker) OnError(e error) {
+	w.retries.logger.Error(fmt.Sprintf("[%s] %s", w.Name(), e.Error()))
+	w.Worker.OnError(e)
+}
@@ -0,0 +1,235 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+type failingWorker struct {
+	errs  []error
+	calls int
+}
+
+func (w *failingWorker) Name() string       { return "failing-worker" }
+func (w *failingWorker) OnError(e error)    {}
+func (w *failingWorker) Cleanup() error     { return nil }
+func (w *failingWorker) FailureTime() int64 { return 1 }
+func (w *failingWorker) DoWork(m interface{}) error {
+	defer func() { w.calls++ }()
+	if w.calls < len(w.errs) {
+		return w.errs[w.calls]
+	}
+	return nil
+}
+
+// selectiveWorker sends the payload "fails" to the failing worker & everything else to the recording one
+type selectiveWorker struct {
+	failing   *failingWorker
+	recording *recordingWorker
+}
+
+func (w *selectiveWorker) Name() string       { return "selective-worker" }
+func (w *selectiveWorker) OnError(e error)    {}
+func (w *selectiveWorker) Cleanup() error     { return nil }
+func (w *selectiveWorker) FailureTime() int64 { return 1 }
+func (w *selectiveWorker) DoWork(m interface{}) error {
+	if m == "fails" {
+		return w.failing.DoWork(m)
+	}
+	return w.recording.DoWork(m)
+}
+
+func TestRetryingWorker(t *testing.T) {
+	policy := &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, RetryableStatusCodes: []int{500}}
+	deadLetters := NewInMemoryDeadLetterStore(10)
+	var waits []time.Duration
+	retries := newRetrier(KindEvents, policy, deadLetters, logging.NewLogger(nil))
+	retries.after = func(d time.Duration, f func()) *time.Timer {
+		waits = append(waits, d)
+		return time.AfterFunc(time.Hour, f) // fired manually below
+	}
+	requeued := make([]*pendingRetry, 0)
+	retries.requeue = func(r *pendingRetry) bool {
+		requeued = append(requeued, r)
+		return true
+	}
+
+	// runs the payload through the worker, firing retries right away until none is scheduled
+	run := func(inner workerpool.Worker, message interface{}) error {
+		worker := retries.wrap(inner)
+		err := worker.DoWork(message)
+		for retries.pendingCount() > 0 {
+			for retry := range retries.pending {
+				retries.fire(retry)
+			}
+			err = worker.DoWork(requeued[len(requeued)-1])
+		}
+		return err
+	}
+
+	// succeeds on the 2nd attempt
+	inner := &failingWorker{errs: []error{&dtos.HTTPError{Code: 500}}}
+	assert.Nil(t, run(inner, internal.NewRawEvents(dtos.Metadata{}, []byte("e1"))))
+	assert.Equal(t, 2, inner.calls)
+	assert.Equal(t, []time.Duration{time.Millisecond}, waits)
+	assert.Empty(t, deadLetters.List())
+
+	// exhausts all attempts
+	waits = nil
+	inner = &failingWorker{errs: []error{errors.New("timeout"), &dtos.HTTPError{Code: 500}, &dtos.HTTPError{Code: 500, Message: "boom"}}}
+	assert.NotNil(t, run(inner, internal.NewRawEvents(dtos.Metadata{SDKVersion: "go-1.0.0"}, []byte("e2"))))
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, waits)
+
+	// non retryable
+	waits = nil
+	inner = &failingWorker{errs: []error{&dtos.HTTPError{Code: 400, Message: "bad request"}}}
+	assert.NotNil(t, run(inner, internal.NewRawEvents(dtos.Metadata{}, []byte("e3"))))
+	assert.Equal(t, 1, inner.calls)
+	assert.Empty(t, waits)
+
+	letters := deadLetters.List()
+	assert.Len(t, letters, 2)
+	assert.Equal(t, KindEvents, letters[0].Kind)
+	assert.Equal(t, 3, letters[0].Attempts)
+	assert.Equal(t, 500, letters[0].StatusCode)
+	assert.Equal(t, "boom", letters[0].LastError)
+	metadata, payload, ok := letters[0].Payload()
+	assert.True(t, ok)
+	assert.Equal(t, "go-1.0.0", metadata.SDKVersion)
+	assert.Equal(t, []byte("e2"), payload)
+	assert.Equal(t, 1, letters[1].Attempts)
+	assert.Equal(t, 400, letters[1].StatusCode)
+
+	// pending retries are handed back when stopping, & later failures go straight to dead letters
+	inner = &failingWorker{errs: []error{&dtos.HTTPError{Code: 500}, &dtos.HTTPError{Code: 500}}}
+	assert.Nil(t, retries.wrap(inner).DoWork("e4"))
+	assert.Equal(t, []interface{}{"e4"}, retries.stop())
+	assert.Equal(t, 0, retries.pendingCount())
+	assert.Nil(t, retries.wrap(inner).DoWork("e5"))
+	assert.Equal(t, "e5", deadLetters.List()[2].Item)
+
+	// no policy means no decoration
+	assert.Nil(t, newRetrier(KindEvents, nil, deadLetters, logging.NewLogger(nil)))
+}
+
+func TestRetriesDoNotBlockWorkers(t *testing.T) {
+	policy := &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Hour, RetryableStatusCodes: []int{500}}
+	var received []interface{}
+	var mutex sync.Mutex
+	failing := &failingWorker{errs: []error{&dtos.HTTPError{Code: 500}}}
+	factory := func() workerpool.Worker {
+		return &selectiveWorker{failing: failing, recording: &recordingWorker{received: &received, mutex: &mutex}}
+	}
+	retries := newRetrier(KindEvents, policy, nil, logging.NewLogger(nil))
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 3600, 5, 1, nil, retries)
+
+	// the first payload fails & waits an hour to be retried, while the single worker keeps posting the rest
+	assert.Nil(t, task.Stage("fails"))
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, task.Stage(fmt.Sprintf("ok%d", i)))
+	}
+	task.Start()
+	defer task.Stop(false)
+	task.task.WakeUp()
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, retries.pendingCount())
+}
+
+func TestInMemoryDeadLetterStore(t *testing.T) {
+	store := NewInMemoryDeadLetterStore(2)
+	store.Add(KindEvents, "e1", 1, errors.New("e1"))
+	store.Add(KindImpressions, "i1", 1, errors.New("i1"))
+	store.Add(KindEvents, "e2", 1, errors.New("e2"))
+
+	letters := store.List()
+	assert.Len(t, letters, 2)
+	assert.Equal(t, "i1", letters[0].Item)
+	assert.Equal(t, "e2", letters[1].Item)
+	assert.Equal(t, int64(1), store.Dropped())
+
+	letter, ok := store.Get(letters[1].ID)
+	assert.True(t, ok)
+	assert.Equal(t, "e2", letter.Item)
+	_, ok = store.Get(12345)
+	assert.False(t, ok)
+
+	removed := store.Remove(func(l *DeadLetter) bool { return l.Kind == KindImpressions })
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "i1", removed[0].Item)
+	assert.Len(t, store.List(), 1)
+
+	assert.Len(t, store.Remove(nil), 1)
+	assert.Empty(t, store.List())
+
+	disabled := NewInMemoryDeadLetterStore(0)
+	disabled.Add(KindEvents, "e1", 1, nil)
+	assert.Empty(t, disabled.List())
+	assert.Equal(t, int64(1), disabled.Dropped())
+}
+
+func TestBoltDeadLetterStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.db")
+	db, err := persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+
+	metadata := dtos.Metadata{SDKVersion: "go-1.2.3"}
+	store, err := NewBoltDeadLetterStore(db, "deadLetters", 2)
+	assert.Nil(t, err)
+	store.Add(KindEvents, internal.NewRawEvents(metadata, []byte("e1")), 1, errors.New("e1"))
+	store.Add(KindImpressions, internal.NewRawImpressions(metadata, "optimized", []byte("i1")), 1, errors.New("i1"))
+	store.Add(KindEvents, internal.NewRawEvents(metadata, []byte("e2")), 3, &dtos.HTTPError{Code: 500, Message: "e2"})
+
+	letters := store.List()
+	assert.Len(t, letters, 2)
+	assert.Equal(t, internal.NewRawImpressions(metadata, "optimized", []byte("i1")), letters[0].Item)
+	assert.Equal(t, internal.NewRawEvents(metadata, []byte("e2")), letters[1].Item)
+	assert.Equal(t, 500, letters[1].StatusCode)
+	assert.Equal(t, int64(1), store.Dropped())
+
+	// dead letters survive restarts
+	assert.Nil(t, db.Close())
+	db, err = persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+	store, err = NewBoltDeadLetterStore(db, "deadLetters", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, letters, store.List())
+
+	letter, ok := store.Get(letters[1].ID)
+	assert.True(t, ok)
+	_, _, ok = letter.Payload()
+	assert.True(t, ok)
+	_, ok = store.Get(12345)
+	assert.False(t, ok)
+
+	// new ones keep evicting the oldest ones & get newer ids
+	store.Add(KindEvents, internal.NewRawEvents(metadata, []byte("e3")), 1, nil)
+	letters = store.List()
+	assert.Len(t, letters, 2)
+	assert.Equal(t, internal.NewRawEvents(metadata, []byte("e3")), letters[1].Item)
+	assert.Greater(t, letters[1].ID, letters[0].ID)
+
+	removed := store.Remove(func(l *DeadLetter) bool { return l.Kind == KindEvents })
+	assert.Len(t, removed, 2)
+	assert.Empty(t, store.List())
+	assert.Equal(t, 0, store.Len())
+}
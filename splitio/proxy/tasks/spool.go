@@ -0,0 +1,187 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrSpoolFull is returned when an item doesn't fit in the spool without exceeding its size cap
+var ErrSpoolFull = errors.New("spool is full, data dropped")
+
+// Spool defines the interface for an on-disk FIFO queue used to hold data that doesn't fit in memory
+type Spool interface {
+	Push(item interface{}) error
+	Pop() (interface{}, error)
+	PopInto(consume func(item interface{}) bool) (bool, error)
+	Stats() SpoolStats
+}
+
+// SpoolStats contains the current state of a spool
+type SpoolStats struct {
+	Items    int64
+	Bytes    int64
+	MaxBytes int64
+	Dropped  int64
+}
+
+// BoltSpool is a Spool backed by a boltdb bucket. Items are keyed by an ever-increasing sequence number, so that
+// iterating the bucket yields them in the order they were pushed.
+// The db should not be shared with the one used for flags & segments, to keep spooled data out of snapshots
+type BoltSpool struct {
+	db       persistent.DBWrapper
+	bucket   []byte
+	maxBytes int64
+	items    int64
+	bytes    int64
+	dropped  int64
+	mutex    sync.Mutex
+}
+
+// spooledItem wraps the data pushed to the spool so that the concrete type can be recovered when decoding
+type spooledItem struct {
+	Item interface{}
+}
+
+func init() {
+	gob.Register(&internal.RawData{})
+	gob.Register(&internal.RawImpressions{})
+}
+
+// NewBoltSpool constructs a spool on the bucket `name` of the supplied db. Items already present in the bucket
+// (from a previous run) are accounted for and will be popped before new ones. A maxBytes <= 0 means no cap
+func NewBoltSpool(db persistent.DBWrapper, name string, maxBytes int64) (*BoltSpool, error) {
+	spool := &BoltSpool{db: db, bucket: []byte(name), maxBytes: maxBytes}
+	err := db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(spool.bucket)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			spool.items++
+			spool.bytes += int64(len(v))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error setting up spool bucket '%s': %w", name, err)
+	}
+	return spool, nil
+}
+
+// Push appends an item to the end of the spool. If doing so would exceed the size cap, the item is dropped
+func (s *BoltSpool) Push(item interface{}) error {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(spooledItem{Item: item}); err != nil {
+		s.countDropped()
+		return fmt.Errorf("error encoding item of type %T: %w", item, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.maxBytes > 0 && s.bytes+int64(buffer.Len()) > s.maxBytes {
+		s.dropped++
+		return ErrSpoolFull
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return persistent.ErrorBucketNotFound
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return bucket.Put(key, buffer.Bytes())
+	})
+	if err != nil {
+		s.dropped++
+		return fmt.Errorf("error writing item to spool: %w", err)
+	}
+
+	s.items++
+	s.bytes += int64(buffer.Len())
+	return nil
+}
+
+// Pop removes the oldest item from the spool and returns it. If the spool is empty, nil is returned
+func (s *BoltSpool) Pop() (interface{}, error) {
+	var item interface{}
+	_, err := s.PopInto(func(oldest interface{}) bool {
+		item = oldest
+		return true
+	})
+	return item, err
+}
+
+// PopInto hands the oldest item to `consume`, and removes it from the spool only if accepted, so that items
+// that can't be consumed yet keep their place. Returns whether an item was consumed (false if the spool is empty)
+func (s *BoltSpool) PopInto(consume func(item interface{}) bool) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var size int
+	var consumed bool
+	var decodeErr error
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return persistent.ErrorBucketNotFound
+		}
+		cursor := bucket.Cursor()
+		key, value := cursor.First()
+		if key == nil {
+			return nil
+		}
+
+		size = len(value)
+		var decoded spooledItem
+		if decodeErr = gob.NewDecoder(bytes.NewReader(value)).Decode(&decoded); decodeErr != nil {
+			return cursor.Delete() // it will never be readable, discard it
+		}
+		if consumed = consume(decoded.Item); !consumed {
+			size = 0
+			return nil
+		}
+		return cursor.Delete()
+	})
+	if err != nil {
+		return false, fmt.Errorf("error reading item from spool: %w", err)
+	}
+
+	if size > 0 {
+		s.items--
+		s.bytes -= int64(size)
+	}
+	if decodeErr != nil {
+		s.dropped++
+		return false, fmt.Errorf("error decoding spooled item: %w", decodeErr)
+	}
+	return consumed, nil
+}
+
+// Stats returns the current number of items & bytes in the spool, along with the amount of items dropped
+func (s *BoltSpool) Stats() SpoolStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return SpoolStats{Items: s.items, Bytes: s.bytes, MaxBytes: s.maxBytes, Dropped: s.dropped}
+}
+
+func (s *BoltSpool) countDropped() {
+	s.mutex.Lock()
+	s.dropped++
+	s.mutex.Unlock()
+}
+
+var _ Spool = (*BoltSpool)(nil)
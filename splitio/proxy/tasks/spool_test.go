@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v6/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+)
+
+func TestBoltSpoolFIFO(t *testing.T) {
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	spool, err := NewBoltSpool(db, "impressions", 0)
+	assert.Nil(t, err)
+
+	item, err := spool.Pop()
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+
+	metadata := dtos.Metadata{SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"}
+	assert.Nil(t, spool.Push(internal.NewRawImpressions(metadata, "optimized", []byte("imps1"))))
+	assert.Nil(t, spool.Push(internal.NewRawEvents(metadata, []byte("events1"))))
+	assert.Nil(t, spool.Push(internal.NewRawImpressions(metadata, "debug", []byte("imps2"))))
+	assert.Equal(t, int64(3), spool.Stats().Items)
+
+	// items that can't be consumed keep their place
+	var offered []interface{}
+	consumed, err := spool.PopInto(func(item interface{}) bool {
+		offered = append(offered, item)
+		return false
+	})
+	assert.Nil(t, err)
+	assert.False(t, consumed)
+	assert.Equal(t, []interface{}{internal.NewRawImpressions(metadata, "optimized", []byte("imps1"))}, offered)
+	assert.Equal(t, int64(3), spool.Stats().Items)
+
+	item, err = spool.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, internal.NewRawImpressions(metadata, "optimized", []byte("imps1")), item)
+
+	item, err = spool.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, internal.NewRawEvents(metadata, []byte("events1")), item)
+
+	item, err = spool.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, internal.NewRawImpressions(metadata, "debug", []byte("imps2")), item)
+
+	item, err = spool.Pop()
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+	assert.Equal(t, SpoolStats{}, spool.Stats())
+}
+
+func TestBoltSpoolCapAndRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.db")
+	db, err := persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+
+	spool, err := NewBoltSpool(db, "events", 300)
+	assert.Nil(t, err)
+
+	var pushed int64
+	for i := 0; i < 10; i++ {
+		if err := spool.Push(internal.NewRawEvents(dtos.Metadata{}, []byte(fmt.Sprintf("events%d", i)))); err == nil {
+			pushed++
+		} else {
+			assert.ErrorIs(t, err, ErrSpoolFull)
+		}
+	}
+
+	stats := spool.Stats()
+	assert.Greater(t, pushed, int64(0))
+	assert.Less(t, pushed, int64(10))
+	assert.Equal(t, pushed, stats.Items)
+	assert.Equal(t, 10-pushed, stats.Dropped)
+	assert.LessOrEqual(t, stats.Bytes, int64(300))
+	assert.Nil(t, db.Close())
+
+	// reopening the file should pick up where we left
+	db, err = persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+	defer db.Close()
+	spool, err = NewBoltSpool(db, "events", 300)
+	assert.Nil(t, err)
+	assert.Equal(t, SpoolStats{Items: stats.Items, Bytes: stats.Bytes, MaxBytes: 300}, spool.Stats())
+
+	item, err := spool.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, internal.NewRawEvents(dtos.Metadata{}, []byte("events0")), item)
+}
+
+type recordingWorker struct {
+	received *[]interface{}
+	mutex    *sync.Mutex
+}
+
+func (w *recordingWorker) Name() string       { return "recording-worker" }
+func (w *recordingWorker) OnError(e error)    {}
+func (w *recordingWorker) Cleanup() error     { return nil }
+func (w *recordingWorker) FailureTime() int64 { return 1 }
+func (w *recordingWorker) DoWork(m interface{}) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	*w.received = append(*w.received, m)
+	return nil
+}
+
+func TestDeferredTaskOverflowsToSpool(t *testing.T) {
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	spool, err := NewBoltSpool(db, "events", 0)
+	assert.Nil(t, err)
+
+	var received []interface{}
+	var mutex sync.Mutex
+	factory := func() workerpool.Worker { return &recordingWorker{received: &received, mutex: &mutex} }
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 1, 1, 1, spool, nil)
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, task.Stage(internal.NewRawEvents(dtos.Metadata{}, []byte(fmt.Sprintf("events%d", i)))))
+	}
+	assert.Equal(t, int64(4), task.SpoolStats().Items)
+
+	task.Start()
+	defer task.Stop(false)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == 5
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(0), task.SpoolStats().Items)
+	assert.Equal(t, int64(0), task.SpoolStats().Dropped)
+
+	// without a spool, data that doesn't fit in memory is rejected
+	noSpool := newDeferredFlushTask(logging.NewLogger(nil), factory, 1, 1, 1, nil, nil)
+	assert.Nil(t, noSpool.Stage(internal.NewRawEvents(dtos.Metadata{}, []byte("events"))))
+	assert.ErrorIs(t, noSpool.Stage(internal.NewRawEvents(dtos.Metadata{}, []byte("events"))), ErrQueueFull)
+	assert.Nil(t, noSpool.SpoolStats())
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 )
 
@@ -40,7 +41,9 @@ func (w *TelemetryConfigWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/metrics/config", asTelemetryConfig.Payload, asTelemetryConfig.Metadata, nil)
+	if err := w.recorder.RecordRaw("/metrics/config", asTelemetryConfig.Payload, asTelemetryConfig.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting telemetry config to Split servers: %w", err)
+	}
 	return nil
 }
 
@@ -53,8 +56,25 @@ func newTelemetryConfigWorkerFactory(name string, recorder *api.HTTPTelemetryRec
 }
 
 // NewTelemetryConfigFlushTask creates a new impressions flushing task
-func NewTelemetryConfigFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
-	return newDeferredFlushTask(logger, newTelemetryConfigWorkerFactory("telemetry-config-worker", recorder, logger), period, queueSize, threads)
+func NewTelemetryConfigFlushTask(
+	recorder *api.HTTPTelemetryRecorder,
+	logger logging.LoggerInterface,
+	period int,
+	queueSize int,
+	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
+) *DeferredRecordingTaskImpl {
+	return newDeferredFlushTask(
+		logger,
+		newTelemetryConfigWorkerFactory("telemetry-config-worker", recorder, logger),
+		period,
+		queueSize,
+		threads,
+		spool,
+		newRetrier(KindTelemetryConfig, retryPolicy, deadLetters, logger),
+	)
 }
 
 // USAGE
@@ -86,7 +106,9 @@ func (w *TelemetryUsageWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/metrics/usage", asTelemetryUsage.Payload, asTelemetryUsage.Metadata, nil)
+	if err := w.recorder.RecordRaw("/metrics/usage", asTelemetryUsage.Payload, asTelemetryUsage.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting telemetry usage to Split servers: %w", err)
+	}
 	return nil
 }
 
@@ -99,8 +121,25 @@ func newTelemetryUsageWorkerFactory(name string, recorder *api.HTTPTelemetryReco
 }
 
 // NewTelemetryUsageFlushTask creates a new impressions flushing task
-func NewTelemetryUsageFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
-	return newDeferredFlushTask(logger, newTelemetryUsageWorkerFactory("telemetry-config-worker", recorder, logger), period, queueSize, threads)
+func NewTelemetryUsageFlushTask(
+	recorder *api.HTTPTelemetryRecorder,
+	logger logging.LoggerInterface,
+	period int,
+	queueSize int,
+	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
+) *DeferredRecordingTaskImpl {
+	return newDeferredFlushTask(
+		logger,
+		newTelemetryUsageWorkerFactory("telemetry-config-worker", recorder, logger),
+		period,
+		queueSize,
+		threads,
+		spool,
+		newRetrier(KindTelemetryUsage, retryPolicy, deadLetters, logger),
+	)
 }
 
 // Keys Client Side
@@ -132,7 +171,9 @@ func (w *TelemetryKeysClientSideWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/keys/cs", asTelemetryKeysClientSide.Payload, asTelemetryKeysClientSide.Metadata, nil)
+	if err := w.recorder.RecordRaw("/keys/cs", asTelemetryKeysClientSide.Payload, asTelemetryKeysClientSide.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting client side keys to Split servers: %w", err)
+	}
 	return nil
 }
 
@@ -145,8 +186,25 @@ func newTelemetryKeysClientSideWorkerFactory(name string, recorder *api.HTTPTele
 }
 
 // NewTelemetryKeysClientSideFlushTask creates a new flushing task
-func NewTelemetryKeysClientSideFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
-	return newDeferredFlushTask(logger, newTelemetryKeysClientSideWorkerFactory("telemetry-keys-client-side-worker", recorder, logger), period, queueSize, threads)
+func NewTelemetryKeysClientSideFlushTask(
+	recorder *api.HTTPTelemetryRecorder,
+	logger logging.LoggerInterface,
+	period int,
+	queueSize int,
+	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
+) *DeferredRecordingTaskImpl {
+	return newDeferredFlushTask(
+		logger,
+		newTelemetryKeysClientSideWorkerFactory("telemetry-keys-client-side-worker", recorder, logger),
+		period,
+		queueSize,
+		threads,
+		spool,
+		newRetrier(KindTelemetryKeysClientSide, retryPolicy, deadLetters, logger),
+	)
 }
 
 // Keys Server Side
@@ -178,7 +236,9 @@ func (w *TelemetryKeysServerSideWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/keys/ss", asTelemetryKeysServerSide.Payload, asTelemetryKeysServerSide.Metadata, nil)
+	if err := w.recorder.RecordRaw("/keys/ss", asTelemetryKeysServerSide.Payload, asTelemetryKeysServerSide.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting server side keys to Split servers: %w", err)
+	}
 	return nil
 }
 
@@ -191,6 +251,23 @@ func newTelemetryKeysServerSideWorkerWorkerFactory(name string, recorder *api.HT
 }
 
 // NewTelemetryKeysServerSideFlushTask creates a new flushing task
-func NewTelemetryKeysServerSideFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
-	return newDeferredFlushTask(logger, newTelemetryKeysServerSideWorkerWorkerFactory("telemetry-keys-server-side-worker", recorder, logger), period, queueSize, threads)
+func NewTelemetryKeysServerSideFlushTask(
+	recorder *api.HTTPTelemetryRecorder,
+	logger logging.LoggerInterface,
+	period int,
+	queueSize int,
+	threads int,
+	spool Spool,
+	retryPolicy *retry.Policy,
+	deadLetters DeadLetterStore,
+) *DeferredRecordingTaskImpl {
+	return newDeferredFlushTask(
+		logger,
+		newTelemetryKeysServerSideWorkerWorkerFactory("telemetry-keys-server-side-worker", recorder, logger),
+		period,
+		queueSize,
+		threads,
+		spool,
+		newRetrier(KindTelemetryKeysServerSide, retryPolicy, deadLetters, logger),
+	)
 }